@@ -0,0 +1,133 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sort"
+
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+// otherBaggageKeys is the key tag of the metrics of the baggage keys not in TracerOptions.BaggageMetricsKeys.
+const otherBaggageKeys = "other"
+
+// baggageKeyMetrics is a container of the stats of the baggage items with a given key.
+type baggageKeyMetrics struct {
+	// Number of times the baggage item was truncated as per baggage restrictions
+	Truncations metrics.Counter `metric:"baggage_key_truncations" help:"Number of times the baggage item was truncated as per baggage restrictions"`
+
+	// Number of times the baggage item was rejected or dropped to keep the baggage within the size budget
+	BudgetExceeded metrics.Counter `metric:"baggage_budget_exceeded" help:"Number of times the baggage item was rejected or dropped to keep the baggage within the size budget"`
+}
+
+// baggageMetricsByKey holds the metrics of the allowlisted baggage keys. The baggage keys come from
+// untrusted requests, so the other keys share the metrics tagged with otherBaggageKeys.
+// It is immutable once created.
+type baggageMetricsByKey struct {
+	byKey map[string]*baggageKeyMetrics
+	other *baggageKeyMetrics
+}
+
+func newBaggageMetricsByKey(factory metrics.Factory, keys []string) *baggageMetricsByKey {
+	if factory == nil {
+		factory = metrics.NullFactory
+	}
+	factory = factory.Namespace(metrics.NSOptions{Name: "jaeger"}).Namespace(metrics.NSOptions{Name: "tracer"})
+	m := &baggageMetricsByKey{
+		byKey: make(map[string]*baggageKeyMetrics, len(keys)),
+		other: &baggageKeyMetrics{},
+	}
+	metrics.Init(m.other, factory, map[string]string{"key": otherBaggageKeys})
+	for _, key := range keys {
+		if _, ok := m.byKey[key]; ok || key == otherBaggageKeys {
+			continue
+		}
+		met := &baggageKeyMetrics{}
+		metrics.Init(met, factory, map[string]string{"key": key})
+		m.byKey[key] = met
+	}
+	return m
+}
+
+func (m *baggageMetricsByKey) get(key string) *baggageKeyMetrics {
+	if met, ok := m.byKey[key]; ok {
+		return met
+	}
+	return m.other
+}
+
+// itemSize returns the size of the baggage item in bytes, counted as the length of the key and value,
+// and of the baggage item holding its signature if it is signed.
+func (s *baggageSetter) itemSize(key, value string) int {
+	return len(key) + len(value) + s.signer.signatureSize(key)
+}
+
+// baggageSize returns the size of the baggage items in bytes, see itemSize.
+func (s *baggageSetter) baggageSize(baggage map[string]string) int {
+	size := 0
+	for k, v := range baggage {
+		size += s.itemSize(k, v)
+	}
+	return size
+}
+
+// exceedsBudget returns true if setting the baggage item would make the baggage exceed the size budget.
+func (s *baggageSetter) exceedsBudget(baggage map[string]string, key, value string) bool {
+	if s.maxBaggageSize <= 0 {
+		return false
+	}
+	size := s.baggageSize(baggage) + s.itemSize(key, value)
+	if prev, ok := baggage[key]; ok {
+		size -= s.itemSize(key, prev)
+	}
+	return size > s.maxBaggageSize
+}
+
+// enforceBudget drops the largest baggage items of the context, e.g. extracted from an upstream service,
+// until the baggage is within the size budget.
+func (s *baggageSetter) enforceBudget(ctx SpanContext) SpanContext {
+	if s.maxBaggageSize <= 0 {
+		return ctx
+	}
+	size := s.baggageSize(ctx.baggage)
+	if size <= s.maxBaggageSize {
+		return ctx
+	}
+	keys := make([]string, 0, len(ctx.baggage))
+	for k := range ctx.baggage {
+		keys = append(keys, k)
+	}
+	itemSize := func(k string) int { return s.itemSize(k, ctx.baggage[k]) }
+	sort.Slice(keys, func(i, j int) bool {
+		if itemSize(keys[i]) != itemSize(keys[j]) {
+			return itemSize(keys[i]) > itemSize(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	baggage := make(map[string]string, len(ctx.baggage))
+	for k, v := range ctx.baggage {
+		baggage[k] = v
+	}
+	for _, k := range keys {
+		if size <= s.maxBaggageSize {
+			break
+		}
+		size -= itemSize(k)
+		delete(baggage, k)
+		s.keyMetrics.get(k).BudgetExceeded.Inc(1)
+	}
+	ctx.baggage = baggage
+	return ctx
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestMaxBaggageSizeRejectsItems(t *testing.T) {
+	factory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.MetricsFactory(factory),
+		TracerOptions.MaxBaggageSize(20),
+		TracerOptions.BaggageMetricsKeys("session"),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.SetBaggageItem("tenant", "acme")        // 10 bytes
+	sp.SetBaggageItem("user", "bob")           // 17 bytes
+	sp.SetBaggageItem("session", "0123456789") // rejected
+	sp.SetBaggageItem("tenant", "acme-cn")     // 20 bytes, overwrites the previous value
+	assert.Equal(t, "acme-cn", sp.BaggageItem("tenant"))
+	assert.Equal(t, "bob", sp.BaggageItem("user"))
+	assert.Equal(t, "", sp.BaggageItem("session"))
+	require.Len(t, sp.warnings, 1)
+	assert.Contains(t, sp.warnings[0], `"session" rejected`)
+
+	factory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "jaeger.tracer.baggage_budget_exceeded",
+		Tags:  map[string]string{"key": "session"},
+		Value: 1,
+	})
+}
+
+func TestMaxBaggageSizeOnExtract(t *testing.T) {
+	factory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.MetricsFactory(factory),
+		TracerOptions.MaxBaggageSize(20),
+	)
+	defer closer.Close()
+
+	carrier := opentracing.TextMapCarrier{
+		TraceContextHeaderName:         "1:2:0:1",
+		TraceBaggageHeaderPrefix + "a": "1",
+		TraceBaggageHeaderPrefix + "b": strings.Repeat("x", 15),
+		TraceBaggageHeaderPrefix + "c": strings.Repeat("x", 10),
+		TraceBaggageHeaderPrefix + "d": strings.Repeat("x", 5),
+	}
+	ctx, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	// the largest item is dropped, the remaining items take 19 bytes
+	assert.Equal(t, map[string]string{"a": "1", "c": strings.Repeat("x", 10), "d": "xxxxx"}, ctx.(SpanContext).baggage)
+	assert.Len(t, carrier, 5, "the carrier is not modified")
+
+	factory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "jaeger.tracer.baggage_budget_exceeded",
+		Tags:  map[string]string{"key": otherBaggageKeys},
+		Value: 1,
+	})
+}
+
+func TestBaggageMetricsByKeyAllowlist(t *testing.T) {
+	factory := metricstest.NewFactory(0)
+	m := newBaggageMetricsByKey(factory, []string{"tenant", "tenant", otherBaggageKeys})
+	for i := 0; i < 10; i++ {
+		m.get("key" + strconv.Itoa(i)).Truncations.Inc(1)
+	}
+	m.get("tenant").Truncations.Inc(1)
+	assert.Len(t, m.byKey, 1)
+	factory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{
+			Name:  "jaeger.tracer.baggage_key_truncations",
+			Tags:  map[string]string{"key": otherBaggageKeys},
+			Value: 10,
+		},
+		metricstest.ExpectedMetric{
+			Name:  "jaeger.tracer.baggage_key_truncations",
+			Tags:  map[string]string{"key": "tenant"},
+			Value: 1,
+		},
+	)
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+// withDefaultBaggage returns the baggage with the default baggage items added, see TracerOptions.DefaultBaggage.
+// The items already in the baggage, e.g. from the debug ID container, take precedence.
+func (t *Tracer) withDefaultBaggage(baggage map[string]string) map[string]string {
+	if len(t.options.defaultBaggage) == 0 {
+		return baggage
+	}
+	if baggage == nil {
+		baggage = make(map[string]string, len(t.options.defaultBaggage))
+	}
+	for k, v := range t.options.defaultBaggage {
+		if _, ok := baggage[k]; !ok {
+			baggage[k] = v
+		}
+	}
+	return baggage
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultBaggage(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.DefaultBaggage("region", "cn-hangzhou"),
+		TracerOptions.DefaultBaggage("cluster", "c1"),
+		TracerOptions.BaggageTags("region"))
+	defer closer.Close()
+
+	root := tracer.StartSpan("root").(*Span)
+	assert.Equal(t, "cn-hangzhou", root.BaggageItem("region"))
+	assert.Equal(t, "c1", root.BaggageItem("cluster"))
+	assert.Equal(t, "cn-hangzhou", root.Tags()["region"])
+
+	root.SetBaggageItem("region", "overridden")
+	child := tracer.StartSpan("child", opentracing.ChildOf(root.Context())).(*Span)
+	assert.Equal(t, "overridden", child.BaggageItem("region"), "children inherit the baggage of the parent")
+
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(NewSpanContext(TraceID{Low: 1}, 2, 0, true, nil), opentracing.TextMap, carrier))
+	remote, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	server := tracer.StartSpan("server", opentracing.ChildOf(remote)).(*Span)
+	assert.Empty(t, server.BaggageItem("region"), "spans joining remote traces do not get the default baggage")
+
+	debug := tracer.StartSpan("debug", opentracing.ChildOf(SpanContext{}.WithDebugID("abc").WithBaggageItem("cluster", "c2"))).(*Span)
+	assert.Equal(t, "c2", debug.BaggageItem("cluster"))
+	assert.Equal(t, "cn-hangzhou", debug.BaggageItem("region"))
+}
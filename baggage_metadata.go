@@ -0,0 +1,140 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// W3CBaggageHeader is the HTTP header of the W3C baggage format.
+const W3CBaggageHeader = "baggage"
+
+// BaggageItem is a baggage item with its optional metadata. In the W3C baggage format, the metadata
+// holds the properties of the item, e.g. "prop1;prop2=value", without the leading semicolon.
+type BaggageItem struct {
+	Key      string
+	Value    string
+	Metadata string
+}
+
+// BaggageItem returns the baggage item with the given key and its metadata, if the context has it.
+func (c SpanContext) BaggageItem(key string) (BaggageItem, bool) {
+	value, ok := c.baggage[key]
+	if !ok {
+		return BaggageItem{}, false
+	}
+	return BaggageItem{Key: key, Value: value, Metadata: c.baggageMetadata[key]}, true
+}
+
+// ForeachBaggageItemWithMetadata is like ForeachBaggageItem, but also passes the metadata of the items.
+func (c SpanContext) ForeachBaggageItemWithMetadata(handler func(item BaggageItem) bool) {
+	for k, v := range c.baggage {
+		if !handler(BaggageItem{Key: k, Value: v, Metadata: c.baggageMetadata[k]}) {
+			break
+		}
+	}
+}
+
+// WithBaggageItemMetadata creates a new context with an extra baggage item with the given metadata.
+func (c SpanContext) WithBaggageItemMetadata(key, value, metadata string) SpanContext {
+	ctx := c.WithBaggageItem(key, value)
+	ctx.baggageMetadata = withBaggageMetadata(c.baggageMetadata, key, metadata)
+	return ctx
+}
+
+// W3CBaggage returns the baggage items of the context with their metadata in the W3C baggage format,
+// as the value of the W3CBaggageHeader. The items are sorted by key.
+func (c SpanContext) W3CBaggage() string {
+	keys := make([]string, 0, len(c.baggage))
+	for k := range c.baggage {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	members := make([]string, 0, len(keys))
+	for _, k := range keys {
+		member := k + "=" + url.PathEscape(c.baggage[k])
+		if metadata := c.baggageMetadata[k]; metadata != "" {
+			member += ";" + metadata
+		}
+		members = append(members, member)
+	}
+	return strings.Join(members, ",")
+}
+
+// WithW3CBaggage creates a new context with the baggage items and metadata parsed from the value
+// of the W3CBaggageHeader added to the baggage of the context. If the value is malformed,
+// it returns the context unchanged with an error.
+func (c SpanContext) WithW3CBaggage(header string) (SpanContext, error) {
+	ctx := c
+	for _, member := range strings.Split(header, ",") {
+		if strings.TrimSpace(member) == "" {
+			continue
+		}
+		parts := strings.SplitN(member, ";", 2)
+		kv := strings.SplitN(parts[0], "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || key == "" {
+			return c, fmt.Errorf("malformed W3C baggage member %q", member)
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return c, fmt.Errorf("malformed W3C baggage member %q: %v", member, err)
+		}
+		metadata := ""
+		if len(parts) == 2 {
+			metadata = strings.TrimSpace(parts[1])
+		}
+		ctx = ctx.WithBaggageItemMetadata(key, value, metadata)
+	}
+	return ctx, nil
+}
+
+// SetBaggageItemWithMetadata is like SetBaggageItem, but also sets the metadata of the baggage item,
+// e.g. its W3C properties.
+func (s *Span) SetBaggageItemWithMetadata(key, value, metadata string) opentracing.Span {
+	if s.usedAfterFinish("SetBaggageItem") {
+		return s
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.tracer.baggageSetter.setBaggageWithMetadata(s, key, value, metadata)
+	return s
+}
+
+// withBaggageMetadata returns a copy of the metadata with the metadata of the given key replaced,
+// or removed if empty. The metadata is not copied if it does not change.
+func withBaggageMetadata(metadata map[string]string, key, value string) map[string]string {
+	if metadata[key] == value {
+		return metadata
+	}
+	newMetadata := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		newMetadata[k] = v
+	}
+	if value == "" {
+		delete(newMetadata, key)
+	} else {
+		newMetadata[key] = value
+	}
+	if len(newMetadata) == 0 {
+		return nil
+	}
+	return newMetadata
+}
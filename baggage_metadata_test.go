@@ -0,0 +1,83 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaggageMetadata(t *testing.T) {
+	ctx := NewSpanContext(TraceID{Low: 1}, 2, 0, true, nil).
+		WithBaggageItemMetadata("tenant", "acme", "source=edge").
+		WithBaggageItem("user", "bob")
+
+	item, ok := ctx.BaggageItem("tenant")
+	require.True(t, ok)
+	assert.Equal(t, BaggageItem{Key: "tenant", Value: "acme", Metadata: "source=edge"}, item)
+	item, ok = ctx.BaggageItem("user")
+	require.True(t, ok)
+	assert.Equal(t, BaggageItem{Key: "user", Value: "bob"}, item)
+	_, ok = ctx.BaggageItem("missing")
+	assert.False(t, ok)
+
+	items := map[string]BaggageItem{}
+	ctx.ForeachBaggageItemWithMetadata(func(item BaggageItem) bool {
+		items[item.Key] = item
+		return true
+	})
+	assert.Len(t, items, 2)
+
+	replaced := ctx.WithBaggageItem("tenant", "other")
+	item, _ = replaced.BaggageItem("tenant")
+	assert.Equal(t, "", item.Metadata, "replacing an item drops its metadata")
+	item, _ = ctx.BaggageItem("tenant")
+	assert.Equal(t, "source=edge", item.Metadata, "contexts are immutable")
+}
+
+func TestW3CBaggage(t *testing.T) {
+	ctx, err := SpanContext{}.WithW3CBaggage(" tenant = acme ; source=edge;internal , user=bob%20smith,,")
+	require.NoError(t, err)
+	item, _ := ctx.BaggageItem("tenant")
+	assert.Equal(t, BaggageItem{Key: "tenant", Value: "acme", Metadata: "source=edge;internal"}, item)
+	item, _ = ctx.BaggageItem("user")
+	assert.Equal(t, BaggageItem{Key: "user", Value: "bob smith"}, item)
+	assert.Equal(t, "tenant=acme;source=edge;internal,user=bob%20smith", ctx.W3CBaggage())
+
+	for _, header := range []string{"novalue", "=value", "k=%zz"} {
+		unchanged, err := ctx.WithW3CBaggage(header)
+		assert.Error(t, err, header)
+		assert.Equal(t, ctx, unchanged)
+	}
+}
+
+func TestBaggageMetadataPropagatesToChildren(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+
+	parent := tracer.StartSpan("parent").(*Span)
+	parent.SetBaggageItemWithMetadata("tenant", "acme", "source=edge")
+	child := tracer.StartSpan("child", opentracing.ChildOf(parent.Context())).(*Span)
+	item, ok := child.SpanContext().BaggageItem("tenant")
+	require.True(t, ok)
+	assert.Equal(t, BaggageItem{Key: "tenant", Value: "acme", Metadata: "source=edge"}, item)
+
+	child.SetBaggageItem("tenant", "other")
+	item, _ = child.SpanContext().BaggageItem("tenant")
+	assert.Equal(t, BaggageItem{Key: "tenant", Value: "other"}, item)
+}
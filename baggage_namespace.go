@@ -0,0 +1,204 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/uber/jaeger-client-go/internal/baggage"
+)
+
+// BaggageNamespaceSeparator separates the name of a baggage namespace from the keys of its baggage items.
+const BaggageNamespaceSeparator = "."
+
+const defaultMaxValueLength = 2048
+
+// BaggageNamespace is a view of the baggage scoped to the baggage items of a namespace, which allows
+// libraries to read and write their own baggage items without colliding with the baggage of the application.
+// The baggage items of the namespace "mylib" are propagated with the keys prefixed by "mylib.".
+//
+// When the namespace is registered with TracerOptions.BaggageNamespace, its baggage items can only be read
+// and written through the BaggageNamespace, and are hidden from Span.BaggageItem and Tracer.ForeachBaggageItem.
+// SpanContext.ForeachBaggageItem, which the propagators use, still includes them.
+type BaggageNamespace struct {
+	name   string
+	prefix string
+}
+
+// NewBaggageNamespace returns the view of the baggage of the namespace with the given name.
+func NewBaggageNamespace(name string) BaggageNamespace {
+	return BaggageNamespace{name: name, prefix: name + BaggageNamespaceSeparator}
+}
+
+// Name returns the name of the namespace.
+func (n BaggageNamespace) Name() string {
+	return n.name
+}
+
+// SetBaggageItem sets the baggage item of the namespace with the given key on the span.
+func (n BaggageNamespace) SetBaggageItem(span opentracing.Span, key, value string) {
+	switch sp := span.(type) {
+	case *Span:
+		sp.setScopedBaggageItem(n.prefix+key, value)
+	case *unsampledSpan:
+		sp.setScopedBaggageItem(n.prefix+key, value)
+	default:
+		span.SetBaggageItem(n.prefix+key, value)
+	}
+}
+
+// BaggageItem returns the baggage item of the namespace with the given key from the span.
+func (n BaggageNamespace) BaggageItem(span opentracing.Span, key string) string {
+	if ctx, ok := span.Context().(SpanContext); ok {
+		return ctx.baggage[n.prefix+key]
+	}
+	return span.BaggageItem(n.prefix + key)
+}
+
+// ForeachBaggageItem calls the handler with the unprefixed keys and the values of the baggage items
+// of the namespace, until the handler returns false.
+func (n BaggageNamespace) ForeachBaggageItem(ctx opentracing.SpanContext, handler func(k, v string) bool) {
+	ctx.ForeachBaggageItem(func(k, v string) bool {
+		if strings.HasPrefix(k, n.prefix) {
+			return handler(k[len(n.prefix):], v)
+		}
+		return true
+	})
+}
+
+// ForeachBaggageItem calls the handler with the baggage items of the context, except the ones of the
+// namespaces registered with TracerOptions.BaggageNamespace, until the handler returns false. Unlike
+// SpanContext.ForeachBaggageItem, it only shows the baggage of the application.
+func (t *Tracer) ForeachBaggageItem(ctx opentracing.SpanContext, handler func(k, v string) bool) {
+	ctx.ForeachBaggageItem(func(k, v string) bool {
+		if t.baggageSetter.hidden(k) {
+			return true
+		}
+		return handler(k, v)
+	})
+}
+
+// BaggageNamespaceRules are the restrictions of the baggage items of a namespace registered with
+// TracerOptions.BaggageNamespace, which apply instead of the baggage restrictions of the tracer.
+type BaggageNamespaceRules struct {
+	// AllowedKeys are the unprefixed keys of the baggage items allowed in the namespace, which can be
+	// glob patterns like "retry-*". All keys are allowed if empty.
+	AllowedKeys []string
+
+	// MaxValueLength is the max length of the values of the baggage items, 2048 by default.
+	MaxValueLength int
+}
+
+// baggageNamespaceRestrictions are the compiled rules of a registered namespace.
+type baggageNamespaceRestrictions struct {
+	BaggageNamespace
+	rules       BaggageNamespaceRules
+	allowedKeys *keyMatcher
+	denyAll     bool
+	allowed     *baggage.Restriction
+	denied      *baggage.Restriction
+}
+
+func newBaggageNamespaceRestrictions(name string, rules BaggageNamespaceRules) *baggageNamespaceRestrictions {
+	if rules.MaxValueLength == 0 {
+		rules.MaxValueLength = defaultMaxValueLength
+	}
+	return &baggageNamespaceRestrictions{
+		BaggageNamespace: NewBaggageNamespace(name),
+		rules:            rules,
+		allowed:          baggage.NewRestriction(true, rules.MaxValueLength),
+		denied:           baggage.NewRestriction(false, 0),
+	}
+}
+
+// compile creates the matcher of the allowed keys. If the patterns are invalid, no keys are allowed.
+func (r *baggageNamespaceRestrictions) compile() error {
+	matcher, err := newKeyMatcher(r.rules.AllowedKeys)
+	if err != nil {
+		r.denyAll = true
+		return err
+	}
+	r.allowedKeys = matcher
+	return nil
+}
+
+func (r *baggageNamespaceRestrictions) restriction(key string) *baggage.Restriction {
+	key = key[len(r.prefix):]
+	if r.denyAll || (r.allowedKeys != nil && !r.allowedKeys.matches(key)) {
+		return r.denied
+	}
+	return r.allowed
+}
+
+// sortBaggageNamespaces sorts the namespaces by decreasing length of their prefix, so that
+// namespaceOf finds the most specific namespace of a key, e.g. "mylib.sub." before "mylib.".
+func sortBaggageNamespaces(namespaces []*baggageNamespaceRestrictions) {
+	sort.SliceStable(namespaces, func(i, j int) bool {
+		return len(namespaces[i].prefix) > len(namespaces[j].prefix)
+	})
+}
+
+// namespaceOf returns the registered namespace of the baggage key, or nil.
+func (s *baggageSetter) namespaceOf(key string) *baggageNamespaceRestrictions {
+	for _, ns := range s.namespaces {
+		if strings.HasPrefix(key, ns.prefix) {
+			return ns
+		}
+	}
+	return nil
+}
+
+// getRestriction returns the restriction of the baggage key, from its namespace if registered.
+func (s *baggageSetter) getRestriction(serviceName, key string) *baggage.Restriction {
+	if ns := s.namespaceOf(key); ns != nil {
+		return ns.restriction(key)
+	}
+	return s.restrictionManager.GetRestriction(serviceName, key)
+}
+
+// reservedKeyError returns an error if the key belongs to a registered namespace and cannot be
+// set outside of the BaggageNamespace.
+func (s *baggageSetter) reservedKeyError(key string) error {
+	if ns := s.namespaceOf(key); ns != nil {
+		return fmt.Errorf("reserved for the baggage namespace %q", ns.name)
+	}
+	return nil
+}
+
+// hidden returns true if the key belongs to a registered namespace and is hidden from Span.BaggageItem,
+// Tracer.ForeachBaggageItem and the baggage tags of the spans.
+func (s *baggageSetter) hidden(key string) bool {
+	return len(s.namespaces) > 0 && s.namespaceOf(key) != nil
+}
+
+func (s *Span) setScopedBaggageItem(key, value string) {
+	if s.usedAfterFinish("SetBaggageItem") {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.tracer.baggageSetter.setScopedBaggage(s, key, value)
+}
+
+func (s *unsampledSpan) setScopedBaggageItem(key, value string) {
+	s.Lock()
+	defer s.Unlock()
+	update := s.tracer.baggageSetter.updateBaggage(s.tracer.serviceName, s.context, key, value, "", true)
+	s.context = update.context
+}
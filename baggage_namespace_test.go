@@ -0,0 +1,141 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestBaggageNamespace(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.BaggageNamespace("mylib", BaggageNamespaceRules{
+			AllowedKeys:    []string{"retry-*"},
+			MaxValueLength: 4,
+		}),
+	)
+	defer closer.Close()
+	ns := NewBaggageNamespace("mylib")
+	assert.Equal(t, "mylib", ns.Name())
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.SetBaggageItem("retry-count", "1")
+	sp.SetBaggageItem("mylib.retry-count", "2")
+	ns.SetBaggageItem(sp, "retry-count", "12345")
+	ns.SetBaggageItem(sp, "other", "x")
+
+	assert.Equal(t, "1", sp.BaggageItem("retry-count"))
+	assert.Equal(t, "1234", ns.BaggageItem(sp, "retry-count"), "truncated by the namespace rules")
+	assert.Equal(t, "", ns.BaggageItem(sp, "other"), "not allowed by the namespace rules")
+	assert.Equal(t, "", sp.BaggageItem("mylib.retry-count"), "hidden from the application")
+	assert.Equal(t, []string{
+		`baggage item "mylib.retry-count" rejected: reserved for the baggage namespace "mylib"`,
+		`baggage item "mylib.retry-count" truncated to 4 characters`,
+		`baggage item "mylib.other" not allowed by the baggage restrictions`,
+	}, sp.warnings)
+
+	items := map[string]string{}
+	ns.ForeachBaggageItem(sp.Context(), func(k, v string) bool {
+		items[k] = v
+		return true
+	})
+	assert.Equal(t, map[string]string{"retry-count": "1234"}, items)
+	items = map[string]string{}
+	tracer.(*Tracer).ForeachBaggageItem(sp.Context(), func(k, v string) bool {
+		items[k] = v
+		return true
+	})
+	assert.Equal(t, map[string]string{"retry-count": "1"}, items, "hidden from the application")
+	items = map[string]string{}
+	sp.Context().ForeachBaggageItem(func(k, v string) bool {
+		items[k] = v
+		return true
+	})
+	assert.Equal(t, map[string]string{"retry-count": "1", "mylib.retry-count": "1234"}, items,
+		"the context has all the baggage, to be propagated")
+
+	// the baggage of the namespace is propagated with the prefixed keys
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(sp.Context(), opentracing.TextMap, carrier))
+	assert.Equal(t, "1234", carrier[TraceBaggageHeaderPrefix+"mylib.retry-count"])
+	ctx, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	tracer.(*Tracer).ForeachBaggageItem(ctx, func(k, v string) bool {
+		assert.Equal(t, "retry-count", k, "hidden from the application")
+		return true
+	})
+	child := tracer.StartSpan("child", opentracing.ChildOf(ctx))
+	assert.Equal(t, "1234", ns.BaggageItem(child, "retry-count"))
+}
+
+func TestBaggageNamespaceNested(t *testing.T) {
+	// the most specific namespace applies regardless of the order of registration
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.BaggageNamespace("mylib", BaggageNamespaceRules{MaxValueLength: 2}),
+		TracerOptions.BaggageNamespace("mylib.sub", BaggageNamespaceRules{MaxValueLength: 4}),
+		TracerOptions.BaggageTags("mylib.sub.key"),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	NewBaggageNamespace("mylib.sub").SetBaggageItem(sp, "key", "12345")
+	assert.Equal(t, "1234", NewBaggageNamespace("mylib.sub").BaggageItem(sp, "key"))
+	assert.NotContains(t, sp.Tags(), "mylib.sub.key", "hidden from the baggage tags")
+}
+
+func TestBaggageNamespaceUnregistered(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.MinimalUnsampledSpans(true),
+	)
+	defer closer.Close()
+	ns := NewBaggageNamespace("mylib")
+
+	root := tracer.StartSpan("root")
+	defer root.Finish()
+	sp := tracer.StartSpan("op", opentracing.ChildOf(root.Context()))
+	require.IsType(t, &unsampledSpan{}, sp)
+	ns.SetBaggageItem(sp, "key", "value")
+	assert.Equal(t, "value", ns.BaggageItem(sp, "key"))
+	assert.Equal(t, "value", sp.BaggageItem("mylib.key"), "unregistered namespaces are not hidden")
+
+	// other tracers
+	noop := opentracing.NoopTracer{}.StartSpan("op")
+	ns.SetBaggageItem(noop, "key", "value")
+	assert.Equal(t, "", ns.BaggageItem(noop, "key"))
+	ns.ForeachBaggageItem(noop.Context(), func(k, v string) bool {
+		t.Errorf("unexpected baggage item %s", k)
+		return true
+	})
+}
+
+func TestBaggageNamespaceInvalidPattern(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Logger(logger),
+		TracerOptions.BaggageNamespace("mylib", BaggageNamespaceRules{AllowedKeys: []string{"["}}),
+	)
+	defer closer.Close()
+	assert.True(t, strings.Contains(logger.String(), `Invalid allowed keys of the baggage namespace "mylib"`))
+
+	sp := tracer.StartSpan("op")
+	NewBaggageNamespace("mylib").SetBaggageItem(sp, "key", "value")
+	assert.Equal(t, "", NewBaggageNamespace("mylib").BaggageItem(sp, "key"))
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+// BaggageChange describes an attempt to set a baggage item on a span, see BaggageObserver.
+type BaggageChange struct {
+	// ServiceName is the service of the tracer setting the baggage item.
+	ServiceName string
+	// Context is the context of the span before the change.
+	Context SpanContext
+	Key     string
+	// OldValue is the previous value of the item, if Overwritten.
+	OldValue string
+	// NewValue is the value of the item after the restrictions were applied.
+	NewValue    string
+	Overwritten bool
+	// Truncated is true if the value was truncated to the maximum length allowed by the restrictions.
+	Truncated bool
+	// Allowed is false if the restrictions do not allow the key, in which case the item is not set.
+	Allowed bool
+}
+
+// BaggageObserver is notified whenever baggage is set or overwritten on a span, e.g. to audit which
+// services set which baggage items, see TracerOptions.BaggageObserver. It is called while the span
+// is locked, so it must not call the methods of the span, and it must be safe for concurrent use.
+type BaggageObserver interface {
+	OnSetBaggageItem(change BaggageChange)
+}
+
+// BaggageObserverFunc is a function implementing BaggageObserver.
+type BaggageObserverFunc func(change BaggageChange)
+
+// OnSetBaggageItem implements BaggageObserver.
+func (f BaggageObserverFunc) OnSetBaggageItem(change BaggageChange) {
+	f(change)
+}
+
+// compositeBaggageObserver notifies several baggage observers.
+type compositeBaggageObserver []BaggageObserver
+
+func (o compositeBaggageObserver) OnSetBaggageItem(change BaggageChange) {
+	for _, observer := range o {
+		observer.OnSetBaggageItem(change)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/internal/baggage"
+)
+
+type restrictKey struct {
+	baggage.RestrictionManager
+	key string
+}
+
+func (r restrictKey) GetRestriction(service, key string) *baggage.Restriction {
+	if key == r.key {
+		return baggage.NewRestriction(false, 0)
+	}
+	return baggage.NewRestriction(true, 5)
+}
+
+func TestBaggageObserver(t *testing.T) {
+	var changes, otherChanges []BaggageChange
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.BaggageRestrictionManager(restrictKey{key: "password"}),
+		TracerOptions.BaggageObserver(BaggageObserverFunc(func(change BaggageChange) {
+			changes = append(changes, change)
+		})),
+		TracerOptions.BaggageObserver(BaggageObserverFunc(func(change BaggageChange) {
+			otherChanges = append(otherChanges, change)
+		})))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("x").(*Span)
+	sp.SetBaggageItem("user", "bob")
+	sp.SetBaggageItem("user", "alice-smith")
+	sp.SetBaggageItem("password", "secret")
+
+	require.Len(t, changes, 3)
+	assert.Equal(t, changes, otherChanges)
+	for _, change := range changes {
+		assert.Equal(t, "x", change.ServiceName)
+		assert.Equal(t, sp.context.spanID, change.Context.spanID)
+	}
+	assert.Equal(t, BaggageChange{Key: "user", NewValue: "bob", Allowed: true},
+		BaggageChange{Key: changes[0].Key, NewValue: changes[0].NewValue, Allowed: changes[0].Allowed, Overwritten: changes[0].Overwritten})
+	assert.Equal(t, "bob", changes[1].OldValue)
+	assert.Equal(t, "alice", changes[1].NewValue)
+	assert.True(t, changes[1].Overwritten)
+	assert.True(t, changes[1].Truncated)
+	assert.Equal(t, "password", changes[2].Key)
+	assert.False(t, changes[2].Allowed)
+	assert.Empty(t, sp.BaggageItem("password"))
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+// baggagePropagationFilter decides which baggage items are injected into the carriers of outbound
+// requests, see TracerOptions.PropagatedBaggage and TracerOptions.LocalBaggage.
+type baggagePropagationFilter struct {
+	allowed *keyMatcher // nil if all keys are allowed
+	denied  *keyMatcher
+	denyAll bool // if the patterns are invalid, no baggage is propagated
+}
+
+// newBaggagePropagationFilter returns nil if all baggage items are propagated.
+func newBaggagePropagationFilter(allowed, denied []string) (*baggagePropagationFilter, error) {
+	if len(allowed) == 0 && len(denied) == 0 {
+		return nil, nil
+	}
+	allowedMatcher, err := newKeyMatcher(allowed)
+	if err != nil {
+		return &baggagePropagationFilter{denyAll: true}, err
+	}
+	deniedMatcher, err := newKeyMatcher(denied)
+	if err != nil {
+		return &baggagePropagationFilter{denyAll: true}, err
+	}
+	return &baggagePropagationFilter{allowed: allowedMatcher, denied: deniedMatcher}, nil
+}
+
+func (f *baggagePropagationFilter) propagates(key string) bool {
+	if f.denyAll || f.denied.matches(key) {
+		return false
+	}
+	// the debug level is not application baggage, it is only kept local if listed in LocalBaggage
+	return f.allowed == nil || key == DebugLevelBaggageKey || f.allowed.matches(key)
+}
+
+// filter returns the context with only the baggage items that can be propagated.
+func (f *baggagePropagationFilter) filter(ctx SpanContext) SpanContext {
+	if f == nil || len(ctx.baggage) == 0 {
+		return ctx
+	}
+	var baggage map[string]string
+	for k, v := range ctx.baggage {
+		if f.propagates(k) {
+			if baggage == nil {
+				baggage = make(map[string]string, len(ctx.baggage))
+			}
+			baggage[k] = v
+		}
+	}
+	ctx.baggage = baggage
+	return ctx
+}
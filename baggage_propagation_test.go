@@ -0,0 +1,89 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestBaggagePropagationFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []TracerOption
+		propagated map[string]string
+	}{
+		{
+			name:       "all",
+			propagated: map[string]string{"tenant": "acme", "x-user": "bob", "x-internal": "secret"},
+		},
+		{
+			name:       "allowlist",
+			options:    []TracerOption{TracerOptions.PropagatedBaggage("tenant")},
+			propagated: map[string]string{"tenant": "acme"},
+		},
+		{
+			name:       "denylist",
+			options:    []TracerOption{TracerOptions.LocalBaggage("x-internal")},
+			propagated: map[string]string{"tenant": "acme", "x-user": "bob"},
+		},
+		{
+			name: "both",
+			options: []TracerOption{
+				TracerOptions.PropagatedBaggage("x-*"),
+				TracerOptions.LocalBaggage("x-internal"),
+			},
+			propagated: map[string]string{"x-user": "bob"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(), tc.options...)
+			defer closer.Close()
+
+			sp := tracer.StartSpan("x")
+			sp.SetBaggageItem("tenant", "acme")
+			sp.SetBaggageItem("x-user", "bob")
+			sp.SetBaggageItem("x-internal", "secret")
+			assert.Equal(t, "secret", sp.BaggageItem("x-internal"), "all baggage is available in the process")
+
+			carrier := opentracing.TextMapCarrier{}
+			require.NoError(t, tracer.Inject(sp.Context(), opentracing.TextMap, carrier))
+			extracted, err := tracer.Extract(opentracing.TextMap, carrier)
+			require.NoError(t, err)
+			assert.Equal(t, tc.propagated, extracted.(SpanContext).baggage)
+		})
+	}
+}
+
+func TestBaggagePropagationFilterInvalidPattern(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.LocalBaggage("[invalid"),
+		TracerOptions.Logger(logger))
+	defer closer.Close()
+	assert.Contains(t, logger.String(), "no baggage is propagated")
+
+	sp := tracer.StartSpan("x")
+	sp.SetBaggageItem("tenant", "acme")
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(sp.Context(), opentracing.TextMap, carrier))
+	assert.Len(t, carrier, 1, "only the trace context is propagated")
+}
@@ -25,34 +25,132 @@ import (
 type baggageSetter struct {
 	restrictionManager baggage.RestrictionManager
 	metrics            *Metrics
+	keyMetrics         *baggageMetricsByKey
+	observer           BaggageObserver
+	transformers       []keyedBaggageTransformer
+	namespaces         []*baggageNamespaceRestrictions
+
+	// maxBaggageSize is the budget of the total size of the baggage, see TracerOptions.MaxBaggageSize
+	maxBaggageSize int
+	// signer counts the signatures of the signed baggage items toward maxBaggageSize
+	signer *baggageSigner
 }
 
 func newBaggageSetter(restrictionManager baggage.RestrictionManager, metrics *Metrics) *baggageSetter {
 	return &baggageSetter{
 		restrictionManager: restrictionManager,
 		metrics:            metrics,
+		keyMetrics:         newBaggageMetricsByKey(nil, nil),
 	}
 }
 
 // (NB) span should hold the lock before making this call
 func (s *baggageSetter) setBaggage(span *Span, key, value string) {
-	var truncated bool
-	var prevItem string
-	restriction := s.restrictionManager.GetRestriction(span.serviceName(), key)
+	s.setBaggageWithMetadata(span, key, value, "")
+}
+
+// (NB) span should hold the lock before making this call
+func (s *baggageSetter) setBaggageWithMetadata(span *Span, key, value, metadata string) {
+	s.set(span, key, value, metadata, false)
+}
+
+// (NB) span should hold the lock before making this call
+func (s *baggageSetter) setScopedBaggage(span *Span, key, value string) {
+	s.set(span, key, value, "", true)
+}
+
+// set sets the baggage item on the span. The baggage of the registered namespaces can only be set
+// when scoped, i.e. through a BaggageNamespace.
+func (s *baggageSetter) set(span *Span, key, value, metadata string, scoped bool) {
+	update := s.updateBaggage(span.serviceName(), span.context, key, value, metadata, scoped)
+	key = update.key
+	s.logFields(span, key, update.value, update.prevItem, update.truncated, update.valid)
+	if update.rejection != nil {
+		span.addWarningNoLocking("baggage item %q rejected: %s", key, update.rejection.Error())
+	} else if update.overBudget {
+		span.addWarningNoLocking("baggage item %q rejected, the baggage would exceed %d bytes", key, s.maxBaggageSize)
+	} else if !update.valid {
+		span.addWarningNoLocking("baggage item %q not allowed by the baggage restrictions", key)
+	} else if update.truncated {
+		span.addWarningNoLocking("baggage item %q truncated to %d characters", key, len(update.value))
+	}
+	span.context = update.context
+	if update.valid {
+		span.setBaggageTagNoLocking(key, update.value)
+	}
+}
+
+// baggageUpdate is the result of applying the baggage restrictions to a new baggage item.
+type baggageUpdate struct {
+	context    SpanContext
+	key        string
+	value      string
+	prevItem   string
+	truncated  bool
+	valid      bool
+	overBudget bool
+	rejection  error // the error of the BaggageTransformer rejecting the item
+}
+
+func (s *baggageSetter) updateBaggage(
+	serviceName string,
+	ctx SpanContext,
+	key, value, metadata string,
+	scoped bool,
+) baggageUpdate {
+	var update baggageUpdate
+	k, v, err := s.transformBaggage(key, value)
+	if err == nil && !scoped {
+		err = s.reservedKeyError(k)
+	}
+	if err != nil {
+		s.metrics.BaggageUpdateFailure.Inc(1)
+		update = baggageUpdate{context: ctx, key: key, value: value, rejection: err}
+	} else {
+		key, value = k, v
+		update = s.applyRestriction(serviceName, ctx, key, value, metadata)
+	}
+	if s.observer != nil {
+		oldValue, overwritten := ctx.baggage[key]
+		s.observer.OnSetBaggageItem(BaggageChange{
+			ServiceName: serviceName,
+			Context:     ctx,
+			Key:         key,
+			OldValue:    oldValue,
+			NewValue:    update.value,
+			Overwritten: overwritten,
+			Truncated:   update.truncated,
+			Allowed:     update.valid,
+		})
+	}
+	return update
+}
+
+func (s *baggageSetter) applyRestriction(serviceName string, ctx SpanContext, key, value, metadata string) baggageUpdate {
+	update := baggageUpdate{context: ctx, key: key, value: value}
+	restriction := s.getRestriction(serviceName, key)
 	if !restriction.KeyAllowed() {
-		s.logFields(span, key, value, prevItem, truncated, restriction.KeyAllowed())
 		s.metrics.BaggageUpdateFailure.Inc(1)
-		return
+		return update
 	}
+	update.valid = true
 	if len(value) > restriction.MaxValueLength() {
-		truncated = true
-		value = value[:restriction.MaxValueLength()]
+		update.truncated = true
+		update.value = value[:restriction.MaxValueLength()]
 		s.metrics.BaggageTruncate.Inc(1)
+		s.keyMetrics.get(key).Truncations.Inc(1)
+	}
+	if s.exceedsBudget(ctx.baggage, key, update.value) {
+		update.valid = false
+		update.overBudget = true
+		s.metrics.BaggageUpdateFailure.Inc(1)
+		s.keyMetrics.get(key).BudgetExceeded.Inc(1)
+		return update
 	}
-	prevItem = span.context.baggage[key]
-	s.logFields(span, key, value, prevItem, truncated, restriction.KeyAllowed())
-	span.context = span.context.WithBaggageItem(key, value)
+	update.prevItem = ctx.baggage[key]
+	update.context = ctx.WithBaggageItemMetadata(key, update.value, metadata)
 	s.metrics.BaggageUpdateSuccess.Inc(1)
+	return update
 }
 
 func (s *baggageSetter) logFields(span *Span, key, value, prevItem string, truncated, valid bool) {
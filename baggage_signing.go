@@ -0,0 +1,160 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// BaggageSignatureSuffix is appended to the key of a signed baggage item to form the key of the
+// baggage item holding its signature, see TracerOptions.SignedBaggage.
+const BaggageSignatureSuffix = ".sig"
+
+// baggageSigner signs the designated baggage items on Inject and verifies them on Extract,
+// see TracerOptions.SignedBaggage. The keys are signed in lower case, because the HTTP headers
+// extractor lowercases the keys of the baggage items.
+type baggageSigner struct {
+	secret  []byte
+	keys    map[string]struct{}
+	metrics *Metrics
+	// denyAll is true if the secret is empty, the signed baggage items are then never propagated
+	denyAll bool
+}
+
+// signatureLength is the length of the base64 encoded signatures.
+var signatureLength = base64.RawURLEncoding.EncodedLen(sha256.Size)
+
+// newBaggageSigner returns nil if no baggage items are signed. If the secret is empty,
+// it returns an error and a signer dropping the signed baggage items.
+func newBaggageSigner(secret []byte, keys []string, metrics *Metrics) (*baggageSigner, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	s := &baggageSigner{
+		secret:  secret,
+		keys:    make(map[string]struct{}, len(keys)),
+		metrics: metrics,
+	}
+	for _, k := range keys {
+		s.keys[strings.ToLower(k)] = struct{}{}
+	}
+	if len(secret) == 0 {
+		s.denyAll = true
+		return s, errors.New("the secret of the signed baggage is empty")
+	}
+	return s, nil
+}
+
+// signedKey returns the canonical key of the baggage item if it is signed.
+func (s *baggageSigner) signedKey(key string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	key = strings.ToLower(key)
+	_, ok := s.keys[key]
+	return key, ok
+}
+
+// isSignature returns true if the baggage item holds the signature of a signed baggage item.
+func (s *baggageSigner) isSignature(key string) bool {
+	key = strings.ToLower(key)
+	if !strings.HasSuffix(key, BaggageSignatureSuffix) {
+		return false
+	}
+	_, ok := s.keys[strings.TrimSuffix(key, BaggageSignatureSuffix)]
+	return ok
+}
+
+// signatureSize returns the size of the baggage item holding the signature of the baggage item
+// with the given key, or 0 if it is not signed. It counts toward TracerOptions.MaxBaggageSize.
+func (s *baggageSigner) signatureSize(key string) int {
+	key, ok := s.signedKey(key)
+	if !ok {
+		return 0
+	}
+	return len(key) + len(BaggageSignatureSuffix) + signatureLength
+}
+
+// signature returns the HMAC-SHA256 of the baggage item, bound to the trace so that
+// the signed items cannot be replayed in other traces.
+func (s *baggageSigner) signature(traceID TraceID, key, value string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(traceID.String()))
+	mac.Write([]byte{0})
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sign returns the context with the signatures of the signed baggage items added to the baggage.
+func (s *baggageSigner) sign(ctx SpanContext) SpanContext {
+	if s == nil || len(ctx.baggage) == 0 {
+		return ctx
+	}
+	var baggage map[string]string
+	for k, v := range ctx.baggage {
+		key, ok := s.signedKey(k)
+		if !ok {
+			continue
+		}
+		if baggage == nil {
+			baggage = make(map[string]string, len(ctx.baggage)+len(s.keys))
+			for k, v := range ctx.baggage {
+				baggage[k] = v
+			}
+		}
+		if s.denyAll {
+			delete(baggage, k)
+			continue
+		}
+		baggage[key+BaggageSignatureSuffix] = s.signature(ctx.traceID, key, v)
+	}
+	if baggage != nil {
+		ctx.baggage = baggage
+	}
+	return ctx
+}
+
+// verify returns the context without the signatures of the signed baggage items, and without
+// the signed baggage items whose signatures are missing or invalid.
+func (s *baggageSigner) verify(ctx SpanContext) SpanContext {
+	if s == nil || len(ctx.baggage) == 0 {
+		return ctx
+	}
+	baggage := make(map[string]string, len(ctx.baggage))
+	for k, v := range ctx.baggage {
+		if !s.isSignature(k) {
+			baggage[k] = v
+		}
+	}
+	for k, v := range baggage {
+		key, ok := s.signedKey(k)
+		if !ok {
+			continue
+		}
+		sig, signed := ctx.baggage[key+BaggageSignatureSuffix]
+		if s.denyAll || !signed || !hmac.Equal([]byte(sig), []byte(s.signature(ctx.traceID, key, v))) {
+			delete(baggage, k)
+			s.metrics.BaggageSignatureInvalid.Inc(1)
+		}
+	}
+	ctx.baggage = baggage
+	return ctx
+}
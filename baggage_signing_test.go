@@ -0,0 +1,159 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestSignedBaggage(t *testing.T) {
+	secret := []byte("s3cr3t")
+	upstream, closer := NewTracer("upstream", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.SignedBaggage(secret, "tenant"),
+	)
+	defer closer.Close()
+	factory := metricstest.NewFactory(0)
+	downstream, closer := NewTracer("downstream", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Metrics(NewMetrics(factory, nil)),
+		TracerOptions.SignedBaggage(secret, "tenant"),
+	)
+	defer closer.Close()
+
+	sp := upstream.StartSpan("op")
+	sp.SetBaggageItem("tenant", "acme")
+	sp.SetBaggageItem("user", "bob")
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, upstream.Inject(sp.Context(), opentracing.TextMap, carrier))
+	assert.NotEmpty(t, carrier[TraceBaggageHeaderPrefix+"tenant"+BaggageSignatureSuffix])
+	assert.Empty(t, carrier[TraceBaggageHeaderPrefix+"user"+BaggageSignatureSuffix])
+	assert.Empty(t, sp.Context().(SpanContext).baggage["tenant"+BaggageSignatureSuffix],
+		"the signatures are not added to the context of the span")
+
+	tests := []struct {
+		name    string
+		modify  func(carrier opentracing.TextMapCarrier)
+		tenant  string
+		invalid int
+	}{
+		{
+			name:   "valid",
+			modify: func(opentracing.TextMapCarrier) {},
+			tenant: "acme",
+		},
+		{
+			name: "spoofed value",
+			modify: func(carrier opentracing.TextMapCarrier) {
+				carrier[TraceBaggageHeaderPrefix+"tenant"] = "evil"
+			},
+			invalid: 1,
+		},
+		{
+			name: "missing signature",
+			modify: func(carrier opentracing.TextMapCarrier) {
+				delete(carrier, TraceBaggageHeaderPrefix+"tenant"+BaggageSignatureSuffix)
+			},
+			invalid: 1,
+		},
+		{
+			name: "replayed in another trace",
+			modify: func(carrier opentracing.TextMapCarrier) {
+				carrier[TraceContextHeaderName] = "1:2:0:1"
+			},
+			invalid: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := opentracing.TextMapCarrier{}
+			for k, v := range carrier {
+				c[k] = v
+			}
+			tc.modify(c)
+			ctx, err := downstream.Extract(opentracing.TextMap, c)
+			require.NoError(t, err)
+			baggage := ctx.(SpanContext).baggage
+			assert.Equal(t, tc.tenant, baggage["tenant"])
+			assert.Equal(t, "bob", baggage["user"], "unsigned items are not verified")
+			_, ok := baggage["tenant"+BaggageSignatureSuffix]
+			assert.False(t, ok, "the signatures are removed")
+			factory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+				Name:  "jaeger.tracer.baggage_signature_invalid",
+				Value: tc.invalid,
+			})
+			factory.Clear()
+		})
+	}
+}
+
+func TestSignedBaggageHTTPHeaders(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.SignedBaggage([]byte("s3cr3t"), "Tenant"),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op")
+	sp.SetBaggageItem("Tenant", "acme")
+	carrier := opentracing.HTTPHeadersCarrier{}
+	require.NoError(t, tracer.Inject(sp.Context(), opentracing.HTTPHeaders, carrier))
+	ctx, err := tracer.Extract(opentracing.HTTPHeaders, carrier)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, ctx.(SpanContext).baggage,
+		"the keys lowercased by the extractor are verified")
+}
+
+func TestSignedBaggageBudget(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.SignedBaggage([]byte("s3cr3t"), "tenant"),
+		TracerOptions.MaxBaggageSize(len("tenant")+len("acme")+len("tenant"+BaggageSignatureSuffix)+signatureLength),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op")
+	sp.SetBaggageItem("tenant", "acme")
+	sp.SetBaggageItem("user", "b")
+	assert.Equal(t, map[string]string{"tenant": "acme"}, sp.Context().(SpanContext).baggage,
+		"the signature counts toward the budget")
+}
+
+func TestSignedBaggageEmptySecret(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Logger(logger),
+		TracerOptions.SignedBaggage(nil, "tenant"),
+	)
+	defer closer.Close()
+	assert.Contains(t, logger.String(), "the secret of the signed baggage is empty")
+
+	sp := tracer.StartSpan("op")
+	sp.SetBaggageItem("tenant", "acme")
+	sp.SetBaggageItem("user", "bob")
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(sp.Context(), opentracing.TextMap, carrier))
+	assert.NotContains(t, carrier, TraceBaggageHeaderPrefix+"tenant")
+
+	carrier[TraceBaggageHeaderPrefix+"tenant"] = "evil"
+	carrier[TraceBaggageHeaderPrefix+"tenant"+BaggageSignatureSuffix] = (&baggageSigner{}).signature(
+		sp.Context().(SpanContext).TraceID(), "tenant", "evil")
+	ctx, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"user": "bob"}, ctx.(SpanContext).baggage)
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// BaggageTransformer validates and transforms the baggage items set on the spans, before the restrictions
+// are applied and the items are stored and propagated. It returns the key and value to store, which can be
+// canonicalized, e.g. lowercased, or an error to reject the baggage item.
+type BaggageTransformer func(key, value string) (string, string, error)
+
+// LowercaseBaggageKey is a BaggageTransformer that lowercases the keys of the baggage items.
+func LowercaseBaggageKey(key, value string) (string, string, error) {
+	return strings.ToLower(key), value, nil
+}
+
+// ASCIIBaggageValue is a BaggageTransformer that rejects the baggage items with non-ASCII values.
+func ASCIIBaggageValue(key, value string) (string, string, error) {
+	for i := 0; i < len(value); i++ {
+		if value[i] >= utf8.RuneSelf {
+			return key, value, fmt.Errorf("non-ASCII value")
+		}
+	}
+	return key, value, nil
+}
+
+// keyedBaggageTransformer is a BaggageTransformer applied to the keys matching the patterns, or to all keys
+// if there are no patterns.
+type keyedBaggageTransformer struct {
+	patterns  []string
+	keys      *keyMatcher
+	transform BaggageTransformer
+}
+
+// compile creates the matcher of the patterns. If the patterns are invalid, the transformer rejects
+// all baggage items rather than skipping a validator.
+func (t *keyedBaggageTransformer) compile() error {
+	matcher, err := newKeyMatcher(t.patterns)
+	if err != nil {
+		t.keys = nil
+		t.transform = func(key, value string) (string, string, error) {
+			return key, value, fmt.Errorf("invalid baggage key pattern: %s", err.Error())
+		}
+		return err
+	}
+	t.keys = matcher
+	return nil
+}
+
+// transformBaggage applies the transformers in the order they were registered, each transformer
+// seeing the key and value returned by the previous ones.
+func (s *baggageSetter) transformBaggage(key, value string) (string, string, error) {
+	for _, t := range s.transformers {
+		if t.keys != nil && !t.keys.matches(key) {
+			continue
+		}
+		var err error
+		if key, value, err = t.transform(key, value); err != nil {
+			return key, value, err
+		}
+	}
+	return key, value, nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestBaggageTransformers(t *testing.T) {
+	canonicalTenant := func(key, value string) (string, string, error) {
+		value = strings.TrimPrefix(strings.ToLower(value), "tenant-")
+		if value == "" {
+			return key, value, errors.New("empty tenant")
+		}
+		return key, value, nil
+	}
+	var changes []BaggageChange
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.BaggageTransformer(LowercaseBaggageKey),
+		TracerOptions.BaggageTransformer(ASCIIBaggageValue, "user-*"),
+		TracerOptions.BaggageTransformer(canonicalTenant, "tenant"),
+		TracerOptions.BaggageObserver(BaggageObserverFunc(func(c BaggageChange) {
+			changes = append(changes, c)
+		})),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.SetBaggageItem("Tenant", "Tenant-ACME")
+	sp.SetBaggageItem("User-Name", "zoë")
+	sp.SetBaggageItem("user-id", "bob")
+	sp.SetBaggageItem("TENANT", "tenant-")
+	sp.SetBaggageItem("other", "zoë")
+
+	assert.Equal(t, map[string]string{"tenant": "acme", "user-id": "bob", "other": "zoë"}, sp.context.baggage)
+	assert.Equal(t, []string{
+		`baggage item "User-Name" rejected: non-ASCII value`,
+		`baggage item "TENANT" rejected: empty tenant`,
+	}, sp.warnings)
+	require.Len(t, changes, 5)
+	assert.Equal(t, "tenant", changes[0].Key)
+	assert.Equal(t, "acme", changes[0].NewValue)
+	assert.True(t, changes[0].Allowed)
+	assert.False(t, changes[1].Allowed)
+}
+
+func TestBaggageTransformersUnsampled(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.MinimalUnsampledSpans(true),
+		TracerOptions.BaggageTransformer(LowercaseBaggageKey),
+	)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root")
+	defer root.Finish()
+	sp := tracer.StartSpan("op", opentracing.ChildOf(root.Context()))
+	require.IsType(t, &unsampledSpan{}, sp)
+	sp.SetBaggageItem("User-ID", "bob")
+	assert.Equal(t, "bob", sp.BaggageItem("user-id"))
+}
+
+func TestBaggageTransformerInvalidPattern(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Logger(logger),
+		TracerOptions.BaggageTransformer(LowercaseBaggageKey, "["),
+	)
+	defer closer.Close()
+	assert.Contains(t, logger.String(), "Invalid baggage key pattern of a BaggageTransformer")
+
+	sp := tracer.StartSpan("op")
+	sp.SetBaggageItem("tenant", "acme")
+	assert.Equal(t, "", sp.BaggageItem("tenant"))
+}
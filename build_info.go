@@ -0,0 +1,43 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package jaeger
+
+import (
+	"runtime/debug"
+)
+
+// readBuildInfo returns the build information embedded in the binary, if any.
+func readBuildInfo() (buildInfo, bool) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return buildInfo{}, false
+	}
+	info := buildInfo{
+		module:  bi.Main.Path,
+		version: bi.Main.Version,
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.vcsRevision = setting.Value
+		case "vcs.modified":
+			info.vcsModified = setting.Value
+		}
+	}
+	return info, true
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import "time"
+
+// Clock is the source of time of the tracer, see TracerOptions.Clock.
+type Clock interface {
+	// Now returns the wall clock time, used for the timestamps of spans and logs.
+	Now() time.Time
+
+	// Monotonic returns the reading of a monotonic clock, used to compute the durations of spans.
+	// The readings are only meaningful relative to each other.
+	Monotonic() time.Duration
+}
+
+// systemClock is the default Clock. Durations are measured with the monotonic clock reading
+// of time.Now, so they are not affected by changes of the wall clock.
+type systemClock struct {
+	start time.Time
+}
+
+func newSystemClock() *systemClock {
+	return &systemClock{start: time.Now()}
+}
+
+func (c *systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *systemClock) Monotonic() time.Duration {
+	return time.Since(c.start)
+}
+
+// monotonicNow returns the monotonic clock reading, and false if the tracer
+// has no monotonic clock because it was configured with TracerOptions.TimeNow.
+func (t *Tracer) monotonicNow() (time.Duration, bool) {
+	if t.clock == nil {
+		return 0, false
+	}
+	return t.clock.Monotonic(), true
+}
+
+// elapsedNoLocking returns the time elapsed since the start of the span until the given time.
+// It uses the monotonic clock readings when both are known, and never returns a negative duration.
+func (s *Span) elapsedNoLocking(wall time.Time, monotonic time.Duration, hasMonotonic bool) time.Duration {
+	var elapsed time.Duration
+	if hasMonotonic && s.hasStartMonotonic {
+		elapsed = monotonic - s.startMonotonic
+	} else {
+		elapsed = wall.Sub(s.startTime)
+	}
+	if elapsed < 0 {
+		return 0
+	}
+	return elapsed
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ClockSkewTagKey is the process tag with the offset of the clock of the remote time source from
+// the local clock in milliseconds, see TracerOptions.ClockSkewDetection. A positive value means
+// that the local clock is behind.
+const ClockSkewTagKey = "jaeger.clock_skew_ms"
+
+// clockSkewTagThreshold is the minimal change of the measured offset that updates the ClockSkewTagKey
+// process tag, since each update changes the process of the spans, and makes the transports emit the
+// spans buffered until then in a separate batch.
+const clockSkewTagThreshold = 100 * time.Millisecond
+
+// httpDateSamples is the number of requests of HTTPDateClock to measure the offset of the remote clock,
+// each of them but the first halves the uncertainty of one second of the Date header.
+const httpDateSamples = 5
+
+// RemoteClock returns the current time of a remote reference clock, e.g. of the host of the agent
+// or the collector, which the tracer compares against the local time to detect clock skew.
+type RemoteClock interface {
+	Now() (time.Time, error)
+}
+
+// RemoteClockFunc wraps a function into RemoteClock.
+type RemoteClockFunc func() (time.Time, error)
+
+// Now implements RemoteClock.
+func (f RemoteClockFunc) Now() (time.Time, error) {
+	return f()
+}
+
+// HTTPDateClock returns a RemoteClock that reads the time from the Date header of the responses
+// to HEAD requests to the given URL, e.g. the endpoint of the collector. Each request is limited
+// by the timeout (DefaultResourceDetectionTimeout if zero). The Date header has a resolution of
+// one second, so a few requests are timed around the change of the second of the remote clock
+// to measure its offset to within about a tenth of a second, which takes up to a few seconds.
+func HTTPDateClock(url string, timeout time.Duration) RemoteClock {
+	if timeout == 0 {
+		timeout = DefaultResourceDetectionTimeout
+	}
+	return &httpDateClock{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+		after:  time.After,
+	}
+}
+
+type httpDateClock struct {
+	url    string
+	client *http.Client
+	after  func(time.Duration) <-chan time.Time
+}
+
+// clockOffsetMeasurer is implemented by the RemoteClocks measuring their offset from the local
+// clock themselves, over several requests.
+type clockOffsetMeasurer interface {
+	offset(now func() time.Time, stop <-chan struct{}) (time.Duration, error)
+}
+
+// Now implements RemoteClock.
+func (c *httpDateClock) Now() (time.Time, error) {
+	offset, err := c.offset(time.Now, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().Add(offset), nil
+}
+
+// offset measures the offset of the remote clock from the local clock. Each response bounds the offset
+// to an interval of one second, since the Date header is truncated to the second. Each request after the
+// first one is timed so that the remote clock changes its second at the middle of the current bounds,
+// so that the response tells in which half the offset is. It returns the estimate so far if stop is closed.
+func (c *httpDateClock) offset(now func() time.Time, stop <-chan struct{}) (time.Duration, error) {
+	var lower, upper, rtt time.Duration
+	for i := 0; i < httpDateSamples; i++ {
+		if i > 0 {
+			remote := now().Add(lower + (upper-lower)/2)
+			wait := remote.Truncate(time.Second).Add(time.Second).Sub(remote) - rtt/2
+			if wait < 0 {
+				wait += time.Second
+			}
+			select {
+			case <-c.after(wait):
+			case <-stop:
+				return lower + (upper-lower)/2, nil
+			}
+		}
+		before := now()
+		date, err := c.date()
+		if err != nil {
+			return 0, err
+		}
+		after := now()
+		rtt = after.Sub(before)
+		local := before.Add(rtt / 2)
+		low, high := date.Sub(local)-rtt/2, date.Add(time.Second).Sub(local)+rtt/2
+		if i == 0 || low > upper || high < lower {
+			// the bounds are reset if inconsistent, e.g. when a clock was adjusted
+			lower, upper = low, high
+			continue
+		}
+		if low > lower {
+			lower = low
+		}
+		if high < upper {
+			upper = high
+		}
+	}
+	return lower + (upper-lower)/2, nil
+}
+
+func (c *httpDateClock) date() (time.Time, error) {
+	resp, err := c.client.Head(c.url)
+	if err != nil {
+		return time.Time{}, err
+	}
+	resp.Body.Close()
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid Date header of %s: %v", c.url, err)
+	}
+	return date, nil
+}
+
+// clockSkewDetector periodically measures the offset of the remote clock from the local clock,
+// and reports it in the ClockSkewTagKey process tag.
+type clockSkewDetector struct {
+	tracer   *Tracer
+	clock    RemoteClock
+	interval time.Duration
+	failing  bool
+	// reported is the offset in the process tag, if hasReported
+	reported    time.Duration
+	hasReported bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  sync.WaitGroup
+}
+
+func newClockSkewDetector(tracer *Tracer, clock RemoteClock, interval time.Duration) *clockSkewDetector {
+	d := &clockSkewDetector{
+		tracer:   tracer,
+		clock:    clock,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+	d.stopped.Add(1)
+	go d.run()
+	return d
+}
+
+func (d *clockSkewDetector) run() {
+	defer d.stopped.Done()
+	d.measure()
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.measure()
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// measure compares the remote time against the local time in the middle of the round trip
+// to the remote clock, and updates the process tag if the offset changed by clockSkewTagThreshold.
+func (d *clockSkewDetector) measure() {
+	offset, err := d.offset()
+	if err != nil {
+		// only the first of consecutive failures is logged
+		if !d.failing {
+			d.tracer.logger.Error("Unable to measure clock skew: " + err.Error())
+		}
+		d.failing = true
+		return
+	}
+	d.failing = false
+	select {
+	case <-d.stop:
+		return // the measurement was interrupted
+	default:
+	}
+	if change := offset - d.reported; d.hasReported && change < clockSkewTagThreshold && change > -clockSkewTagThreshold {
+		return
+	}
+	d.reported, d.hasReported = offset, true
+	d.tracer.SetProcessTag(ClockSkewTagKey, int64(offset/time.Millisecond))
+}
+
+func (d *clockSkewDetector) offset() (time.Duration, error) {
+	if measurer, ok := d.clock.(clockOffsetMeasurer); ok {
+		return measurer.offset(d.tracer.timeNow, d.stop)
+	}
+	before := d.tracer.timeNow()
+	remote, err := d.clock.Now()
+	if err != nil {
+		return 0, err
+	}
+	after := d.tracer.timeNow()
+	return remote.Sub(before.Add(after.Sub(before) / 2)), nil
+}
+
+func (d *clockSkewDetector) close() {
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+	d.stopped.Wait()
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestClockSkewDetection(t *testing.T) {
+	clock := &steppedClock{wall: time.Unix(1500000000, 0)}
+	remote := RemoteClockFunc(func() (time.Time, error) {
+		return time.Unix(1500000002, 0), nil
+	})
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Clock(clock),
+		TracerOptions.ClockSkewDetection(remote, time.Hour),
+	)
+	defer closer.Close()
+
+	assert.Eventually(t, func() bool {
+		tags, _ := tracer.(*Tracer).processTags()
+		return assert.ObjectsAreEqual(Tag{key: ClockSkewTagKey, value: int64(2000)}, tags[len(tags)-1])
+	}, time.Second, time.Millisecond)
+	assert.Contains(t, tracer.(*Tracer).Tags(), opentracing.Tag{Key: ClockSkewTagKey, Value: int64(2000)})
+}
+
+func TestClockSkewDetectionError(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	calls := make(chan struct{}, 10)
+	remote := RemoteClockFunc(func() (time.Time, error) {
+		calls <- struct{}{}
+		return time.Time{}, errors.New("unreachable")
+	})
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Logger(logger),
+		TracerOptions.ClockSkewDetection(remote, time.Millisecond),
+	)
+	for i := 0; i < 3; i++ {
+		<-calls
+	}
+	closer.Close()
+
+	assert.Equal(t, "ERROR: Unable to measure clock skew: unreachable\n", logger.String(),
+		"consecutive failures are logged once")
+	_, ok := tracer.(*Tracer).getTag(ClockSkewTagKey)
+	assert.False(t, ok)
+}
+
+func TestHTTPDateClock(t *testing.T) {
+	var lock sync.Mutex
+	local := time.Date(2017, 7, 14, 2, 40, 0, 300*int(time.Millisecond), time.UTC)
+	now := func() time.Time {
+		lock.Lock()
+		defer lock.Unlock()
+		return local
+	}
+	for _, skew := range []time.Duration{1234 * time.Millisecond, -200 * time.Millisecond, 5 * time.Second} {
+		t.Run(skew.String(), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodHead, r.Method)
+				w.Header().Set("Date", now().Add(skew).Format(http.TimeFormat))
+			}))
+			defer server.Close()
+
+			clock := HTTPDateClock(server.URL, 0).(*httpDateClock)
+			clock.after = func(d time.Duration) <-chan time.Time {
+				lock.Lock()
+				defer lock.Unlock()
+				local = local.Add(d)
+				return time.After(0)
+			}
+			offset, err := clock.offset(now, nil)
+			require.NoError(t, err)
+			assert.InDelta(t, float64(skew), float64(offset), float64(50*time.Millisecond),
+				"the offset is measured to within less than the resolution of the Date header")
+		})
+	}
+}
+
+func TestHTTPDateClockNow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/invalid" {
+			w.Header()["Date"] = []string{"yesterday"}
+			return
+		}
+		w.Header().Set("Date", time.Now().Add(time.Hour).Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	clock := HTTPDateClock(server.URL, 0).(*httpDateClock)
+	clock.after = func(time.Duration) <-chan time.Time { return time.After(0) }
+	now, err := clock.Now()
+	require.NoError(t, err)
+	assert.InDelta(t, float64(time.Hour), float64(now.Sub(time.Now())), float64(2*time.Second))
+
+	_, err = HTTPDateClock(server.URL+"/invalid", 0).Now()
+	assert.Error(t, err)
+}
+
+func TestClockSkewDetectionThreshold(t *testing.T) {
+	offsets := make(chan time.Duration)
+	remote := RemoteClockFunc(func() (time.Time, error) {
+		return time.Now().Add(<-offsets), nil
+	})
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.ClockSkewDetection(remote, time.Millisecond),
+	)
+	defer closer.Close()
+	jTracer := tracer.(*Tracer)
+
+	offsets <- time.Second
+	offsets <- time.Second + 10*time.Millisecond // the first measurement is done
+	_, version := jTracer.processTags()
+	offsets <- time.Second - 10*time.Millisecond
+	offsets <- 2 * time.Second
+	offsets <- 2 * time.Second
+	_, last := jTracer.processTags()
+	assert.Equal(t, version+1, last, "only the change beyond the threshold updates the process")
+	value, _ := jTracer.getTag(ClockSkewTagKey)
+	assert.InDelta(t, 2000, value, 5)
+}
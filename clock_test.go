@@ -0,0 +1,102 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// steppedClock is a Clock whose wall time can be stepped independently of the monotonic time.
+type steppedClock struct {
+	wall      time.Time
+	monotonic time.Duration
+}
+
+func (c *steppedClock) Now() time.Time           { return c.wall }
+func (c *steppedClock) Monotonic() time.Duration { return c.monotonic }
+
+func (c *steppedClock) advance(d, wallStep time.Duration) {
+	c.monotonic += d
+	c.wall = c.wall.Add(d + wallStep)
+}
+
+func TestSpanDurationMonotonic(t *testing.T) {
+	clock := &steppedClock{wall: time.Unix(1500000000, 0)}
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter, TracerOptions.Clock(clock))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("stepped-back")
+	clock.advance(time.Second, -time.Hour)
+	sp.Finish()
+
+	sp = tracer.StartSpan("stepped-forward")
+	clock.advance(time.Second, time.Hour)
+	sp.Finish()
+
+	spans := reporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, time.Second, spans[0].(*Span).Duration())
+	assert.Equal(t, time.Second, spans[1].(*Span).Duration())
+}
+
+func TestSpanDurationExplicitTimestamps(t *testing.T) {
+	clock := &steppedClock{wall: time.Unix(1500000000, 0)}
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter, TracerOptions.Clock(clock))
+	defer closer.Close()
+
+	start := clock.wall.Add(-time.Minute)
+	sp := tracer.StartSpan("explicit-start", opentracing.StartTime(start))
+	clock.advance(time.Second, 0)
+	sp.Finish()
+
+	sp = tracer.StartSpan("finish-before-start")
+	sp.FinishWithOptions(opentracing.FinishOptions{FinishTime: clock.wall.Add(-time.Second)})
+
+	spans := reporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, time.Minute+time.Second, spans[0].(*Span).Duration())
+	assert.Equal(t, time.Duration(0), spans[1].(*Span).Duration())
+}
+
+func TestSpanDurationTimeNow(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter,
+		TracerOptions.Clock(&steppedClock{}),
+		TracerOptions.TimeNow(func() time.Time { return now }),
+	)
+	defer closer.Close()
+	assert.Nil(t, tracer.(*Tracer).clock)
+
+	sp := tracer.StartSpan("op")
+	now = now.Add(time.Second)
+	sp.Finish()
+	require.Len(t, reporter.GetSpans(), 1)
+	assert.Equal(t, time.Second, reporter.GetSpans()[0].(*Span).Duration())
+}
+
+func TestSystemClock(t *testing.T) {
+	clock := newSystemClock()
+	first := clock.Monotonic()
+	assert.True(t, clock.Monotonic() >= first)
+	assert.False(t, clock.Now().IsZero())
+}
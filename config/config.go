@@ -15,19 +15,26 @@
 package config
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
 
 	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/internal/baggage/file"
 	"github.com/uber/jaeger-client-go/internal/baggage/remote"
 	throttler "github.com/uber/jaeger-client-go/internal/throttler/remote"
+	"github.com/uber/jaeger-client-go/log"
 	"github.com/uber/jaeger-client-go/rpcmetrics"
 	"github.com/uber/jaeger-client-go/transport"
+	"github.com/uber/jaeger-client-go/utils"
 	"github.com/uber/jaeger-lib/metrics"
 )
 
@@ -48,11 +55,102 @@ type Configuration struct {
 	// Tags can be provided via environment variable named JAEGER_TAGS
 	Tags []opentracing.Tag `yaml:"tags"`
 
+	// Gen128Bit instructs the tracer to generate 128-bit trace IDs, see jaeger.TracerOptions.Gen128Bit.
+	// Can be provided via environment variable named JAEGER_TRACEID_128BIT
+	Gen128Bit bool `yaml:"gen128Bit"`
+
+	// Propagation lists the formats used to inject and extract span contexts in HTTP headers and text
+	// maps, "jaeger" and/or "b3". With several formats, the span contexts are injected in all of them,
+	// and extracted from the first format found in the carrier. Defaults to "jaeger".
+	// Can be provided via environment variable named JAEGER_PROPAGATION, e.g. "jaeger,b3"
+	Propagation []string `yaml:"propagation"`
+
+	// MaxTagValueLength is the max length of the string values of the tags, see
+	// jaeger.TracerOptions.MaxTagValueLength. Zero means jaeger.DefaultMaxTagValueLength.
+	// Can be provided via environment variable named JAEGER_MAX_TAG_VALUE_LENGTH
+	MaxTagValueLength int `yaml:"maxTagValueLength"`
+
+	// MaxLogsPerSecond limits the number of logs recorded on each span per second, see
+	// jaeger.TracerOptions.MaxLogsPerSecond. Zero disables the limit.
+	// Can be provided via environment variable named JAEGER_SPAN_MAX_LOGS_PER_SECOND
+	MaxLogsPerSecond int `yaml:"maxLogsPerSecond"`
+
+	// EnvPrecedence controls how Configuration.FromEnv merges the environment variables with the values
+	// set in code: EnvOverridesCode (the default) replaces the values set in code, while CodeOverridesEnv
+	// only fills the values left empty.
+	EnvPrecedence EnvPrecedence `yaml:"envPrecedence"`
+
+	// EnvPrecedenceByVar overrides EnvPrecedence for individual environment variables, e.g.
+	// {"JAEGER_SERVICE_NAME": CodeOverridesEnv} keeps the service name set in code while the
+	// other environment variables still apply.
+	EnvPrecedenceByVar map[string]EnvPrecedence `yaml:"envPrecedenceByVar"`
+
 	Sampler             *SamplerConfig             `yaml:"sampler"`
 	Reporter            *ReporterConfig            `yaml:"reporter"`
 	Headers             *jaeger.HeadersConfig      `yaml:"headers"`
 	BaggageRestrictions *BaggageRestrictionsConfig `yaml:"baggage_restrictions"`
 	Throttler           *ThrottlerConfig           `yaml:"throttler"`
+	Limits              *LimitsConfig              `yaml:"limits"`
+
+	// Services configures several services sharing the same reporter and transport, for binaries
+	// hosting several services that report under different names, see NewServiceTracers.
+	Services []ServiceConfig `yaml:"services"`
+}
+
+// LimitsConfig configures the limits protecting the tracer, the reporter and the downstream services
+// from oversized spans and baggage. The options given to NewTracer take precedence over these values.
+type LimitsConfig struct {
+	// MaxTagsPerSpan limits the number of tags of a span, see jaeger.TracerOptions.MaxTagsPerSpan.
+	// Zero disables the limit.
+	// Can be provided via environment variable named JAEGER_SPAN_MAX_TAGS
+	MaxTagsPerSpan int `yaml:"maxTagsPerSpan"`
+
+	// MaxLogsPerSpan limits the number of logs recorded on a span, see jaeger.TracerOptions.LogRetentionPolicy.
+	// Zero disables the limit.
+	// Can be provided via environment variable named JAEGER_SPAN_MAX_LOGS
+	MaxLogsPerSpan int `yaml:"maxLogsPerSpan"`
+
+	// LogRetention selects which logs are kept once a span reaches MaxLogsPerSpan: "keep_first" (the default),
+	// "keep_last" or "head_tail".
+	// Can be provided via environment variable named JAEGER_SPAN_LOG_RETENTION
+	LogRetention string `yaml:"logRetention"`
+
+	// MaxBaggageBytes limits the total size of the baggage of a trace, both set locally and extracted from
+	// the requests, see jaeger.TracerOptions.MaxBaggageSize. Zero disables the limit.
+	// Can be provided via environment variable named JAEGER_MAX_BAGGAGE_BYTES
+	MaxBaggageBytes int `yaml:"maxBaggageBytes"`
+
+	// MaxTagValueLengthByKey overrides Configuration.MaxTagValueLength for the tags with the given keys,
+	// see jaeger.TracerOptions.MaxTagValueLengthByKey.
+	// Can be provided via environment variable named JAEGER_MAX_TAG_VALUE_LENGTH_BY_KEY,
+	// e.g. "db.statement=4096,http.url=1024"
+	MaxTagValueLengthByKey map[string]int `yaml:"maxTagValueLengthByKey"`
+}
+
+// applyTo sets the limits in the options, unless they are already set by the options.
+func (lc *LimitsConfig) applyTo(opts *Options) {
+	if opts.maxTagsPerSpan == 0 {
+		opts.maxTagsPerSpan = lc.MaxTagsPerSpan
+	}
+	if opts.logRetentionPolicy.MaxLogs == 0 {
+		opts.logRetentionPolicy = jaeger.LogRetentionPolicy{
+			MaxLogs:   lc.MaxLogsPerSpan,
+			Retention: jaeger.LogRetention(lc.LogRetention),
+		}
+	}
+	if opts.maxBaggageSize == 0 {
+		opts.maxBaggageSize = lc.MaxBaggageBytes
+	}
+	if len(lc.MaxTagValueLengthByKey) > 0 {
+		maxTagValueLengthByKey := make(map[string]int, len(lc.MaxTagValueLengthByKey)+len(opts.maxTagValueLengthByKey))
+		for key, maxLength := range lc.MaxTagValueLengthByKey {
+			maxTagValueLengthByKey[key] = maxLength
+		}
+		for key, maxLength := range opts.maxTagValueLengthByKey {
+			maxTagValueLengthByKey[key] = maxLength
+		}
+		opts.maxTagValueLengthByKey = maxTagValueLengthByKey
+	}
 }
 
 // SamplerConfig allows initializing a non-default sampler.  All fields are optional.
@@ -101,13 +199,35 @@ type ReporterConfig struct {
 	// Can be set by exporting an environment variable named JAEGER_REPORTER_FLUSH_INTERVAL
 	BufferFlushInterval time.Duration
 
+	// BufferFlushMaxSpans, if greater than zero, flushes the buffer as soon as it accumulates this many spans.
+	// Can be set by exporting an environment variable named JAEGER_REPORTER_FLUSH_MAX_SPANS
+	BufferFlushMaxSpans int `yaml:"bufferFlushMaxSpans"`
+
+	// BufferFlushMaxBytes, if greater than zero, flushes the buffer as soon as the serialized size
+	// of the accumulated spans reaches this many bytes.
+	// Can be set by exporting an environment variable named JAEGER_REPORTER_FLUSH_MAX_BYTES
+	BufferFlushMaxBytes int `yaml:"bufferFlushMaxBytes"`
+
+	// BufferFlushIdleTimeout, if greater than zero, flushes the buffer when no new spans have been
+	// reported for this long. It is useful for low traffic services that want their spans delivered
+	// sooner than BufferFlushInterval.
+	// Can be set by exporting an environment variable named JAEGER_REPORTER_FLUSH_IDLE_TIMEOUT
+	BufferFlushIdleTimeout time.Duration `yaml:"bufferFlushIdleTimeout"`
+
+	// ErrorLogInterval, if greater than zero, aggregates the errors of span submissions: the first
+	// error is logged right away, and the errors that follow within the interval are logged as
+	// a single summary message, so that an unreachable agent does not flood the application logs.
+	ErrorLogInterval time.Duration `yaml:"errorLogInterval"`
+
 	// LogSpans, when true, enables LoggingReporter that runs in parallel with the main reporter
 	// and logs all submitted spans. Main Configuration.Logger must be initialized in the code
 	// for this option to have any effect.
 	// Can be set by exporting an environment variable named JAEGER_REPORTER_LOG_SPANS
 	LogSpans bool `yaml:"logSpans"`
 
-	// LocalAgentHostPort instructs reporter to send spans to jaeger-agent at this address
+	// LocalAgentHostPort instructs reporter to send spans to jaeger-agent at this address.
+	// IPv6 addresses must be enclosed in brackets, e.g. [::1]:6831, and a unix domain socket
+	// can be specified as unixgram:///path/to/socket.
 	// Can be set by exporting an environment variable named JAEGER_AGENT_HOST / JAEGER_AGENT_PORT
 	LocalAgentHostPort string `yaml:"localAgentHostPort"`
 
@@ -122,6 +242,110 @@ type ReporterConfig struct {
 	// Password instructs reporter to include a password for basic http authentication when sending spans to
 	// jaeger-collector. Can be set by exporting an environment variable named JAEGER_PASSWORD
 	Password string `yaml:"password"`
+
+	// TLS configures the TLS connections to jaeger-collector. This option only applies if
+	// CollectorEndpoint is specified.
+	// Can be set by exporting the environment variables named JAEGER_TLS_*, see TLSConfig.
+	TLS *TLSConfig `yaml:"tls"`
+
+	// MaxSpansPerSecond, if greater than zero, limits the number of spans per second submitted to
+	// jaeger-agent or jaeger-collector. Spans in excess of the limit are dropped.
+	// Can be set by exporting an environment variable named JAEGER_REPORTER_MAX_SPANS_PER_SECOND
+	MaxSpansPerSecond float64 `yaml:"maxSpansPerSecond"`
+
+	// MaxBytesPerSecond, if greater than zero, limits the number of bytes of serialized spans per second
+	// submitted to jaeger-agent or jaeger-collector. Spans in excess of the limit are dropped.
+	// Can be set by exporting an environment variable named JAEGER_REPORTER_MAX_BYTES_PER_SECOND
+	MaxBytesPerSecond float64 `yaml:"maxBytesPerSecond"`
+
+	// MaxBurstBytes is the max number of bytes of serialized spans submitted at once when MaxBytesPerSecond
+	// is set. Spans larger than MaxBurstBytes are dropped. Defaults to MaxBytesPerSecond, bounded by 65000 bytes.
+	// Can be set by exporting an environment variable named JAEGER_REPORTER_MAX_BURST_BYTES
+	MaxBurstBytes float64 `yaml:"maxBurstBytes"`
+
+	// MaxPacketSize is the max size of UDP packets sent to jaeger-agent. Spans that do not fit
+	// into a single packet are dropped. Defaults to 65000 bytes, which only works reliably when
+	// the agent runs on the same host. This option only applies if LocalAgentHostPort is specified.
+	// Can be set by exporting an environment variable named JAEGER_REPORTER_MAX_PACKET_SIZE
+	MaxPacketSize int `yaml:"maxPacketSize"`
+
+	// AutoDetectMaxPacketSize when true and MaxPacketSize is not set, detects the max size of
+	// UDP packets from the MTU of the network path to jaeger-agent.
+	AutoDetectMaxPacketSize bool `yaml:"autoDetectMaxPacketSize"`
+
+	// LocalAddr is the local IP address or host:port to send UDP packets to jaeger-agent from.
+	// This option only applies if LocalAgentHostPort is specified.
+	LocalAddr string `yaml:"localAddr"`
+
+	// LocalInterface is the name of the network interface to send UDP packets to jaeger-agent from.
+	// It takes precedence over LocalAddr. This option only applies if LocalAgentHostPort is specified.
+	LocalInterface string `yaml:"localInterface"`
+
+	// WriteBufferSize is the size of the socket send buffer used for UDP packets, defaults to MaxPacketSize.
+	// This option only applies if LocalAgentHostPort is specified.
+	WriteBufferSize int `yaml:"writeBufferSize"`
+
+	// TruncationSteps defines how spans that do not fit into a UDP packet are truncated before being
+	// dropped, as a list of steps applied in order: "logs", "tag_values" and "tags". Defaults to all
+	// of them in this order. This option only applies if LocalAgentHostPort is specified.
+	TruncationSteps []string `yaml:"truncationSteps"`
+
+	// DisableAttemptReconnecting when true, disables udp connection helper that periodically re-resolves
+	// the agent's hostname and reconnects if there was a change. This option only
+	// applies if LocalAgentHostPort is specified.
+	DisableAttemptReconnecting bool `yaml:"disableAttemptReconnecting"`
+
+	// AttemptReconnectInterval controls how often the agent client re-resolves the provided hostname
+	// in order to detect address changes. This option only applies if DisableAttemptReconnecting is false.
+	AttemptReconnectInterval time.Duration `yaml:"attemptReconnectInterval"`
+}
+
+// TLSConfig configures TLS connections. All fields are optional.
+type TLSConfig struct {
+	// CAPath is the path of a PEM file with the CA certificates used to verify the server certificate,
+	// instead of the system CA certificates.
+	// Can be set by exporting an environment variable named JAEGER_TLS_CA
+	CAPath string `yaml:"ca"`
+
+	// CertPath and KeyPath are the paths of the PEM files with the client certificate and its private key,
+	// for mutual TLS.
+	// Can be set by exporting the environment variables named JAEGER_TLS_CERT and JAEGER_TLS_KEY
+	CertPath string `yaml:"cert"`
+	KeyPath  string `yaml:"key"`
+
+	// ServerName overrides the host name used to verify the server certificate.
+	// Can be set by exporting an environment variable named JAEGER_TLS_SERVER_NAME
+	ServerName string `yaml:"serverName"`
+
+	// SkipHostVerify disables the verification of the server certificate. It should only be used for testing.
+	// Can be set by exporting an environment variable named JAEGER_TLS_SKIP_HOST_VERIFY
+	SkipHostVerify bool `yaml:"skipHostVerify"`
+}
+
+// newTLSConfig loads the certificates and creates the tls.Config.
+func (tc *TLSConfig) newTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         tc.ServerName,
+		InsecureSkipVerify: tc.SkipHostVerify,
+	}
+	if tc.CAPath != "" {
+		ca, err := ioutil.ReadFile(tc.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read TLS CA file: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file %s", tc.CAPath)
+		}
+	}
+	if tc.CertPath != "" || tc.KeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(tc.CertPath, tc.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load TLS client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
 }
 
 // BaggageRestrictionsConfig configures the baggage restrictions manager which can be used to whitelist
@@ -136,8 +360,20 @@ type BaggageRestrictionsConfig struct {
 	// HostPort is the hostPort of jaeger-agent's baggage restrictions server
 	HostPort string `yaml:"hostPort"`
 
+	// ServerURL is the URL of the endpoint serving the baggage restrictions, e.g. on jaeger-collector,
+	// to use instead of jaeger-agent at HostPort.
+	ServerURL string `yaml:"serverURL"`
+
+	// Headers are added to the requests retrieving the baggage restrictions, e.g. for authentication.
+	Headers map[string]string `yaml:"headers"`
+
+	// File is the path of a local JSON or YAML file with the baggage restrictions, to use instead of
+	// jaeger-agent, e.g. in serverless or air-gapped environments. The file is reloaded when it changes,
+	// see the file.RestrictionManager for its format.
+	File string `yaml:"file"`
+
 	// RefreshInterval controls how often the baggage restriction manager will poll
-	// jaeger-agent for the most recent baggage restrictions.
+	// jaeger-agent, or the File, for the most recent baggage restrictions.
 	RefreshInterval time.Duration `yaml:"refreshInterval"`
 }
 
@@ -163,6 +399,19 @@ type nullCloser struct{}
 
 func (*nullCloser) Close() error { return nil }
 
+// loggerCloser closes the logger after the tracer, so that the messages logged
+// while closing the tracer are not lost.
+type loggerCloser struct {
+	io.Closer
+	logger io.Closer
+}
+
+func (c *loggerCloser) Close() error {
+	err := c.Closer.Close()
+	c.logger.Close()
+	return err
+}
+
 // New creates a new Jaeger Tracer, and a closer func that can be used to flush buffers
 // before shutdown.
 //
@@ -178,27 +427,91 @@ func (c Configuration) New(
 	return c.NewTracer(options...)
 }
 
+// TracerComponents are the tracer created by Configuration.NewJaegerTracer, and the reporter
+// and the sampler it was assembled with.
+type TracerComponents struct {
+	Tracer   *jaeger.Tracer
+	Reporter jaeger.Reporter
+	Sampler  jaeger.Sampler
+}
+
 // NewTracer returns a new tracer based on the current configuration, using the given options,
 // and a closer func that can be used to flush buffers before shutdown.
 func (c Configuration) NewTracer(options ...Option) (opentracing.Tracer, io.Closer, error) {
 	if c.Disabled {
 		return &opentracing.NoopTracer{}, &nullCloser{}, nil
 	}
+	components, closer, err := c.newTracer(options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return components.Tracer, closer, nil
+}
 
+// NewJaegerTracer is like NewTracer, but returns the concrete *jaeger.Tracer together with the
+// reporter and the sampler it was assembled with, to give access to the Jaeger specific APIs
+// without type assertions. Since there is no Jaeger tracer to return, it returns an error
+// if the configuration is disabled.
+func (c Configuration) NewJaegerTracer(options ...Option) (*TracerComponents, io.Closer, error) {
+	if c.Disabled {
+		return nil, nil, errors.New("cannot create a Jaeger tracer, the configuration is disabled")
+	}
+	return c.newTracer(options...)
+}
+
+func (c Configuration) newTracer(options ...Option) (*TracerComponents, io.Closer, error) {
 	if c.ServiceName == "" {
 		return nil, nil, errors.New("no service name provided")
 	}
 
 	opts := applyOptions(options...)
+	if opts.metricsNamespace != "" || len(opts.metricsTags) > 0 {
+		opts.metrics = opts.metrics.Namespace(metrics.NSOptions{Name: opts.metricsNamespace, Tags: opts.metricsTags})
+	}
 	tracerMetrics := jaeger.NewMetrics(opts.metrics, nil)
 	if c.RPCMetrics {
+		normalizer := opts.rpcMetricsNormalizer
+		if normalizer == nil {
+			normalizer = rpcmetrics.DefaultNameNormalizer
+		}
 		Observer(
 			rpcmetrics.NewObserver(
 				opts.metrics.Namespace(metrics.NSOptions{Name: "jaeger-rpc", Tags: map[string]string{"component": "jaeger"}}),
-				rpcmetrics.DefaultNameNormalizer,
+				normalizer,
+				opts.rpcMetricsOptions...,
 			),
 		)(&opts) // adds to c.observers
 	}
+	if c.Gen128Bit {
+		opts.gen128Bit = true
+	}
+	if opts.maxTagValueLength == 0 {
+		opts.maxTagValueLength = c.MaxTagValueLength
+	}
+	if opts.maxLogsPerSecond == 0 {
+		opts.maxLogsPerSecond = c.MaxLogsPerSecond
+	}
+	if c.Limits != nil {
+		c.Limits.applyTo(&opts)
+	}
+	if len(c.Propagation) > 0 {
+		httpHeaders, textMap, err := newPropagators(c.Propagation, c.Headers, tracerMetrics)
+		if err != nil {
+			return nil, nil, err
+		}
+		for format, p := range map[interface{}]propagator{
+			opentracing.HTTPHeaders: httpHeaders,
+			opentracing.TextMap:     textMap,
+		} {
+			// the injectors and extractors given as options take precedence
+			if _, ok := opts.injectors[format]; !ok {
+				opts.injectors[format] = p
+			}
+			if _, ok := opts.extractors[format]; !ok {
+				opts.extractors[format] = p
+			}
+		}
+	}
 	if c.Sampler == nil {
 		c.Sampler = &SamplerConfig{
 			Type:  jaeger.SamplerTypeRemote,
@@ -211,17 +524,28 @@ func (c Configuration) NewTracer(options ...Option) (opentracing.Tracer, io.Clos
 
 	sampler := opts.sampler
 	if sampler == nil {
-		s, err := c.Sampler.NewSampler(c.ServiceName, tracerMetrics)
+		s, err := c.Sampler.newSampler(c.ServiceName, tracerMetrics,
+			jaeger.SamplerOptions.DiagnosticsCallback(opts.diagnosticsCallback))
 		if err != nil {
 			return nil, nil, err
 		}
 		sampler = s
 	}
 
+	var dedupLogger *log.DeduplicatingLogger
+	if opts.logDeduplicationInterval > 0 {
+		dedupLogger = log.NewDeduplicatingLogger(opts.logger, opts.logDeduplicationInterval)
+		opts.logger = dedupLogger
+	}
+
 	reporter := opts.reporter
 	if reporter == nil {
-		r, err := c.Reporter.NewReporter(c.ServiceName, tracerMetrics, opts.logger)
+		r, err := c.Reporter.newReporter(c.ServiceName, tracerMetrics, opts.logger,
+			jaeger.ReporterOptions.DiagnosticsCallback(opts.diagnosticsCallback))
 		if err != nil {
+			if dedupLogger != nil {
+				dedupLogger.Close()
+			}
 			return nil, nil, err
 		}
 		reporter = r
@@ -229,19 +553,84 @@ func (c Configuration) NewTracer(options ...Option) (opentracing.Tracer, io.Clos
 
 	tracerOptions := []jaeger.TracerOption{
 		jaeger.TracerOptions.Metrics(tracerMetrics),
+		jaeger.TracerOptions.MetricsFactory(opts.metrics),
 		jaeger.TracerOptions.Logger(opts.logger),
 		jaeger.TracerOptions.CustomHeaderKeys(c.Headers),
 		jaeger.TracerOptions.Gen128Bit(opts.gen128Bit),
+		jaeger.TracerOptions.IDGenerator(opts.idGenerator),
 		jaeger.TracerOptions.PoolSpans(opts.poolSpans),
 		jaeger.TracerOptions.ZipkinSharedRPCSpan(opts.zipkinSharedRPCSpan),
+		jaeger.TracerOptions.ZipkinSharedMessagingSpan(opts.zipkinSharedMessagingSpan),
+		jaeger.TracerOptions.BaggageTags(opts.baggageTags...),
+		jaeger.TracerOptions.PropagatedBaggage(opts.propagatedBaggage...),
+		jaeger.TracerOptions.LocalBaggage(opts.localBaggage...),
+		jaeger.TracerOptions.MaxBaggageSize(opts.maxBaggageSize),
+		jaeger.TracerOptions.BaggageMetricsKeys(opts.baggageMetricsKeys...),
+		jaeger.TracerOptions.SignedBaggage(opts.baggageSigningSecret, opts.signedBaggage...),
+		jaeger.TracerOptions.OperationNameNormalizer(opts.operationNameNormalizer),
+		jaeger.TracerOptions.StartSpanInterceptors(opts.startSpanInterceptors...),
 		jaeger.TracerOptions.MaxTagValueLength(opts.maxTagValueLength),
+		jaeger.TracerOptions.MaxTagValueLengthByKey(opts.maxTagValueLengthByKey),
+		jaeger.TracerOptions.RedactTags(opts.redactedTags...),
+		jaeger.TracerOptions.MinSpanDuration(opts.minSpanDuration),
+		jaeger.TracerOptions.MaxEventsPerSpan(opts.maxEventsPerSpan),
+		jaeger.TracerOptions.MaxTagsPerSpan(opts.maxTagsPerSpan),
+		jaeger.TracerOptions.TagErrorsFromLogs(opts.tagErrorsFromLogs),
+		jaeger.TracerOptions.DelayedSampling(opts.delayedSampling),
+		jaeger.TracerOptions.MinimalUnsampledSpans(opts.minimalUnsampledSpans),
+		jaeger.TracerOptions.ResourceDetectors(opts.resourceDetectors...),
+		jaeger.TracerOptions.LogRetentionPolicy(opts.logRetentionPolicy),
+		jaeger.TracerOptions.MaxLogsPerSecond(opts.maxLogsPerSecond),
 		jaeger.TracerOptions.NoDebugFlagOnForcedSampling(opts.noDebugFlagOnForcedSampling),
+		jaeger.TracerOptions.DisableHostTags(opts.disableHostTags),
+		jaeger.TracerOptions.InstanceID(opts.instanceID),
+		jaeger.TracerOptions.InstanceIDFile(opts.instanceIDFile),
+		jaeger.TracerOptions.AutoFinishChildren(opts.autoFinishChildren),
+		jaeger.TracerOptions.SampleOrphans(opts.sampleOrphans),
+		jaeger.TracerOptions.DetectUseAfterFinish(opts.useAfterFinish),
+		jaeger.TracerOptions.FinishStackTraces(opts.finishStackTraces),
+		jaeger.TracerOptions.ReportSpanStart(opts.reportSpanStart),
+		jaeger.TracerOptions.PartialSpanReporting(opts.partialSpanMinAge, opts.partialSpanInterval),
+		jaeger.TracerOptions.TrackInFlightSpans(opts.inFlightSpansInterval),
+		jaeger.TracerOptions.ClockSkewDetection(opts.remoteClock, opts.clockSkewInterval),
+		jaeger.TracerOptions.LogMetricsSummary(opts.metricsSummaryInterval),
+	}
+
+	if opts.stackTraceOnError {
+		tracerOptions = append(tracerOptions,
+			jaeger.TracerOptions.StackTraceOnError(opts.stackTraceMaxDepth, opts.stackTraceMaxPerSecond))
+	}
+
+	if opts.spanDurationHistograms {
+		tracerOptions = append(tracerOptions,
+			jaeger.TracerOptions.SpanDurationHistograms(opts.spanDurationBuckets...))
 	}
 
 	for _, tag := range opts.tags {
 		tracerOptions = append(tracerOptions, jaeger.TracerOptions.Tag(tag.Key, tag.Value))
 	}
 
+	for _, observer := range opts.baggageObservers {
+		tracerOptions = append(tracerOptions, jaeger.TracerOptions.BaggageObserver(observer))
+	}
+
+	for _, t := range opts.baggageTransformers {
+		tracerOptions = append(tracerOptions, jaeger.TracerOptions.BaggageTransformer(t.transformer, t.keys...))
+	}
+
+	namespaces := make([]string, 0, len(opts.baggageNamespaces))
+	for name := range opts.baggageNamespaces {
+		namespaces = append(namespaces, name)
+	}
+	sort.Strings(namespaces)
+	for _, name := range namespaces {
+		tracerOptions = append(tracerOptions, jaeger.TracerOptions.BaggageNamespace(name, opts.baggageNamespaces[name]))
+	}
+
+	for key, value := range opts.defaultBaggage {
+		tracerOptions = append(tracerOptions, jaeger.TracerOptions.DefaultBaggage(key, value))
+	}
+
 	for _, tag := range c.Tags {
 		tracerOptions = append(tracerOptions, jaeger.TracerOptions.Tag(tag.Key, tag.Value))
 	}
@@ -262,23 +651,37 @@ func (c Configuration) NewTracer(options ...Option) (opentracing.Tracer, io.Clos
 		tracerOptions = append(tracerOptions, jaeger.TracerOptions.Extractor(format, extractor))
 	}
 
-	if c.BaggageRestrictions != nil {
-		mgr := remote.NewRestrictionManager(
-			c.ServiceName,
+	if c.BaggageRestrictions != nil && c.BaggageRestrictions.File != "" {
+		mgr := file.NewRestrictionManager(
+			c.BaggageRestrictions.File,
+			file.Options.Metrics(tracerMetrics),
+			file.Options.Logger(opts.logger),
+			file.Options.RefreshInterval(c.BaggageRestrictions.RefreshInterval),
+			file.Options.DenyBaggageOnInitializationFailure(
+				c.BaggageRestrictions.DenyBaggageOnInitializationFailure,
+			),
+		)
+		tracerOptions = append(tracerOptions, jaeger.TracerOptions.BaggageRestrictionManager(mgr))
+	} else if c.BaggageRestrictions != nil {
+		mgrOptions := []remote.Option{
 			remote.Options.Metrics(tracerMetrics),
 			remote.Options.Logger(opts.logger),
 			remote.Options.HostPort(c.BaggageRestrictions.HostPort),
+			remote.Options.ServerURL(c.BaggageRestrictions.ServerURL),
 			remote.Options.RefreshInterval(c.BaggageRestrictions.RefreshInterval),
 			remote.Options.DenyBaggageOnInitializationFailure(
 				c.BaggageRestrictions.DenyBaggageOnInitializationFailure,
 			),
-		)
+		}
+		for key, value := range c.BaggageRestrictions.Headers {
+			mgrOptions = append(mgrOptions, remote.Options.Header(key, value))
+		}
+		mgr := remote.NewRestrictionManager(c.ServiceName, mgrOptions...)
 		tracerOptions = append(tracerOptions, jaeger.TracerOptions.BaggageRestrictionManager(mgr))
 	}
 
 	if c.Throttler != nil {
-		debugThrottler := throttler.NewThrottler(
-			c.ServiceName,
+		throttlerOptions := []throttler.Option{
 			throttler.Options.Metrics(tracerMetrics),
 			throttler.Options.Logger(opts.logger),
 			throttler.Options.HostPort(c.Throttler.HostPort),
@@ -286,7 +689,11 @@ func (c Configuration) NewTracer(options ...Option) (opentracing.Tracer, io.Clos
 			throttler.Options.SynchronousInitialization(
 				c.Throttler.SynchronousInitialization,
 			),
-		)
+		}
+		if opts.throttlerCreditManager != nil {
+			throttlerOptions = append(throttlerOptions, throttler.Options.CreditManager(opts.throttlerCreditManager))
+		}
+		debugThrottler := throttler.NewThrottler(c.ServiceName, throttlerOptions...)
 
 		tracerOptions = append(tracerOptions, jaeger.TracerOptions.DebugThrottler(debugThrottler))
 	}
@@ -297,8 +704,15 @@ func (c Configuration) NewTracer(options ...Option) (opentracing.Tracer, io.Clos
 		reporter,
 		tracerOptions...,
 	)
+	if dedupLogger != nil {
+		closer = &loggerCloser{Closer: closer, logger: dedupLogger}
+	}
 
-	return tracer, closer, nil
+	return &TracerComponents{
+		Tracer:   tracer.(*jaeger.Tracer),
+		Reporter: reporter,
+		Sampler:  sampler,
+	}, closer, nil
 }
 
 // InitGlobalTracer creates a new Jaeger Tracer, and sets it as global OpenTracing Tracer.
@@ -322,6 +736,16 @@ func (c Configuration) InitGlobalTracer(
 func (sc *SamplerConfig) NewSampler(
 	serviceName string,
 	metrics *jaeger.Metrics,
+) (jaeger.Sampler, error) {
+	return sc.newSampler(serviceName, metrics)
+}
+
+// newSampler creates a new sampler based on the configuration, passing the extra
+// options to the remotely controlled sampler.
+func (sc *SamplerConfig) newSampler(
+	serviceName string,
+	metrics *jaeger.Metrics,
+	extraOptions ...jaeger.SamplerOption,
 ) (jaeger.Sampler, error) {
 	samplerType := strings.ToLower(sc.Type)
 	if samplerType == jaeger.SamplerTypeConst {
@@ -357,6 +781,7 @@ func (sc *SamplerConfig) NewSampler(
 		if sc.SamplingRefreshInterval != 0 {
 			options = append(options, jaeger.SamplerOptions.SamplingRefreshInterval(sc.SamplingRefreshInterval))
 		}
+		options = append(options, extraOptions...)
 		return jaeger.NewRemotelyControlledSampler(serviceName, options...), nil
 	}
 	return nil, fmt.Errorf("Unknown sampler type %v", sc.Type)
@@ -368,16 +793,40 @@ func (rc *ReporterConfig) NewReporter(
 	metrics *jaeger.Metrics,
 	logger jaeger.Logger,
 ) (jaeger.Reporter, error) {
-	sender, err := rc.newTransport()
+	return rc.newReporter(serviceName, metrics, logger)
+}
+
+// newReporter instantiates a new reporter that submits spans to the collector,
+// passing the extra options to the remote reporter.
+func (rc *ReporterConfig) newReporter(
+	serviceName string,
+	metrics *jaeger.Metrics,
+	logger jaeger.Logger,
+	extraOptions ...jaeger.ReporterOption,
+) (jaeger.Reporter, error) {
+	sender, err := rc.newTransport(metrics, logger)
 	if err != nil {
 		return nil, err
 	}
-	reporter := jaeger.NewRemoteReporter(
-		sender,
+	options := []jaeger.ReporterOption{
 		jaeger.ReporterOptions.QueueSize(rc.QueueSize),
 		jaeger.ReporterOptions.BufferFlushInterval(rc.BufferFlushInterval),
+		jaeger.ReporterOptions.BufferFlushMaxSpans(rc.BufferFlushMaxSpans),
+		jaeger.ReporterOptions.BufferFlushMaxBytes(rc.BufferFlushMaxBytes),
+		jaeger.ReporterOptions.BufferFlushIdleTimeout(rc.BufferFlushIdleTimeout),
+		jaeger.ReporterOptions.ErrorLogInterval(rc.ErrorLogInterval),
 		jaeger.ReporterOptions.Logger(logger),
-		jaeger.ReporterOptions.Metrics(metrics))
+		jaeger.ReporterOptions.Metrics(metrics),
+	}
+	reporter := jaeger.NewRemoteReporter(sender, append(options, extraOptions...)...)
+	if rc.MaxSpansPerSecond > 0 {
+		reporter = jaeger.NewRateLimitedReporter(reporter, rc.MaxSpansPerSecond, metrics)
+	}
+	if rc.MaxBytesPerSecond > 0 && rc.MaxBurstBytes > 0 {
+		reporter = jaeger.NewBytesRateLimitedReporterWithBurst(reporter, rc.MaxBytesPerSecond, rc.MaxBurstBytes, metrics)
+	} else if rc.MaxBytesPerSecond > 0 {
+		reporter = jaeger.NewBytesRateLimitedReporter(reporter, rc.MaxBytesPerSecond, metrics)
+	}
 	if rc.LogSpans && logger != nil {
 		logger.Infof("Initializing logging reporter\n")
 		reporter = jaeger.NewCompositeReporter(jaeger.NewLoggingReporter(logger), reporter)
@@ -385,14 +834,43 @@ func (rc *ReporterConfig) NewReporter(
 	return reporter, err
 }
 
-func (rc *ReporterConfig) newTransport() (jaeger.Transport, error) {
+func (rc *ReporterConfig) newTransport(metrics *jaeger.Metrics, logger jaeger.Logger) (jaeger.Transport, error) {
 	switch {
-	case rc.CollectorEndpoint != "" && rc.User != "" && rc.Password != "":
-		return transport.NewHTTPTransport(rc.CollectorEndpoint, transport.HTTPBatchSize(1),
-			transport.HTTPBasicAuth(rc.User, rc.Password)), nil
 	case rc.CollectorEndpoint != "":
-		return transport.NewHTTPTransport(rc.CollectorEndpoint, transport.HTTPBatchSize(1)), nil
+		options := []transport.HTTPOption{transport.HTTPBatchSize(1), transport.HTTPLogger(logger)}
+		if rc.User != "" && rc.Password != "" {
+			options = append(options, transport.HTTPBasicAuth(rc.User, rc.Password))
+		}
+		if rc.TLS != nil {
+			tlsConfig, err := rc.TLS.newTLSConfig()
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, transport.HTTPTLSConfig(tlsConfig))
+		}
+		return transport.NewHTTPTransport(rc.CollectorEndpoint, options...), nil
 	default:
-		return jaeger.NewUDPTransport(rc.LocalAgentHostPort, 0)
+		var truncationPolicy *jaeger.SpanTruncationPolicy
+		if len(rc.TruncationSteps) > 0 {
+			truncationPolicy = &jaeger.SpanTruncationPolicy{}
+			for _, step := range rc.TruncationSteps {
+				truncationPolicy.Steps = append(truncationPolicy.Steps, jaeger.SpanTruncationStep(step))
+			}
+		}
+		return jaeger.NewUDPTransportWithParams(jaeger.UDPTransportParams{
+			AgentClientUDPParams: utils.AgentClientUDPParams{
+				HostPort:                   rc.LocalAgentHostPort,
+				MaxPacketSize:              rc.MaxPacketSize,
+				AutoDetectMaxPacketSize:    rc.AutoDetectMaxPacketSize,
+				LocalAddr:                  rc.LocalAddr,
+				LocalInterface:             rc.LocalInterface,
+				WriteBufferSize:            rc.WriteBufferSize,
+				Logger:                     logger,
+				DisableAttemptReconnecting: rc.DisableAttemptReconnecting,
+				AttemptReconnectInterval:   rc.AttemptReconnectInterval,
+			},
+			TruncationPolicy: truncationPolicy,
+			Metrics:          metrics,
+		})
 	}
 }
@@ -16,6 +16,7 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"strconv"
@@ -47,8 +48,55 @@ const (
 	envPassword               = "JAEGER_PASSWORD"
 	envAgentHost              = "JAEGER_AGENT_HOST"
 	envAgentPort              = "JAEGER_AGENT_PORT"
+	envGen128Bit              = "JAEGER_TRACEID_128BIT"
+	envPropagation            = "JAEGER_PROPAGATION"
+	envMaxTagValueLength      = "JAEGER_MAX_TAG_VALUE_LENGTH"
+	envSpanMaxLogsPerSecond   = "JAEGER_SPAN_MAX_LOGS_PER_SECOND"
+	envTLSCA                  = "JAEGER_TLS_CA"
+	envTLSCert                = "JAEGER_TLS_CERT"
+	envTLSKey                 = "JAEGER_TLS_KEY"
+	envTLSServerName          = "JAEGER_TLS_SERVER_NAME"
+	envTLSSkipHostVerify      = "JAEGER_TLS_SKIP_HOST_VERIFY"
+
+	envReporterFlushMaxSpans     = "JAEGER_REPORTER_FLUSH_MAX_SPANS"
+	envReporterFlushMaxBytes     = "JAEGER_REPORTER_FLUSH_MAX_BYTES"
+	envReporterFlushIdleTimeout  = "JAEGER_REPORTER_FLUSH_IDLE_TIMEOUT"
+	envReporterMaxSpansPerSecond = "JAEGER_REPORTER_MAX_SPANS_PER_SECOND"
+	envReporterMaxBytesPerSecond = "JAEGER_REPORTER_MAX_BYTES_PER_SECOND"
+	envReporterMaxBurstBytes     = "JAEGER_REPORTER_MAX_BURST_BYTES"
+	envReporterMaxPacketSize     = "JAEGER_REPORTER_MAX_PACKET_SIZE"
+	envSpanMaxTags               = "JAEGER_SPAN_MAX_TAGS"
+	envSpanMaxLogs               = "JAEGER_SPAN_MAX_LOGS"
+	envSpanLogRetention          = "JAEGER_SPAN_LOG_RETENTION"
+	envMaxBaggageBytes           = "JAEGER_MAX_BAGGAGE_BYTES"
+	envMaxTagValueLengthByKey    = "JAEGER_MAX_TAG_VALUE_LENGTH_BY_KEY"
+)
+
+// EnvPrecedence controls whether the environment variables override the values set in code.
+type EnvPrecedence string
+
+const (
+	// EnvOverridesCode replaces the values set in code with the environment variables. This is the default.
+	EnvOverridesCode EnvPrecedence = "env"
+
+	// CodeOverridesEnv only uses the environment variables for the values left empty in code.
+	CodeOverridesEnv EnvPrecedence = "code"
 )
 
+// envVars lists the environment variables read by FromEnv.
+var envVars = map[string]struct{}{
+	envServiceName: {}, envDisabled: {}, envRPCMetrics: {}, envTags: {},
+	envSamplerType: {}, envSamplerParam: {}, envSamplerManagerHostPort: {}, envSamplerMaxOperations: {},
+	envSamplerRefreshInterval: {}, envReporterMaxQueueSize: {}, envReporterFlushInterval: {},
+	envReporterLogSpans: {}, envEndpoint: {}, envUser: {}, envPassword: {}, envAgentHost: {}, envAgentPort: {},
+	envGen128Bit: {}, envPropagation: {}, envMaxTagValueLength: {}, envSpanMaxLogsPerSecond: {},
+	envTLSCA: {}, envTLSCert: {}, envTLSKey: {}, envTLSServerName: {}, envTLSSkipHostVerify: {},
+	envReporterFlushMaxSpans: {}, envReporterFlushMaxBytes: {}, envReporterFlushIdleTimeout: {},
+	envReporterMaxSpansPerSecond: {}, envReporterMaxBytesPerSecond: {}, envReporterMaxBurstBytes: {},
+	envReporterMaxPacketSize: {}, envSpanMaxTags: {}, envSpanMaxLogs: {}, envSpanLogRetention: {},
+	envMaxBaggageBytes: {}, envMaxTagValueLengthByKey: {},
+}
+
 // FromEnv uses environment variables to set the tracer's Configuration
 func FromEnv() (*Configuration, error) {
 	c := &Configuration{}
@@ -77,6 +125,40 @@ func FromEnv() (*Configuration, error) {
 		c.Tags = parseTags(e)
 	}
 
+	if e := os.Getenv(envGen128Bit); e != "" {
+		if value, err := strconv.ParseBool(e); err == nil {
+			c.Gen128Bit = value
+		} else {
+			return nil, errors.Wrapf(err, "cannot parse env var %s=%s", envGen128Bit, e)
+		}
+	}
+
+	if e := os.Getenv(envPropagation); e != "" {
+		for _, format := range strings.Split(e, ",") {
+			format = strings.ToLower(strings.TrimSpace(format))
+			if format != PropagationJaeger && format != PropagationB3 {
+				return nil, errors.Errorf("cannot parse env var %s=%s: unknown propagation format %q", envPropagation, e, format)
+			}
+			c.Propagation = append(c.Propagation, format)
+		}
+	}
+
+	if e := os.Getenv(envMaxTagValueLength); e != "" {
+		if value, err := strconv.ParseInt(e, 10, 0); err == nil {
+			c.MaxTagValueLength = int(value)
+		} else {
+			return nil, errors.Wrapf(err, "cannot parse env var %s=%s", envMaxTagValueLength, e)
+		}
+	}
+
+	if e := os.Getenv(envSpanMaxLogsPerSecond); e != "" {
+		if value, err := strconv.ParseInt(e, 10, 0); err == nil {
+			c.MaxLogsPerSecond = int(value)
+		} else {
+			return nil, errors.Wrapf(err, "cannot parse env var %s=%s", envSpanMaxLogsPerSecond, e)
+		}
+	}
+
 	if s, err := samplerConfigFromEnv(); err == nil {
 		c.Sampler = s
 	} else {
@@ -89,9 +171,213 @@ func FromEnv() (*Configuration, error) {
 		return nil, errors.Wrap(err, "cannot obtain reporter config from env")
 	}
 
+	if l, err := limitsConfigFromEnv(); err == nil {
+		c.Limits = l
+	} else {
+		return nil, errors.Wrap(err, "cannot obtain limits config from env")
+	}
+
+	return c, nil
+}
+
+// FromEnv merges the environment variables into the Configuration, following c.EnvPrecedence
+// and c.EnvPrecedenceByVar, and returns the Configuration. The variables are merged per field,
+// e.g. with CodeOverridesEnv, JAEGER_SAMPLER_PARAM still applies if the sampler param is not set
+// in code, and the tags from JAEGER_TAGS are added to the tags set in code unless their key is
+// already used. The zero values, e.g. false, count as not set in code.
+//
+// JAEGER_ENDPOINT, JAEGER_USER, JAEGER_PASSWORD and JAEGER_TLS_* are merged together, following
+// the precedence of JAEGER_ENDPOINT. JAEGER_MAX_TAG_VALUE_LENGTH_BY_KEY replaces the whole map.
+func (c *Configuration) FromEnv() (*Configuration, error) {
+	env, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if c.Sampler == nil {
+		c.Sampler = &SamplerConfig{}
+	}
+	if c.Reporter == nil {
+		c.Reporter = &ReporterConfig{}
+	}
+	sc, rc := c.Sampler, c.Reporter
+
+	if c.useEnv(envServiceName, c.ServiceName == "") {
+		c.ServiceName = env.ServiceName
+	}
+	if c.useEnv(envDisabled, !c.Disabled) {
+		c.Disabled = env.Disabled
+	}
+	if c.useEnv(envRPCMetrics, !c.RPCMetrics) {
+		c.RPCMetrics = env.RPCMetrics
+	}
+	if os.Getenv(envTags) != "" {
+		c.Tags = c.mergeTags(env.Tags)
+	}
+	if c.useEnv(envGen128Bit, !c.Gen128Bit) {
+		c.Gen128Bit = env.Gen128Bit
+	}
+	if c.useEnv(envPropagation, len(c.Propagation) == 0) {
+		c.Propagation = env.Propagation
+	}
+	if c.useEnv(envMaxTagValueLength, c.MaxTagValueLength == 0) {
+		c.MaxTagValueLength = env.MaxTagValueLength
+	}
+	if c.useEnv(envSpanMaxLogsPerSecond, c.MaxLogsPerSecond == 0) {
+		c.MaxLogsPerSecond = env.MaxLogsPerSecond
+	}
+
+	if c.useEnv(envSamplerType, sc.Type == "") {
+		sc.Type = env.Sampler.Type
+	}
+	if c.useEnv(envSamplerParam, sc.Param == 0) {
+		sc.Param = env.Sampler.Param
+	}
+	if c.useEnv(envSamplerManagerHostPort, sc.SamplingServerURL == "") {
+		sc.SamplingServerURL = env.Sampler.SamplingServerURL
+	} else if getEnv(envSamplerManagerHostPort) == "" && sc.SamplingServerURL == "" {
+		// the fallback on the agent host only fills the value left empty
+		sc.SamplingServerURL = env.Sampler.SamplingServerURL
+	}
+	if c.useEnv(envSamplerMaxOperations, sc.MaxOperations == 0) {
+		sc.MaxOperations = env.Sampler.MaxOperations
+	}
+	if c.useEnv(envSamplerRefreshInterval, sc.SamplingRefreshInterval == 0) {
+		sc.SamplingRefreshInterval = env.Sampler.SamplingRefreshInterval
+	}
+
+	if c.useEnv(envReporterMaxQueueSize, rc.QueueSize == 0) {
+		rc.QueueSize = env.Reporter.QueueSize
+	}
+	if c.useEnv(envReporterFlushInterval, rc.BufferFlushInterval == 0) {
+		rc.BufferFlushInterval = env.Reporter.BufferFlushInterval
+	}
+	if c.useEnv(envReporterLogSpans, !rc.LogSpans) {
+		rc.LogSpans = env.Reporter.LogSpans
+	}
+	if c.useEnv(envReporterFlushMaxSpans, rc.BufferFlushMaxSpans == 0) {
+		rc.BufferFlushMaxSpans = env.Reporter.BufferFlushMaxSpans
+	}
+	if c.useEnv(envReporterFlushMaxBytes, rc.BufferFlushMaxBytes == 0) {
+		rc.BufferFlushMaxBytes = env.Reporter.BufferFlushMaxBytes
+	}
+	if c.useEnv(envReporterFlushIdleTimeout, rc.BufferFlushIdleTimeout == 0) {
+		rc.BufferFlushIdleTimeout = env.Reporter.BufferFlushIdleTimeout
+	}
+	if c.useEnv(envReporterMaxSpansPerSecond, rc.MaxSpansPerSecond == 0) {
+		rc.MaxSpansPerSecond = env.Reporter.MaxSpansPerSecond
+	}
+	if c.useEnv(envReporterMaxBytesPerSecond, rc.MaxBytesPerSecond == 0) {
+		rc.MaxBytesPerSecond = env.Reporter.MaxBytesPerSecond
+	}
+	if c.useEnv(envReporterMaxBurstBytes, rc.MaxBurstBytes == 0) {
+		rc.MaxBurstBytes = env.Reporter.MaxBurstBytes
+	}
+	if c.useEnv(envReporterMaxPacketSize, rc.MaxPacketSize == 0) {
+		rc.MaxPacketSize = env.Reporter.MaxPacketSize
+	}
+	if c.useEnv(envEndpoint, rc.CollectorEndpoint == "" && rc.LocalAgentHostPort == "") {
+		rc.CollectorEndpoint = env.Reporter.CollectorEndpoint
+		rc.User = env.Reporter.User
+		rc.Password = env.Reporter.Password
+		rc.TLS = env.Reporter.TLS
+		rc.LocalAgentHostPort = ""
+	} else if rc.CollectorEndpoint == "" {
+		rc.LocalAgentHostPort = c.mergeAgentHostPort(env.Reporter.LocalAgentHostPort)
+	}
+
+	if env.Limits != nil {
+		if c.Limits == nil {
+			c.Limits = &LimitsConfig{}
+		}
+		lc := c.Limits
+		if c.useEnv(envSpanMaxTags, lc.MaxTagsPerSpan == 0) {
+			lc.MaxTagsPerSpan = env.Limits.MaxTagsPerSpan
+		}
+		if c.useEnv(envSpanMaxLogs, lc.MaxLogsPerSpan == 0) {
+			lc.MaxLogsPerSpan = env.Limits.MaxLogsPerSpan
+		}
+		if c.useEnv(envSpanLogRetention, lc.LogRetention == "") {
+			lc.LogRetention = env.Limits.LogRetention
+		}
+		if c.useEnv(envMaxBaggageBytes, lc.MaxBaggageBytes == 0) {
+			lc.MaxBaggageBytes = env.Limits.MaxBaggageBytes
+		}
+		if c.useEnv(envMaxTagValueLengthByKey, len(lc.MaxTagValueLengthByKey) == 0) {
+			lc.MaxTagValueLengthByKey = env.Limits.MaxTagValueLengthByKey
+		}
+	}
+
 	return c, nil
 }
 
+// useEnv returns true if the value of the given environment variable must replace the value set in code.
+func (c *Configuration) useEnv(name string, emptyInCode bool) bool {
+	if getEnv(name) == "" {
+		return false
+	}
+	return emptyInCode || c.envPrecedence(name) != CodeOverridesEnv
+}
+
+// envPrecedence returns the precedence of the given environment variable.
+func (c *Configuration) envPrecedence(name string) EnvPrecedence {
+	if precedence, ok := c.EnvPrecedenceByVar[name]; ok {
+		return precedence
+	}
+	return c.EnvPrecedence
+}
+
+// mergeTags adds the tags from the environment to the tags set in code. When both define
+// the same key, the precedence of JAEGER_TAGS selects the value.
+func (c *Configuration) mergeTags(tagsFromEnv []opentracing.Tag) []opentracing.Tag {
+	tags := append([]opentracing.Tag{}, c.Tags...)
+	for _, envTag := range tagsFromEnv {
+		found := false
+		for i := range tags {
+			if tags[i].Key == envTag.Key {
+				found = true
+				if c.envPrecedence(envTags) != CodeOverridesEnv {
+					tags[i].Value = envTag.Value
+				}
+			}
+		}
+		if !found {
+			tags = append(tags, envTag)
+		}
+	}
+	return tags
+}
+
+// mergeAgentHostPort merges JAEGER_AGENT_HOST and JAEGER_AGENT_PORT into the agent address set in code,
+// so that e.g. JAEGER_AGENT_PORT alone keeps the host set in code. The given address is the one
+// obtained from the environment, with the defaults.
+func (c *Configuration) mergeAgentHostPort(envHostPort string) string {
+	hostPort := c.Reporter.LocalAgentHostPort
+	if hostPort == "" {
+		return envHostPort
+	}
+	if strings.Contains(hostPort, "://") || strings.Contains(envHostPort, "://") {
+		if c.useEnv(envAgentHost, false) {
+			return envHostPort
+		}
+		return hostPort
+	}
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return hostPort
+	}
+	envHost, envPort, err := net.SplitHostPort(envHostPort)
+	if err != nil {
+		return hostPort
+	}
+	if c.useEnv(envAgentHost, host == "") {
+		host = envHost
+	}
+	if c.useEnv(envAgentPort, port == "") {
+		port = envPort
+	}
+	return net.JoinHostPort(host, port)
+}
+
 // samplerConfigFromEnv creates a new SamplerConfig based on the environment variables
 func samplerConfigFromEnv() (*SamplerConfig, error) {
 	sc := &SamplerConfig{}
@@ -108,11 +394,11 @@ func samplerConfigFromEnv() (*SamplerConfig, error) {
 		}
 	}
 
-	if e := os.Getenv(envSamplerManagerHostPort); e != "" {
+	if e := getEnv(envSamplerManagerHostPort); e != "" {
 		sc.SamplingServerURL = e
-	} else if e := os.Getenv(envAgentHost); e != "" {
+	} else if e := getEnv(envAgentHost); e != "" && !strings.Contains(e, "://") {
 		// Fallback if we know the agent host - try the sampling endpoint there
-		sc.SamplingServerURL = fmt.Sprintf("http://%s:%d/sampling", e, jaeger.DefaultSamplingServerPort)
+		sc.SamplingServerURL = fmt.Sprintf("http://%s/sampling", net.JoinHostPort(e, strconv.Itoa(jaeger.DefaultSamplingServerPort)))
 	}
 
 	if e := os.Getenv(envSamplerMaxOperations); e != "" {
@@ -162,7 +448,29 @@ func reporterConfigFromEnv() (*ReporterConfig, error) {
 		}
 	}
 
-	if e := os.Getenv(envEndpoint); e != "" {
+	if err := intFromEnv(envReporterFlushMaxSpans, &rc.BufferFlushMaxSpans); err != nil {
+		return nil, err
+	}
+	if err := intFromEnv(envReporterFlushMaxBytes, &rc.BufferFlushMaxBytes); err != nil {
+		return nil, err
+	}
+	if err := durationFromEnv(envReporterFlushIdleTimeout, &rc.BufferFlushIdleTimeout); err != nil {
+		return nil, err
+	}
+	if err := floatFromEnv(envReporterMaxSpansPerSecond, &rc.MaxSpansPerSecond); err != nil {
+		return nil, err
+	}
+	if err := floatFromEnv(envReporterMaxBytesPerSecond, &rc.MaxBytesPerSecond); err != nil {
+		return nil, err
+	}
+	if err := floatFromEnv(envReporterMaxBurstBytes, &rc.MaxBurstBytes); err != nil {
+		return nil, err
+	}
+	if err := intFromEnv(envReporterMaxPacketSize, &rc.MaxPacketSize); err != nil {
+		return nil, err
+	}
+
+	if e := getEnv(envEndpoint); e != "" {
 		u, err := url.ParseRequestURI(e)
 		if err != nil {
 			return nil, errors.Wrapf(err, "cannot parse env var %s=%s", envEndpoint, e)
@@ -175,46 +483,148 @@ func reporterConfigFromEnv() (*ReporterConfig, error) {
 		}
 		rc.User = user
 		rc.Password = pswd
+		tls, err := tlsConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		rc.TLS = tls
 	} else {
 		host := jaeger.DefaultUDPSpanServerHost
-		if e := os.Getenv(envAgentHost); e != "" {
+		if e := getEnv(envAgentHost); e != "" {
 			host = e
 		}
 
-		port := jaeger.DefaultUDPSpanServerPort
-		if e := os.Getenv(envAgentPort); e != "" {
-			if value, err := strconv.ParseInt(e, 10, 0); err == nil {
-				port = int(value)
-			} else {
-				return nil, errors.Wrapf(err, "cannot parse env var %s=%s", envAgentPort, e)
+		if strings.Contains(host, "://") {
+			// unix domain socket path, e.g. unixgram:///var/run/jaeger-agent.sock
+			rc.LocalAgentHostPort = host
+		} else {
+			port := jaeger.DefaultUDPSpanServerPort
+			if e := getEnv(envAgentPort); e != "" {
+				if value, err := strconv.ParseInt(e, 10, 0); err == nil {
+					port = int(value)
+				} else {
+					return nil, errors.Wrapf(err, "cannot parse env var %s=%s", envAgentPort, e)
+				}
 			}
+			rc.LocalAgentHostPort = net.JoinHostPort(host, strconv.Itoa(port))
 		}
-		rc.LocalAgentHostPort = fmt.Sprintf("%s:%d", host, port)
 	}
 
 	return rc, nil
 }
 
+// limitsConfigFromEnv creates a new LimitsConfig based on the environment variables,
+// or returns nil if none of them is set
+func limitsConfigFromEnv() (*LimitsConfig, error) {
+	lc := &LimitsConfig{
+		LogRetention: os.Getenv(envSpanLogRetention),
+	}
+
+	if err := intFromEnv(envSpanMaxTags, &lc.MaxTagsPerSpan); err != nil {
+		return nil, err
+	}
+	if err := intFromEnv(envSpanMaxLogs, &lc.MaxLogsPerSpan); err != nil {
+		return nil, err
+	}
+	if err := intFromEnv(envMaxBaggageBytes, &lc.MaxBaggageBytes); err != nil {
+		return nil, err
+	}
+
+	if e := os.Getenv(envMaxTagValueLengthByKey); e != "" {
+		lc.MaxTagValueLengthByKey = make(map[string]int)
+		for _, p := range strings.Split(e, ",") {
+			kv := strings.SplitN(p, "=", 2)
+			if len(kv) != 2 {
+				return nil, errors.Errorf("cannot parse env var %s=%s: expecting key=length pairs", envMaxTagValueLengthByKey, e)
+			}
+			value, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 0)
+			if err != nil {
+				return nil, errors.Wrapf(err, "cannot parse env var %s=%s", envMaxTagValueLengthByKey, e)
+			}
+			lc.MaxTagValueLengthByKey[strings.TrimSpace(kv[0])] = int(value)
+		}
+	}
+
+	if lc.MaxTagsPerSpan == 0 && lc.MaxLogsPerSpan == 0 && lc.LogRetention == "" &&
+		lc.MaxBaggageBytes == 0 && lc.MaxTagValueLengthByKey == nil {
+		return nil, nil
+	}
+	return lc, nil
+}
+
+// intFromEnv parses the environment variable into value, if the variable is set.
+func intFromEnv(name string, value *int) error {
+	if e := os.Getenv(name); e != "" {
+		v, err := strconv.ParseInt(e, 10, 0)
+		if err != nil {
+			return errors.Wrapf(err, "cannot parse env var %s=%s", name, e)
+		}
+		*value = int(v)
+	}
+	return nil
+}
+
+// floatFromEnv parses the environment variable into value, if the variable is set.
+func floatFromEnv(name string, value *float64) error {
+	if e := os.Getenv(name); e != "" {
+		v, err := strconv.ParseFloat(e, 64)
+		if err != nil {
+			return errors.Wrapf(err, "cannot parse env var %s=%s", name, e)
+		}
+		*value = v
+	}
+	return nil
+}
+
+// durationFromEnv parses the environment variable into value, if the variable is set.
+func durationFromEnv(name string, value *time.Duration) error {
+	if e := os.Getenv(name); e != "" {
+		v, err := time.ParseDuration(e)
+		if err != nil {
+			return errors.Wrapf(err, "cannot parse env var %s=%s", name, e)
+		}
+		*value = v
+	}
+	return nil
+}
+
+// tlsConfigFromEnv creates a new TLSConfig based on the environment variables,
+// or returns nil if none of them is set
+func tlsConfigFromEnv() (*TLSConfig, error) {
+	tc := &TLSConfig{
+		CAPath:     os.Getenv(envTLSCA),
+		CertPath:   os.Getenv(envTLSCert),
+		KeyPath:    os.Getenv(envTLSKey),
+		ServerName: os.Getenv(envTLSServerName),
+	}
+
+	if e := os.Getenv(envTLSSkipHostVerify); e != "" {
+		if value, err := strconv.ParseBool(e); err == nil {
+			tc.SkipHostVerify = value
+		} else {
+			return nil, errors.Wrapf(err, "cannot parse env var %s=%s", envTLSSkipHostVerify, e)
+		}
+	}
+
+	if *tc == (TLSConfig{}) {
+		return nil, nil
+	}
+	if (tc.CertPath == "") != (tc.KeyPath == "") {
+		return nil, errors.Errorf("you must set %s and %s env vars together", envTLSCert, envTLSKey)
+	}
+	return tc, nil
+}
+
 // parseTags parses the given string into a collection of Tags.
 // Spec for this value:
 // - comma separated list of key=value
-// - value can be specified using the notation ${envVar:defaultValue}, where `envVar`
-// is an environment variable and `defaultValue` is the value to use in case the env var is not set
+// - value can refer to environment variables, see expandEnv
 func parseTags(sTags string) []opentracing.Tag {
 	pairs := strings.Split(sTags, ",")
 	tags := make([]opentracing.Tag, 0)
 	for _, p := range pairs {
 		kv := strings.SplitN(p, "=", 2)
-		k, v := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
-
-		if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") {
-			ed := strings.SplitN(v[2:len(v)-1], ":", 2)
-			e, d := ed[0], ed[1]
-			v = os.Getenv(e)
-			if v == "" && d != "" {
-				v = d
-			}
-		}
+		k, v := strings.TrimSpace(kv[0]), expandEnv(strings.TrimSpace(kv[1]))
 
 		tag := opentracing.Tag{Key: k, Value: v}
 		tags = append(tags, tag)
@@ -222,3 +632,64 @@ func parseTags(sTags string) []opentracing.Tag {
 
 	return tags
 }
+
+// getEnv returns the value of the environment variable, with the references to other variables
+// expanded, see expandEnv.
+func getEnv(name string) string {
+	return expandEnv(os.Getenv(name))
+}
+
+// expandEnv replaces the references to environment variables in the given string. A reference uses the
+// notation ${envVar:defaultValue}, where `envVar` is an environment variable and `defaultValue` is the
+// value to use in case the env var is not set or empty. The default value is optional and can itself
+// refer to environment variables, e.g. ${JAEGER_COLLECTOR_HOST:${HOST_IP:localhost}}. The references
+// can be part of a longer value, e.g. http://${HOST_IP}:14268/api/traces. Unterminated references are
+// kept as is.
+func expandEnv(s string) string {
+	var expanded strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			break
+		}
+		end := closingBrace(s, start+2)
+		if end < 0 {
+			break
+		}
+		expanded.WriteString(s[:start])
+		expanded.WriteString(expandEnvReference(s[start+2 : end]))
+		s = s[end+1:]
+	}
+	expanded.WriteString(s)
+	return expanded.String()
+}
+
+// expandEnvReference returns the value of the reference envVar:defaultValue.
+func expandEnvReference(ref string) string {
+	name, defaultValue := ref, ""
+	if i := strings.Index(ref, ":"); i >= 0 {
+		name, defaultValue = ref[:i], ref[i+1:]
+	}
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return expandEnv(defaultValue)
+}
+
+// closingBrace returns the index of the brace closing the reference starting at the given index,
+// skipping the nested references, or -1 if the reference is not terminated.
+func closingBrace(s string, from int) int {
+	depth := 0
+	for i := from; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}' && depth == 0:
+			return i
+		case s[i] == '}':
+			depth--
+		}
+	}
+	return -1
+}
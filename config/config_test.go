@@ -16,6 +16,7 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"testing"
 	"time"
@@ -104,6 +105,129 @@ func TestFromEnv(t *testing.T) {
 	os.Unsetenv(envRPCMetrics)
 }
 
+func TestTracerOptionsFromEnv(t *testing.T) {
+	os.Setenv(envGen128Bit, "true")
+	os.Setenv(envPropagation, "Jaeger, b3")
+	os.Setenv(envMaxTagValueLength, "128")
+	os.Setenv(envSpanMaxLogsPerSecond, "20")
+
+	cfg, err := FromEnv()
+	assert.NoError(t, err)
+	assert.True(t, cfg.Gen128Bit)
+	assert.Equal(t, []string{PropagationJaeger, PropagationB3}, cfg.Propagation)
+	assert.Equal(t, 128, cfg.MaxTagValueLength)
+	assert.Equal(t, 20, cfg.MaxLogsPerSecond)
+
+	os.Setenv(envPropagation, "jaeger,w3c")
+	_, err = FromEnv()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `cannot parse env var JAEGER_PROPAGATION=jaeger,w3c: unknown propagation format "w3c"`)
+
+	os.Unsetenv(envGen128Bit)
+	os.Unsetenv(envPropagation)
+	os.Unsetenv(envMaxTagValueLength)
+	os.Unsetenv(envSpanMaxLogsPerSecond)
+}
+
+func TestConfigurationFromEnvPrecedence(t *testing.T) {
+	os.Setenv(envServiceName, "env-service")
+	os.Setenv(envTags, "a=env,c=env")
+	os.Setenv(envSamplerType, "const")
+	os.Setenv(envSamplerParam, "1")
+	os.Setenv(envAgentPort, "6832")
+	os.Setenv(envReporterMaxQueueSize, "10")
+	defer func() {
+		for _, name := range []string{envServiceName, envTags, envSamplerType, envSamplerParam, envAgentPort, envReporterMaxQueueSize} {
+			os.Unsetenv(name)
+		}
+	}()
+
+	newConfiguration := func() *Configuration {
+		return &Configuration{
+			ServiceName: "code-service",
+			Tags:        []opentracing.Tag{{Key: "a", Value: "code"}, {Key: "b", Value: "code"}},
+			Sampler:     &SamplerConfig{Type: "probabilistic"},
+			Reporter:    &ReporterConfig{LocalAgentHostPort: "agent:6831"},
+		}
+	}
+
+	// env overrides code by default
+	cfg, err := newConfiguration().FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "env-service", cfg.ServiceName)
+	assert.Equal(t, []opentracing.Tag{{Key: "a", Value: "env"}, {Key: "b", Value: "code"}, {Key: "c", Value: "env"}}, cfg.Tags)
+	assert.Equal(t, "const", cfg.Sampler.Type)
+	assert.Equal(t, 1.0, cfg.Sampler.Param)
+	assert.Equal(t, "agent:6832", cfg.Reporter.LocalAgentHostPort)
+	assert.Equal(t, 10, cfg.Reporter.QueueSize)
+
+	// env only fills the values left empty in code
+	c := newConfiguration()
+	c.EnvPrecedence = CodeOverridesEnv
+	cfg, err = c.FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "code-service", cfg.ServiceName)
+	assert.Equal(t, []opentracing.Tag{{Key: "a", Value: "code"}, {Key: "b", Value: "code"}, {Key: "c", Value: "env"}}, cfg.Tags)
+	assert.Equal(t, "probabilistic", cfg.Sampler.Type)
+	assert.Equal(t, 1.0, cfg.Sampler.Param)
+	assert.Equal(t, "agent:6831", cfg.Reporter.LocalAgentHostPort)
+	assert.Equal(t, 10, cfg.Reporter.QueueSize)
+
+	// per variable precedence
+	c = newConfiguration()
+	c.EnvPrecedenceByVar = map[string]EnvPrecedence{envServiceName: CodeOverridesEnv}
+	cfg, err = c.FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "code-service", cfg.ServiceName)
+	assert.Equal(t, "const", cfg.Sampler.Type)
+
+	c = newConfiguration()
+	c.EnvPrecedence = CodeOverridesEnv
+	c.EnvPrecedenceByVar = map[string]EnvPrecedence{envSamplerType: EnvOverridesCode}
+	cfg, err = c.FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "code-service", cfg.ServiceName)
+	assert.Equal(t, "const", cfg.Sampler.Type)
+
+	cfg, err = (&Configuration{}).FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "env-service", cfg.ServiceName)
+	assert.Equal(t, "localhost:6832", cfg.Reporter.LocalAgentHostPort)
+
+	os.Setenv(envSamplerParam, "NOT_A_FLOAT")
+	_, err = newConfiguration().FromEnv()
+	assert.Error(t, err)
+}
+
+func TestConfigurationFromEnvEndpoint(t *testing.T) {
+	os.Setenv(envEndpoint, "http://1.2.3.4:5678/api/traces")
+	os.Setenv(envUser, "user")
+	os.Setenv(envPassword, "password")
+	defer func() {
+		os.Unsetenv(envEndpoint)
+		os.Unsetenv(envUser)
+		os.Unsetenv(envPassword)
+	}()
+
+	c := &Configuration{Reporter: &ReporterConfig{LocalAgentHostPort: "agent:6831"}}
+	cfg, err := c.FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "http://1.2.3.4:5678/api/traces", cfg.Reporter.CollectorEndpoint)
+	assert.Equal(t, "user", cfg.Reporter.User)
+	assert.Equal(t, "password", cfg.Reporter.Password)
+	assert.Equal(t, "", cfg.Reporter.LocalAgentHostPort)
+
+	c = &Configuration{
+		Reporter:           &ReporterConfig{LocalAgentHostPort: "agent:6831"},
+		EnvPrecedenceByVar: map[string]EnvPrecedence{envEndpoint: CodeOverridesEnv},
+	}
+	cfg, err = c.FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "", cfg.Reporter.CollectorEndpoint)
+	assert.Equal(t, "", cfg.Reporter.User)
+	assert.Equal(t, "agent:6831", cfg.Reporter.LocalAgentHostPort)
+}
+
 func TestNoServiceNameFromEnv(t *testing.T) {
 	os.Unsetenv(envServiceName)
 
@@ -162,6 +286,26 @@ func TestSamplerConfigOnAgentFromEnv(t *testing.T) {
 	os.Unsetenv(envAgentHost)
 }
 
+func TestAgentAddressFromEnv(t *testing.T) {
+	os.Setenv(envAgentHost, "::1")
+	os.Setenv(envAgentPort, "6832")
+
+	cfg, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "[::1]:6832", cfg.Reporter.LocalAgentHostPort)
+	assert.Equal(t, "http://[::1]:5778/sampling", cfg.Sampler.SamplingServerURL)
+
+	os.Setenv(envAgentHost, "unixgram:///var/run/jaeger-agent.sock")
+
+	cfg, err = FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "unixgram:///var/run/jaeger-agent.sock", cfg.Reporter.LocalAgentHostPort)
+	assert.Equal(t, "", cfg.Sampler.SamplingServerURL)
+
+	os.Unsetenv(envAgentHost)
+	os.Unsetenv(envAgentPort)
+}
+
 func TestReporterConfigFromEnv(t *testing.T) {
 	// prepare
 	os.Setenv(envReporterMaxQueueSize, "10")
@@ -204,6 +348,140 @@ func TestReporterConfigFromEnv(t *testing.T) {
 	os.Unsetenv(envPassword)
 }
 
+func TestReporterLimitsFromEnv(t *testing.T) {
+	os.Setenv(envReporterFlushMaxSpans, "100")
+	os.Setenv(envReporterFlushMaxBytes, "4096")
+	os.Setenv(envReporterFlushIdleTimeout, "50ms")
+	os.Setenv(envReporterMaxSpansPerSecond, "1000")
+	os.Setenv(envReporterMaxBytesPerSecond, "1e6")
+	os.Setenv(envReporterMaxBurstBytes, "65000")
+	os.Setenv(envReporterMaxPacketSize, "1400")
+	defer func() {
+		os.Unsetenv(envReporterFlushMaxSpans)
+		os.Unsetenv(envReporterFlushMaxBytes)
+		os.Unsetenv(envReporterFlushIdleTimeout)
+		os.Unsetenv(envReporterMaxSpansPerSecond)
+		os.Unsetenv(envReporterMaxBytesPerSecond)
+		os.Unsetenv(envReporterMaxBurstBytes)
+		os.Unsetenv(envReporterMaxPacketSize)
+	}()
+
+	cfg, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Reporter.BufferFlushMaxSpans)
+	assert.Equal(t, 4096, cfg.Reporter.BufferFlushMaxBytes)
+	assert.Equal(t, 50*time.Millisecond, cfg.Reporter.BufferFlushIdleTimeout)
+	assert.Equal(t, 1000.0, cfg.Reporter.MaxSpansPerSecond)
+	assert.Equal(t, 1e6, cfg.Reporter.MaxBytesPerSecond)
+	assert.Equal(t, 65000.0, cfg.Reporter.MaxBurstBytes)
+	assert.Equal(t, 1400, cfg.Reporter.MaxPacketSize)
+
+	cfg = &Configuration{
+		EnvPrecedence: CodeOverridesEnv,
+		Reporter:      &ReporterConfig{BufferFlushMaxSpans: 10, MaxPacketSize: 9000},
+	}
+	_, err = cfg.FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 10, cfg.Reporter.BufferFlushMaxSpans)
+	assert.Equal(t, 9000, cfg.Reporter.MaxPacketSize)
+	assert.Equal(t, 4096, cfg.Reporter.BufferFlushMaxBytes, "the values left empty in code are set from env")
+}
+
+func TestLimitsConfigFromEnv(t *testing.T) {
+	cfg, err := FromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, cfg.Limits)
+
+	os.Setenv(envSpanMaxTags, "64")
+	os.Setenv(envSpanMaxLogs, "100")
+	os.Setenv(envSpanLogRetention, "head_tail")
+	os.Setenv(envMaxBaggageBytes, "8192")
+	os.Setenv(envMaxTagValueLengthByKey, "db.statement=4096, http.url = 1024")
+	defer func() {
+		os.Unsetenv(envSpanMaxTags)
+		os.Unsetenv(envSpanMaxLogs)
+		os.Unsetenv(envSpanLogRetention)
+		os.Unsetenv(envMaxBaggageBytes)
+		os.Unsetenv(envMaxTagValueLengthByKey)
+	}()
+
+	expected := &LimitsConfig{
+		MaxTagsPerSpan:         64,
+		MaxLogsPerSpan:         100,
+		LogRetention:           "head_tail",
+		MaxBaggageBytes:        8192,
+		MaxTagValueLengthByKey: map[string]int{"db.statement": 4096, "http.url": 1024},
+	}
+	cfg, err = FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, expected, cfg.Limits)
+
+	cfg = &Configuration{}
+	_, err = cfg.FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, expected, cfg.Limits)
+
+	cfg = &Configuration{
+		EnvPrecedence: CodeOverridesEnv,
+		Limits:        &LimitsConfig{MaxTagsPerSpan: 32, MaxTagValueLengthByKey: map[string]int{"sql": 100}},
+	}
+	_, err = cfg.FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, 32, cfg.Limits.MaxTagsPerSpan)
+	assert.Equal(t, map[string]int{"sql": 100}, cfg.Limits.MaxTagValueLengthByKey)
+	assert.Equal(t, 8192, cfg.Limits.MaxBaggageBytes)
+
+	os.Setenv(envMaxTagValueLengthByKey, "db.statement")
+	_, err = FromEnv()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("cannot parse env var %s=db.statement", envMaxTagValueLengthByKey))
+}
+
+func TestTLSConfigFromEnv(t *testing.T) {
+	os.Setenv(envEndpoint, "https://1.2.3.4:5678/api/traces")
+	os.Setenv(envTLSServerName, "collector")
+
+	cfg, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &TLSConfig{ServerName: "collector"}, cfg.Reporter.TLS)
+
+	os.Setenv(envTLSCA, "/etc/jaeger/ca.pem")
+	os.Setenv(envTLSCert, "/etc/jaeger/cert.pem")
+	os.Setenv(envTLSKey, "/etc/jaeger/key.pem")
+	os.Setenv(envTLSSkipHostVerify, "true")
+
+	cfg, err = FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &TLSConfig{
+		CAPath:         "/etc/jaeger/ca.pem",
+		CertPath:       "/etc/jaeger/cert.pem",
+		KeyPath:        "/etc/jaeger/key.pem",
+		ServerName:     "collector",
+		SkipHostVerify: true,
+	}, cfg.Reporter.TLS)
+
+	os.Unsetenv(envTLSKey)
+	_, err = FromEnv()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("you must set %s and %s env vars together", envTLSCert, envTLSKey))
+
+	os.Setenv(envTLSSkipHostVerify, "NOT_A_BOOLEAN")
+	_, err = FromEnv()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), fmt.Sprintf("cannot parse env var %s=NOT_A_BOOLEAN", envTLSSkipHostVerify))
+
+	os.Unsetenv(envTLSCA)
+	os.Unsetenv(envTLSCert)
+	os.Unsetenv(envTLSServerName)
+	os.Unsetenv(envTLSSkipHostVerify)
+
+	cfg, err = FromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, cfg.Reporter.TLS)
+
+	os.Unsetenv(envEndpoint)
+}
+
 func TestParsingErrorsFromEnv(t *testing.T) {
 	os.Setenv(envAgentHost, "localhost") // we require this in order to test the parsing of the port
 
@@ -219,6 +497,18 @@ func TestParsingErrorsFromEnv(t *testing.T) {
 			envVar: envDisabled,
 			value:  "NOT_A_BOOLEAN",
 		},
+		{
+			envVar: envGen128Bit,
+			value:  "NOT_A_BOOLEAN",
+		},
+		{
+			envVar: envMaxTagValueLength,
+			value:  "NOT_AN_INT",
+		},
+		{
+			envVar: envSpanMaxLogsPerSecond,
+			value:  "NOT_AN_INT",
+		},
 		{
 			envVar: envSamplerParam,
 			value:  "NOT_A_FLOAT",
@@ -247,6 +537,30 @@ func TestParsingErrorsFromEnv(t *testing.T) {
 			envVar: envAgentPort,
 			value:  "NOT_AN_INT",
 		},
+		{
+			envVar: envReporterFlushMaxSpans,
+			value:  "NOT_AN_INT",
+		},
+		{
+			envVar: envReporterFlushIdleTimeout,
+			value:  "NOT_A_DURATION",
+		},
+		{
+			envVar: envReporterMaxBytesPerSecond,
+			value:  "NOT_A_FLOAT",
+		},
+		{
+			envVar: envReporterMaxPacketSize,
+			value:  "NOT_AN_INT",
+		},
+		{
+			envVar: envSpanMaxTags,
+			value:  "NOT_AN_INT",
+		},
+		{
+			envVar: envMaxTagValueLengthByKey,
+			value:  "db.statement=NOT_AN_INT",
+		},
 		{
 			envVar: envEndpoint,
 			value:  "NOT_A_URL",
@@ -305,19 +619,58 @@ func TestInvalidSamplerType(t *testing.T) {
 func TestUDPTransportType(t *testing.T) {
 	rc := &ReporterConfig{LocalAgentHostPort: "localhost:1234"}
 	expect, _ := jaeger.NewUDPTransport(rc.LocalAgentHostPort, 0)
-	sender, err := rc.newTransport()
+	sender, err := rc.newTransport(jaeger.NewNullMetrics(), log.NullLogger)
 	require.NoError(t, err)
 	require.IsType(t, expect, sender)
 }
 
+func TestUDPTransportTruncationSteps(t *testing.T) {
+	rc := &ReporterConfig{LocalAgentHostPort: "localhost:1234", TruncationSteps: []string{"tags", "logs"}}
+	sender, err := rc.newTransport(jaeger.NewNullMetrics(), log.NullLogger)
+	require.NoError(t, err)
+	sender.Close()
+
+	rc.TruncationSteps = []string{"logs", "annotations"}
+	_, err = rc.newTransport(jaeger.NewNullMetrics(), log.NullLogger)
+	assert.EqualError(t, err, `unknown span truncation step "annotations"`)
+}
+
 func TestHTTPTransportType(t *testing.T) {
 	rc := &ReporterConfig{CollectorEndpoint: "http://1.2.3.4:5678/api/traces"}
 	expect := transport.NewHTTPTransport(rc.CollectorEndpoint)
-	sender, err := rc.newTransport()
+	sender, err := rc.newTransport(jaeger.NewNullMetrics(), log.NullLogger)
 	require.NoError(t, err)
 	require.IsType(t, expect, sender)
 }
 
+func TestHTTPTransportTLS(t *testing.T) {
+	rc := &ReporterConfig{
+		CollectorEndpoint: "https://1.2.3.4:5678/api/traces",
+		TLS:               &TLSConfig{ServerName: "collector", SkipHostVerify: true},
+	}
+	sender, err := rc.newTransport(jaeger.NewNullMetrics(), log.NullLogger)
+	require.NoError(t, err)
+	sender.Close()
+
+	rc.TLS.CAPath = "/does/not/exist/ca.pem"
+	_, err = rc.newTransport(jaeger.NewNullMetrics(), log.NullLogger)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot read TLS CA file")
+
+	caFile, err := ioutil.TempFile("", "ca")
+	require.NoError(t, err)
+	defer os.Remove(caFile.Name())
+	caFile.Close()
+	rc.TLS.CAPath = caFile.Name()
+	_, err = rc.newTransport(jaeger.NewNullMetrics(), log.NullLogger)
+	assert.EqualError(t, err, "no certificates found in TLS CA file "+caFile.Name())
+
+	rc.TLS = &TLSConfig{CertPath: "/does/not/exist/cert.pem", KeyPath: "/does/not/exist/key.pem"}
+	_, err = rc.newTransport(jaeger.NewNullMetrics(), log.NullLogger)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot load TLS client certificate")
+}
+
 func TestDefaultConfig(t *testing.T) {
 	cfg := Configuration{}
 	_, _, err := cfg.New("", Metrics(metrics.NullFactory), Logger(log.NullLogger))
@@ -412,6 +765,30 @@ func TestConfigWithReporter(t *testing.T) {
 	assert.Len(t, r.GetSpans(), 1)
 }
 
+func TestConfigWithRateLimitedReporter(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	c := Configuration{
+		Sampler: &SamplerConfig{
+			Type:  "const",
+			Param: 1,
+		},
+		Reporter: &ReporterConfig{
+			MaxSpansPerSecond: 1,
+		},
+	}
+	tracer, closer, err := c.New("test", Metrics(metricsFactory))
+	require.NoError(t, err)
+	defer closer.Close()
+
+	tracer.StartSpan("test1").Finish()
+	tracer.StartSpan("test2").Finish()
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "jaeger.tracer.reporter_spans",
+		Tags:  map[string]string{"result": "rate_limited"},
+		Value: 1,
+	})
+}
+
 func TestConfigWithRPCMetrics(t *testing.T) {
 	metrics := metricstest.NewFactory(0)
 	c := Configuration{
@@ -442,6 +819,72 @@ func TestConfigWithRPCMetrics(t *testing.T) {
 	)
 }
 
+// foreignSpanContext is a SpanContext of another tracer, which Jaeger spans cannot reference
+type foreignSpanContext struct{}
+
+func (foreignSpanContext) ForeachBaggageItem(handler func(k, v string) bool) {}
+
+func TestConfigWithDeduplicateLogs(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	c := Configuration{
+		Sampler: &SamplerConfig{
+			Type:  "const",
+			Param: 1,
+		},
+	}
+	tracer, closer, err := c.New(
+		"test",
+		Reporter(jaeger.NewInMemoryReporter()),
+		Logger(logger),
+		DeduplicateLogs(time.Hour),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		tracer.StartSpan("test", opentracing.ChildOf(foreignSpanContext{})).Finish()
+	}
+	assert.Equal(t, "ERROR: Reference contains invalid type of SpanReference: {}\n", logger.String())
+
+	require.NoError(t, closer.Close())
+	assert.Contains(t, logger.String(),
+		"ERROR: suppressed 2 similar messages in the last 0s: Reference contains invalid type of SpanReference: {}\n")
+}
+
+func TestConfigWithMetricsNamespaceAndTags(t *testing.T) {
+	metrics := metricstest.NewFactory(0)
+	c := Configuration{
+		Sampler: &SamplerConfig{
+			Type:  "const",
+			Param: 1,
+		},
+		RPCMetrics: true,
+	}
+	tracer, closer, err := c.New(
+		"test",
+		Reporter(jaeger.NewInMemoryReporter()),
+		Metrics(metrics),
+		MetricsNamespace("checkout"),
+		MetricsTag("env", "prod"),
+	)
+	require.NoError(t, err)
+	defer closer.Close()
+
+	tracer.StartSpan("test", ext.SpanKindRPCServer).Finish()
+
+	metrics.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{
+			Name:  "checkout.jaeger.tracer.finished_spans",
+			Tags:  map[string]string{"env": "prod"},
+			Value: 1,
+		},
+		metricstest.ExpectedMetric{
+			Name:  "checkout.jaeger-rpc.requests",
+			Tags:  map[string]string{"env": "prod", "component": "jaeger", "endpoint": "test", "error": "false"},
+			Value: 1,
+		},
+	)
+}
+
 func TestBaggageRestrictionsConfig(t *testing.T) {
 	m := metricstest.NewFactory(0)
 	c := Configuration{
@@ -478,6 +921,34 @@ func TestBaggageRestrictionsConfig(t *testing.T) {
 	)
 }
 
+func TestBaggageRestrictionsFileConfig(t *testing.T) {
+	f, err := ioutil.TempFile("", "baggage-restrictions")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(`{"tenant": 4}`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	c := Configuration{
+		Sampler: &SamplerConfig{
+			Type:  "const",
+			Param: 1,
+		},
+		BaggageRestrictions: &BaggageRestrictionsConfig{
+			File: f.Name(),
+		},
+	}
+	tracer, closer, err := c.New("test")
+	require.NoError(t, err)
+	defer closer.Close()
+
+	span := tracer.StartSpan("test")
+	span.SetBaggageItem("tenant", "acme-cn")
+	span.SetBaggageItem("user", "bob")
+	assert.Equal(t, "acme", span.BaggageItem("tenant"))
+	assert.Equal(t, "", span.BaggageItem("user"))
+}
+
 func TestConfigWithGen128Bit(t *testing.T) {
 	c := Configuration{
 		Sampler: &SamplerConfig{
@@ -497,6 +968,155 @@ func TestConfigWithGen128Bit(t *testing.T) {
 	require.True(t, traceID.Low != 0)
 }
 
+func TestConfigWithGen128BitField(t *testing.T) {
+	c := Configuration{Gen128Bit: true}
+	tracer, closer, err := c.New("test")
+	require.NoError(t, err)
+	defer closer.Close()
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	require.True(t, span.Context().(jaeger.SpanContext).TraceID().High != 0)
+}
+
+func TestConfigWithPropagation(t *testing.T) {
+	c := Configuration{
+		Sampler:     &SamplerConfig{Type: "const", Param: 1},
+		Propagation: []string{PropagationJaeger, PropagationB3},
+	}
+	tracer, closer, err := c.New("test")
+	require.NoError(t, err)
+	defer closer.Close()
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+
+	for _, format := range []interface{}{opentracing.HTTPHeaders, opentracing.TextMap} {
+		carrier := opentracing.TextMapCarrier{}
+		require.NoError(t, tracer.Inject(span.Context(), format, carrier))
+		assert.Contains(t, carrier, "uber-trace-id")
+		assert.Contains(t, carrier, "x-b3-traceid")
+
+		delete(carrier, "uber-trace-id")
+		sc, err := tracer.Extract(format, carrier)
+		require.NoError(t, err)
+		assert.Equal(t, span.Context().(jaeger.SpanContext).TraceID(), sc.(jaeger.SpanContext).TraceID())
+
+		_, err = tracer.Extract(format, opentracing.TextMapCarrier{})
+		assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+	}
+}
+
+func TestConfigWithB3PropagationOnly(t *testing.T) {
+	c := Configuration{Propagation: []string{PropagationB3}}
+	tracer, closer, err := c.New("test", Injector(opentracing.TextMap, fakeInjector{}))
+	require.NoError(t, err)
+	defer closer.Close()
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(span.Context(), opentracing.HTTPHeaders, carrier))
+	assert.Contains(t, carrier, "x-b3-traceid")
+	assert.NotContains(t, carrier, "uber-trace-id")
+
+	// the injector given as an option takes precedence
+	carrier = opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(span.Context(), opentracing.TextMap, carrier))
+	assert.Empty(t, carrier)
+}
+
+func TestConfigWithUnknownPropagation(t *testing.T) {
+	c := Configuration{Propagation: []string{"w3c"}}
+	_, _, err := c.New("test")
+	assert.EqualError(t, err, `unknown propagation format "w3c"`)
+}
+
+func TestConfigWithMaxTagValueLength(t *testing.T) {
+	c := Configuration{
+		Sampler:           &SamplerConfig{Type: "const", Param: 1},
+		MaxTagValueLength: 5,
+	}
+	tracer, closer, err := c.New("test")
+	require.NoError(t, err)
+	defer closer.Close()
+
+	span := tracer.StartSpan("test").(*jaeger.Span)
+	span.SetTag("key", "0123456789")
+	assert.Equal(t, "01234", thriftTagValue(span, "key"))
+	span.Finish()
+
+	// the option takes precedence
+	tracer, closer, err = c.New("test", MaxTagValueLength(7))
+	require.NoError(t, err)
+	defer closer.Close()
+
+	span = tracer.StartSpan("test").(*jaeger.Span)
+	span.SetTag("key", "0123456789")
+	assert.Equal(t, "0123456", thriftTagValue(span, "key"))
+	span.Finish()
+}
+
+func thriftTagValue(span *jaeger.Span, key string) string {
+	for _, tag := range jaeger.BuildJaegerThrift(span).Tags {
+		if tag.Key == key {
+			return tag.GetVStr()
+		}
+	}
+	return ""
+}
+
+func TestConfigWithLimits(t *testing.T) {
+	c := Configuration{
+		Sampler: &SamplerConfig{Type: "const", Param: 1},
+		Limits: &LimitsConfig{
+			MaxTagsPerSpan:         4,
+			MaxLogsPerSpan:         2,
+			LogRetention:           "keep_last",
+			MaxBaggageBytes:        10,
+			MaxTagValueLengthByKey: map[string]int{"short": 2, "long": 8},
+		},
+	}
+	tracer, closer, err := c.New("test", MaxTagValueLengthByKey(map[string]int{"long": 6}))
+	require.NoError(t, err)
+	defer closer.Close()
+
+	span := tracer.StartSpan("test").(*jaeger.Span)
+	for _, key := range []string{"short", "long", "other"} {
+		span.SetTag(key, "0123456789")
+	}
+	span.SetBaggageItem("key", "value")
+	span.SetBaggageItem("other", "value")
+	for i := 0; i < 3; i++ {
+		span.LogKV("event", i)
+	}
+
+	assert.Equal(t, "01", thriftTagValue(span, "short"))
+	assert.Equal(t, "012345", thriftTagValue(span, "long"))
+	assert.Equal(t, "", thriftTagValue(span, "other"))
+	logs := jaeger.BuildJaegerThrift(span).Logs
+	require.Len(t, logs, 2)
+	assert.Equal(t, int64(1), logs[0].Fields[0].GetVLong())
+	assert.Equal(t, "value", span.BaggageItem("key"))
+	assert.Equal(t, "", span.BaggageItem("other"))
+	span.Finish()
+
+	carrier := opentracing.TextMapCarrier{
+		"uber-trace-id":   "1:1:0:1",
+		"uberctx-key":     "value",
+		"uberctx-another": "0123456789",
+	}
+	sc, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	baggage := map[string]string{}
+	sc.ForeachBaggageItem(func(k, v string) bool {
+		baggage[k] = v
+		return true
+	})
+	assert.Equal(t, map[string]string{"key": "value"}, baggage)
+}
+
 func TestConfigWithInjector(t *testing.T) {
 	c := Configuration{}
 	tracer, closer, err := c.New("test", Injector("custom.format", fakeInjector{}))
@@ -563,6 +1183,32 @@ func TestNewTracerWithNoDebugFlagOnForcedSampling(t *testing.T) {
 	assert.True(t, span.SpanContext().IsSampled())
 }
 
+func TestNewJaegerTracer(t *testing.T) {
+	cfg := &Configuration{
+		ServiceName: "my-service",
+		Sampler:     &SamplerConfig{Type: "const", Param: 1},
+		Tags:        []opentracing.Tag{{Key: "version", Value: "1.0"}},
+	}
+	reporter := jaeger.NewInMemoryReporter()
+	components, closer, err := cfg.NewJaegerTracer(Reporter(reporter))
+	require.NoError(t, err)
+	defer closer.Close()
+
+	assert.Equal(t, reporter, components.Reporter)
+	assert.IsType(t, &jaeger.ConstSampler{}, components.Sampler)
+	assert.Contains(t, components.Tracer.Tags(), opentracing.Tag{Key: "version", Value: "1.0"})
+
+	components.Tracer.StartSpan("test").Finish()
+	assert.Equal(t, 1, reporter.SpansSubmitted())
+
+	cfg.Disabled = true
+	_, _, err = cfg.NewJaegerTracer()
+	assert.EqualError(t, err, "cannot create a Jaeger tracer, the configuration is disabled")
+
+	_, _, err = (&Configuration{}).NewJaegerTracer()
+	assert.EqualError(t, err, "no service name provided")
+}
+
 func TestNewTracerWithoutServiceName(t *testing.T) {
 	cfg := &Configuration{}
 	_, _, err := cfg.NewTracer(Metrics(metrics.NullFactory), Logger(log.NullLogger))
@@ -590,6 +1236,61 @@ func TestParseTags(t *testing.T) {
 	os.Unsetenv("existing")
 }
 
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("existing", "not-default")
+	os.Setenv("HOST_IP", "10.0.0.1")
+	defer os.Unsetenv("existing")
+	defer os.Unsetenv("HOST_IP")
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "value", expected: "value"},
+		{value: "${existing}", expected: "not-default"},
+		{value: "${existing:default}", expected: "not-default"},
+		{value: "${nonExisting}", expected: ""},
+		{value: "${nonExisting:default}", expected: "default"},
+		{value: "${nonExisting:${existing:default}}", expected: "not-default"},
+		{value: "${nonExisting:${nonExisting2:default}}", expected: "default"},
+		{value: "${nonExisting:${nonExisting2:${HOST_IP}}}", expected: "10.0.0.1"},
+		{value: "${nonExisting:http://${HOST_IP}:14268}", expected: "http://10.0.0.1:14268"},
+		{value: "http://${HOST_IP:localhost}:14268/api/traces", expected: "http://10.0.0.1:14268/api/traces"},
+		{value: "${existing}-${nonExisting:x}", expected: "not-default-x"},
+		{value: "${existing", expected: "${existing"},
+		{value: "${nonExisting:${existing}", expected: "${nonExisting:${existing}"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, expandEnv(test.value), test.value)
+	}
+
+	ts := parseTags("k1=${nonExisting:${existing}},k2=${nonExisting}")
+	assert.Equal(t, []opentracing.Tag{{Key: "k1", Value: "not-default"}, {Key: "k2", Value: ""}}, ts)
+}
+
+func TestEndpointsWithEnvReferences(t *testing.T) {
+	os.Setenv("HOST_IP", "10.0.0.1")
+	os.Setenv(envAgentHost, "${JAEGER_AGENT_IP:${HOST_IP}}")
+	os.Setenv(envAgentPort, "${JAEGER_AGENT_UDP_PORT:6832}")
+	defer func() {
+		for _, name := range []string{"HOST_IP", envAgentHost, envAgentPort, envEndpoint, envSamplerManagerHostPort} {
+			os.Unsetenv(name)
+		}
+	}()
+
+	cfg, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:6832", cfg.Reporter.LocalAgentHostPort)
+	assert.Equal(t, "http://10.0.0.1:5778/sampling", cfg.Sampler.SamplingServerURL)
+
+	os.Setenv(envSamplerManagerHostPort, "http://${HOST_IP}:5779/sampling")
+	os.Setenv(envEndpoint, "http://${JAEGER_COLLECTOR_HOST:${HOST_IP}}:14268/api/traces")
+	cfg, err = FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "http://10.0.0.1:14268/api/traces", cfg.Reporter.CollectorEndpoint)
+	assert.Equal(t, "http://10.0.0.1:5779/sampling", cfg.Sampler.SamplingServerURL)
+}
+
 func TestServiceNameViaConfiguration(t *testing.T) {
 	cfg := &Configuration{ServiceName: "my-service"}
 	_, closer, err := cfg.New("")
@@ -612,3 +1313,28 @@ func TestThrottlerDefaultConfig(t *testing.T) {
 	assert.NoError(t, err)
 	defer closer.Close()
 }
+
+type testCreditManager struct {
+	operations []string
+}
+
+func (m *testCreditManager) FetchCredits(uuid, serviceName string, operations []string) (map[string]float64, error) {
+	m.operations = append(m.operations, operations...)
+	return map[string]float64{operations[0]: 1}, nil
+}
+
+func TestThrottlerCreditManager(t *testing.T) {
+	cfg := &Configuration{
+		Throttler: &ThrottlerConfig{SynchronousInitialization: true},
+	}
+	manager := &testCreditManager{}
+	tracer, closer, err := cfg.New("test-service", ThrottlerCreditManager(manager))
+	require.NoError(t, err)
+	defer closer.Close()
+
+	span := tracer.StartSpan("debug-op")
+	ext.SamplingPriority.Set(span, 1)
+	assert.True(t, span.Context().(jaeger.SpanContext).IsDebug())
+	span.Finish()
+	assert.Equal(t, []string{"debug-op"}, manager.operations)
+}
@@ -15,10 +15,14 @@
 package config
 
 import (
+	"time"
+
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/uber/jaeger-lib/metrics"
 
 	"github.com/uber/jaeger-client-go"
+	throttler "github.com/uber/jaeger-client-go/internal/throttler/remote"
+	"github.com/uber/jaeger-client-go/rpcmetrics"
 )
 
 // Option is a function that sets some option on the client.
@@ -27,19 +31,71 @@ type Option func(c *Options)
 // Options control behavior of the client.
 type Options struct {
 	metrics                     metrics.Factory
+	metricsNamespace            string
+	metricsTags                 map[string]string
 	logger                      jaeger.Logger
 	reporter                    jaeger.Reporter
 	sampler                     jaeger.Sampler
 	contribObservers            []jaeger.ContribObserver
 	observers                   []jaeger.Observer
+	rpcMetricsNormalizer        rpcmetrics.NameNormalizer
+	rpcMetricsOptions           []rpcmetrics.Option
 	gen128Bit                   bool
+	idGenerator                 jaeger.IDGenerator
 	poolSpans                   bool
 	zipkinSharedRPCSpan         bool
+	zipkinSharedMessagingSpan   bool
+	baggageTags                 []string
+	operationNameNormalizer     func(operationName string) string
+	startSpanInterceptors       []jaeger.StartSpanInterceptor
 	maxTagValueLength           int
+	maxTagValueLengthByKey      map[string]int
+	redactedTags                []string
+	minSpanDuration             time.Duration
+	maxLogsPerSecond            int
+	maxEventsPerSpan            int
+	maxTagsPerSpan              int
+	tagErrorsFromLogs           bool
+	delayedSampling             bool
+	minimalUnsampledSpans       bool
+	inFlightSpansInterval       time.Duration
+	remoteClock                 jaeger.RemoteClock
+	clockSkewInterval           time.Duration
+	spanDurationHistograms      bool
+	spanDurationBuckets         []time.Duration
+	metricsSummaryInterval      time.Duration
+	logDeduplicationInterval    time.Duration
+	diagnosticsCallback         jaeger.DiagnosticsCallback
+	resourceDetectors           []jaeger.ResourceDetector
+	stackTraceOnError           bool
+	stackTraceMaxDepth          int
+	stackTraceMaxPerSecond      float64
+	logRetentionPolicy          jaeger.LogRetentionPolicy
 	noDebugFlagOnForcedSampling bool
+	disableHostTags             bool
+	instanceID                  string
+	instanceIDFile              string
+	autoFinishChildren          bool
+	sampleOrphans               bool
+	useAfterFinish              jaeger.UseAfterFinishAction
+	finishStackTraces           bool
+	reportSpanStart             bool
+	partialSpanMinAge           time.Duration
+	partialSpanInterval         time.Duration
 	tags                        []opentracing.Tag
+	defaultBaggage              map[string]string
+	baggageObservers            []jaeger.BaggageObserver
+	propagatedBaggage           []string
+	localBaggage                []string
+	maxBaggageSize              int
+	baggageMetricsKeys          []string
+	baggageSigningSecret        []byte
+	signedBaggage               []string
+	baggageTransformers         []baggageTransformer
+	baggageNamespaces           map[string]jaeger.BaggageNamespaceRules
 	injectors                   map[interface{}]jaeger.Injector
 	extractors                  map[interface{}]jaeger.Extractor
+	throttlerCreditManager      throttler.CreditManager
 }
 
 // Metrics creates an Option that initializes Metrics in the tracer,
@@ -50,6 +106,26 @@ func Metrics(factory metrics.Factory) Option {
 	}
 }
 
+// MetricsNamespace creates an Option that prefixes the names of all metrics of the tracer, its reporter,
+// sampler and other components with the given namespace, e.g. "checkout" for "checkout.jaeger.tracer.*",
+// so that the tracers of different services in the same binary emit distinct metrics.
+func MetricsNamespace(namespace string) Option {
+	return func(c *Options) {
+		c.metricsNamespace = namespace
+	}
+}
+
+// MetricsTag creates an Option that adds a constant tag, e.g. the service, environment or region,
+// to all metrics of the tracer, its reporter, sampler and other components.
+func MetricsTag(key, value string) Option {
+	return func(c *Options) {
+		if c.metricsTags == nil {
+			c.metricsTags = make(map[string]string)
+		}
+		c.metricsTags[key] = value
+	}
+}
+
 // Logger can be provided to log Reporter errors, as well as to log spans
 // if Reporter.LogSpans is set to true.
 func Logger(logger jaeger.Logger) Option {
@@ -80,6 +156,23 @@ func Observer(observer jaeger.Observer) Option {
 	}
 }
 
+// RPCMetricsNameNormalizer sets the normalizer of the endpoint names used by the RPC metrics
+// when Configuration.RPCMetrics is enabled, e.g. a rpcmetrics.RewriteNameNormalizer collapsing
+// the parameters of REST paths. Defaults to rpcmetrics.DefaultNameNormalizer.
+func RPCMetricsNameNormalizer(normalizer rpcmetrics.NameNormalizer) Option {
+	return func(c *Options) {
+		c.rpcMetricsNormalizer = normalizer
+	}
+}
+
+// RPCMetricsOptions adds the options of the RPC metrics observer used when Configuration.RPCMetrics
+// is enabled, e.g. rpcmetrics.Options.MaxEndpoints.
+func RPCMetricsOptions(options ...rpcmetrics.Option) Option {
+	return func(c *Options) {
+		c.rpcMetricsOptions = append(c.rpcMetricsOptions, options...)
+	}
+}
+
 // ContribObserver can be registered with the Tracer to receive notifications
 // about new spans.
 func ContribObserver(observer jaeger.ContribObserver) Option {
@@ -88,6 +181,14 @@ func ContribObserver(observer jaeger.ContribObserver) Option {
 	}
 }
 
+// IDGenerator creates an option that sets the generator of trace and span IDs,
+// see jaeger.TracerOptions.IDGenerator.
+func IDGenerator(idGenerator jaeger.IDGenerator) Option {
+	return func(c *Options) {
+		c.idGenerator = idGenerator
+	}
+}
+
 // Gen128Bit specifies whether to generate 128bit trace IDs.
 func Gen128Bit(gen128Bit bool) Option {
 	return func(c *Options) {
@@ -111,6 +212,14 @@ func ZipkinSharedRPCSpan(zipkinSharedRPCSpan bool) Option {
 	}
 }
 
+// ZipkinSharedMessagingSpan creates an option that enables sharing span ID between producer
+// and consumer spans a la zipkin, independently of ZipkinSharedRPCSpan.
+func ZipkinSharedMessagingSpan(zipkinSharedMessagingSpan bool) Option {
+	return func(c *Options) {
+		c.zipkinSharedMessagingSpan = zipkinSharedMessagingSpan
+	}
+}
+
 // MaxTagValueLength can be provided to override the default max tag value length.
 func MaxTagValueLength(maxTagValueLength int) Option {
 	return func(c *Options) {
@@ -118,6 +227,38 @@ func MaxTagValueLength(maxTagValueLength int) Option {
 	}
 }
 
+// MaxTagValueLengthByKey can be provided to override the max length of the values of the tags
+// with the given keys, see jaeger.TracerOptions.MaxTagValueLengthByKey.
+func MaxTagValueLengthByKey(maxTagValueLengthByKey map[string]int) Option {
+	return func(c *Options) {
+		c.maxTagValueLengthByKey = maxTagValueLengthByKey
+	}
+}
+
+// RedactTags can be provided to replace the values of the span tags with the given keys or glob patterns
+// with a fixed mask, see jaeger.TracerOptions.RedactTags.
+func RedactTags(keys ...string) Option {
+	return func(c *Options) {
+		c.redactedTags = append(c.redactedTags, keys...)
+	}
+}
+
+// MinSpanDuration can be provided to stop the tracer from reporting sampled spans shorter than
+// the given duration, except local roots and error spans, see jaeger.TracerOptions.MinSpanDuration.
+func MinSpanDuration(minSpanDuration time.Duration) Option {
+	return func(c *Options) {
+		c.minSpanDuration = minSpanDuration
+	}
+}
+
+// MaxLogsPerSecond can be provided to limit the number of logs recorded on each span per second,
+// see jaeger.TracerOptions.MaxLogsPerSecond.
+func MaxLogsPerSecond(maxLogsPerSecond int) Option {
+	return func(c *Options) {
+		c.maxLogsPerSecond = maxLogsPerSecond
+	}
+}
+
 // NoDebugFlagOnForcedSampling can be used to decide whether debug flag will be set or not
 // when calling span.setSamplingPriority to force sample a span.
 func NoDebugFlagOnForcedSampling(noDebugFlagOnForcedSampling bool) Option {
@@ -126,6 +267,218 @@ func NoDebugFlagOnForcedSampling(noDebugFlagOnForcedSampling bool) Option {
 	}
 }
 
+// DisableHostTags can be provided to stop the tracer from adding the hostname and ip process tags
+// and from looking up the IP address of the host, see jaeger.TracerOptions.DisableHostTags.
+func DisableHostTags(disableHostTags bool) Option {
+	return func(c *Options) {
+		c.disableHostTags = disableHostTags
+	}
+}
+
+// AutoFinishChildren can be provided to finish the unfinished children of the spans when they
+// finish, see jaeger.TracerOptions.AutoFinishChildren.
+func AutoFinishChildren(autoFinishChildren bool) Option {
+	return func(c *Options) {
+		c.autoFinishChildren = autoFinishChildren
+	}
+}
+
+// SampleOrphans can be provided to start new sampled traces under unsampled upstream traces,
+// see jaeger.TracerOptions.SampleOrphans.
+func SampleOrphans(sampleOrphans bool) Option {
+	return func(c *Options) {
+		c.sampleOrphans = sampleOrphans
+	}
+}
+
+// DetectUseAfterFinish can be provided to detect the spans used after Finish,
+// see jaeger.TracerOptions.DetectUseAfterFinish.
+func DetectUseAfterFinish(action jaeger.UseAfterFinishAction) Option {
+	return func(c *Options) {
+		c.useAfterFinish = action
+	}
+}
+
+// FinishStackTraces can be provided to report the stack trace of Finish for the spans used after Finish,
+// see jaeger.TracerOptions.FinishStackTraces.
+func FinishStackTraces(finishStackTraces bool) Option {
+	return func(c *Options) {
+		c.finishStackTraces = finishStackTraces
+	}
+}
+
+// InstanceID can be provided to report a stable UUID of the process, see jaeger.TracerOptions.InstanceID.
+func InstanceID(instanceID string) Option {
+	return func(c *Options) {
+		c.instanceID = instanceID
+	}
+}
+
+// InstanceIDFile can be provided to persist the UUID of the process across restarts,
+// see jaeger.TracerOptions.InstanceIDFile.
+func InstanceIDFile(path string) Option {
+	return func(c *Options) {
+		c.instanceIDFile = path
+	}
+}
+
+// MaxEventsPerSpan can be provided to override the default max number of events recorded on a span.
+func MaxEventsPerSpan(maxEventsPerSpan int) Option {
+	return func(c *Options) {
+		c.maxEventsPerSpan = maxEventsPerSpan
+	}
+}
+
+// MaxTagsPerSpan can be provided to limit the number of tags of a span.
+func MaxTagsPerSpan(maxTagsPerSpan int) Option {
+	return func(c *Options) {
+		c.maxTagsPerSpan = maxTagsPerSpan
+	}
+}
+
+// TagErrorsFromLogs creates an option that makes the tracer set the error tag on spans
+// that log an error object or the "error" event.
+func TagErrorsFromLogs(tagErrorsFromLogs bool) Option {
+	return func(c *Options) {
+		c.tagErrorsFromLogs = tagErrorsFromLogs
+	}
+}
+
+// DelayedSampling creates an option that makes the tracer defer the sampling decision of root spans
+// until their first tag is set, see jaeger.TracerOptions.DelayedSampling.
+func DelayedSampling(delayedSampling bool) Option {
+	return func(c *Options) {
+		c.delayedSampling = delayedSampling
+	}
+}
+
+// ResourceDetectors creates an option that adds the tags of the resource detectors
+// to the process tags, see jaeger.TracerOptions.ResourceDetectors.
+func ResourceDetectors(detectors ...jaeger.ResourceDetector) Option {
+	return func(c *Options) {
+		c.resourceDetectors = append(c.resourceDetectors, detectors...)
+	}
+}
+
+// MinimalUnsampledSpans creates an option that makes the tracer return minimal spans
+// for the spans of unsampled traces other than their roots, see jaeger.TracerOptions.MinimalUnsampledSpans.
+func MinimalUnsampledSpans(minimalUnsampledSpans bool) Option {
+	return func(c *Options) {
+		c.minimalUnsampledSpans = minimalUnsampledSpans
+	}
+}
+
+// StackTraceOnError creates an option that makes the tracer log the stack trace of the caller
+// when the error tag is set on a span, see jaeger.TracerOptions.StackTraceOnError.
+func StackTraceOnError(maxDepth int, maxPerSecond float64) Option {
+	return func(c *Options) {
+		c.stackTraceOnError = true
+		c.stackTraceMaxDepth = maxDepth
+		c.stackTraceMaxPerSecond = maxPerSecond
+	}
+}
+
+// LogRetentionPolicy can be provided to limit the number of logs recorded on a span.
+func LogRetentionPolicy(policy jaeger.LogRetentionPolicy) Option {
+	return func(c *Options) {
+		c.logRetentionPolicy = policy
+	}
+}
+
+// ReportSpanStart creates an option that makes the tracer report a lightweight record of each
+// sampled span when it starts, in addition to the full span when it finishes.
+func ReportSpanStart(reportSpanStart bool) Option {
+	return func(c *Options) {
+		c.reportSpanStart = reportSpanStart
+	}
+}
+
+// PartialSpanReporting creates an option that makes the tracer periodically report snapshots of
+// sampled spans running for longer than minAge, see jaeger.TracerOptions.PartialSpanReporting.
+func PartialSpanReporting(minAge, interval time.Duration) Option {
+	return func(c *Options) {
+		c.partialSpanMinAge = minAge
+		c.partialSpanInterval = interval
+	}
+}
+
+// TrackInFlightSpans creates an option that makes the tracer keep track of the spans started
+// but not finished, see jaeger.TracerOptions.TrackInFlightSpans.
+func TrackInFlightSpans(interval time.Duration) Option {
+	return func(c *Options) {
+		c.inFlightSpansInterval = interval
+	}
+}
+
+// ClockSkewDetection creates an option that makes the tracer periodically measure the skew
+// of the local clock, see jaeger.TracerOptions.ClockSkewDetection.
+func ClockSkewDetection(clock jaeger.RemoteClock, interval time.Duration) Option {
+	return func(c *Options) {
+		c.remoteClock = clock
+		c.clockSkewInterval = interval
+	}
+}
+
+// SpanDurationHistograms creates an option that records the durations of all spans, sampled or not,
+// in histograms per operation, see jaeger.TracerOptions.SpanDurationHistograms.
+func SpanDurationHistograms(buckets ...time.Duration) Option {
+	return func(c *Options) {
+		c.spanDurationHistograms = true
+		c.spanDurationBuckets = buckets
+	}
+}
+
+// LogMetricsSummary creates an option that makes the tracer log a summary of its own metrics
+// every interval, see jaeger.TracerOptions.LogMetricsSummary.
+func LogMetricsSummary(interval time.Duration) Option {
+	return func(c *Options) {
+		c.metricsSummaryInterval = interval
+	}
+}
+
+// DeduplicateLogs creates an option that rate-limits the identical messages logged by the tracer
+// and its components, e.g. the errors of span submissions during an outage of the agent: the first
+// occurrence of a message is logged, and the identical messages that follow within the interval are
+// summarized in a single "suppressed N similar messages" line, see log.DeduplicatingLogger.
+func DeduplicateLogs(interval time.Duration) Option {
+	return func(c *Options) {
+		c.logDeduplicationInterval = interval
+	}
+}
+
+// DiagnosticsCallback creates an option that sets the callback invoked with the notable internal
+// events of the sampler and the reporter created from the configuration, e.g. when a new sampling
+// strategy is applied or the reporter queue starts overflowing, see jaeger.DiagnosticsCallback.
+func DiagnosticsCallback(callback jaeger.DiagnosticsCallback) Option {
+	return func(c *Options) {
+		c.diagnosticsCallback = callback
+	}
+}
+
+// BaggageTags creates an option that copies the values of the given baggage items to the tags
+// of the spans, see jaeger.TracerOptions.BaggageTags.
+func BaggageTags(keys ...string) Option {
+	return func(c *Options) {
+		c.baggageTags = append(c.baggageTags, keys...)
+	}
+}
+
+// OperationNameNormalizer creates an option that rewrites the operation names of the spans,
+// see jaeger.TracerOptions.OperationNameNormalizer.
+func OperationNameNormalizer(normalizer func(operationName string) string) Option {
+	return func(c *Options) {
+		c.operationNameNormalizer = normalizer
+	}
+}
+
+// StartSpanInterceptors creates an option that adds interceptors to the chain called by StartSpan,
+// see jaeger.TracerOptions.StartSpanInterceptors.
+func StartSpanInterceptors(interceptors ...jaeger.StartSpanInterceptor) Option {
+	return func(c *Options) {
+		c.startSpanInterceptors = append(c.startSpanInterceptors, interceptors...)
+	}
+}
+
 // Tag creates an option that adds a tracer-level tag.
 func Tag(key string, value interface{}) Option {
 	return func(c *Options) {
@@ -133,6 +486,90 @@ func Tag(key string, value interface{}) Option {
 	}
 }
 
+// DefaultBaggage creates an option that adds a baggage item to every new trace,
+// see jaeger.TracerOptions.DefaultBaggage.
+func DefaultBaggage(key, value string) Option {
+	return func(c *Options) {
+		if c.defaultBaggage == nil {
+			c.defaultBaggage = make(map[string]string)
+		}
+		c.defaultBaggage[key] = value
+	}
+}
+
+// BaggageObserver creates an option that adds an observer of the baggage set on the spans,
+// see jaeger.TracerOptions.BaggageObserver.
+func BaggageObserver(observer jaeger.BaggageObserver) Option {
+	return func(c *Options) {
+		c.baggageObservers = append(c.baggageObservers, observer)
+	}
+}
+
+// PropagatedBaggage creates an option that restricts the baggage propagated to other processes
+// to the given keys, see jaeger.TracerOptions.PropagatedBaggage.
+func PropagatedBaggage(keys ...string) Option {
+	return func(c *Options) {
+		c.propagatedBaggage = append(c.propagatedBaggage, keys...)
+	}
+}
+
+// LocalBaggage creates an option that prevents the baggage with the given keys from being propagated
+// to other processes, see jaeger.TracerOptions.LocalBaggage.
+func LocalBaggage(keys ...string) Option {
+	return func(c *Options) {
+		c.localBaggage = append(c.localBaggage, keys...)
+	}
+}
+
+// MaxBaggageSize creates an option that limits the total size of the baggage of a trace,
+// see jaeger.TracerOptions.MaxBaggageSize.
+func MaxBaggageSize(maxBaggageSize int) Option {
+	return func(c *Options) {
+		c.maxBaggageSize = maxBaggageSize
+	}
+}
+
+// BaggageMetricsKeys creates an option that tags the baggage metrics with the given baggage keys,
+// see jaeger.TracerOptions.BaggageMetricsKeys.
+func BaggageMetricsKeys(keys ...string) Option {
+	return func(c *Options) {
+		c.baggageMetricsKeys = append(c.baggageMetricsKeys, keys...)
+	}
+}
+
+// SignedBaggage creates an option that signs the baggage items with the given keys,
+// see jaeger.TracerOptions.SignedBaggage.
+func SignedBaggage(secret []byte, keys ...string) Option {
+	return func(c *Options) {
+		c.baggageSigningSecret = secret
+		c.signedBaggage = append(c.signedBaggage, keys...)
+	}
+}
+
+type baggageTransformer struct {
+	transformer jaeger.BaggageTransformer
+	keys        []string
+}
+
+// BaggageTransformer creates an option that registers a transformer of the baggage items with the given keys,
+// see jaeger.TracerOptions.BaggageTransformer.
+func BaggageTransformer(transformer jaeger.BaggageTransformer, keys ...string) Option {
+	return func(c *Options) {
+		c.baggageTransformers = append(c.baggageTransformers, baggageTransformer{transformer: transformer, keys: keys})
+	}
+}
+
+// BaggageNamespace creates an option that registers a baggage namespace with the given restriction rules,
+// see jaeger.TracerOptions.BaggageNamespace.
+func BaggageNamespace(name string, rules jaeger.BaggageNamespaceRules) Option {
+	return func(c *Options) {
+		if c.baggageNamespaces == nil {
+			c.baggageNamespaces = make(map[string]jaeger.BaggageNamespaceRules)
+		}
+		c.baggageNamespaces[name] = rules
+	}
+}
+
 // Injector registers an Injector with the given format.
 func Injector(format interface{}, injector jaeger.Injector) Option {
 	return func(c *Options) {
@@ -147,6 +584,16 @@ func Extractor(format interface{}, extractor jaeger.Extractor) Option {
 	}
 }
 
+// ThrottlerCreditManager can be provided to make the debug throttler fetch its credits from
+// the given manager instead of jaeger-agent, e.g. from a backend of deployments without an agent.
+// The manager implements FetchCredits(uuid, serviceName string, operations []string)
+// (map[string]float64, error). It is used only when Configuration.Throttler is set.
+func ThrottlerCreditManager(manager throttler.CreditManager) Option {
+	return func(c *Options) {
+		c.throttlerCreditManager = manager
+	}
+}
+
 func applyOptions(options ...Option) Options {
 	opts := Options{
 		injectors:  make(map[interface{}]jaeger.Injector),
@@ -15,7 +15,9 @@
 package config
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
@@ -24,6 +26,7 @@ import (
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 
 	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/rpcmetrics"
 )
 
 func TestApplyOptions(t *testing.T) {
@@ -31,28 +34,130 @@ func TestApplyOptions(t *testing.T) {
 	observer := fakeObserver{}
 	sampler := &fakeSampler{}
 	contribObserver := fakeContribObserver{}
+	idGenerator := fakeIDGenerator{}
+	remoteClock := jaeger.HTTPDateClock("http://localhost:14268", 0)
 	opts := applyOptions(
 		Metrics(metricsFactory),
+		MetricsNamespace("checkout"),
+		MetricsTag("env", "prod"),
+		MetricsTag("region", "cn-hangzhou"),
 		Logger(jaeger.StdLogger),
 		Observer(observer),
 		Sampler(sampler),
 		ContribObserver(contribObserver),
+		RPCMetricsNameNormalizer(rpcmetrics.PathNameNormalizer),
+		RPCMetricsOptions(rpcmetrics.Options.MaxEndpoints(500)),
 		Gen128Bit(true),
+		IDGenerator(idGenerator),
 		PoolSpans(true),
 		ZipkinSharedRPCSpan(true),
+		ZipkinSharedMessagingSpan(true),
+		BaggageTags("tenant-id"),
+		OperationNameNormalizer(strings.ToLower),
+		StartSpanInterceptors(jaeger.DefaultTagsInterceptor(opentracing.Tags{"team": "fc"})),
 		MaxTagValueLength(1024),
+		MaxTagValueLengthByKey(map[string]int{"db.statement": 20480}),
+		RedactTags("password", "http.header.*"),
+		MinSpanDuration(time.Millisecond),
+		MaxLogsPerSecond(100),
+		MaxEventsPerSpan(16),
+		MaxTagsPerSpan(64),
+		TagErrorsFromLogs(true),
+		DelayedSampling(true),
+		MinimalUnsampledSpans(true),
+		ResourceDetectors(jaeger.FCResourceDetector()),
+		StackTraceOnError(16, 10),
+		LogRetentionPolicy(jaeger.LogRetentionPolicy{MaxLogs: 10, Retention: jaeger.LogRetentionHeadTail}),
 		NoDebugFlagOnForcedSampling(true),
+		DisableHostTags(true),
+		InstanceID("pod-1"),
+		InstanceIDFile("/var/run/jaeger-uuid"),
+		AutoFinishChildren(true),
+		SampleOrphans(true),
+		DetectUseAfterFinish(jaeger.UseAfterFinishPanic),
+		FinishStackTraces(true),
+		DefaultBaggage("region", "cn-hangzhou"),
+		BaggageObserver(jaeger.BaggageObserverFunc(func(jaeger.BaggageChange) {})),
+		PropagatedBaggage("tenant", "x-*"),
+		LocalBaggage("x-internal"),
+		MaxBaggageSize(4096),
+		BaggageMetricsKeys("tenant"),
+		SignedBaggage([]byte("secret"), "tenant"),
+		BaggageTransformer(jaeger.LowercaseBaggageKey),
+		BaggageTransformer(jaeger.ASCIIBaggageValue, "tenant", "user-*"),
+		BaggageNamespace("mylib", jaeger.BaggageNamespaceRules{MaxValueLength: 64}),
+		ReportSpanStart(true),
+		PartialSpanReporting(5*time.Minute, time.Minute),
+		TrackInFlightSpans(time.Minute),
+		ClockSkewDetection(remoteClock, time.Hour),
+		SpanDurationHistograms(time.Millisecond, time.Second),
+		LogMetricsSummary(5*time.Minute),
+		DeduplicateLogs(time.Minute),
+		DiagnosticsCallback(func(jaeger.DiagnosticEvent) {}),
 	)
 	assert.Equal(t, jaeger.StdLogger, opts.logger)
 	assert.Equal(t, sampler, opts.sampler)
 	assert.Equal(t, metricsFactory, opts.metrics)
+	assert.Equal(t, "checkout", opts.metricsNamespace)
+	assert.Equal(t, map[string]string{"env": "prod", "region": "cn-hangzhou"}, opts.metricsTags)
 	assert.Equal(t, []jaeger.Observer{observer}, opts.observers)
 	assert.Equal(t, []jaeger.ContribObserver{contribObserver}, opts.contribObservers)
+	assert.Equal(t, rpcmetrics.PathNameNormalizer, opts.rpcMetricsNormalizer)
+	assert.Len(t, opts.rpcMetricsOptions, 1)
 	assert.True(t, opts.gen128Bit)
+	assert.Equal(t, idGenerator, opts.idGenerator)
 	assert.True(t, opts.poolSpans)
 	assert.True(t, opts.zipkinSharedRPCSpan)
+	assert.True(t, opts.zipkinSharedMessagingSpan)
+	assert.Equal(t, []string{"tenant-id"}, opts.baggageTags)
+	assert.Equal(t, "get", opts.operationNameNormalizer("GET"))
+	assert.Len(t, opts.startSpanInterceptors, 1)
 	assert.True(t, opts.noDebugFlagOnForcedSampling)
+	assert.True(t, opts.disableHostTags)
+	assert.Equal(t, "pod-1", opts.instanceID)
+	assert.Equal(t, "/var/run/jaeger-uuid", opts.instanceIDFile)
+	assert.True(t, opts.autoFinishChildren)
+	assert.True(t, opts.sampleOrphans)
+	assert.Equal(t, jaeger.UseAfterFinishPanic, opts.useAfterFinish)
+	assert.True(t, opts.finishStackTraces)
+	assert.Equal(t, map[string]string{"region": "cn-hangzhou"}, opts.defaultBaggage)
+	assert.Len(t, opts.baggageObservers, 1)
+	assert.Equal(t, []string{"tenant", "x-*"}, opts.propagatedBaggage)
+	assert.Equal(t, []string{"x-internal"}, opts.localBaggage)
+	assert.Equal(t, 4096, opts.maxBaggageSize)
+	assert.Equal(t, []string{"tenant"}, opts.baggageMetricsKeys)
+	assert.Equal(t, []byte("secret"), opts.baggageSigningSecret)
+	assert.Equal(t, []string{"tenant"}, opts.signedBaggage)
+	require.Len(t, opts.baggageTransformers, 2)
+	assert.Empty(t, opts.baggageTransformers[0].keys)
+	assert.Equal(t, []string{"tenant", "user-*"}, opts.baggageTransformers[1].keys)
+	assert.Equal(t, map[string]jaeger.BaggageNamespaceRules{"mylib": {MaxValueLength: 64}}, opts.baggageNamespaces)
+	assert.True(t, opts.reportSpanStart)
+	assert.Equal(t, 5*time.Minute, opts.partialSpanMinAge)
+	assert.Equal(t, time.Minute, opts.partialSpanInterval)
 	assert.Equal(t, 1024, opts.maxTagValueLength)
+	assert.Equal(t, map[string]int{"db.statement": 20480}, opts.maxTagValueLengthByKey)
+	assert.Equal(t, []string{"password", "http.header.*"}, opts.redactedTags)
+	assert.Equal(t, time.Millisecond, opts.minSpanDuration)
+	assert.Equal(t, 100, opts.maxLogsPerSecond)
+	assert.Equal(t, 16, opts.maxEventsPerSpan)
+	assert.Equal(t, 64, opts.maxTagsPerSpan)
+	assert.True(t, opts.tagErrorsFromLogs)
+	assert.True(t, opts.delayedSampling)
+	assert.True(t, opts.minimalUnsampledSpans)
+	assert.Equal(t, time.Minute, opts.inFlightSpansInterval)
+	assert.NotNil(t, opts.remoteClock)
+	assert.Equal(t, time.Hour, opts.clockSkewInterval)
+	assert.True(t, opts.spanDurationHistograms)
+	assert.Equal(t, 5*time.Minute, opts.metricsSummaryInterval)
+	assert.Equal(t, time.Minute, opts.logDeduplicationInterval)
+	assert.NotNil(t, opts.diagnosticsCallback)
+	assert.Equal(t, []time.Duration{time.Millisecond, time.Second}, opts.spanDurationBuckets)
+	assert.Len(t, opts.resourceDetectors, 1)
+	assert.True(t, opts.stackTraceOnError)
+	assert.Equal(t, 16, opts.stackTraceMaxDepth)
+	assert.Equal(t, 10.0, opts.stackTraceMaxPerSecond)
+	assert.Equal(t, jaeger.LogRetentionPolicy{MaxLogs: 10, Retention: jaeger.LogRetentionHeadTail}, opts.logRetentionPolicy)
 }
 
 func TestTraceTagOption(t *testing.T) {
@@ -69,6 +174,11 @@ func TestApplyOptionsDefaults(t *testing.T) {
 	assert.Equal(t, metrics.NullFactory, opts.metrics)
 }
 
+type fakeIDGenerator struct{}
+
+func (fakeIDGenerator) NextTraceID() jaeger.TraceID { return jaeger.TraceID{Low: 1} }
+func (fakeIDGenerator) NextSpanID() jaeger.SpanID   { return 2 }
+
 type fakeSampler struct {
 	lastTraceID   jaeger.TraceID
 	lastOperation string
@@ -0,0 +1,107 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+
+	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/zipkin"
+)
+
+const (
+	// PropagationJaeger is the name of the Jaeger propagation format, using the uber-trace-id header.
+	PropagationJaeger = "jaeger"
+
+	// PropagationB3 is the name of the Zipkin B3 propagation format, using the x-b3-* headers.
+	PropagationB3 = "b3"
+)
+
+// propagator is implemented by the jaeger and zipkin propagators.
+type propagator interface {
+	jaeger.Injector
+	jaeger.Extractor
+}
+
+// newPropagators returns the propagators for the HTTPHeaders and TextMap formats
+// that implement the given propagation formats.
+func newPropagators(
+	formats []string,
+	headers *jaeger.HeadersConfig,
+	metrics *jaeger.Metrics,
+) (httpHeaders, textMap propagator, err error) {
+	if headers == nil {
+		headers = &jaeger.HeadersConfig{}
+	}
+	headers = headers.ApplyDefaults()
+	var httpHeadersPropagators, textMapPropagators compositePropagator
+	for _, format := range formats {
+		switch format {
+		case PropagationJaeger:
+			httpHeadersPropagators = append(httpHeadersPropagators, jaeger.NewHTTPHeaderPropagator(headers, *metrics))
+			textMapPropagators = append(textMapPropagators, jaeger.NewTextMapPropagator(headers, *metrics))
+		case PropagationB3:
+			b3 := zipkin.NewZipkinB3HTTPHeaderPropagator()
+			httpHeadersPropagators = append(httpHeadersPropagators, b3)
+			textMapPropagators = append(textMapPropagators, b3)
+		default:
+			return nil, nil, fmt.Errorf("unknown propagation format %q", format)
+		}
+	}
+	if len(httpHeadersPropagators) == 1 {
+		return httpHeadersPropagators[0], textMapPropagators[0], nil
+	}
+	return httpHeadersPropagators, textMapPropagators, nil
+}
+
+// compositePropagator injects the span context in all of its formats, and extracts it
+// from the first format found in the carrier.
+type compositePropagator []propagator
+
+// PropagationFormat implements jaeger.NamedPropagationFormat.
+func (p compositePropagator) PropagationFormat() string {
+	names := make([]string, len(p))
+	for i, propagator := range p {
+		names[i] = "other"
+		if named, ok := propagator.(jaeger.NamedPropagationFormat); ok {
+			names[i] = named.PropagationFormat()
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Inject implements jaeger.Injector.
+func (p compositePropagator) Inject(sc jaeger.SpanContext, carrier interface{}) error {
+	for _, propagator := range p {
+		if err := propagator.Inject(sc, carrier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Extract implements jaeger.Extractor.
+func (p compositePropagator) Extract(carrier interface{}) (jaeger.SpanContext, error) {
+	for _, propagator := range p {
+		sc, err := propagator.Extract(carrier)
+		if err != opentracing.ErrSpanContextNotFound {
+			return sc, err
+		}
+	}
+	return jaeger.SpanContext{}, opentracing.ErrSpanContextNotFound
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/uber/jaeger-client-go"
+)
+
+// ServiceConfig configures one of the services of Configuration.Services.
+type ServiceConfig struct {
+	// ServiceName is the name the service reports its spans under. It must be unique.
+	ServiceName string `yaml:"serviceName"`
+
+	// Sampler configures the sampler of the service, it defaults to Configuration.Sampler.
+	Sampler *SamplerConfig `yaml:"sampler"`
+
+	// Tags are added to the tags of all the spans of the service, overriding Configuration.Tags
+	// with the same keys.
+	Tags []opentracing.Tag `yaml:"tags"`
+}
+
+// NewServiceTracers creates a tracer for each of c.Services, with its own service name, sampler and tags.
+// All the other settings are shared, and the tracers report their spans with a single reporter and
+// transport. It returns the tracers by service name, and a closer that closes all of them and the reporter.
+// Configuration.Sampler and Tags are the defaults of the services, Configuration.ServiceName is ignored.
+func (c Configuration) NewServiceTracers(options ...Option) (map[string]opentracing.Tracer, io.Closer, error) {
+	if len(c.Services) == 0 {
+		return nil, nil, errors.New("no services provided")
+	}
+	names := make(map[string]struct{}, len(c.Services))
+	for _, service := range c.Services {
+		if service.ServiceName == "" {
+			return nil, nil, errors.New("no service name provided")
+		}
+		if _, ok := names[service.ServiceName]; ok {
+			return nil, nil, fmt.Errorf("duplicate service name %q", service.ServiceName)
+		}
+		names[service.ServiceName] = struct{}{}
+	}
+
+	tracers := make(map[string]opentracing.Tracer, len(c.Services))
+	if c.Disabled {
+		for _, service := range c.Services {
+			tracers[service.ServiceName] = &opentracing.NoopTracer{}
+		}
+		return tracers, &nullCloser{}, nil
+	}
+
+	// The first tracer creates the reporter, unless it is given as an option, and closes it.
+	// The other tracers share it, and are closed first.
+	var closers serviceClosers
+	for _, service := range c.Services {
+		components, closer, err := c.forService(service).newTracer(options...)
+		if err != nil {
+			closers.Close()
+			return nil, nil, err
+		}
+		tracers[service.ServiceName] = components.Tracer
+		closers = append(closers, closer)
+		if len(closers) == 1 {
+			options = append(options[:len(options):len(options)], Reporter(sharedReporter{components.Reporter}))
+		}
+	}
+	return tracers, closers, nil
+}
+
+// forService returns the configuration of the given service.
+func (c Configuration) forService(service ServiceConfig) Configuration {
+	c.ServiceName = service.ServiceName
+	if service.Sampler != nil {
+		c.Sampler = service.Sampler
+	}
+	if len(service.Tags) > 0 {
+		tags := make([]opentracing.Tag, 0, len(c.Tags)+len(service.Tags))
+		for _, tag := range c.Tags {
+			if !hasTag(service.Tags, tag.Key) {
+				tags = append(tags, tag)
+			}
+		}
+		c.Tags = append(tags, service.Tags...)
+	}
+	c.Services = nil
+	return c
+}
+
+func hasTag(tags []opentracing.Tag, key string) bool {
+	for _, tag := range tags {
+		if tag.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sharedReporter is a reporter shared by several tracers, which is not closed by them.
+type sharedReporter struct {
+	jaeger.Reporter
+}
+
+// Close implements Reporter. The reporter is closed by the tracer that created it.
+func (r sharedReporter) Close() {}
+
+// CheckHealth implements jaeger.HealthChecker by delegating to the shared reporter.
+func (r sharedReporter) CheckHealth() error {
+	if checker, ok := r.Reporter.(jaeger.HealthChecker); ok {
+		return checker.CheckHealth()
+	}
+	return nil
+}
+
+// Stats returns the runtime statistics of the shared reporter, see jaeger.Tracer.ReporterStats.
+func (r sharedReporter) Stats() jaeger.ReporterStats {
+	if reporter, ok := r.Reporter.(interface{ Stats() jaeger.ReporterStats }); ok {
+		return reporter.Stats()
+	}
+	return jaeger.ReporterStats{}
+}
+
+// serviceClosers closes the tracers of NewServiceTracers in the reverse order of their creation,
+// so that the tracer closing the shared reporter is closed last.
+type serviceClosers []io.Closer
+
+// Close implements io.Closer.
+func (closers serviceClosers) Close() error {
+	var firstErr error
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
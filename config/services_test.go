@@ -0,0 +1,156 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestNewServiceTracers(t *testing.T) {
+	reporter := &closeCountingReporter{InMemoryReporter: jaeger.NewInMemoryReporter()}
+	cfg := Configuration{
+		ServiceName: "ignored",
+		Sampler:     &SamplerConfig{Type: "const", Param: 1},
+		Tags:        []opentracing.Tag{{Key: "zone", Value: "cn-hangzhou"}, {Key: "version", Value: "1"}},
+		Services: []ServiceConfig{
+			{ServiceName: "orders"},
+			{
+				ServiceName: "payments",
+				Sampler:     &SamplerConfig{Type: "const", Param: 0},
+				Tags:        []opentracing.Tag{{Key: "version", Value: "2"}},
+			},
+		},
+	}
+	tracers, closer, err := cfg.NewServiceTracers(Reporter(reporter))
+	require.NoError(t, err)
+	require.Len(t, tracers, 2)
+
+	orders := tracers["orders"].(*jaeger.Tracer)
+	payments := tracers["payments"].(*jaeger.Tracer)
+	assert.Contains(t, orders.Tags(), opentracing.Tag{Key: "version", Value: "1"})
+	assert.Contains(t, payments.Tags(), opentracing.Tag{Key: "version", Value: "2"})
+	assert.NotContains(t, payments.Tags(), opentracing.Tag{Key: "version", Value: "1"})
+	assert.Contains(t, payments.Tags(), opentracing.Tag{Key: "zone", Value: "cn-hangzhou"})
+
+	orders.StartSpan("create").Finish()
+	payments.StartSpan("charge").Finish()
+	payments.StartSpan("refund", opentracing.Tag{Key: string(ext.SamplingPriority), Value: uint16(1)}).Finish()
+
+	spans := reporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "orders", spans[0].(*jaeger.Span).ServiceName())
+	assert.Equal(t, "payments", spans[1].(*jaeger.Span).ServiceName())
+	assert.Equal(t, "refund", spans[1].(*jaeger.Span).OperationName())
+
+	require.NoError(t, closer.Close())
+	assert.Equal(t, 1, reporter.closed)
+}
+
+type closeCountingReporter struct {
+	*jaeger.InMemoryReporter
+	closed int
+}
+
+func (r *closeCountingReporter) Close() {
+	r.closed++
+}
+
+func TestNewServiceTracersSharedReporter(t *testing.T) {
+	cfg := Configuration{
+		Reporter: &ReporterConfig{LocalAgentHostPort: "localhost:6831"},
+		Services: []ServiceConfig{{ServiceName: "a"}, {ServiceName: "b"}},
+	}
+	tracers, closer, err := cfg.NewServiceTracers()
+	require.NoError(t, err)
+	defer closer.Close()
+
+	a := tracers["a"].(*jaeger.Tracer)
+	b := tracers["b"].(*jaeger.Tracer)
+	assert.NotNil(t, a)
+	assert.NotNil(t, b)
+}
+
+func TestNewServiceTracersSharedReporterStats(t *testing.T) {
+	reporter := &statsReporter{InMemoryReporter: jaeger.NewInMemoryReporter(), err: errors.New("unreachable")}
+	cfg := Configuration{
+		Sampler:  &SamplerConfig{Type: "const", Param: 1},
+		Services: []ServiceConfig{{ServiceName: "a"}, {ServiceName: "b"}},
+	}
+	tracers, closer, err := cfg.NewServiceTracers(Reporter(reporter))
+	require.NoError(t, err)
+	defer closer.Close()
+
+	for _, name := range []string{"a", "b"} {
+		tracer := tracers[name].(*jaeger.Tracer)
+		assert.Equal(t, int64(7), tracer.ReporterStats().SpansSubmitted, name)
+		assert.EqualError(t, tracer.LastExportError(), "unreachable", name)
+		assert.EqualError(t, tracer.Ready(), "unreachable", name)
+	}
+}
+
+type statsReporter struct {
+	*jaeger.InMemoryReporter
+	err error
+}
+
+func (r *statsReporter) Stats() jaeger.ReporterStats {
+	return jaeger.ReporterStats{SpansSubmitted: 7, LastError: r.err}
+}
+
+func (r *statsReporter) CheckHealth() error {
+	return r.err
+}
+
+func TestNewServiceTracersErrors(t *testing.T) {
+	tests := []struct {
+		config Configuration
+		err    string
+	}{
+		{config: Configuration{}, err: "no services provided"},
+		{config: Configuration{Services: []ServiceConfig{{}}}, err: "no service name provided"},
+		{
+			config: Configuration{Services: []ServiceConfig{{ServiceName: "a"}, {ServiceName: "a"}}},
+			err:    `duplicate service name "a"`,
+		},
+		{
+			config: Configuration{Services: []ServiceConfig{
+				{ServiceName: "a"},
+				{ServiceName: "b", Sampler: &SamplerConfig{Type: "unknown"}},
+			}},
+			err: "Unknown sampler type unknown",
+		},
+	}
+	for _, test := range tests {
+		_, _, err := test.config.NewServiceTracers()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.err)
+	}
+}
+
+func TestNewServiceTracersDisabled(t *testing.T) {
+	cfg := Configuration{Disabled: true, Services: []ServiceConfig{{ServiceName: "a"}, {ServiceName: "b"}}}
+	tracers, closer, err := cfg.NewServiceTracers()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]opentracing.Tracer{"a": &opentracing.NoopTracer{}, "b": &opentracing.NoopTracer{}}, tracers)
+	assert.NoError(t, closer.Close())
+}
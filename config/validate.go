@@ -0,0 +1,229 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/uber/jaeger-client-go"
+)
+
+// ValidationError is returned by Configuration.Validate with all the problems found in the configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	return "invalid Jaeger configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// validator accumulates the problems found in the configuration.
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) nonNegative(name string, value float64) {
+	if value < 0 {
+		v.addf("%s must not be negative, got %v", name, value)
+	}
+}
+
+func (v *validator) nonNegativeDuration(name string, value time.Duration) {
+	if value < 0 {
+		v.addf("%s must not be negative, got %v", name, value)
+	}
+}
+
+// httpURL checks that the value is an absolute http or https URL, if it is set.
+func (v *validator) httpURL(name string, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		v.addf("%s is not a valid URL: %v", name, err)
+	} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf("%s must be an http or https URL with a host, got %q", name, value)
+	}
+}
+
+// hostPort checks that the value is a host:port address, if it is set.
+func (v *validator) hostPort(name string, value string) {
+	if value == "" {
+		return
+	}
+	if _, port, err := net.SplitHostPort(value); err != nil {
+		v.addf("%s is not a valid host:port address: %v", name, err)
+	} else if port == "" {
+		v.addf("%s has no port: %q", name, value)
+	}
+}
+
+// envPrecedence checks that the value is a known EnvPrecedence, if it is set.
+func (v *validator) envPrecedence(name string, value EnvPrecedence) {
+	if value != "" && value != EnvOverridesCode && value != CodeOverridesEnv {
+		v.addf("unknown %s %q, expecting env or code", name, value)
+	}
+}
+
+// Validate checks the whole configuration and returns a *ValidationError listing all the problems
+// found, e.g. an unknown sampler type, a negative queue size, a malformed endpoint URL or conflicting
+// agent and collector settings, or nil if the configuration is valid. It allows the problems to be
+// reported at startup rather than when the tracer is created or the settings are first used.
+func (c Configuration) Validate() error {
+	if c.Disabled {
+		return nil
+	}
+	v := &validator{}
+	if c.ServiceName == "" && len(c.Services) == 0 {
+		v.addf("no service name provided")
+	}
+	names := make(map[string]struct{}, len(c.Services))
+	for i, service := range c.Services {
+		if service.ServiceName == "" {
+			v.addf("no service name provided for services[%d]", i)
+		} else if _, ok := names[service.ServiceName]; ok {
+			v.addf("duplicate service name %q", service.ServiceName)
+		}
+		names[service.ServiceName] = struct{}{}
+		if service.Sampler != nil {
+			service.Sampler.validate(v)
+		}
+	}
+	for _, format := range c.Propagation {
+		if format != PropagationJaeger && format != PropagationB3 {
+			v.addf("unknown propagation format %q, expecting jaeger or b3", format)
+		}
+	}
+	v.envPrecedence("envPrecedence", c.EnvPrecedence)
+	for name, precedence := range c.EnvPrecedenceByVar {
+		if _, ok := envVars[name]; !ok {
+			v.addf("unknown environment variable %q in envPrecedenceByVar", name)
+		}
+		v.envPrecedence(fmt.Sprintf("envPrecedenceByVar[%s]", name), precedence)
+	}
+	v.nonNegative("maxTagValueLength", float64(c.MaxTagValueLength))
+	v.nonNegative("maxLogsPerSecond", float64(c.MaxLogsPerSecond))
+	if c.Sampler != nil {
+		c.Sampler.validate(v)
+	}
+	if c.Reporter != nil {
+		c.Reporter.validate(v)
+	}
+	if c.BaggageRestrictions != nil {
+		c.BaggageRestrictions.validate(v)
+	}
+	if c.Limits != nil {
+		c.Limits.validate(v)
+	}
+	if c.Throttler != nil {
+		v.hostPort("throttler hostPort", c.Throttler.HostPort)
+		v.nonNegativeDuration("throttler refreshInterval", c.Throttler.RefreshInterval)
+	}
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func (sc *SamplerConfig) validate(v *validator) {
+	switch strings.ToLower(sc.Type) {
+	case jaeger.SamplerTypeConst:
+	case jaeger.SamplerTypeRateLimiting:
+		v.nonNegative("sampler param of the rateLimiting sampler", sc.Param)
+	case jaeger.SamplerTypeProbabilistic, jaeger.SamplerTypeRemote, "":
+		if sc.Param < 0 || sc.Param > 1 {
+			v.addf("sampler param must be a probability between 0 and 1, got %v", sc.Param)
+		}
+	default:
+		v.addf("unknown sampler type %q, expecting one of const, probabilistic, rateLimiting or remote", sc.Type)
+	}
+	v.httpURL("sampler samplingServerURL", sc.SamplingServerURL)
+	v.nonNegative("sampler maxOperations", float64(sc.MaxOperations))
+	v.nonNegativeDuration("sampler samplingRefreshInterval", sc.SamplingRefreshInterval)
+}
+
+func (rc *ReporterConfig) validate(v *validator) {
+	v.nonNegative("reporter queueSize", float64(rc.QueueSize))
+	v.nonNegativeDuration("reporter bufferFlushInterval", rc.BufferFlushInterval)
+	v.nonNegative("reporter bufferFlushMaxSpans", float64(rc.BufferFlushMaxSpans))
+	v.nonNegative("reporter bufferFlushMaxBytes", float64(rc.BufferFlushMaxBytes))
+	v.nonNegativeDuration("reporter bufferFlushIdleTimeout", rc.BufferFlushIdleTimeout)
+	v.nonNegativeDuration("reporter errorLogInterval", rc.ErrorLogInterval)
+	v.nonNegative("reporter maxSpansPerSecond", rc.MaxSpansPerSecond)
+	v.nonNegative("reporter maxBytesPerSecond", rc.MaxBytesPerSecond)
+	v.nonNegative("reporter maxBurstBytes", rc.MaxBurstBytes)
+	v.nonNegative("reporter maxPacketSize", float64(rc.MaxPacketSize))
+	v.nonNegative("reporter writeBufferSize", float64(rc.WriteBufferSize))
+	v.nonNegativeDuration("reporter attemptReconnectInterval", rc.AttemptReconnectInterval)
+
+	v.httpURL("reporter collectorEndpoint", rc.CollectorEndpoint)
+	if rc.CollectorEndpoint != "" && rc.LocalAgentHostPort != "" {
+		v.addf("both reporter collectorEndpoint and localAgentHostPort are set, only one of them can be used")
+	}
+	if (rc.User == "") != (rc.Password == "") {
+		v.addf("reporter user and password must be set together")
+	} else if rc.User != "" && rc.CollectorEndpoint == "" {
+		v.addf("reporter user and password require a collectorEndpoint")
+	}
+	if rc.TLS != nil && rc.CollectorEndpoint == "" {
+		v.addf("reporter tls requires a collectorEndpoint")
+	}
+	if rc.TLS != nil && (rc.TLS.CertPath == "") != (rc.TLS.KeyPath == "") {
+		v.addf("reporter tls cert and key must be set together")
+	}
+	if !strings.Contains(rc.LocalAgentHostPort, "://") {
+		v.hostPort("reporter localAgentHostPort", rc.LocalAgentHostPort)
+	}
+	for _, step := range rc.TruncationSteps {
+		switch jaeger.SpanTruncationStep(step) {
+		case jaeger.SpanTruncationLogs, jaeger.SpanTruncationTagValues, jaeger.SpanTruncationTags:
+		default:
+			v.addf("unknown reporter truncation step %q, expecting logs, tag_values or tags", step)
+		}
+	}
+}
+
+func (lc *LimitsConfig) validate(v *validator) {
+	v.nonNegative("limits maxTagsPerSpan", float64(lc.MaxTagsPerSpan))
+	v.nonNegative("limits maxLogsPerSpan", float64(lc.MaxLogsPerSpan))
+	v.nonNegative("limits maxBaggageBytes", float64(lc.MaxBaggageBytes))
+	for key, maxLength := range lc.MaxTagValueLengthByKey {
+		v.nonNegative(fmt.Sprintf("limits maxTagValueLengthByKey[%s]", key), float64(maxLength))
+	}
+	switch jaeger.LogRetention(lc.LogRetention) {
+	case "", jaeger.LogRetentionKeepFirst, jaeger.LogRetentionKeepLast, jaeger.LogRetentionHeadTail:
+	default:
+		v.addf("unknown limits logRetention %q, expecting keep_first, keep_last or head_tail", lc.LogRetention)
+	}
+}
+
+func (bc *BaggageRestrictionsConfig) validate(v *validator) {
+	v.hostPort("baggage restrictions hostPort", bc.HostPort)
+	v.httpURL("baggage restrictions serverURL", bc.ServerURL)
+	if bc.File != "" && bc.ServerURL != "" {
+		v.addf("both baggage restrictions file and serverURL are set, only one of them can be used")
+	}
+	v.nonNegativeDuration("baggage restrictions refreshInterval", bc.RefreshInterval)
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Configuration
+		problems []string
+	}{
+		{
+			name:   "disabled",
+			config: Configuration{Disabled: true},
+		},
+		{
+			name:     "empty",
+			config:   Configuration{},
+			problems: []string{"no service name provided"},
+		},
+		{
+			name: "valid",
+			config: Configuration{
+				ServiceName: "svc",
+				Sampler:     &SamplerConfig{Type: "remote", Param: 0.5, SamplingServerURL: "http://localhost:5778/sampling"},
+				Reporter: &ReporterConfig{
+					QueueSize:          100,
+					LocalAgentHostPort: "[::1]:6831",
+					TruncationSteps:    []string{"logs", "tags"},
+				},
+				BaggageRestrictions: &BaggageRestrictionsConfig{HostPort: "localhost:5778"},
+				Throttler:           &ThrottlerConfig{HostPort: "localhost:5778"},
+			},
+		},
+		{
+			name: "unix socket agent and rate limiting sampler",
+			config: Configuration{
+				ServiceName: "svc",
+				Sampler:     &SamplerConfig{Type: "rateLimiting", Param: 100},
+				Reporter:    &ReporterConfig{LocalAgentHostPort: "unixgram:///var/run/jaeger-agent.sock"},
+			},
+		},
+		{
+			name: "tracer",
+			config: Configuration{
+				ServiceName:       "svc",
+				Propagation:       []string{"jaeger", "w3c"},
+				MaxTagValueLength: -1,
+				MaxLogsPerSecond:  -1,
+				EnvPrecedence:     "platform",
+				EnvPrecedenceByVar: map[string]EnvPrecedence{
+					"JAEGER_SERVICE_NAME": CodeOverridesEnv,
+					"JAEGER_SERVICE":      "code",
+				},
+			},
+			problems: []string{
+				`unknown propagation format "w3c", expecting jaeger or b3`,
+				`unknown envPrecedence "platform", expecting env or code`,
+				`unknown environment variable "JAEGER_SERVICE" in envPrecedenceByVar`,
+				"maxTagValueLength must not be negative, got -1",
+				"maxLogsPerSecond must not be negative, got -1",
+			},
+		},
+		{
+			name: "services",
+			config: Configuration{
+				Services: []ServiceConfig{
+					{ServiceName: "svc"},
+					{ServiceName: "svc", Sampler: &SamplerConfig{Type: "probabilistic", Param: 2}},
+					{},
+				},
+			},
+			problems: []string{
+				`duplicate service name "svc"`,
+				"sampler param must be a probability between 0 and 1, got 2",
+				"no service name provided for services[2]",
+			},
+		},
+		{
+			name: "limits",
+			config: Configuration{
+				ServiceName: "svc",
+				Limits: &LimitsConfig{
+					MaxTagsPerSpan:         -1,
+					MaxLogsPerSpan:         -1,
+					LogRetention:           "keep_middle",
+					MaxBaggageBytes:        -1,
+					MaxTagValueLengthByKey: map[string]int{"db.statement": -1},
+				},
+			},
+			problems: []string{
+				"limits maxTagsPerSpan must not be negative, got -1",
+				"limits maxLogsPerSpan must not be negative, got -1",
+				"limits maxBaggageBytes must not be negative, got -1",
+				"limits maxTagValueLengthByKey[db.statement] must not be negative, got -1",
+				`unknown limits logRetention "keep_middle", expecting keep_first, keep_last or head_tail`,
+			},
+		},
+		{
+			name: "reporter tls",
+			config: Configuration{
+				ServiceName: "svc",
+				Reporter:    &ReporterConfig{TLS: &TLSConfig{CertPath: "cert.pem"}},
+			},
+			problems: []string{
+				"reporter tls requires a collectorEndpoint",
+				"reporter tls cert and key must be set together",
+			},
+		},
+		{
+			name: "sampler",
+			config: Configuration{
+				ServiceName: "svc",
+				Sampler: &SamplerConfig{
+					Type:                    "adaptive",
+					SamplingServerURL:       "localhost:5778",
+					MaxOperations:           -1,
+					SamplingRefreshInterval: -time.Second,
+				},
+			},
+			problems: []string{
+				`unknown sampler type "adaptive", expecting one of const, probabilistic, rateLimiting or remote`,
+				`sampler samplingServerURL must be an http or https URL with a host, got "localhost:5778"`,
+				"sampler maxOperations must not be negative, got -1",
+				"sampler samplingRefreshInterval must not be negative, got -1s",
+			},
+		},
+		{
+			name: "sampler param",
+			config: Configuration{
+				ServiceName: "svc",
+				Sampler:     &SamplerConfig{Type: "probabilistic", Param: 1.5},
+			},
+			problems: []string{"sampler param must be a probability between 0 and 1, got 1.5"},
+		},
+		{
+			name: "reporter",
+			config: Configuration{
+				ServiceName: "svc",
+				Reporter: &ReporterConfig{
+					QueueSize:          -1,
+					MaxSpansPerSecond:  -10,
+					MaxBurstBytes:      -1,
+					CollectorEndpoint:  "collector:14268/api/traces",
+					LocalAgentHostPort: "localhost",
+					User:               "user",
+					TruncationSteps:    []string{"logs", "baggage"},
+				},
+			},
+			problems: []string{
+				"reporter queueSize must not be negative, got -1",
+				"reporter maxSpansPerSecond must not be negative, got -10",
+				"reporter maxBurstBytes must not be negative, got -1",
+				`reporter collectorEndpoint must be an http or https URL with a host, got "collector:14268/api/traces"`,
+				"both reporter collectorEndpoint and localAgentHostPort are set, only one of them can be used",
+				"reporter user and password must be set together",
+				"reporter localAgentHostPort is not a valid host:port address: address localhost: missing port in address",
+				`unknown reporter truncation step "baggage", expecting logs, tag_values or tags`,
+			},
+		},
+		{
+			name: "credentials without collector",
+			config: Configuration{
+				ServiceName: "svc",
+				Reporter:    &ReporterConfig{User: "user", Password: "secret"},
+			},
+			problems: []string{"reporter user and password require a collectorEndpoint"},
+		},
+		{
+			name: "baggage restrictions and throttler",
+			config: Configuration{
+				ServiceName: "svc",
+				BaggageRestrictions: &BaggageRestrictionsConfig{
+					File:            "/etc/jaeger/baggage.json",
+					ServerURL:       "http://collector:14268/baggageRestrictions",
+					RefreshInterval: -time.Minute,
+				},
+				Throttler: &ThrottlerConfig{HostPort: "localhost:"},
+			},
+			problems: []string{
+				"both baggage restrictions file and serverURL are set, only one of them can be used",
+				"baggage restrictions refreshInterval must not be negative, got -1m0s",
+				`throttler hostPort has no port: "localhost:"`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.Validate()
+			if test.problems == nil {
+				assert.NoError(t, err)
+				return
+			}
+			require.IsType(t, &ValidationError{}, err)
+			assert.Equal(t, test.problems, err.(*ValidationError).Problems)
+		})
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := &ValidationError{Problems: []string{"no service name provided", "reporter queueSize must not be negative, got -1"}}
+	assert.EqualError(t, err,
+		"invalid Jaeger configuration: no service name provided; reporter queueSize must not be negative, got -1")
+}
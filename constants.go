@@ -47,6 +47,58 @@ const (
 	// TracerUUIDTagKey used to report UUID of the client process.
 	TracerUUIDTagKey = "client-uuid"
 
+	// TruncatedLogsTagKey reports the number of logs removed from a span that was too large to be sent.
+	TruncatedLogsTagKey = "jaeger.truncated_logs"
+
+	// TruncatedTagValuesTagKey reports the number of tag values shortened in a span that was too large to be sent.
+	TruncatedTagValuesTagKey = "jaeger.truncated_tag_values"
+
+	// TruncatedTagsTagKey reports the number of tags removed from a span that was too large to be sent.
+	TruncatedTagsTagKey = "jaeger.truncated_tags"
+
+	// SpanPhaseTagKey marks the records of spans reported when they start, see TracerOptions.ReportSpanStart.
+	SpanPhaseTagKey = "jaeger.span_phase"
+
+	// SpanPhaseStarted is the value of SpanPhaseTagKey tag for records of spans reported when they start.
+	SpanPhaseStarted = "started"
+
+	// EventNameFieldKey is the log field holding the name of a span event, see SpanEvent.
+	EventNameFieldKey = "event"
+
+	// StackTraceFieldKey is the log field holding the stack trace captured when an error is recorded,
+	// see TracerOptions.StackTraceOnError.
+	StackTraceFieldKey = "stack"
+
+	// DroppedTagsTagKey reports the number of tags dropped because the span reached MaxTagsPerSpan.
+	DroppedTagsTagKey = "jaeger.dropped_tags"
+
+	// DroppedLogsTagKey reports the number of logs dropped because of the LogRetentionPolicy of the tracer.
+	DroppedLogsTagKey = "jaeger.dropped_logs"
+
+	// SuppressedLogsTagKey reports the number of logs suppressed because the span exceeded the
+	// MaxLogsPerSecond limit of the tracer.
+	SuppressedLogsTagKey = "jaeger.suppressed_logs"
+
+	// DroppedEventsTagKey reports the number of events dropped because the span reached MaxEventsPerSpan.
+	DroppedEventsTagKey = "jaeger.dropped_events"
+
+	// LinkTagKeyPrefix is the prefix of the tags describing span links, see Link.
+	LinkTagKeyPrefix = "link."
+
+	// PartialSpanTagKey marks snapshots of long-running spans, see TracerOptions.PartialSpanReporting.
+	PartialSpanTagKey = "jaeger.partial"
+
+	// ServiceNameTagKey, when passed as a tag to StartSpan, overrides the service name of the span.
+	// It is not recorded as a span tag, see ServiceName.
+	ServiceNameTagKey = "jaeger.service.name"
+
+	// WarningsTagKey reports the warnings about the data of the span dropped or truncated by the client,
+	// one tag per warning, see Span.Warnings.
+	WarningsTagKey = "jaeger.internal.warnings"
+
+	// FirehoseTagKey, when set to true on a span, enables the firehose flag, see Firehose.
+	FirehoseTagKey = "jaeger.firehose"
+
 	// SamplerTypeTagKey reports which sampler was used on the root span.
 	SamplerTypeTagKey = "sampler.type"
 
@@ -95,9 +147,15 @@ const (
 	// DefaultMaxTagValueLength is the default max length of byte array or string allowed in the tag value.
 	DefaultMaxTagValueLength = 256
 
+	// DefaultMaxEventsPerSpan is the default max number of events recorded on a span, see Span.AddEvent.
+	DefaultMaxEventsPerSpan = 128
+
 	// SelfRefType is a jaeger specific reference type that supports creating a span
 	// with an already defined context.
 	selfRefType opentracing.SpanReferenceType = 99
+
+	// linkRefType is a jaeger specific reference type used by LinkTo to pass span links to StartSpan.
+	linkRefType opentracing.SpanReferenceType = 98
 )
 
 var (
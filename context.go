@@ -27,6 +27,12 @@ const (
 	flagFirehose = byte(8)
 )
 
+const (
+	// the parameters of the 64-bit FNV-1a hash function
+	fnvOffset64 = uint64(14695981039346656037)
+	fnvPrime64  = uint64(1099511628211)
+)
+
 var (
 	errEmptyTracerStateString     = errors.New("Cannot convert empty string to tracer state")
 	errMalformedTracerStateString = errors.New("String does not match tracer state format")
@@ -67,6 +73,13 @@ type SpanContext struct {
 	//
 	// See JaegerDebugHeader in constants.go
 	debugID string
+
+	// remote is true if the context was extracted from a carrier, i.e. propagated from another process.
+	remote bool
+
+	// baggageMetadata holds the optional metadata (W3C properties) of the baggage items, by key.
+	// Like baggage, it is a snapshot in time, never modified after the context is created.
+	baggageMetadata map[string]string
 }
 
 // ForeachBaggageItem implements ForeachBaggageItem() of opentracing.SpanContext
@@ -128,7 +141,7 @@ func ContextFromString(value string) (SpanContext, error) {
 	}
 	flags, err := strconv.ParseUint(parts[3], 10, 8)
 	if err != nil {
-		return emptyContext, err
+		return emptyContext, &flagsParseError{err: err}
 	}
 	context.flags = byte(flags)
 	return context, nil
@@ -149,7 +162,9 @@ func (c SpanContext) ParentID() SpanID {
 	return c.parentID
 }
 
-// NewSpanContext creates a new instance of SpanContext
+// NewSpanContext creates a new instance of SpanContext.
+// The debug and firehose flags and the debug ID can be set with the With* methods of the returned context,
+// e.g. NewSpanContext(traceID, spanID, 0, true, nil).WithDebug(true).
 func NewSpanContext(traceID TraceID, spanID, parentID SpanID, sampled bool, baggage map[string]string) SpanContext {
 	flags := byte(0)
 	if sampled {
@@ -170,6 +185,7 @@ func (c *SpanContext) CopyFrom(ctx *SpanContext) {
 	c.spanID = ctx.spanID
 	c.parentID = ctx.parentID
 	c.flags = ctx.flags
+	c.baggageMetadata = ctx.baggageMetadata
 	if l := len(ctx.baggage); l > 0 {
 		c.baggage = make(map[string]string, l)
 		for k, v := range ctx.baggage {
@@ -180,7 +196,8 @@ func (c *SpanContext) CopyFrom(ctx *SpanContext) {
 	}
 }
 
-// WithBaggageItem creates a new context with an extra baggage item.
+// WithBaggageItem creates a new context with an extra baggage item, without metadata.
+// See WithBaggageItemMetadata.
 func (c SpanContext) WithBaggageItem(key, value string) SpanContext {
 	var newBaggage map[string]string
 	if c.baggage == nil {
@@ -193,7 +210,76 @@ func (c SpanContext) WithBaggageItem(key, value string) SpanContext {
 		newBaggage[key] = value
 	}
 	// Use positional parameters so the compiler will help catch new fields.
-	return SpanContext{c.traceID, c.spanID, c.parentID, c.flags, newBaggage, ""}
+	return SpanContext{c.traceID, c.spanID, c.parentID, c.flags, newBaggage, "", c.remote,
+		withBaggageMetadata(c.baggageMetadata, key, "")}
+}
+
+// WithSampled creates a new context with the sampled flag set or cleared.
+// Clearing the sampled flag also clears the debug and firehose flags, which imply sampling.
+func (c SpanContext) WithSampled(sampled bool) SpanContext {
+	if sampled {
+		c.flags |= flagSampled
+	} else {
+		c.flags &^= flagSampled | flagDebug | flagFirehose
+	}
+	return c
+}
+
+// WithDebug creates a new context with the debug flag set or cleared. Setting the debug flag
+// also sets the sampled flag.
+func (c SpanContext) WithDebug(debug bool) SpanContext {
+	if debug {
+		c.flags |= flagDebug | flagSampled
+	} else {
+		c.flags &^= flagDebug
+	}
+	return c
+}
+
+// WithFirehose creates a new context with the firehose flag set or cleared. Setting the firehose
+// flag also sets the sampled flag.
+func (c SpanContext) WithFirehose(firehose bool) SpanContext {
+	if firehose {
+		c.flags |= flagFirehose | flagSampled
+	} else {
+		c.flags &^= flagFirehose
+	}
+	return c
+}
+
+// WithDebugID creates a new context with the given debug/correlation ID, like the one extracted
+// from the JaegerDebugHeader. If the context has no valid trace ID, a span started as its child
+// starts a new debug trace and reports the ID as the JaegerDebugHeader tag.
+func (c SpanContext) WithDebugID(debugID string) SpanContext {
+	c.debugID = debugID
+	return c
+}
+
+// SpanContextKey identifies a span by its trace and span IDs. Unlike SpanContext, it is comparable,
+// so it can be used as a map key, e.g. to deduplicate contexts.
+type SpanContextKey struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+// Key returns the key identifying the span of the context.
+func (c SpanContext) Key() SpanContextKey {
+	return SpanContextKey{TraceID: c.traceID, SpanID: c.spanID}
+}
+
+// Equal returns true if both contexts have the same trace, span and parent IDs and the same flags.
+// The baggage and the debug ID of the contexts are not compared.
+func (c SpanContext) Equal(other SpanContext) bool {
+	return c.traceID == other.traceID &&
+		c.spanID == other.spanID &&
+		c.parentID == other.parentID &&
+		c.flags == other.flags
+}
+
+// Hash64 returns a hash of the trace and span IDs of the context, which is stable across processes
+// and versions of the client. Equal contexts have the same hash.
+func (c SpanContext) Hash64() uint64 {
+	return hashUint64(hashUint64(hashUint64(fnvOffset64, c.traceID.High), c.traceID.Low), uint64(c.spanID))
 }
 
 // isDebugIDContainerOnly returns true when the instance of the context is only
@@ -218,47 +304,185 @@ func (t TraceID) String() string {
 	return fmt.Sprintf("%x%016x", t.High, t.Low)
 }
 
-// TraceIDFromString creates a TraceID from a hexadecimal string
+// PaddedString returns the trace ID as 16 lower-case hex characters, or 32 if the trace ID
+// has 128 bits, e.g. for formats like B3 that expect fixed-width IDs.
+func (t TraceID) PaddedString() string {
+	if t.High == 0 {
+		return fmt.Sprintf("%016x", t.Low)
+	}
+	return fmt.Sprintf("%016x%016x", t.High, t.Low)
+}
+
+// TraceIDFromString creates a TraceID from a hexadecimal string of up to 32 characters,
+// padded or not. It returns an *IDParseError if the string is empty, too long, or not hex.
+// A zero trace ID is accepted, see ParseTraceID.
 func TraceIDFromString(s string) (TraceID, error) {
 	var hi, lo uint64
 	var err error
 	if len(s) > 32 {
-		return TraceID{}, fmt.Errorf("TraceID cannot be longer than 32 hex characters: %s", s)
+		return TraceID{}, &IDParseError{Kind: IDTooLong, ID: "TraceID", Value: s}
 	} else if len(s) > 16 {
 		hiLen := len(s) - 16
-		if hi, err = strconv.ParseUint(s[0:hiLen], 16, 64); err != nil {
+		if hi, err = parseHexID("TraceID", s[0:hiLen], s); err != nil {
 			return TraceID{}, err
 		}
-		if lo, err = strconv.ParseUint(s[hiLen:], 16, 64); err != nil {
+		if lo, err = parseHexID("TraceID", s[hiLen:], s); err != nil {
 			return TraceID{}, err
 		}
 	} else {
-		if lo, err = strconv.ParseUint(s, 16, 64); err != nil {
+		if lo, err = parseHexID("TraceID", s, s); err != nil {
 			return TraceID{}, err
 		}
 	}
 	return TraceID{High: hi, Low: lo}, nil
 }
 
+// ParseTraceID is like TraceIDFromString, but also returns an *IDParseError if the trace ID is zero.
+func ParseTraceID(s string) (TraceID, error) {
+	traceID, err := TraceIDFromString(s)
+	if err != nil {
+		return TraceID{}, err
+	}
+	if !traceID.IsValid() {
+		return TraceID{}, &IDParseError{Kind: IDZero, ID: "TraceID", Value: s}
+	}
+	return traceID, nil
+}
+
 // IsValid checks if the trace ID is valid, i.e. not zero.
 func (t TraceID) IsValid() bool {
 	return t.High != 0 || t.Low != 0
 }
 
+// Equal returns true if both trace IDs are the same.
+func (t TraceID) Equal(other TraceID) bool {
+	return t == other
+}
+
+// Hash64 returns the 64-bit FNV-1a hash of the big-endian bytes of the trace ID, which is stable
+// across processes and versions of the client, e.g. to shard per-trace caches.
+func (t TraceID) Hash64() uint64 {
+	return hashUint64(hashUint64(fnvOffset64, t.High), t.Low)
+}
+
 // ------- SpanID -------
 
 func (s SpanID) String() string {
 	return fmt.Sprintf("%x", uint64(s))
 }
 
-// SpanIDFromString creates a SpanID from a hexadecimal string
+// PaddedString returns the span ID as 16 lower-case hex characters.
+func (s SpanID) PaddedString() string {
+	return fmt.Sprintf("%016x", uint64(s))
+}
+
+// Equal returns true if both span IDs are the same.
+func (s SpanID) Equal(other SpanID) bool {
+	return s == other
+}
+
+// Hash64 returns the 64-bit FNV-1a hash of the big-endian bytes of the span ID, which is stable
+// across processes and versions of the client.
+func (s SpanID) Hash64() uint64 {
+	return hashUint64(fnvOffset64, uint64(s))
+}
+
+// hashUint64 adds the big-endian bytes of the value to the FNV-1a hash.
+func hashUint64(hash uint64, value uint64) uint64 {
+	for shift := uint(56); ; shift -= 8 {
+		hash ^= (value >> shift) & 0xff
+		hash *= fnvPrime64
+		if shift == 0 {
+			return hash
+		}
+	}
+}
+
+// SpanIDFromString creates a SpanID from a hexadecimal string of up to 16 characters,
+// padded or not. It returns an *IDParseError if the string is empty, too long, or not hex.
+// A zero span ID is accepted, e.g. for the parent ID of root spans, see ParseSpanID.
 func SpanIDFromString(s string) (SpanID, error) {
 	if len(s) > 16 {
-		return SpanID(0), fmt.Errorf("SpanID cannot be longer than 16 hex characters: %s", s)
+		return SpanID(0), &IDParseError{Kind: IDTooLong, ID: "SpanID", Value: s}
 	}
-	id, err := strconv.ParseUint(s, 16, 64)
+	id, err := parseHexID("SpanID", s, s)
 	if err != nil {
 		return SpanID(0), err
 	}
 	return SpanID(id), nil
 }
+
+// ParseSpanID is like SpanIDFromString, but also returns an *IDParseError if the span ID is zero.
+func ParseSpanID(s string) (SpanID, error) {
+	spanID, err := SpanIDFromString(s)
+	if err != nil {
+		return SpanID(0), err
+	}
+	if spanID == 0 {
+		return SpanID(0), &IDParseError{Kind: IDZero, ID: "SpanID", Value: s}
+	}
+	return spanID, nil
+}
+
+// flagsParseError is returned when the flags of a trace context string cannot be parsed.
+type flagsParseError struct {
+	err error
+}
+
+func (e *flagsParseError) Error() string {
+	return e.err.Error()
+}
+
+// ------- IDParseError -------
+
+// IDParseErrorKind describes why a trace or span ID could not be parsed.
+type IDParseErrorKind int
+
+const (
+	// IDEmpty means the ID string is empty.
+	IDEmpty IDParseErrorKind = iota + 1
+	// IDTooLong means the ID string has more hex characters than the ID has room for.
+	IDTooLong
+	// IDNotHex means the ID string contains characters other than hex digits.
+	IDNotHex
+	// IDZero means the ID is zero, which is not a valid trace or span ID.
+	IDZero
+)
+
+// IDParseError is returned when a trace or span ID cannot be parsed from a string.
+type IDParseError struct {
+	Kind IDParseErrorKind
+	// ID is the type of the ID, "TraceID" or "SpanID".
+	ID string
+	// Value is the string that could not be parsed.
+	Value string
+}
+
+func (e *IDParseError) Error() string {
+	switch e.Kind {
+	case IDEmpty:
+		return fmt.Sprintf("%s cannot be empty", e.ID)
+	case IDTooLong:
+		maxLen := 16
+		if e.ID == "TraceID" {
+			maxLen = 32
+		}
+		return fmt.Sprintf("%s cannot be longer than %d hex characters: %s", e.ID, maxLen, e.Value)
+	case IDZero:
+		return fmt.Sprintf("%s cannot be zero: %s", e.ID, e.Value)
+	default:
+		return fmt.Sprintf("%s must only contain hex characters: %s", e.ID, e.Value)
+	}
+}
+
+// parseHexID parses the hex digits of an ID of at most 16 characters, which is part of value.
+func parseHexID(id, s, value string) (uint64, error) {
+	if s == "" {
+		return 0, &IDParseError{Kind: IDEmpty, ID: id, Value: value}
+	}
+	n, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, &IDParseError{Kind: IDNotHex, ID: id, Value: value}
+	}
+	return n, nil
+}
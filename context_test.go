@@ -15,6 +15,7 @@
 package jaeger
 
 import (
+	"hash/fnv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -70,6 +71,36 @@ func TestContextFromString(t *testing.T) {
 	assert.Equal(t, "ff00000000000000ff:1:1:0", ctx.String())
 }
 
+func TestSpanContext_WithFlags(t *testing.T) {
+	ctx := NewSpanContext(TraceID{Low: 1}, 2, 0, false, map[string]string{"k": "v"})
+	assert.False(t, ctx.IsSampled())
+
+	debug := ctx.WithDebug(true)
+	assert.True(t, debug.IsSampled())
+	assert.True(t, debug.IsDebug())
+	assert.False(t, ctx.IsDebug(), "the original context must not change")
+	assert.Equal(t, "1:2:0:3", debug.String())
+	assert.Equal(t, ctx.baggage, debug.baggage)
+
+	firehose := ctx.WithFirehose(true)
+	assert.True(t, firehose.IsSampled())
+	assert.True(t, firehose.IsFirehose())
+	assert.False(t, firehose.WithFirehose(false).IsFirehose())
+	assert.True(t, firehose.WithFirehose(false).IsSampled())
+
+	unsampled := debug.WithFirehose(true).WithSampled(false)
+	assert.False(t, unsampled.IsSampled())
+	assert.False(t, unsampled.IsDebug())
+	assert.False(t, unsampled.IsFirehose())
+	assert.True(t, ctx.WithSampled(true).IsSampled())
+	assert.False(t, debug.WithDebug(false).IsDebug())
+	assert.True(t, debug.WithDebug(false).IsSampled())
+
+	withDebugID := ctx.WithDebugID("Coraline")
+	assert.Equal(t, "Coraline", withDebugID.debugID)
+	assert.Empty(t, ctx.debugID)
+}
+
 func TestSpanContext_WithBaggageItem(t *testing.T) {
 	var ctx SpanContext
 	ctx = ctx.WithBaggageItem("some-KEY", "Some-Value")
@@ -166,3 +197,103 @@ func TestSpanContext_CopyFrom(t *testing.T) {
 	assert.Equal(t, ctx, ctx2)
 	assert.Equal(t, "y", ctx2.baggage["x"])
 }
+
+func TestSpanContextEqualAndHash(t *testing.T) {
+	ctx1 := NewSpanContext(TraceID{High: 1, Low: 2}, 3, 4, true, map[string]string{"k": "v"})
+	ctx2 := NewSpanContext(TraceID{High: 1, Low: 2}, 3, 4, true, nil)
+	assert.True(t, ctx1.Equal(ctx2), "baggage is ignored")
+	assert.Equal(t, ctx1.Hash64(), ctx2.Hash64())
+	assert.Equal(t, ctx1.Key(), ctx2.Key())
+	assert.False(t, ctx1.Equal(ctx1.WithDebug(true)))
+	assert.False(t, ctx1.Equal(NewSpanContext(TraceID{High: 1, Low: 2}, 5, 4, true, nil)))
+	assert.NotEqual(t, ctx1.Hash64(), NewSpanContext(TraceID{High: 1, Low: 2}, 5, 4, true, nil).Hash64())
+
+	seen := map[SpanContextKey]bool{ctx1.Key(): true}
+	assert.True(t, seen[ctx2.Key()])
+
+	assert.True(t, TraceID{High: 1, Low: 2}.Equal(ctx1.TraceID()))
+	assert.False(t, TraceID{Low: 2}.Equal(ctx1.TraceID()))
+	assert.True(t, SpanID(3).Equal(ctx1.SpanID()))
+
+	// the hashes must not change between versions of the client
+	h := fnv.New64a()
+	h.Write([]byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2})
+	assert.Equal(t, h.Sum64(), TraceID{High: 1, Low: 2}.Hash64())
+	h = fnv.New64a()
+	h.Write([]byte{0, 0, 0, 0, 0, 0, 0, 3})
+	assert.Equal(t, h.Sum64(), SpanID(3).Hash64())
+	h = fnv.New64a()
+	h.Write([]byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3})
+	assert.Equal(t, h.Sum64(), ctx1.Hash64())
+}
+
+func TestIDFromStringErrors(t *testing.T) {
+	tests := []struct {
+		in      string
+		parse   func(string) error
+		kind    IDParseErrorKind
+		message string
+	}{
+		{"", parseTraceID, IDEmpty, "TraceID cannot be empty"},
+		{"012345678901234567890123456789012", parseTraceID, IDTooLong,
+			"TraceID cannot be longer than 32 hex characters: 012345678901234567890123456789012"},
+		{"x1", parseTraceID, IDNotHex, "TraceID must only contain hex characters: x1"},
+		{"1234567890123456x", parseTraceID, IDNotHex, "TraceID must only contain hex characters: 1234567890123456x"},
+		{"00000000000000000000000000000000", parseTraceID, IDZero,
+			"TraceID cannot be zero: 00000000000000000000000000000000"},
+		{"", parseSpanID, IDEmpty, "SpanID cannot be empty"},
+		{"01234567890123456", parseSpanID, IDTooLong,
+			"SpanID cannot be longer than 16 hex characters: 01234567890123456"},
+		{"-1", parseSpanID, IDNotHex, "SpanID must only contain hex characters: -1"},
+		{"0", parseSpanID, IDZero, "SpanID cannot be zero: 0"},
+	}
+	for _, tc := range tests {
+		err := tc.parse(tc.in)
+		require.IsType(t, &IDParseError{}, err, tc.in)
+		assert.Equal(t, tc.kind, err.(*IDParseError).Kind, tc.in)
+		assert.EqualError(t, err, tc.message)
+	}
+
+	traceID, err := TraceIDFromString("0")
+	assert.NoError(t, err, "zero IDs are accepted by TraceIDFromString")
+	assert.Equal(t, TraceID{}, traceID)
+	spanID, err := SpanIDFromString("0000000000000000")
+	assert.NoError(t, err, "zero IDs are accepted by SpanIDFromString")
+	assert.Equal(t, SpanID(0), spanID)
+}
+
+func parseTraceID(s string) error {
+	_, err := ParseTraceID(s)
+	return err
+}
+
+func parseSpanID(s string) error {
+	_, err := ParseSpanID(s)
+	return err
+}
+
+func TestIDPaddedString(t *testing.T) {
+	tests := []struct {
+		traceID          TraceID
+		unpadded, padded string
+	}{
+		{TraceID{Low: 0xabc}, "abc", "0000000000000abc"},
+		{TraceID{High: 1, Low: 0xabc}, "10000000000000abc", "00000000000000010000000000000abc"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.unpadded, tc.traceID.String())
+		assert.Equal(t, tc.padded, tc.traceID.PaddedString())
+		for _, s := range []string{tc.unpadded, tc.padded} {
+			traceID, err := ParseTraceID(s)
+			require.NoError(t, err)
+			assert.Equal(t, tc.traceID, traceID)
+		}
+	}
+
+	spanID := SpanID(0xabc)
+	assert.Equal(t, "abc", spanID.String())
+	assert.Equal(t, "0000000000000abc", spanID.PaddedString())
+	parsed, err := ParseSpanID(spanID.PaddedString())
+	require.NoError(t, err)
+	assert.Equal(t, spanID, parsed)
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import "time"
+
+// DiagnosticEventType is the type of a notable internal event of the tracer, see DiagnosticsCallback.
+type DiagnosticEventType string
+
+const (
+	// DiagnosticSamplingStrategyApplied means the remotely controlled sampler applied a new sampling
+	// strategy received from the agent.
+	DiagnosticSamplingStrategyApplied DiagnosticEventType = "sampling_strategy_applied"
+
+	// DiagnosticReporterFailing means the reporter failed to send a batch of spans after it was
+	// previously able to, e.g. because the agent or the collector became unreachable. Further
+	// failures are not published until the reporter recovers.
+	DiagnosticReporterFailing DiagnosticEventType = "reporter_failing"
+
+	// DiagnosticReporterRecovered means the reporter successfully sent a batch of spans after
+	// a DiagnosticReporterFailing event.
+	DiagnosticReporterRecovered DiagnosticEventType = "reporter_recovered"
+
+	// DiagnosticQueueOverflowStarted means the reporter started dropping spans because its queue is full.
+	DiagnosticQueueOverflowStarted DiagnosticEventType = "queue_overflow_started"
+
+	// DiagnosticQueueOverflowEnded means the reporter accepted a span after a DiagnosticQueueOverflowStarted event.
+	DiagnosticQueueOverflowEnded DiagnosticEventType = "queue_overflow_ended"
+)
+
+// DiagnosticEvent is a notable internal event of the tracer.
+type DiagnosticEvent struct {
+	Type DiagnosticEventType
+	Time time.Time
+	// Message describes the event for humans, e.g. the applied sampling strategy
+	Message string
+	// Err is the error that caused the event, if any
+	Err error
+}
+
+// DiagnosticsCallback is invoked by the sampler and the reporter for their notable internal events,
+// so that the health subsystem of the application can consume them programmatically. The events are
+// published from the tracer's background go-routines, and the queue overflow events from the
+// go-routines that finish the spans, so the callback must be thread-safe and return quickly.
+type DiagnosticsCallback func(event DiagnosticEvent)
+
+// publish invokes the callback, if any, with a new event.
+func (c DiagnosticsCallback) publish(eventType DiagnosticEventType, message string, err error) {
+	if c == nil {
+		return
+	}
+	c(DiagnosticEvent{Type: eventType, Time: time.Now(), Message: message, Err: err})
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/thrift-gen/sampling"
+)
+
+type diagnosticsRecorder struct {
+	sync.Mutex
+	events []DiagnosticEvent
+}
+
+func (r *diagnosticsRecorder) callback(event DiagnosticEvent) {
+	r.Lock()
+	defer r.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *diagnosticsRecorder) types() []DiagnosticEventType {
+	r.Lock()
+	defer r.Unlock()
+	var types []DiagnosticEventType
+	for _, event := range r.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func (r *diagnosticsRecorder) waitFor(t *testing.T, expected ...DiagnosticEventType) {
+	for i := 0; i < 1000 && len(r.types()) < len(expected); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, expected, r.types())
+}
+
+func TestDiagnosticsSamplingStrategyApplied(t *testing.T) {
+	agent, remoteSampler, _ := initAgent(t)
+	defer agent.Close()
+	recorder := &diagnosticsRecorder{}
+	remoteSampler.diagnosticsCallback = recorder.callback
+
+	agent.AddSamplingStrategy("client app",
+		getSamplingStrategyResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.5))
+	remoteSampler.updateSampler()
+	remoteSampler.updateSampler() // the same strategy is not published again
+	recorder.waitFor(t, DiagnosticSamplingStrategyApplied)
+	assert.Contains(t, recorder.events[0].Message, "SamplingRate:0.5")
+	assert.NoError(t, recorder.events[0].Err)
+
+	agent.AddSamplingStrategy("client app",
+		getSamplingStrategyResponse(sampling.SamplingStrategyType_PROBABILISTIC, 0.1))
+	remoteSampler.updateSampler()
+	recorder.waitFor(t, DiagnosticSamplingStrategyApplied, DiagnosticSamplingStrategyApplied)
+	assert.Contains(t, recorder.events[1].Message, "SamplingRate:0.1")
+}
+
+func TestDiagnosticsSamplingStrategyAppliedCallbackUsesSampler(t *testing.T) {
+	agent, remoteSampler, _ := initAgent(t)
+	defer agent.Close()
+	var sampled int32
+	remoteSampler.diagnosticsCallback = func(event DiagnosticEvent) {
+		// the callback is not called while the sampler is locked
+		if ok, _ := remoteSampler.IsSampled(TraceID{Low: 1}, "op"); ok {
+			atomic.StoreInt32(&sampled, 1)
+		}
+	}
+
+	agent.AddSamplingStrategy("client app",
+		getSamplingStrategyResponse(sampling.SamplingStrategyType_PROBABILISTIC, 1))
+	remoteSampler.updateSampler()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&sampled))
+}
+
+func TestDiagnosticsReporterFailingAndRecovered(t *testing.T) {
+	recorder := &diagnosticsRecorder{}
+	sender := &fakeSender{bufferSize: 1, flushErr: errors.New("connection refused")}
+	reporter := NewRemoteReporter(sender, ReporterOptions.DiagnosticsCallback(recorder.callback))
+	tracer, closer := NewTracer("reporter-test-service", NewConstSampler(true), reporter)
+	defer closer.Close()
+
+	tracer.StartSpan("sp1").Finish()
+	tracer.StartSpan("sp2").Finish()
+	sender.assertFlushedSpans(t, 2)
+	recorder.waitFor(t, DiagnosticReporterFailing)
+	assert.EqualError(t, recorder.events[0].Err, "connection refused")
+
+	sender.mutex.Lock()
+	sender.flushErr = nil
+	sender.mutex.Unlock()
+	tracer.StartSpan("sp3").Finish()
+	tracer.StartSpan("sp4").Finish()
+	sender.assertFlushedSpans(t, 4)
+	recorder.waitFor(t, DiagnosticReporterFailing, DiagnosticReporterRecovered)
+}
+
+func TestDiagnosticsQueueOverflow(t *testing.T) {
+	recorder := &diagnosticsRecorder{}
+	sender := &sizedSender{fakeSender: &fakeSender{bufferSize: 10}, release: make(chan struct{})}
+	reporter := NewRemoteReporter(sender,
+		ReporterOptions.QueueSize(1),
+		ReporterOptions.DiagnosticsCallback(recorder.callback),
+	).(*remoteReporter)
+	tracer, closer := NewTracer("reporter-test-service", NewConstSampler(true), reporter)
+	defer closer.Close()
+	defer close(sender.release)
+
+	waitForEmptyQueue := func() {
+		for i := 0; i < 1000 && atomic.LoadInt64(&reporter.queueLength) > 0; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		require.EqualValues(t, 0, atomic.LoadInt64(&reporter.queueLength))
+	}
+
+	// the first span blocks the reporter in Append, the second one fills the queue
+	tracer.StartSpan("sp1").Finish()
+	waitForEmptyQueue()
+	tracer.StartSpan("sp2").Finish()
+	tracer.StartSpan("sp3").Finish()
+	tracer.StartSpan("sp4").Finish()
+	recorder.waitFor(t, DiagnosticQueueOverflowStarted)
+
+	sender.release <- struct{}{}
+	waitForEmptyQueue()
+	tracer.StartSpan("sp5").Finish()
+	recorder.waitFor(t, DiagnosticQueueOverflowStarted, DiagnosticQueueOverflowEnded)
+}
+
+func TestDiagnosticsCallbackNil(t *testing.T) {
+	var callback DiagnosticsCallback
+	callback.publish(DiagnosticReporterFailing, "failed", nil)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync/atomic"
+)
+
+// IDGenerator generates the IDs of new traces and spans, see TracerOptions.IDGenerator.
+// It must be safe for concurrent use.
+type IDGenerator interface {
+	// NextTraceID returns the ID of a new trace. Its low 64 bits must not be 0,
+	// they are also used as the ID of the root span of the trace.
+	NextTraceID() TraceID
+
+	// NextSpanID returns the ID of a new span, other than the root span of a trace.
+	// It must not be 0.
+	NextSpanID() SpanID
+}
+
+// randomIDGenerator is the default IDGenerator, which generates random IDs using the
+// RandomNumber and HighTraceIDGenerator functions of the tracer.
+type randomIDGenerator struct {
+	tracer *Tracer
+}
+
+func (g randomIDGenerator) NextTraceID() TraceID {
+	traceID := TraceID{Low: g.tracer.randomID()}
+	if g.tracer.options.gen128Bit {
+		traceID.High = g.tracer.options.highTraceIDGenerator()
+	}
+	return traceID
+}
+
+func (g randomIDGenerator) NextSpanID() SpanID {
+	return SpanID(g.tracer.randomID())
+}
+
+// SequentialIDGenerator is an IDGenerator that generates the IDs 1, 2, 3, ... in order,
+// so that the IDs of traces and spans created by tests are reproducible, e.g. in
+// golden files of injected headers or reported batches. It should not be used in
+// production, since the IDs of different processes collide.
+type SequentialIDGenerator struct {
+	gen128Bit bool
+	last      uint64
+}
+
+// NewSequentialIDGenerator creates a SequentialIDGenerator. When gen128Bit is true,
+// the high 64 bits of trace IDs are equal to their low 64 bits.
+func NewSequentialIDGenerator(gen128Bit bool) *SequentialIDGenerator {
+	return &SequentialIDGenerator{gen128Bit: gen128Bit}
+}
+
+// NextTraceID implements NextTraceID of IDGenerator.
+func (g *SequentialIDGenerator) NextTraceID() TraceID {
+	traceID := TraceID{Low: g.next()}
+	if g.gen128Bit {
+		traceID.High = traceID.Low
+	}
+	return traceID
+}
+
+// NextSpanID implements NextSpanID of IDGenerator.
+func (g *SequentialIDGenerator) NextSpanID() SpanID {
+	return SpanID(g.next())
+}
+
+// Reset restarts the sequence, so that the next generated ID is 1 again.
+func (g *SequentialIDGenerator) Reset() {
+	atomic.StoreUint64(&g.last, 0)
+}
+
+func (g *SequentialIDGenerator) next() uint64 {
+	return atomic.AddUint64(&g.last, 1)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+// regionIDGenerator embeds a region in the high bits of the IDs.
+type regionIDGenerator struct {
+	region  uint64
+	counter uint64
+}
+
+func (g *regionIDGenerator) next() uint64 {
+	return g.region<<56 | atomic.AddUint64(&g.counter, 1)
+}
+
+func (g *regionIDGenerator) NextTraceID() TraceID {
+	return TraceID{High: g.region, Low: g.next()}
+}
+
+func (g *regionIDGenerator) NextSpanID() SpanID {
+	return SpanID(g.next())
+}
+
+func TestIDGenerator(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.IDGenerator(&regionIDGenerator{region: 7}),
+		TracerOptions.Gen128Bit(true),
+		TracerOptions.Logger(logger),
+	)
+	defer closer.Close()
+	assert.Contains(t, logger.String(), `options are ignored when the "IDGenerator" option is used`)
+
+	root := tracer.StartSpan("root").(*Span)
+	assert.Equal(t, TraceID{High: 7, Low: 7<<56 | 1}, root.context.traceID)
+	assert.Equal(t, SpanID(7<<56|1), root.context.spanID)
+
+	child := tracer.StartSpan("child", opentracing.ChildOf(root.Context())).(*Span)
+	assert.Equal(t, root.context.traceID, child.context.traceID)
+	assert.Equal(t, SpanID(7<<56|2), child.context.spanID)
+}
+
+func TestRandomIDGenerator(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Gen128Bit(true),
+		TracerOptions.HighTraceIDGenerator(func() uint64 { return 42 }),
+	)
+	defer closer.Close()
+	generator := tracer.(*Tracer).idGenerator
+	assert.IsType(t, randomIDGenerator{}, generator)
+
+	traceID := generator.NextTraceID()
+	assert.EqualValues(t, 42, traceID.High)
+	assert.NotZero(t, traceID.Low)
+	assert.NotZero(t, generator.NextSpanID())
+}
+
+func TestSequentialIDGenerator(t *testing.T) {
+	generator := NewSequentialIDGenerator(true)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.IDGenerator(generator),
+	)
+	defer closer.Close()
+
+	for i := 0; i < 2; i++ {
+		root := tracer.StartSpan("root")
+		child := tracer.StartSpan("child", opentracing.ChildOf(root.Context()))
+		assert.Equal(t, "10000000000000001:2:1:1",
+			child.Context().(SpanContext).String())
+		generator.Reset()
+	}
+}
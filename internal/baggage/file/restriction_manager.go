@@ -0,0 +1,229 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package file provides a baggage RestrictionManager that loads the baggage restrictions from a local file,
+// for the environments where jaeger-agent's baggage restrictions endpoint is not available.
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-client-go/internal/baggage"
+)
+
+// RestrictionManager manages baggage restrictions by loading them from a local file, and reloading them
+// when the file changes.
+//
+// The file maps the allowed baggage keys to the max length of their values, either in JSON
+// if the file does not have the ".yaml" or ".yml" extension:
+//
+//	{"tenant": 64, "user-id": 128}
+//
+// or as a flat YAML mapping:
+//
+//	# the baggage allowed in the region
+//	tenant: 64
+//	"user-id": 128
+//
+// A max value length of 0 stands for the default length of 2048.
+type RestrictionManager struct {
+	options
+
+	mux                sync.RWMutex
+	path               string
+	restrictions       map[string]*baggage.Restriction
+	modTime            time.Time
+	size               int64
+	pollStopped        sync.WaitGroup
+	stopPoll           chan struct{}
+	invalidRestriction *baggage.Restriction
+	validRestriction   *baggage.Restriction
+
+	// Determines if the manager has successfully loaded the baggage restrictions from the file
+	initialized bool
+}
+
+// NewRestrictionManager returns a BaggageRestrictionManager that loads the baggage restrictions
+// from the file at the given path, and polls the file for changes.
+func NewRestrictionManager(path string, options ...Option) *RestrictionManager {
+	m := &RestrictionManager{
+		options:            applyOptions(options...),
+		path:               path,
+		restrictions:       make(map[string]*baggage.Restriction),
+		stopPoll:           make(chan struct{}),
+		invalidRestriction: baggage.NewRestriction(false, 0),
+		validRestriction:   baggage.NewRestriction(true, defaultMaxValueLength),
+	}
+	if err := m.updateRestrictions(); err != nil {
+		m.logger.Error(fmt.Sprintf("Failed to initialize baggage restrictions: %s", err.Error()))
+	}
+	m.pollStopped.Add(1)
+	go m.pollManager()
+	return m
+}
+
+// GetRestriction implements RestrictionManager#GetRestriction.
+func (m *RestrictionManager) GetRestriction(service, key string) *baggage.Restriction {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	if !m.initialized {
+		if m.denyBaggageOnInitializationFailure {
+			return m.invalidRestriction
+		}
+		return m.validRestriction
+	}
+	if restriction, ok := m.restrictions[key]; ok {
+		return restriction
+	}
+	return m.invalidRestriction
+}
+
+// Close stops polling the file and closes the RestrictionManager.
+func (m *RestrictionManager) Close() error {
+	close(m.stopPoll)
+	m.pollStopped.Wait()
+	return nil
+}
+
+func (m *RestrictionManager) pollManager() {
+	defer m.pollStopped.Done()
+	ticker := time.NewTicker(m.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.updateRestrictions(); err != nil {
+				m.logger.Error(fmt.Sprintf("Failed to update baggage restrictions: %s", err.Error()))
+			}
+		case <-m.stopPoll:
+			return
+		}
+	}
+}
+
+// updateRestrictions reloads the restrictions if the file changed since it was last loaded.
+// On failure, the previously loaded restrictions are kept.
+func (m *RestrictionManager) updateRestrictions() error {
+	info, err := os.Stat(m.path)
+	if err != nil {
+		m.metrics.BaggageRestrictionsUpdateFailure.Inc(1)
+		return err
+	}
+	m.mux.RLock()
+	unchanged := m.initialized && info.ModTime().Equal(m.modTime) && info.Size() == m.size
+	m.mux.RUnlock()
+	if unchanged {
+		m.metrics.BaggageRestrictionsLastUpdate.Update(time.Now().Unix())
+		return nil
+	}
+	data, err := ioutil.ReadFile(m.path)
+	if err != nil {
+		m.metrics.BaggageRestrictionsUpdateFailure.Inc(1)
+		return err
+	}
+	restrictions, err := parseRestrictions(m.path, data)
+	if err != nil {
+		m.metrics.BaggageRestrictionsUpdateFailure.Inc(1)
+		return err
+	}
+	m.metrics.BaggageRestrictionsUpdateSuccess.Inc(1)
+	m.metrics.BaggageRestrictionsLastUpdate.Update(time.Now().Unix())
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.initialized = true
+	m.restrictions = restrictions
+	m.modTime = info.ModTime()
+	m.size = info.Size()
+	return nil
+}
+
+func parseRestrictions(path string, data []byte) (map[string]*baggage.Restriction, error) {
+	var lengths map[string]int
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		lengths, err = parseYAML(data)
+	default:
+		err = json.Unmarshal(data, &lengths)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse baggage restrictions from %s: %s", path, err.Error())
+	}
+	restrictions := make(map[string]*baggage.Restriction, len(lengths))
+	for key, maxValueLength := range lengths {
+		if maxValueLength < 0 {
+			return nil, fmt.Errorf("invalid max value length of baggage key %q in %s: %d", key, path, maxValueLength)
+		}
+		if maxValueLength == 0 {
+			maxValueLength = defaultMaxValueLength
+		}
+		restrictions[key] = baggage.NewRestriction(true, maxValueLength)
+	}
+	return restrictions, nil
+}
+
+// parseYAML parses a flat YAML mapping of the keys to integers, the only YAML supported for the restrictions.
+func parseYAML(data []byte) (map[string]int, error) {
+	lengths := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line == "---" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, err := splitYAMLLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNum, err.Error())
+		}
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		maxValueLength, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: the max value length is not an integer: %q", lineNum, value)
+		}
+		lengths[key] = maxValueLength
+	}
+	return lengths, scanner.Err()
+}
+
+func splitYAMLLine(line string) (key, value string, err error) {
+	if quote := line[0]; quote == '"' || quote == '\'' {
+		end := strings.IndexByte(line[1:], quote)
+		if end < 0 {
+			return "", "", fmt.Errorf("unterminated quoted key: %s", line)
+		}
+		key, line = line[1:end+1], strings.TrimSpace(line[end+2:])
+		if !strings.HasPrefix(line, ":") {
+			return "", "", fmt.Errorf("expected a colon after the key %q", key)
+		}
+		return key, strings.TrimSpace(line[1:]), nil
+	}
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("expected a key and a value separated by a colon: %s", line)
+	}
+	return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]), nil
+}
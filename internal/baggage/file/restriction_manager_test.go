@@ -0,0 +1,144 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/internal/baggage"
+)
+
+var _ io.Closer = new(RestrictionManager) // API check
+
+func withTempDir(t *testing.T, f func(dir string)) {
+	dir, err := ioutil.TempDir("", "baggage-restrictions")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	f(dir)
+}
+
+func TestRestrictionManagerFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "restrictions.json", content: `{"tenant": 10, "user-id": 0}`},
+		{name: "restrictions.yaml", content: "---\n# comment\ntenant: 10 # the tenant ID\n\n'user-id': 0\n"},
+		{name: "restrictions.yml", content: "\"tenant\" : 10\nuser-id: 0\n"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			withTempDir(t, func(dir string) {
+				path := filepath.Join(dir, tc.name)
+				require.NoError(t, ioutil.WriteFile(path, []byte(tc.content), 0644))
+				mgr := NewRestrictionManager(path)
+				defer mgr.Close()
+
+				assert.Equal(t, baggage.NewRestriction(true, 10), mgr.GetRestriction("svc", "tenant"))
+				assert.Equal(t, baggage.NewRestriction(true, defaultMaxValueLength), mgr.GetRestriction("svc", "user-id"))
+				assert.Equal(t, baggage.NewRestriction(false, 0), mgr.GetRestriction("svc", "other"))
+			})
+		})
+	}
+}
+
+func TestParseRestrictionsErrors(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		err     string
+	}{
+		{path: "r.json", content: `[]`, err: "cannot parse baggage restrictions from r.json"},
+		{path: "r.json", content: `{"tenant": -1}`, err: `invalid max value length of baggage key "tenant" in r.json: -1`},
+		{path: "r.yaml", content: "tenant", err: "line 1: expected a key and a value separated by a colon: tenant"},
+		{path: "r.yaml", content: `"tenant: 10`, err: `line 1: unterminated quoted key: "tenant: 10`},
+		{path: "r.yaml", content: `"tenant" 10`, err: `line 1: expected a colon after the key "tenant"`},
+		{path: "r.yaml", content: "tenant: ten", err: `line 1: the max value length is not an integer: "ten"`},
+		{path: "r.yml", content: "tenant: 1\nuser-id:", err: `line 2: the max value length is not an integer: ""`},
+	}
+	for _, tc := range tests {
+		_, err := parseRestrictions(tc.path, []byte(tc.content))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tc.err)
+	}
+}
+
+func TestRestrictionManagerReload(t *testing.T) {
+	withTempDir(t, func(dir string) {
+		path := filepath.Join(dir, "restrictions.json")
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"tenant": 10}`), 0644))
+		factory := metricstest.NewFactory(0)
+		mgr := NewRestrictionManager(path,
+			Options.Metrics(jaeger.NewMetrics(factory, nil)),
+			Options.RefreshInterval(time.Millisecond),
+		)
+		defer mgr.Close()
+		assert.True(t, mgr.GetRestriction("svc", "tenant").KeyAllowed())
+
+		// an invalid file keeps the previous restrictions
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"tenant": `), 0644))
+		require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+		for i := 0; i < 1000; i++ {
+			if counters, _ := factory.Snapshot(); counters["jaeger.tracer.baggage_restrictions_updates|result=err"] > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		assert.True(t, mgr.GetRestriction("svc", "tenant").KeyAllowed())
+
+		require.NoError(t, ioutil.WriteFile(path, []byte(`{"user-id": 20}`), 0644))
+		require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+		for i := 0; i < 1000; i++ {
+			if mgr.GetRestriction("svc", "user-id").KeyAllowed() {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		assert.Equal(t, baggage.NewRestriction(true, 20), mgr.GetRestriction("svc", "user-id"))
+		assert.False(t, mgr.GetRestriction("svc", "tenant").KeyAllowed())
+	})
+}
+
+func TestRestrictionManagerMissingFile(t *testing.T) {
+	withTempDir(t, func(dir string) {
+		path := filepath.Join(dir, "restrictions.json")
+		logger := &countingLogger{}
+		mgr := NewRestrictionManager(path, Options.Logger(logger))
+		assert.True(t, mgr.GetRestriction("svc", "tenant").KeyAllowed())
+		assert.Equal(t, 1, logger.errors)
+		mgr.Close()
+
+		mgr = NewRestrictionManager(path, Options.DenyBaggageOnInitializationFailure(true))
+		assert.False(t, mgr.GetRestriction("svc", "tenant").KeyAllowed())
+		mgr.Close()
+	})
+}
+
+type countingLogger struct {
+	errors int
+}
+
+func (l *countingLogger) Error(msg string)                      { l.errors++ }
+func (l *countingLogger) Infof(msg string, args ...interface{}) {}
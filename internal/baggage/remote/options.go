@@ -15,6 +15,8 @@
 package remote
 
 import (
+	"crypto/tls"
+	"net/http"
 	"time"
 
 	"github.com/uber/jaeger-client-go"
@@ -24,6 +26,7 @@ const (
 	defaultMaxValueLength  = 2048
 	defaultRefreshInterval = time.Minute
 	defaultHostPort        = "localhost:5778"
+	defaultHTTPTimeout     = 5 * time.Second
 )
 
 // Option is a function that sets some option on the RestrictionManager
@@ -37,7 +40,11 @@ type options struct {
 	metrics                            *jaeger.Metrics
 	logger                             jaeger.Logger
 	hostPort                           string
+	serverURL                          string
 	refreshInterval                    time.Duration
+	httpClient                         *http.Client
+	tlsConfig                          *tls.Config
+	headers                            http.Header
 }
 
 // DenyBaggageOnInitializationFailure creates an Option that determines the startup failure mode of RestrictionManager.
@@ -72,6 +79,42 @@ func (options) HostPort(hostPort string) Option {
 	}
 }
 
+// ServerURL creates an Option that sets the URL of the endpoint serving the baggage restrictions,
+// e.g. "https://jaeger-collector:14268/api/baggageRestrictions", to use instead of the agent at HostPort.
+// The service name is added to the query of the URL.
+func (options) ServerURL(serverURL string) Option {
+	return func(o *options) {
+		o.serverURL = serverURL
+	}
+}
+
+// HTTPClient creates an Option that sets the HTTP client used to retrieve the baggage restrictions.
+// When a custom client is provided, the TLSConfig option is ignored.
+func (options) HTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// TLSConfig creates an Option that sets the TLS configuration used to retrieve the baggage restrictions
+// from an HTTPS ServerURL, e.g. to present a client certificate or to trust a private CA.
+func (options) TLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = tlsConfig
+	}
+}
+
+// Header creates an Option that adds a header to the requests retrieving the baggage restrictions,
+// e.g. an "Authorization" header.
+func (options) Header(key, value string) Option {
+	return func(o *options) {
+		if o.headers == nil {
+			o.headers = make(http.Header)
+		}
+		o.headers.Add(key, value)
+	}
+}
+
 // RefreshInterval creates an Option that sets how often the RestrictionManager will poll local agent for
 // the baggage restrictions.
 func (options) RefreshInterval(refreshInterval time.Duration) Option {
@@ -97,5 +140,15 @@ func applyOptions(o ...Option) options {
 	if opts.refreshInterval == 0 {
 		opts.refreshInterval = defaultRefreshInterval
 	}
+	if opts.httpClient == nil {
+		opts.httpClient = &http.Client{Timeout: defaultHTTPTimeout}
+		if opts.tlsConfig != nil {
+			opts.httpClient.Transport = &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				TLSClientConfig:     opts.tlsConfig,
+				TLSHandshakeTimeout: 10 * time.Second,
+			}
+		}
+	}
 	return opts
 }
@@ -16,7 +16,9 @@ package remote
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,20 +28,43 @@ import (
 )
 
 type httpBaggageRestrictionManagerProxy struct {
-	url string
+	url     string
+	client  *http.Client
+	headers http.Header
 }
 
-func newHTTPBaggageRestrictionManagerProxy(hostPort, serviceName string) *httpBaggageRestrictionManagerProxy {
+func newHTTPBaggageRestrictionManagerProxy(opts options, serviceName string) *httpBaggageRestrictionManagerProxy {
 	v := url.Values{}
 	v.Set("service", serviceName)
+	serverURL := opts.serverURL
+	if serverURL == "" {
+		serverURL = fmt.Sprintf("http://%s/baggageRestrictions", opts.hostPort)
+	}
+	separator := "?"
+	if strings.Contains(serverURL, "?") {
+		separator = "&"
+	}
 	return &httpBaggageRestrictionManagerProxy{
-		url: fmt.Sprintf("http://%s/baggageRestrictions?%s", hostPort, v.Encode()),
+		url:     serverURL + separator + v.Encode(),
+		client:  opts.httpClient,
+		headers: opts.headers,
 	}
 }
 
 func (s *httpBaggageRestrictionManagerProxy) GetBaggageRestrictions(serviceName string) ([]*thrift.BaggageRestriction, error) {
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range s.headers {
+		req.Header[key] = values
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
 	var out []*thrift.BaggageRestriction
-	if err := utils.GetJSON(s.url, &out); err != nil {
+	if err := utils.ReadJSON(resp, &out); err != nil {
 		return nil, err
 	}
 	return out, nil
@@ -72,7 +97,7 @@ func NewRestrictionManager(serviceName string, options ...Option) *RestrictionMa
 		serviceName:        serviceName,
 		options:            opts,
 		restrictions:       make(map[string]*baggage.Restriction),
-		thriftProxy:        newHTTPBaggageRestrictionManagerProxy(opts.hostPort, serviceName),
+		thriftProxy:        newHTTPBaggageRestrictionManagerProxy(opts, serviceName),
 		stopPoll:           make(chan struct{}),
 		invalidRestriction: baggage.NewRestriction(false, 0),
 		validRestriction:   baggage.NewRestriction(true, defaultMaxValueLength),
@@ -141,6 +166,7 @@ func (m *RestrictionManager) updateRestrictions() error {
 	}
 	newRestrictions := m.parseRestrictions(restrictions)
 	m.metrics.BaggageRestrictionsUpdateSuccess.Inc(1)
+	m.metrics.BaggageRestrictionsLastUpdate.Update(time.Now().Unix())
 	m.mux.Lock()
 	defer m.mux.Unlock()
 	m.initialized = true
@@ -126,6 +126,8 @@ func TestNewRemoteRestrictionManager(t *testing.T) {
 					Value: 1,
 				},
 			)
+			_, gauges := factory.Snapshot()
+			assert.InDelta(t, time.Now().Unix(), gauges["jaeger.tracer.baggage_restrictions_last_update_timestamp"], 1)
 		})
 }
 
@@ -218,3 +220,49 @@ func getHostPort(t *testing.T, s string) string {
 	require.NoError(t, err, "Failed to parse url")
 	return u.Host
 }
+
+func TestRemoteRestrictionManagerServerURL(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Inc()
+		if r.URL.Path != "/api/baggageRestrictions" || r.URL.Query().Get("service") != service ||
+			r.URL.Query().Get("tenant") != "acme" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(testRestrictions)
+	}))
+	defer server.Close()
+
+	mgr := NewRestrictionManager(
+		service,
+		Options.ServerURL(server.URL+"/api/baggageRestrictions?tenant=acme"),
+		Options.TLSConfig(server.Client().Transport.(*http.Transport).TLSClientConfig),
+		Options.Header("Authorization", "Bearer token"),
+		Options.Logger(jaeger.NullLogger),
+	)
+	defer mgr.Close()
+
+	for i := 0; i < 100 && !mgr.isReady(); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	require.True(t, mgr.isReady())
+	assert.EqualValues(t, baggage.NewRestriction(true, expectedSize), mgr.GetRestriction(service, expectedKey))
+	assert.EqualValues(t, 1, requests.Load())
+}
+
+func TestRemoteRestrictionManagerHTTPClient(t *testing.T) {
+	var requests atomic.Int32
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		requests.Inc()
+		return nil, io.ErrUnexpectedEOF
+	})}
+	mgr := NewRestrictionManager(service, Options.HTTPClient(client), Options.Logger(jaeger.NullLogger))
+	mgr.Close()
+	assert.EqualValues(t, 1, requests.Load())
+	assert.False(t, mgr.isReady())
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
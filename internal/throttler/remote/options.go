@@ -37,6 +37,7 @@ type options struct {
 	hostPort                  string
 	refreshInterval           time.Duration
 	synchronousInitialization bool
+	creditManager             CreditManager
 }
 
 // Metrics creates an Option that initializes Metrics on the Throttler, which is used to emit statistics.
@@ -78,6 +79,14 @@ func (options) SynchronousInitialization(b bool) Option {
 	}
 }
 
+// CreditManager creates an Option that makes the Throttler fetch credits from the given manager
+// instead of the HTTP /credits endpoint of jaeger-agent, in which case HostPort is ignored.
+func (options) CreditManager(creditManager CreditManager) Option {
+	return func(o *options) {
+		o.creditManager = creditManager
+	}
+}
+
 func applyOptions(o ...Option) options {
 	opts := options{}
 	for _, option := range o {
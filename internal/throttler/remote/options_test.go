@@ -30,21 +30,27 @@ func TestDefaults(t *testing.T) {
 	assert.NotNil(t, options.metrics)
 	assert.NotNil(t, options.logger)
 	assert.False(t, options.synchronousInitialization)
+	assert.Nil(t, options.creditManager)
 }
 
 func TestOptions(t *testing.T) {
 	metrics := jaeger.NewNullMetrics()
 	logger := jaeger.NullLogger
+	creditManager := mapCreditManager(func(uuid, serviceName string, operations []string) (map[string]float64, error) {
+		return nil, nil
+	})
 	options := applyOptions(
 		Options.Metrics(metrics),
 		Options.Logger(logger),
 		Options.HostPort(":"),
 		Options.RefreshInterval(time.Second),
 		Options.SynchronousInitialization(true),
+		Options.CreditManager(creditManager),
 	)
 	assert.Equal(t, ":", options.hostPort)
 	assert.Equal(t, time.Second, options.refreshInterval)
 	assert.Equal(t, metrics, options.metrics)
 	assert.Equal(t, logger, options.logger)
 	assert.True(t, options.synchronousInitialization)
+	assert.NotNil(t, options.creditManager)
 }
@@ -46,6 +46,39 @@ type creditResponse struct {
 	Balances []operationBalance `json:"balances"`
 }
 
+// CreditManager fetches the debug span credits of the operations of a service, keyed by
+// operation. The built-in implementation queries the HTTP /credits endpoint of jaeger-agent.
+//
+// There is no built-in gRPC client for jaeger-collector, because the collector does not
+// publish a credits service. Deployments without an agent can fetch the credits from their
+// own backend by passing a CreditManager to Options.CreditManager.
+type CreditManager interface {
+	FetchCredits(uuid, serviceName string, operations []string) (map[string]float64, error)
+}
+
+type creditManager interface {
+	FetchCredits(uuid, serviceName string, operations []string) (*creditResponse, error)
+}
+
+// creditManagerAdapter adapts a user supplied CreditManager to the agent's response format.
+type creditManagerAdapter struct {
+	manager CreditManager
+}
+
+func (a creditManagerAdapter) FetchCredits(uuid, serviceName string, operations []string) (*creditResponse, error) {
+	credits, err := a.manager.FetchCredits(uuid, serviceName, operations)
+	if err != nil {
+		return nil, err
+	}
+	resp := &creditResponse{Balances: make([]operationBalance, 0, len(credits))}
+	for _, op := range operations {
+		if balance, ok := credits[op]; ok {
+			resp.Balances = append(resp.Balances, operationBalance{Operation: op, Balance: balance})
+		}
+	}
+	return resp, nil
+}
+
 type httpCreditManagerProxy struct {
 	hostPort string
 }
@@ -78,7 +111,7 @@ type Throttler struct {
 	mux           sync.RWMutex
 	service       string
 	uuid          atomic.Value
-	creditManager *httpCreditManagerProxy
+	creditManager creditManager
 	credits       map[string]float64 // map of operation->credits
 	close         chan struct{}
 	stopped       sync.WaitGroup
@@ -88,7 +121,10 @@ type Throttler struct {
 // the service.
 func NewThrottler(service string, options ...Option) *Throttler {
 	opts := applyOptions(options...)
-	creditManager := newHTTPCreditManagerProxy(opts.hostPort)
+	var creditManager creditManager = newHTTPCreditManagerProxy(opts.hostPort)
+	if opts.creditManager != nil {
+		creditManager = creditManagerAdapter{manager: opts.creditManager}
+	}
 	t := &Throttler{
 		options:       opts,
 		creditManager: creditManager,
@@ -198,6 +234,7 @@ func (t *Throttler) refreshCredits() {
 		return
 	}
 	t.metrics.ThrottlerUpdateSuccess.Inc(1)
+	t.metrics.ThrottlerLastUpdate.Update(time.Now().Unix())
 
 	t.mux.Lock()
 	defer t.mux.Unlock()
@@ -16,6 +16,7 @@ package remote
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -221,6 +222,8 @@ func TestRemotelyControlledThrottler_pollManager(t *testing.T) {
 			counter, ok := counters["jaeger.tracer.throttler_updates|result=ok"]
 			assert.True(t, ok)
 			assert.True(t, counter >= 1)
+			_, gauges := factory.Snapshot()
+			assert.InDelta(t, time.Now().Unix(), gauges["jaeger.tracer.throttler_last_update_timestamp"], 1)
 		})
 }
 
@@ -265,3 +268,61 @@ func getHostPort(t *testing.T, s string) string {
 	require.NoError(t, err, "Failed to parse url")
 	return u.Host
 }
+
+type fakeCreditManager func(uuid, serviceName string, operations []string) (*creditResponse, error)
+
+func (f fakeCreditManager) FetchCredits(uuid, serviceName string, operations []string) (*creditResponse, error) {
+	return f(uuid, serviceName, operations)
+}
+
+func TestThrottlerWithCreditManager(t *testing.T) {
+	var fetched []string
+	throttler := &Throttler{
+		creditManager: fakeCreditManager(func(uuid, serviceName string, operations []string) (*creditResponse, error) {
+			fetched = append(fetched, uuid+"/"+serviceName+"/"+operations[0])
+			return &creditResponse{Balances: []operationBalance{{Operation: operations[0], Balance: 1}}}, nil
+		}),
+		service: "svc",
+		credits: make(map[string]float64),
+		options: options{logger: log.NullLogger, synchronousInitialization: true, metrics: jaeger.NewNullMetrics()},
+	}
+	throttler.SetProcess(jaeger.Process{UUID: "uuid"})
+	assert.True(t, throttler.IsAllowed(testOperation))
+	assert.Equal(t, []string{"uuid/svc/" + testOperation}, fetched)
+}
+
+type mapCreditManager func(uuid, serviceName string, operations []string) (map[string]float64, error)
+
+func (f mapCreditManager) FetchCredits(uuid, serviceName string, operations []string) (map[string]float64, error) {
+	return f(uuid, serviceName, operations)
+}
+
+func TestNewThrottlerWithCreditManager(t *testing.T) {
+	var lock sync.Mutex
+	var fetched []string
+	manager := mapCreditManager(func(uuid, serviceName string, operations []string) (map[string]float64, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		fetched = append(fetched, uuid+"/"+serviceName+"/"+operations[0])
+		if operations[0] == "failing" {
+			return nil, errors.New("no credits")
+		}
+		return map[string]float64{operations[0]: 1, "unknown": 5}, nil
+	})
+	throttler := NewThrottler("svc",
+		Options.CreditManager(manager),
+		Options.HostPort("localhost:1"),
+		Options.RefreshInterval(time.Hour),
+		Options.SynchronousInitialization(true),
+	)
+	defer throttler.Close()
+	throttler.SetProcess(jaeger.Process{UUID: "uuid"})
+
+	assert.True(t, throttler.IsAllowed(testOperation))
+	assert.False(t, throttler.IsAllowed("failing"))
+	assert.Equal(t, []string{"uuid/svc/" + testOperation, "uuid/svc/failing"}, fetched)
+
+	throttler.mux.RLock()
+	defer throttler.mux.RUnlock()
+	assert.NotContains(t, throttler.credits, "unknown", "credits of operations that were not asked for are ignored")
+}
@@ -31,6 +31,10 @@ type jaegerTraceContextPropagator struct {
 	tracer *Tracer
 }
 
+func (p *jaegerTraceContextPropagator) PropagationFormat() string {
+	return "jaeger"
+}
+
 func (p *jaegerTraceContextPropagator) Inject(
 	ctx SpanContext,
 	abstractCarrier interface{},
@@ -38,10 +38,32 @@ func BuildJaegerThrift(span *Span) *j.Span {
 		Flags:         int32(span.context.flags),
 		StartTime:     startTime,
 		Duration:      duration,
-		Tags:          buildTags(span.tags, span.tracer.options.maxTagValueLength),
+		Tags:          span.tracer.buildTags(span.tags),
 		Logs:          buildLogs(span.logs),
 		References:    buildReferences(span.references),
 	}
+	if len(span.events) > 0 {
+		jaegerSpan.Logs = append(jaegerSpan.Logs, buildEventLogs(span.events)...)
+	}
+	if span.droppedTags > 0 {
+		jaegerSpan.Tags = append(jaegerSpan.Tags, buildTag(&Tag{key: DroppedTagsTagKey, value: span.droppedTags}, span.tracer.options.maxTagValueLength))
+	}
+	if span.droppedLogs > 0 {
+		jaegerSpan.Tags = append(jaegerSpan.Tags, buildTag(&Tag{key: DroppedLogsTagKey, value: span.droppedLogs}, span.tracer.options.maxTagValueLength))
+	}
+	if span.suppressedLogs > 0 {
+		jaegerSpan.Tags = append(jaegerSpan.Tags, buildTag(&Tag{key: SuppressedLogsTagKey, value: span.suppressedLogs}, span.tracer.options.maxTagValueLength))
+	}
+	if span.droppedEvents > 0 {
+		jaegerSpan.Tags = append(jaegerSpan.Tags, buildTag(&Tag{key: DroppedEventsTagKey, value: span.droppedEvents}, span.tracer.options.maxTagValueLength))
+	}
+	if warnings := span.warningsNoLocking(); len(warnings) > 0 {
+		jaegerSpan.Tags = append(jaegerSpan.Tags, buildTags(buildWarningTags(warnings), span.tracer.options.maxTagValueLength)...)
+	}
+	if len(span.links) > 0 {
+		jaegerSpan.References = append(jaegerSpan.References, buildLinkReferences(span.links)...)
+		jaegerSpan.Tags = append(jaegerSpan.Tags, buildLinkTags(span.links, span.tracer.options.maxTagValueLength)...)
+	}
 	return jaegerSpan
 }
 
@@ -49,13 +71,16 @@ func BuildJaegerThrift(span *Span) *j.Span {
 func BuildJaegerProcessThrift(span *Span) *j.Process {
 	span.Lock()
 	defer span.Unlock()
-	return buildJaegerProcessThrift(span.tracer)
+	process := buildJaegerProcessThrift(span.tracer)
+	process.ServiceName = span.serviceName()
+	return process
 }
 
 func buildJaegerProcessThrift(tracer *Tracer) *j.Process {
+	tags, _ := tracer.processTags()
 	process := &j.Process{
 		ServiceName: tracer.serviceName,
-		Tags:        buildTags(tracer.tags, tracer.options.maxTagValueLength),
+		Tags:        tracer.buildTags(tags),
 	}
 	if tracer.process.UUID != "" {
 		process.Tags = append(process.Tags, &j.Tag{Key: TracerUUIDTagKey, VStr: &tracer.process.UUID, VType: j.TagType_STRING})
@@ -72,6 +97,15 @@ func buildTags(tags []Tag, maxTagValueLength int) []*j.Tag {
 	return jTags
 }
 
+// buildTags converts the tags, applying the max length of the values of each tag key.
+func (t *Tracer) buildTags(tags []Tag) []*j.Tag {
+	jTags := make([]*j.Tag, 0, len(tags))
+	for i := range tags {
+		jTags = append(jTags, buildTag(&tags[i], t.maxTagValueLength(tags[i].key)))
+	}
+	return jTags
+}
+
 func buildLogs(logs []opentracing.LogRecord) []*j.Log {
 	jLogs := make([]*j.Log, 0, len(logs))
 	for _, log := range logs {
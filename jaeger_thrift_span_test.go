@@ -39,6 +39,7 @@ var (
 	someBinary      = []byte("hello")
 	someSlice       = []string{"a"}
 	someSliceString = "[a]"
+	someSliceJSON   = `["a"]`
 )
 
 func TestBuildJaegerThrift(t *testing.T) {
@@ -281,7 +282,7 @@ func TestBuildTags(t *testing.T) {
 		{tag: Tag{key: "k", value: float64(123)}, expected: &j.Tag{Key: "k", VType: j.TagType_DOUBLE, VDouble: &someDouble}},
 		{tag: Tag{key: "k", value: someBool}, expected: &j.Tag{Key: "k", VType: j.TagType_BOOL, VBool: &someBool}},
 		{tag: Tag{key: "k", value: someBinary}, expected: &j.Tag{Key: "k", VType: j.TagType_BINARY, VBinary: someBinary}},
-		{tag: Tag{key: "k", value: someSlice}, expected: &j.Tag{Key: "k", VType: j.TagType_STRING, VStr: &someSliceString}},
+		{tag: Tag{key: "k", value: someSlice}, expected: &j.Tag{Key: "k", VType: j.TagType_STRING, VStr: &someSliceJSON}},
 	}
 	for i, test := range tests {
 		testName := fmt.Sprintf("test-%02d", i)
@@ -291,6 +292,59 @@ func TestBuildTags(t *testing.T) {
 	}
 }
 
+func TestBuildStructuredTags(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{value: []string{"a", "b"}, expected: `["a","b"]`},
+		{value: []int{1, 2, 3}, expected: `[1,2,3]`},
+		{value: [2]bool{true, false}, expected: `[true,false]`},
+		{value: map[string]int{"b": 2, "a": 1}, expected: `{"a":1,"b":2}`},
+		{value: map[string]interface{}{"k": []string{"v"}}, expected: `{"k":["v"]}`},
+		{value: struct{ A int }{A: 1}, expected: "{A:1}"},
+	}
+	for _, test := range tests {
+		actual := buildTag(&Tag{key: "k", value: test.value}, DefaultMaxTagValueLength)
+		assert.Equal(t, j.TagType_STRING, actual.VType)
+		require.NotNil(t, actual.VStr)
+		assert.Equal(t, test.expected, *actual.VStr)
+	}
+
+	// the length limit is applied after encoding
+	actual := buildTag(&Tag{key: "k", value: []string{"abc", "def"}}, 5)
+	assert.Equal(t, `["abc`, *actual.VStr)
+}
+
+func TestMaxTagValueLengthByKey(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.MaxTagValueLength(5),
+		TracerOptions.MaxTagValueLengthByKey(map[string]int{"db.statement": 6}),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("s1").(*Span)
+	sp.SetTag("db.statement", "SELECT *")
+	sp.SetTag("other", "abcdefg")
+	sp.SetTag("short", "abcde")
+
+	jSpan := BuildJaegerThrift(sp)
+	assert.Equal(t, "SELECT", *findJaegerTag("db.statement", jSpan.Tags).VStr)
+	assert.Equal(t, "abcde", *findJaegerTag("other", jSpan.Tags).VStr)
+	assert.Equal(t, "abcde", *findJaegerTag("short", jSpan.Tags).VStr)
+	assert.Equal(t, []string{
+		"1 tag values truncated to 5 characters",
+		"1 tag values truncated to 6 characters",
+	}, sp.Warnings())
+
+	zSpan := BuildZipkinThrift(sp)
+	for _, anno := range zSpan.BinaryAnnotations {
+		if anno.Key == "db.statement" {
+			assert.Equal(t, "SELECT", string(anno.Value))
+		}
+	}
+}
+
 func TestBuildReferences(t *testing.T) {
 	references := []Reference{
 		{Type: opentracing.ChildOfRef, Context: SpanContext{traceID: TraceID{High: 1, Low: 1}, spanID: SpanID(1)}},
@@ -0,0 +1,63 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"path"
+	"strings"
+)
+
+// keyMatcher matches the keys of tags or baggage items, by exact key or by glob pattern.
+type keyMatcher struct {
+	keys     map[string]struct{}
+	patterns []string
+}
+
+// newKeyMatcher creates a keyMatcher for the given keys, which are glob patterns in the syntax
+// of path.Match if they contain any of the characters *?[ or \. It returns nil if there are no keys.
+func newKeyMatcher(keys []string) (*keyMatcher, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	r := &keyMatcher{keys: make(map[string]struct{})}
+	for _, key := range keys {
+		if !strings.ContainsAny(key, `*?[\`) {
+			r.keys[key] = struct{}{}
+			continue
+		}
+		if _, err := path.Match(key, ""); err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, key)
+	}
+	return r, nil
+}
+
+// matches returns true if the key matches any of the keys or patterns of the matcher.
+// A nil matcher matches no keys.
+func (r *keyMatcher) matches(key string) bool {
+	if r == nil {
+		return false
+	}
+	if _, ok := r.keys[key]; ok {
+		return true
+	}
+	for _, pattern := range r.patterns {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyMatcher(t *testing.T) {
+	matcher, err := newKeyMatcher(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, matcher)
+	assert.False(t, matcher.matches("password"))
+
+	matcher, err = newKeyMatcher([]string{"password", "*.token", "key?"})
+	assert.NoError(t, err)
+	assert.True(t, matcher.matches("password"))
+	assert.True(t, matcher.matches("auth.token"))
+	assert.True(t, matcher.matches("key1"))
+	assert.False(t, matcher.matches("key12"))
+	assert.False(t, matcher.matches("passwords"))
+}
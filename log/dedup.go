@@ -0,0 +1,215 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxDeduplicatedMessages is the maximum number of distinct messages tracked by a DeduplicatingLogger
+// in an interval. The messages that do not fit are logged without deduplication.
+const maxDeduplicatedMessages = 1000
+
+// DeduplicatingLogger is a DebugLogger that rate-limits identical messages: the first occurrence of
+// a message is logged right away, and the identical messages that follow within the interval are
+// suppressed. At the end of the interval a single line, e.g. "suppressed 1200 similar messages in
+// the last 1m0s: <message>", is logged for each suppressed message, so that an outage of the agent
+// does not flood the application logs.
+//
+// The logger is safe for concurrent use. Close must be called to stop it and log the summaries
+// of the messages suppressed since the last interval.
+type DeduplicatingLogger struct {
+	logger   DebugLogger
+	interval time.Duration
+
+	lock     sync.Mutex
+	messages map[dedupKey]*dedupEntry
+	seen     uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  sync.WaitGroup
+
+	timeNow func() time.Time
+}
+
+type dedupLevel int
+
+const (
+	dedupError dedupLevel = iota
+	dedupInfo
+	dedupDebug
+)
+
+type dedupKey struct {
+	level   dedupLevel
+	message string
+}
+
+type dedupEntry struct {
+	// seq orders the messages by when they were first seen, so that the summaries are logged in that order
+	seq uint64
+	// first is when the message was last logged
+	first time.Time
+	// suppressed is the number of identical messages suppressed since then
+	suppressed int
+}
+
+// NewDeduplicatingLogger creates a DeduplicatingLogger that logs to the given logger, and starts
+// logging the summaries of the suppressed messages every interval, which must be greater than zero.
+func NewDeduplicatingLogger(logger Logger, interval time.Duration) *DeduplicatingLogger {
+	if logger == nil {
+		logger = NullLogger
+	}
+	l := &DeduplicatingLogger{
+		logger:   DebugLogAdapter(logger),
+		interval: interval,
+		messages: make(map[dedupKey]*dedupEntry),
+		stop:     make(chan struct{}),
+		timeNow:  time.Now,
+	}
+	l.stopped.Add(1)
+	go l.run()
+	return l
+}
+
+func (l *DeduplicatingLogger) run() {
+	defer l.stopped.Done()
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.logSummaries(false)
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Close stops the logger and logs the summaries of the suppressed messages, if any.
+func (l *DeduplicatingLogger) Close() error {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+		l.stopped.Wait()
+		l.logSummaries(true)
+	})
+	return nil
+}
+
+// Error implements Logger.
+func (l *DeduplicatingLogger) Error(msg string) {
+	if l.allow(dedupError, msg) {
+		l.logger.Error(msg)
+	}
+}
+
+// ErrorFields implements FieldsLogger. The messages are identical if their errors are identical too;
+// the other fields, e.g. the number of spans, vary between the occurrences and are only logged with
+// the first one.
+func (l *DeduplicatingLogger) ErrorFields(msg string, fields ...Field) {
+	if l.allow(dedupError, FormatFields(msg, errorFields(fields)...)) {
+		ErrorWithFields(l.logger, msg, fields...)
+	}
+}
+
+// errorFields returns the fields with the "error" key.
+func errorFields(fields []Field) []Field {
+	var errs []Field
+	for _, field := range fields {
+		if field.Key == "error" {
+			errs = append(errs, field)
+		}
+	}
+	return errs
+}
+
+// Infof implements Logger.
+func (l *DeduplicatingLogger) Infof(msg string, args ...interface{}) {
+	if message := fmt.Sprintf(msg, args...); l.allow(dedupInfo, message) {
+		l.logger.Infof("%s", message)
+	}
+}
+
+// Debugf implements DebugLogger.
+func (l *DeduplicatingLogger) Debugf(msg string, args ...interface{}) {
+	if message := fmt.Sprintf(msg, args...); l.allow(dedupDebug, message) {
+		l.logger.Debugf("%s", message)
+	}
+}
+
+// allow returns true if the message must be logged, or counts it as suppressed otherwise.
+func (l *DeduplicatingLogger) allow(level dedupLevel, message string) bool {
+	key := dedupKey{level: level, message: message}
+	now := l.timeNow()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if entry, ok := l.messages[key]; ok && now.Sub(entry.first) < l.interval {
+		entry.suppressed++
+		return false
+	} else if ok {
+		l.logSummary(key, entry, now)
+		entry.first, entry.suppressed = now, 0
+		return true
+	}
+	if len(l.messages) < maxDeduplicatedMessages {
+		l.seen++
+		l.messages[key] = &dedupEntry{seq: l.seen, first: now}
+	}
+	return true
+}
+
+// logSummaries logs the summaries of the messages suppressed in the elapsed intervals, or in all
+// the intervals if all is true, and forgets the messages that were not repeated. The summaries are
+// logged in the order in which the messages were first seen.
+func (l *DeduplicatingLogger) logSummaries(all bool) {
+	now := l.timeNow()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var keys []dedupKey
+	for key, entry := range l.messages {
+		if all || now.Sub(entry.first) >= l.interval {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return l.messages[keys[i]].seq < l.messages[keys[j]].seq
+	})
+	for _, key := range keys {
+		l.logSummary(key, l.messages[key], now)
+		delete(l.messages, key)
+	}
+}
+
+// logSummary logs the number of identical messages suppressed since the message was last logged.
+// It must be called while holding the lock.
+func (l *DeduplicatingLogger) logSummary(key dedupKey, entry *dedupEntry, now time.Time) {
+	if entry.suppressed == 0 {
+		return
+	}
+	summary := fmt.Sprintf("suppressed %d similar messages in the last %s: %s",
+		entry.suppressed, now.Sub(entry.first).Round(time.Second), key.message)
+	switch key.level {
+	case dedupError:
+		l.logger.Error(summary)
+	case dedupInfo:
+		l.logger.Infof("%s", summary)
+	default:
+		l.logger.Debugf("%s", summary)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeduplicatingLogger(t *testing.T) {
+	bbLogger := &BytesBufferLogger{}
+	logger := NewDeduplicatingLogger(bbLogger, time.Hour)
+	now := time.Unix(1000, 0)
+	logger.timeNow = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		logger.Error("agent unreachable")
+		logger.Infof("Hi %s", "there")
+		logger.Debugf("Hi %d", i)
+	}
+	assert.Equal(t,
+		"ERROR: agent unreachable\nINFO: Hi there\nDEBUG: Hi 0\nDEBUG: Hi 1\nDEBUG: Hi 2\n",
+		bbLogger.String())
+
+	// the interval has not elapsed yet
+	bbLogger.Flush()
+	logger.logSummaries(false)
+	assert.Empty(t, bbLogger.String())
+
+	// the message is logged again once the interval has elapsed, after the summary
+	now = now.Add(time.Hour)
+	logger.Error("agent unreachable")
+	logger.Error("agent unreachable")
+	assert.Equal(t,
+		"ERROR: suppressed 2 similar messages in the last 1h0m0s: agent unreachable\nERROR: agent unreachable\n",
+		bbLogger.String())
+
+	bbLogger.Flush()
+	now = now.Add(time.Minute)
+	assert.NoError(t, logger.Close())
+	assert.NoError(t, logger.Close())
+	assert.Equal(t,
+		"ERROR: suppressed 1 similar messages in the last 1m0s: agent unreachable\n"+
+			"INFO: suppressed 2 similar messages in the last 1h1m0s: Hi there\n",
+		bbLogger.String())
+	assert.Empty(t, logger.messages)
+}
+
+func TestDeduplicatingLoggerPeriodicSummary(t *testing.T) {
+	bbLogger := &BytesBufferLogger{}
+	logger := NewDeduplicatingLogger(bbLogger, 10*time.Millisecond)
+	defer logger.Close()
+
+	logger.Error("agent unreachable")
+	logger.Error("agent unreachable")
+	for i := 0; i < 100; i++ {
+		if strings.Contains(bbLogger.String(), "suppressed 1 similar messages") {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.Contains(t, bbLogger.String(), "ERROR: suppressed 1 similar messages in the last")
+}
+
+func TestDeduplicatingLoggerMaxMessages(t *testing.T) {
+	logger := NewDeduplicatingLogger(NullLogger, time.Hour)
+	defer logger.Close()
+	for i := 0; i < maxDeduplicatedMessages+10; i++ {
+		logger.Error(fmt.Sprintf("message %d", i))
+	}
+	assert.Len(t, logger.messages, maxDeduplicatedMessages)
+}
+
+func TestDeduplicatingLoggerSummaryOrder(t *testing.T) {
+	bbLogger := &BytesBufferLogger{}
+	logger := NewDeduplicatingLogger(bbLogger, time.Hour)
+	now := time.Unix(1000, 0)
+	logger.timeNow = func() time.Time { return now }
+
+	var expected string
+	for i := 0; i < 20; i++ {
+		message := fmt.Sprintf("message %d", i)
+		logger.Error(message)
+		logger.Error(message)
+		expected += "ERROR: suppressed 1 similar messages in the last 0s: " + message + "\n"
+	}
+	bbLogger.Flush()
+	assert.NoError(t, logger.Close())
+	assert.Equal(t, expected, bbLogger.String())
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field is a key-value pair that adds machine-parseable context, e.g. the endpoint or the number
+// of spans, to a message logged with ErrorWithFields.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String creates a Field with a string value.
+func String(key string, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Int creates a Field with an int value.
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Bool creates a Field with a bool value.
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Duration creates a Field with a time.Duration value.
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Err creates a Field with the "error" key and the message of the error as its value.
+func Err(err error) Field {
+	return Field{Key: "error", Value: err.Error()}
+}
+
+// StructuredLogger is the interface of structured logging libraries, which log key-value fields
+// rather than formatted messages. Use StructuredLogAdapter to pass it to the tracer.
+type StructuredLogger interface {
+	// Error logs a message with the fields at error priority
+	Error(msg string, fields ...Field)
+
+	// Info logs a message with the fields at info priority
+	Info(msg string, fields ...Field)
+}
+
+// FieldsLogger is a Logger that can also log a message with structured fields. If the logger of
+// the tracer implements FieldsLogger, the tracer and its components log their errors with fields
+// instead of concatenating the context into the message.
+type FieldsLogger interface {
+	Logger
+
+	// ErrorFields logs a message with the fields at error priority
+	ErrorFields(msg string, fields ...Field)
+}
+
+// ErrorWithFields logs a message with the fields at error priority. If the logger does not implement
+// FieldsLogger, the fields are appended to the message as key=value pairs.
+func ErrorWithFields(logger Logger, msg string, fields ...Field) {
+	if fieldsLogger, ok := logger.(FieldsLogger); ok {
+		fieldsLogger.ErrorFields(msg, fields...)
+		return
+	}
+	logger.Error(FormatFields(msg, fields...))
+}
+
+// FormatFields returns the message followed by the fields as key=value pairs, e.g.
+// `error when flushing the buffer spans=3 error="connection refused"`. The values are quoted
+// if they are empty or contain spaces, quotes or equal signs.
+func FormatFields(msg string, fields ...Field) string {
+	if len(fields) == 0 {
+		return msg
+	}
+	var buf bytes.Buffer
+	buf.WriteString(msg)
+	for _, field := range fields {
+		value := fmt.Sprint(field.Value)
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(field.Key)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+	}
+	return buf.String()
+}
+
+// StructuredLogAdapter returns a FieldsLogger that logs to the StructuredLogger.
+func StructuredLogAdapter(logger StructuredLogger) FieldsLogger {
+	return structuredLogAdapter{logger: logger}
+}
+
+type structuredLogAdapter struct {
+	logger StructuredLogger
+}
+
+func (l structuredLogAdapter) Error(msg string) {
+	l.logger.Error(msg)
+}
+
+func (l structuredLogAdapter) Infof(msg string, args ...interface{}) {
+	l.logger.Info(fmt.Sprintf(msg, args...))
+}
+
+func (l structuredLogAdapter) ErrorFields(msg string, fields ...Field) {
+	l.logger.Error(msg, fields...)
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStructuredLogger struct {
+	lines []string
+}
+
+func (l *fakeStructuredLogger) Error(msg string, fields ...Field) {
+	l.lines = append(l.lines, fmt.Sprintf("error: %s %v", msg, fields))
+}
+
+func (l *fakeStructuredLogger) Info(msg string, fields ...Field) {
+	l.lines = append(l.lines, fmt.Sprintf("info: %s %v", msg, fields))
+}
+
+func TestFormatFields(t *testing.T) {
+	assert.Equal(t, "message", FormatFields("message"))
+	assert.Equal(t,
+		`error when flushing the buffer endpoint=localhost:6831 spans=3 interval=1m0s empty="" error="connection refused"`,
+		FormatFields("error when flushing the buffer",
+			String("endpoint", "localhost:6831"),
+			Int("spans", 3),
+			Duration("interval", time.Minute),
+			String("empty", ""),
+			Err(errors.New("connection refused"))))
+	assert.Equal(t, `message quoted="a=\"b\""`, FormatFields("message", String("quoted", `a="b"`)))
+}
+
+func TestErrorWithFields(t *testing.T) {
+	bbLogger := &BytesBufferLogger{}
+	ErrorWithFields(bbLogger, "error reporting span", String("operation", "sp1"), Int("spans", 1))
+	assert.Equal(t, "ERROR: error reporting span operation=sp1 spans=1\n", bbLogger.String())
+
+	structuredLogger := &fakeStructuredLogger{}
+	logger := StructuredLogAdapter(structuredLogger)
+	ErrorWithFields(logger, "error reporting span", String("operation", "sp1"), Int("spans", 1))
+	logger.Error("Bad wolf")
+	logger.Infof("Hi %s", "there")
+	assert.Equal(t, []string{
+		"error: error reporting span [{operation sp1} {spans 1}]",
+		"error: Bad wolf []",
+		"info: Hi there []",
+	}, structuredLogger.lines)
+}
+
+func TestErrorWithFieldsWrappers(t *testing.T) {
+	structuredLogger := &fakeStructuredLogger{}
+	leveledLogger := NewLeveledLogger(StructuredLogAdapter(structuredLogger), InfoLevel)
+	ErrorWithFields(leveledLogger, "message", Int("spans", 1))
+
+	dedupLogger := NewDeduplicatingLogger(leveledLogger, time.Hour)
+	defer dedupLogger.Close()
+	ErrorWithFields(dedupLogger, "message", Int("spans", 2), Err(errors.New("refused")))
+	ErrorWithFields(dedupLogger, "message", Int("spans", 2), Err(errors.New("refused")))
+	ErrorWithFields(dedupLogger, "message", Int("spans", 3), Err(errors.New("refused")))
+	ErrorWithFields(dedupLogger, "message", Int("spans", 3), Err(errors.New("timeout")))
+	assert.Equal(t, []string{
+		"error: message [{spans 1}]",
+		"error: message [{spans 2} {error refused}]",
+		"error: message [{spans 3} {error timeout}]",
+	}, structuredLogger.lines)
+	dedupLogger.Close()
+	assert.Equal(t, "error: suppressed 2 similar messages in the last 0s: message error=refused []",
+		structuredLogger.lines[3])
+
+	leveledLogger.SetLevel(Level(2))
+	ErrorWithFields(leveledLogger, "message", Int("spans", 4))
+	assert.Len(t, structuredLogger.lines, 4)
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// DebugLogger is a Logger that can also log messages at debug priority. The tracer and its components
+// log verbose diagnostics, e.g. sampling strategy updates and emitted batches, only to DebugLoggers.
+type DebugLogger interface {
+	Logger
+
+	// Debugf logs a message at debug priority
+	Debugf(msg string, args ...interface{})
+}
+
+// DebugLogAdapter returns the logger as a DebugLogger. If the logger does not implement DebugLogger,
+// the returned logger discards the debug messages.
+func DebugLogAdapter(logger Logger) DebugLogger {
+	if logger == nil {
+		return nil
+	}
+	if debugLogger, ok := logger.(DebugLogger); ok {
+		return debugLogger
+	}
+	return debugDisabledLogAdapter{Logger: logger}
+}
+
+type debugDisabledLogAdapter struct {
+	Logger
+}
+
+func (debugDisabledLogAdapter) Debugf(msg string, args ...interface{}) {}
+
+// Level is the minimum priority of the messages logged by a LeveledLogger.
+type Level int32
+
+const (
+	// DebugLevel logs all messages.
+	DebugLevel Level = iota - 1
+	// InfoLevel logs the messages at info and error priority.
+	InfoLevel
+	// ErrorLevel logs the messages at error priority.
+	ErrorLevel
+)
+
+// String returns the name of the level.
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case ErrorLevel:
+		return "error"
+	}
+	return fmt.Sprintf("Level(%d)", int32(l))
+}
+
+// LeveledLogger is a DebugLogger that only passes the messages at or above its level to the underlying
+// Logger. The level can be changed at any time, e.g. to turn on the debug messages temporarily.
+// The debug messages are logged with Debugf if the underlying Logger is a DebugLogger, and with
+// Infof and a "DEBUG: " prefix otherwise.
+type LeveledLogger struct {
+	logger Logger
+	level  int32
+}
+
+// NewLeveledLogger creates a LeveledLogger that logs to the given logger the messages at or above the level.
+func NewLeveledLogger(logger Logger, level Level) *LeveledLogger {
+	return &LeveledLogger{logger: logger, level: int32(level)}
+}
+
+// Level returns the current level of the logger.
+func (l *LeveledLogger) Level() Level {
+	return Level(atomic.LoadInt32(&l.level))
+}
+
+// SetLevel changes the level of the logger.
+func (l *LeveledLogger) SetLevel(level Level) {
+	atomic.StoreInt32(&l.level, int32(level))
+}
+
+// Enabled returns true if the messages at the given level are logged.
+func (l *LeveledLogger) Enabled(level Level) bool {
+	return level >= l.Level()
+}
+
+// Error implements Logger.
+func (l *LeveledLogger) Error(msg string) {
+	if l.Enabled(ErrorLevel) {
+		l.logger.Error(msg)
+	}
+}
+
+// ErrorFields implements FieldsLogger.
+func (l *LeveledLogger) ErrorFields(msg string, fields ...Field) {
+	if l.Enabled(ErrorLevel) {
+		ErrorWithFields(l.logger, msg, fields...)
+	}
+}
+
+// Infof implements Logger.
+func (l *LeveledLogger) Infof(msg string, args ...interface{}) {
+	if l.Enabled(InfoLevel) {
+		l.logger.Infof(msg, args...)
+	}
+}
+
+// Debugf implements DebugLogger.
+func (l *LeveledLogger) Debugf(msg string, args ...interface{}) {
+	if !l.Enabled(DebugLevel) {
+		return
+	}
+	if debugLogger, ok := l.logger.(DebugLogger); ok {
+		debugLogger.Debugf(msg, args...)
+	} else {
+		l.logger.Infof("DEBUG: "+msg, args...)
+	}
+}
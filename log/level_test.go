@@ -0,0 +1,83 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// infoOnlyLogger hides the Debugf method of the wrapped logger
+type infoOnlyLogger struct {
+	Logger
+}
+
+func TestDebugLogAdapter(t *testing.T) {
+	assert.Nil(t, DebugLogAdapter(nil))
+
+	bbLogger := &BytesBufferLogger{}
+	assert.Equal(t, bbLogger, DebugLogAdapter(bbLogger))
+	DebugLogAdapter(bbLogger).Debugf("Hi %s", "there")
+	assert.Equal(t, "DEBUG: Hi there\n", bbLogger.String())
+
+	bbLogger.Flush()
+	debugLogger := DebugLogAdapter(infoOnlyLogger{bbLogger})
+	debugLogger.Debugf("Hi %s", "there")
+	debugLogger.Infof("Hi")
+	assert.Equal(t, "INFO: Hi\n", bbLogger.String())
+
+	DebugLogAdapter(StdLogger).Debugf("Hi %s", "there")
+}
+
+func TestLeveledLogger(t *testing.T) {
+	bbLogger := &BytesBufferLogger{}
+	logger := NewLeveledLogger(bbLogger, InfoLevel)
+	assert.Equal(t, InfoLevel, logger.Level())
+	assert.False(t, logger.Enabled(DebugLevel))
+	assert.True(t, logger.Enabled(ErrorLevel))
+
+	log := func() {
+		logger.Debugf("Hi %d", 1)
+		logger.Infof("Hi %d", 2)
+		logger.Error("Bad wolf")
+	}
+	log()
+	assert.Equal(t, "INFO: Hi 2\nERROR: Bad wolf\n", bbLogger.String())
+
+	bbLogger.Flush()
+	logger.SetLevel(DebugLevel)
+	log()
+	assert.Equal(t, "DEBUG: Hi 1\nINFO: Hi 2\nERROR: Bad wolf\n", bbLogger.String())
+
+	bbLogger.Flush()
+	logger.SetLevel(ErrorLevel)
+	log()
+	assert.Equal(t, "ERROR: Bad wolf\n", bbLogger.String())
+}
+
+func TestLeveledLoggerWithoutDebugf(t *testing.T) {
+	bbLogger := &BytesBufferLogger{}
+	logger := NewLeveledLogger(infoOnlyLogger{bbLogger}, DebugLevel)
+	logger.Debugf("Hi %s", "there")
+	assert.Equal(t, "INFO: DEBUG: Hi there\n", bbLogger.String())
+}
+
+func TestLevelString(t *testing.T) {
+	assert.Equal(t, "debug", DebugLevel.String())
+	assert.Equal(t, "info", InfoLevel.String())
+	assert.Equal(t, "error", ErrorLevel.String())
+	assert.Equal(t, "Level(5)", Level(5).String())
+}
@@ -33,7 +33,8 @@ type Logger interface {
 	Infof(msg string, args ...interface{})
 }
 
-// StdLogger is implementation of the Logger interface that delegates to default `log` package
+// StdLogger is implementation of the Logger interface that delegates to default `log` package.
+// It does not log debug messages, see NewLeveledLogger to enable them.
 var StdLogger = &stdLogger{}
 
 type stdLogger struct{}
@@ -52,8 +53,9 @@ var NullLogger = &nullLogger{}
 
 type nullLogger struct{}
 
-func (l *nullLogger) Error(msg string)                      {}
-func (l *nullLogger) Infof(msg string, args ...interface{}) {}
+func (l *nullLogger) Error(msg string)                       {}
+func (l *nullLogger) Infof(msg string, args ...interface{})  {}
+func (l *nullLogger) Debugf(msg string, args ...interface{}) {}
 
 // BytesBufferLogger implements Logger backed by a bytes.Buffer.
 type BytesBufferLogger struct {
@@ -75,6 +77,13 @@ func (l *BytesBufferLogger) Infof(msg string, args ...interface{}) {
 	l.mux.Unlock()
 }
 
+// Debugf implements DebugLogger.
+func (l *BytesBufferLogger) Debugf(msg string, args ...interface{}) {
+	l.mux.Lock()
+	l.buf.WriteString("DEBUG: " + fmt.Sprintf(msg, args...) + "\n")
+	l.mux.Unlock()
+}
+
 // String returns string representation of the underlying buffer.
 func (l *BytesBufferLogger) String() string {
 	l.mux.Lock()
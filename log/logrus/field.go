@@ -0,0 +1,72 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrus
+
+import (
+	"context"
+
+	"github.com/uber/jaeger-client-go"
+)
+
+// TraceFields returns the trace_id, span_id and sampled fields of the span in the context, so that
+// the logs can be joined with the traces, see jaeger.TraceLogFields. The result can be passed to
+// WithFields of a logrus Logger or Entry, e.g. logger.WithFields(TraceFields(ctx)).Info("message").
+// It returns nil if the context does not contain a Jaeger span.
+func TraceFields(ctx context.Context) map[string]interface{} {
+	fields := jaeger.TraceLogFieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return nil
+	}
+	logrusFields := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		logrusFields[field.Key] = field.Value
+	}
+	return logrusFields
+}
+
+// Hook adds the trace fields of the span in the context of the log entries, see TraceFields,
+// so that the logs are correlated without calling WithFields explicitly. The fields already
+// set on an entry are kept.
+//
+// The package does not import logrus, so Hook cannot implement logrus.Hook itself;
+// it is registered with a small adapter passing the Context and Data of the entry:
+//
+//	type traceHook struct{ *jaegerlogrus.Hook }
+//
+//	func (h traceHook) Levels() []logrus.Level { return logrus.AllLevels }
+//
+//	func (h traceHook) Fire(entry *logrus.Entry) error { return h.Hook.Fire(entry.Context, entry.Data) }
+//
+//	logger.AddHook(traceHook{jaegerlogrus.NewHook()})
+type Hook struct{}
+
+// NewHook creates a new Hook.
+func NewHook() *Hook {
+	return &Hook{}
+}
+
+// Fire adds the trace fields of the span in the context to the data of a log entry.
+// It is a no-op if the context is nil or does not contain a Jaeger span.
+func (h *Hook) Fire(ctx context.Context, data map[string]interface{}) error {
+	if ctx == nil || data == nil {
+		return nil
+	}
+	for key, value := range TraceFields(ctx) {
+		if _, ok := data[key]; !ok {
+			data[key] = value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/jaeger-client-go"
+)
+
+func TestTraceFields(t *testing.T) {
+	assert.Nil(t, TraceFields(nil))
+	assert.Nil(t, TraceFields(context.Background()))
+
+	tracer, closer := jaeger.NewTracer("serviceName", jaeger.NewConstSampler(false), jaeger.NewNullReporter())
+	defer closer.Close()
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	sc := span.Context().(jaeger.SpanContext)
+
+	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	assert.Equal(t, map[string]interface{}{
+		"trace_id": sc.TraceID().String(),
+		"span_id":  sc.SpanID().String(),
+		"sampled":  false,
+	}, TraceFields(ctx))
+}
+
+// fields has the same underlying type as logrus.Fields, the type of Entry.Data
+type fields map[string]interface{}
+
+func TestHook(t *testing.T) {
+	hook := NewHook()
+	data := fields{"msg": "hello"}
+	assert.NoError(t, hook.Fire(nil, data))
+	assert.NoError(t, hook.Fire(context.Background(), data))
+	assert.Equal(t, fields{"msg": "hello"}, data)
+
+	tracer, closer := jaeger.NewTracer("serviceName", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	sc := span.Context().(jaeger.SpanContext)
+
+	ctx := opentracing.ContextWithSpan(context.Background(), span)
+	data["span_id"] = "explicit"
+	assert.NoError(t, hook.Fire(ctx, data))
+	assert.Equal(t, fields{
+		"msg":      "hello",
+		"trace_id": sc.TraceID().String(),
+		"span_id":  "explicit",
+		"sampled":  true,
+	}, data, "the fields set on the entry are kept")
+}
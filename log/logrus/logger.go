@@ -0,0 +1,54 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logrus provides an adapter from a logrus logger to the jaeger-client-go Logger.
+//
+// The package does not import logrus itself: *logrus.Logger and *logrus.Entry both implement
+// the FieldLogger interface below, so that the tracer does not depend on a particular version of logrus.
+package logrus
+
+// FieldLogger is the subset of the logrus.FieldLogger interface used by the adapter.
+// It is implemented by *logrus.Logger and *logrus.Entry.
+type FieldLogger interface {
+	Error(args ...interface{})
+	Infof(format string, args ...interface{})
+	Debugf(format string, args ...interface{})
+}
+
+// Logger is an adapter from logrus Logger to jaeger-lib Logger.
+// It also implements log.DebugLogger, the debug messages are logged if logrus is at debug level.
+type Logger struct {
+	logger FieldLogger
+}
+
+// NewLogger creates a new Logger.
+func NewLogger(logger FieldLogger) *Logger {
+	return &Logger{logger: logger}
+}
+
+// Error logs a message at error priority
+func (l *Logger) Error(msg string) {
+	// Error rather than Errorf: the message is not a format string and may contain '%'
+	l.logger.Error(msg)
+}
+
+// Infof logs a message at info priority
+func (l *Logger) Infof(msg string, args ...interface{}) {
+	l.logger.Infof(msg, args...)
+}
+
+// Debugf logs a message at debug priority
+func (l *Logger) Debugf(msg string, args ...interface{}) {
+	l.logger.Debugf(msg, args...)
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logrus
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+// fakeLogrus records the calls in the same way as logrus, where only the *f methods format the message
+type fakeLogrus struct {
+	lines []string
+}
+
+func (l *fakeLogrus) Error(args ...interface{}) {
+	l.lines = append(l.lines, "error: "+fmt.Sprint(args...))
+}
+
+func (l *fakeLogrus) Infof(format string, args ...interface{}) {
+	l.lines = append(l.lines, "info: "+fmt.Sprintf(format, args...))
+}
+
+func (l *fakeLogrus) Debugf(format string, args ...interface{}) {
+	l.lines = append(l.lines, "debug: "+fmt.Sprintf(format, args...))
+}
+
+func TestLogger(t *testing.T) {
+	fake := &fakeLogrus{}
+	var logger log.DebugLogger = NewLogger(fake)
+	logger.Infof("Hi %s %d", "there", 5)
+	logger.Error("Bad wolf 100%")
+	logger.Debugf("Hi %s", "there")
+	assert.Equal(t, []string{"info: Hi there 5", "error: Bad wolf 100%", "debug: Hi there"}, fake.lines)
+}
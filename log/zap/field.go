@@ -38,6 +38,26 @@ func Trace(ctx context.Context) zapcore.Field {
 	return zap.Object("trace", trace{ctx})
 }
 
+// TraceFields returns the trace_id, span_id and sampled fields of the span in the context, so that
+// the logs can be joined with the traces, see jaeger.TraceLogFields. Unlike Trace, the fields are
+// not nested under a "trace" key. It returns nil if the context does not contain a Jaeger span.
+func TraceFields(ctx context.Context) []zapcore.Field {
+	fields := jaeger.TraceLogFieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return nil
+	}
+	zapFields := make([]zapcore.Field, 0, len(fields))
+	for _, field := range fields {
+		zapFields = append(zapFields, zap.Any(field.Key, field.Value))
+	}
+	return zapFields
+}
+
+// WithTrace returns a child logger that adds the TraceFields of the context to all its messages.
+func WithTrace(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	return logger.With(TraceFields(ctx)...)
+}
+
 type trace struct {
 	ctx context.Context
 }
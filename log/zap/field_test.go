@@ -15,6 +15,7 @@
 package zap
 
 import (
+	"bytes"
 	"context"
 	"testing"
 
@@ -53,6 +54,30 @@ func TestTraceField(t *testing.T) {
 	})
 }
 
+func TestTraceFields(t *testing.T) {
+	assert.Nil(t, TraceFields(nil))
+	assert.Nil(t, TraceFields(context.Background()))
+
+	withTracedContext(func(ctx context.Context) {
+		sc := opentracing.SpanFromContext(ctx).Context().(jaeger.SpanContext)
+		enc := zapcore.NewMapObjectEncoder()
+		for _, field := range TraceFields(ctx) {
+			field.AddTo(enc)
+		}
+		assert.Equal(t, map[string]interface{}{
+			"trace_id": sc.TraceID().String(),
+			"span_id":  sc.SpanID().String(),
+			"sampled":  true,
+		}, enc.Fields)
+
+		buf := &bytes.Buffer{}
+		encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+		logger := WithTrace(ctx, zap.New(zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.InfoLevel)))
+		logger.Info("Hi")
+		assert.Contains(t, buf.String(), `"trace_id":"`+sc.TraceID().String()+`"`)
+	})
+}
+
 func withTracedContext(f func(ctx context.Context)) {
 	tracer, closer := jaeger.NewTracer(
 		"serviceName", jaeger.NewConstSampler(true), jaeger.NewNullReporter(),
@@ -16,16 +16,20 @@ package zap
 
 import (
 	"go.uber.org/zap"
+
+	"github.com/uber/jaeger-client-go/log"
 )
 
 // Logger is an adapter from zap Logger to jaeger-lib Logger.
+// It also implements log.FieldsLogger and log.DebugLogger, the debug messages are logged if zap is at debug level.
 type Logger struct {
 	logger *zap.SugaredLogger
 }
 
-// NewLogger creates a new Logger.
+// NewLogger creates a new Logger. The caller reported by zap is the caller of the Logger methods
+// rather than the adapter itself.
 func NewLogger(logger *zap.Logger) *Logger {
-	return &Logger{logger: logger.Sugar()}
+	return &Logger{logger: logger.WithOptions(zap.AddCallerSkip(1)).Sugar()}
 }
 
 // Error logs a message at error priority
@@ -33,7 +37,21 @@ func (l *Logger) Error(msg string) {
 	l.logger.Error(msg)
 }
 
+// ErrorFields logs a message with the fields at error priority
+func (l *Logger) ErrorFields(msg string, fields ...log.Field) {
+	keysAndValues := make([]interface{}, 0, 2*len(fields))
+	for _, field := range fields {
+		keysAndValues = append(keysAndValues, field.Key, field.Value)
+	}
+	l.logger.Errorw(msg, keysAndValues...)
+}
+
 // Infof logs a message at info priority
 func (l *Logger) Infof(msg string, args ...interface{}) {
 	l.logger.Infof(msg, args...)
 }
+
+// Debugf logs a message at debug priority
+func (l *Logger) Debugf(msg string, args ...interface{}) {
+	l.logger.Debugf(msg, args...)
+}
@@ -21,6 +21,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/uber/jaeger-client-go/log"
 )
 
 func TestLogger(t *testing.T) {
@@ -32,4 +34,35 @@ func TestLogger(t *testing.T) {
 	buf.Reset()
 	logger.Error("Bad wolf")
 	assert.Equal(t, buf.String(), "Bad wolf\n")
+	buf.Reset()
+	logger.Debugf("Hi %s", "there")
+	assert.Empty(t, buf.String())
+}
+
+func TestLoggerCaller(t *testing.T) {
+	buf := &bytes.Buffer{}
+	encoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+		MessageKey:   "key",
+		CallerKey:    "caller",
+		EncodeCaller: zapcore.ShortCallerEncoder,
+	})
+	logger := NewLogger(zap.New(zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.InfoLevel), zap.AddCaller()))
+	logger.Error("Bad wolf")
+	assert.Contains(t, buf.String(), "zap/logger_test.go")
+}
+
+func TestLoggerErrorFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	encoder := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg"})
+	logger := NewLogger(zap.New(zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.InfoLevel)))
+	log.ErrorWithFields(logger, "error when flushing the buffer", log.Int("spans", 3), log.String("endpoint", "localhost"))
+	assert.Equal(t, `{"msg":"error when flushing the buffer","spans":3,"endpoint":"localhost"}`+"\n", buf.String())
+}
+
+func TestLoggerDebugf(t *testing.T) {
+	buf := &bytes.Buffer{}
+	encoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{MessageKey: "key"})
+	logger := NewLogger(zap.New(zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.DebugLevel)))
+	logger.Debugf("Hi %s %d", "there", 5)
+	assert.Equal(t, buf.String(), "Hi there 5\n")
 }
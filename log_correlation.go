@@ -0,0 +1,65 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+// The keys of the fields returned by TraceLogFields, which correlate application logs with traces.
+const (
+	TraceIDLogField = "trace_id"
+	SpanIDLogField  = "span_id"
+	SampledLogField = "sampled"
+)
+
+// TraceLogFields returns the trace_id, span_id and sampled fields identifying the span, to be added
+// to the application logs so that they can be joined with the traces. It returns nil if the span
+// is nil or is not a valid Jaeger span.
+//
+// Logging does not use the context of the span, so it does not decide a deferred sampling decision
+// or make it final. The sampled field reports the decision at the time of the call, spans with a
+// deferred decision are provisionally sampled.
+func TraceLogFields(span opentracing.Span) []log.Field {
+	if span == nil {
+		return nil
+	}
+	var ctx SpanContext
+	if sp, ok := span.(*Span); ok {
+		ctx = sp.peekContext()
+	} else if ctx, ok = span.Context().(SpanContext); !ok {
+		return nil
+	}
+	if !ctx.IsValid() {
+		return nil
+	}
+	return []log.Field{
+		log.String(TraceIDLogField, ctx.TraceID().String()),
+		log.String(SpanIDLogField, ctx.SpanID().String()),
+		log.Bool(SampledLogField, ctx.IsSampled()),
+	}
+}
+
+// TraceLogFieldsFromContext returns the TraceLogFields of the span in the context, or nil if there is none.
+func TraceLogFieldsFromContext(ctx context.Context) []log.Field {
+	if ctx == nil {
+		return nil
+	}
+	return TraceLogFields(opentracing.SpanFromContext(ctx))
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestTraceLogFields(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+
+	span := tracer.StartSpan("test")
+	defer span.Finish()
+	ctx := span.Context().(SpanContext)
+	expected := []log.Field{
+		{Key: "trace_id", Value: ctx.TraceID().String()},
+		{Key: "span_id", Value: ctx.SpanID().String()},
+		{Key: "sampled", Value: true},
+	}
+	assert.Equal(t, expected, TraceLogFields(span))
+	assert.Equal(t, expected, TraceLogFieldsFromContext(opentracing.ContextWithSpan(context.Background(), span)))
+
+	assert.Nil(t, TraceLogFields(nil))
+	assert.Nil(t, TraceLogFields(opentracing.NoopTracer{}.StartSpan("test")))
+	assert.Nil(t, TraceLogFieldsFromContext(nil))
+	assert.Nil(t, TraceLogFieldsFromContext(context.Background()))
+}
+
+func TestTraceLogFieldsDelayedSampling(t *testing.T) {
+	sampler := &urlSampler{prefix: "/api"}
+	tracer, closer := NewTracer("DOOP", sampler, NewNullReporter(),
+		TracerOptions.DelayedSampling(true),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	defer sp.Finish()
+	fields := TraceLogFields(sp)
+	assert.Len(t, fields, 3)
+	assert.Equal(t, log.Bool(SampledLogField, true), fields[2], "provisionally sampled")
+	assert.True(t, sp.samplingDeferred, "logging does not decide the sampling")
+	assert.False(t, sp.samplingFinalized)
+	assert.Equal(t, 0, sampler.calls)
+
+	ext.HTTPUrl.Set(sp, "/health")
+	assert.Equal(t, 1, sampler.calls)
+	assert.Equal(t, log.Bool(SampledLogField, false), TraceLogFields(sp)[2])
+}
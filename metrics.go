@@ -38,6 +38,9 @@ type Metrics struct {
 	// Number of unsampled spans started by this tracer
 	SpansStartedNotSampled metrics.Counter `metric:"started_spans" tags:"sampled=n" help:"Number of unsampled spans started by this tracer"`
 
+	// Number of unsampled root spans that became sampled after their operation name was changed
+	SpansResampled metrics.Counter `metric:"resampled_spans" help:"Number of unsampled root spans that became sampled after their operation name was changed"`
+
 	// Number of spans finished by this tracer
 	SpansFinished metrics.Counter `metric:"finished_spans" help:"Number of spans finished by this tracer"`
 
@@ -53,9 +56,70 @@ type Metrics struct {
 	// Number of spans dropped due to internal queue overflow
 	ReporterDropped metrics.Counter `metric:"reporter_spans" tags:"result=dropped" help:"Number of spans dropped due to internal queue overflow"`
 
+	// Number of spans dropped because they exceeded the rate limit of the reporter
+	ReporterRateLimited metrics.Counter `metric:"reporter_spans" tags:"result=rate_limited" help:"Number of spans dropped because they exceeded the rate limit of the reporter"`
+
+	// Number of spans dropped because they do not fit into the max packet size of the Sender
+	ReporterSpanTooLarge metrics.Counter `metric:"reporter_spans_too_large" help:"Number of spans dropped because they do not fit into the max packet size of the Sender"`
+
+	// Number of spans not reported because the agent or collector address could not be resolved
+	ReporterFailureResolveError metrics.Counter `metric:"reporter_failures" tags:"cause=resolve_error" help:"Number of spans not reported because the agent or collector address could not be resolved"`
+
+	// Number of spans not reported because sending them timed out
+	ReporterFailureTimeout metrics.Counter `metric:"reporter_failures" tags:"cause=timeout" help:"Number of spans not reported because sending them timed out"`
+
+	// Number of spans not reported because the agent or collector refused the connection
+	ReporterFailureConnectionRefused metrics.Counter `metric:"reporter_failures" tags:"cause=connection_refused" help:"Number of spans not reported because the agent or collector refused the connection"`
+
+	// Number of spans not reported because the batch with them was too large for the agent or collector
+	ReporterFailureBatchTooLarge metrics.Counter `metric:"reporter_failures" tags:"cause=batch_too_large" help:"Number of spans not reported because the batch with them was too large for the agent or collector"`
+
+	// Number of spans not reported because they could not be serialized
+	ReporterFailureSerialization metrics.Counter `metric:"reporter_failures" tags:"cause=serialization" help:"Number of spans not reported because they could not be serialized"`
+
+	// Number of spans not reported due to other Sender failures
+	ReporterFailureOther metrics.Counter `metric:"reporter_failures" tags:"cause=other" help:"Number of spans not reported due to other Sender failures"`
+
+	// Number of sampled spans not reported because they were shorter than the min span duration
+	SpansDroppedTooShort metrics.Counter `metric:"spans_dropped_too_short" help:"Number of sampled spans not reported because they were shorter than the min span duration"`
+
+	// Number of tags not added to spans because the spans reached the max number of tags
+	SpanTagsDropped metrics.Counter `metric:"span_tags_dropped" help:"Number of tags not added to spans because the spans reached the max number of tags"`
+
+	// Number of logs not added to spans because the spans exceeded the max number of logs per second
+	SpanLogsSuppressed metrics.Counter `metric:"span_logs_suppressed" help:"Number of logs not added to spans because the spans exceeded the max number of logs per second"`
+
+	// Number of spans whose logs were removed because the span was too large to be sent
+	ReporterSpanTruncatedLogs metrics.Counter `metric:"reporter_span_truncations" tags:"reason=logs" help:"Number of spans whose logs were removed because the span was too large to be sent"`
+
+	// Number of logs removed from spans that were too large to be sent
+	ReporterTruncatedLogs metrics.Counter `metric:"reporter_truncated_logs" help:"Number of logs removed from spans that were too large to be sent"`
+
+	// Number of spans whose tag values were shortened because the span was too large to be sent
+	ReporterSpanTruncatedTagValues metrics.Counter `metric:"reporter_span_truncations" tags:"reason=tag_values" help:"Number of spans whose tag values were shortened because the span was too large to be sent"`
+
+	// Number of spans whose tags were removed because the span was too large to be sent
+	ReporterSpanTruncatedTags metrics.Counter `metric:"reporter_span_truncations" tags:"reason=tags" help:"Number of spans whose tags were removed because the span was too large to be sent"`
+
 	// Current number of spans in the reporter queue
 	ReporterQueueLength metrics.Gauge `metric:"reporter_queue_length" help:"Current number of spans in the reporter queue"`
 
+	// Number of spans in the last batch emitted by the reporter
+	ReporterBatchSize metrics.Gauge `metric:"reporter_batch_size" help:"Number of spans in the last batch emitted by the reporter"`
+
+	// Serialized size of the last batch emitted by the reporter, if the Sender implements BatchSizer
+	ReporterBatchSizeBytes metrics.Gauge `metric:"reporter_batch_size_bytes" help:"Serialized size in bytes of the last batch emitted by the reporter"`
+
+	// Time from span Finish until the batch with the span is successfully emitted, i.e. the time spent in
+	// the reporter queue and in the buffer of the sender, in seconds
+	ReporterSpanLatency metrics.Histogram `metric:"reporter_span_latency" buckets:"0.01,0.1,0.5,1,2,5,10,30,60" help:"Time in seconds from span Finish until the batch with the span is successfully emitted"`
+
+	// Current number of spans started but not finished, see TracerOptions.TrackInFlightSpans
+	InFlightSpans metrics.Gauge `metric:"in_flight_spans" help:"Current number of spans started but not finished"`
+
+	// Age of the oldest span started but not finished, in milliseconds
+	InFlightSpansOldestAge metrics.Gauge `metric:"in_flight_spans_oldest_age" help:"Age of the oldest span started but not finished, in milliseconds"`
+
 	// Number of times the Sampler succeeded to retrieve sampling strategy
 	SamplerRetrieved metrics.Counter `metric:"sampler_queries" tags:"result=ok" help:"Number of times the Sampler succeeded to retrieve sampling strategy"`
 
@@ -68,6 +132,9 @@ type Metrics struct {
 	// Number of times the Sampler failed to update sampling strategy
 	SamplerUpdateFailure metrics.Counter `metric:"sampler_updates" tags:"result=err" help:"Number of times the Sampler failed to update sampling strategy"`
 
+	// Time of the last successful update of the sampling strategy, in seconds since the epoch
+	SamplerLastUpdate metrics.Gauge `metric:"sampler_last_update_timestamp" help:"Time of the last successful update of the sampling strategy, in seconds since the epoch"`
+
 	// Number of times baggage was successfully written or updated on spans.
 	BaggageUpdateSuccess metrics.Counter `metric:"baggage_updates" tags:"result=ok" help:"Number of times baggage was successfully written or updated on spans"`
 
@@ -77,12 +144,18 @@ type Metrics struct {
 	// Number of times baggage was truncated as per baggage restrictions.
 	BaggageTruncate metrics.Counter `metric:"baggage_truncations" help:"Number of times baggage was truncated as per baggage restrictions"`
 
+	// Number of times signed baggage items were dropped because of missing or invalid signatures.
+	BaggageSignatureInvalid metrics.Counter `metric:"baggage_signature_invalid" help:"Number of times signed baggage items were dropped because of missing or invalid signatures"`
+
 	// Number of times baggage restrictions were successfully updated.
 	BaggageRestrictionsUpdateSuccess metrics.Counter `metric:"baggage_restrictions_updates" tags:"result=ok" help:"Number of times baggage restrictions were successfully updated"`
 
 	// Number of times baggage restrictions failed to update.
 	BaggageRestrictionsUpdateFailure metrics.Counter `metric:"baggage_restrictions_updates" tags:"result=err" help:"Number of times baggage restrictions failed to update"`
 
+	// Time of the last successful update of the baggage restrictions, in seconds since the epoch
+	BaggageRestrictionsLastUpdate metrics.Gauge `metric:"baggage_restrictions_last_update_timestamp" help:"Time of the last successful update of the baggage restrictions, in seconds since the epoch"`
+
 	// Number of times debug spans were throttled.
 	ThrottledDebugSpans metrics.Counter `metric:"throttled_debug_spans" help:"Number of times debug spans were throttled"`
 
@@ -91,6 +164,9 @@ type Metrics struct {
 
 	// Number of times throttler failed to update.
 	ThrottlerUpdateFailure metrics.Counter `metric:"throttler_updates" tags:"result=err" help:"Number of times throttler failed to update"`
+
+	// Time of the last successful update of the throttler credits, in seconds since the epoch
+	ThrottlerLastUpdate metrics.Gauge `metric:"throttler_last_update_timestamp" help:"Time of the last successful update of the throttler credits, in seconds since the epoch"`
 }
 
 // NewMetrics creates a new Metrics struct and initializes it.
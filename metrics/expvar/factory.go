@@ -0,0 +1,175 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package expvar provides a metrics.Factory publishing the metrics of the tracer under expvar,
+// which are served by the standard /debug/vars endpoint without any metrics backend, e.g.
+//
+//	cfg.NewTracer(config.Metrics(expvar.NewFactory("jaeger")))
+package expvar
+
+import (
+	"expvar"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+// NewFactory creates a metrics.Factory publishing the metrics as the members of the expvar.Map
+// with the given name, keyed by the names of the metrics and their tags, e.g.
+// "jaeger.tracer.reporter_spans|result=ok". If a variable with the given name is already published,
+// it must be an expvar.Map, which the metrics are added to.
+func NewFactory(name string) metrics.Factory {
+	return &factory{vars: publishedMap(name)}
+}
+
+var publishMux sync.Mutex
+
+func publishedMap(name string) *expvar.Map {
+	publishMux.Lock()
+	defer publishMux.Unlock()
+	if v := expvar.Get(name); v != nil {
+		if m, ok := v.(*expvar.Map); ok {
+			return m
+		}
+		panic(fmt.Sprintf("expvar %q is already published and is not an expvar.Map", name))
+	}
+	return expvar.NewMap(name)
+}
+
+type factory struct {
+	vars      *expvar.Map
+	namespace string
+	tags      map[string]string
+}
+
+func (f *factory) key(name string, tags map[string]string) string {
+	if f.namespace != "" {
+		name = f.namespace + "." + name
+	}
+	return metrics.GetKey(name, f.mergeTags(tags), "|", "=")
+}
+
+func (f *factory) mergeTags(tags map[string]string) map[string]string {
+	merged := make(map[string]string, len(f.tags)+len(tags))
+	for k, v := range f.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return merged
+}
+
+// getOrAdd returns the variable with the given key, so that the metrics created more than once
+// share the same variable.
+func (f *factory) getOrAdd(key string, newVar func() expvar.Var) expvar.Var {
+	publishMux.Lock()
+	defer publishMux.Unlock()
+	if v := f.vars.Get(key); v != nil {
+		return v
+	}
+	v := newVar()
+	f.vars.Set(key, v)
+	return v
+}
+
+func (f *factory) Counter(options metrics.Options) metrics.Counter {
+	v := f.getOrAdd(f.key(options.Name, options.Tags), func() expvar.Var { return new(expvar.Int) })
+	return counter{v.(*expvar.Int)}
+}
+
+func (f *factory) Gauge(options metrics.Options) metrics.Gauge {
+	v := f.getOrAdd(f.key(options.Name, options.Tags), func() expvar.Var { return new(expvar.Int) })
+	return gauge{v.(*expvar.Int)}
+}
+
+func (f *factory) Timer(options metrics.TimerOptions) metrics.Timer {
+	v := f.getOrAdd(f.key(options.Name, options.Tags), func() expvar.Var { return newSummary() })
+	return timer{v.(*summary)}
+}
+
+func (f *factory) Histogram(options metrics.HistogramOptions) metrics.Histogram {
+	v := f.getOrAdd(f.key(options.Name, options.Tags), func() expvar.Var { return newSummary() })
+	return v.(*summary)
+}
+
+func (f *factory) Namespace(scope metrics.NSOptions) metrics.Factory {
+	namespace := scope.Name
+	if f.namespace != "" && namespace != "" {
+		namespace = f.namespace + "." + namespace
+	} else if namespace == "" {
+		namespace = f.namespace
+	}
+	return &factory{vars: f.vars, namespace: namespace, tags: f.mergeTags(scope.Tags)}
+}
+
+type counter struct {
+	v *expvar.Int
+}
+
+func (c counter) Inc(delta int64) {
+	c.v.Add(delta)
+}
+
+type gauge struct {
+	v *expvar.Int
+}
+
+func (g gauge) Update(value int64) {
+	g.v.Set(value)
+}
+
+type timer struct {
+	s *summary
+}
+
+func (t timer) Record(d time.Duration) {
+	t.s.Record(float64(d) / float64(time.Millisecond))
+}
+
+// summary is an expvar.Var summarizing the recorded values, the durations of the timers in milliseconds.
+type summary struct {
+	sync.Mutex
+	count int64
+	sum   float64
+	min   float64
+	max   float64
+}
+
+func newSummary() *summary {
+	return &summary{min: math.Inf(1), max: math.Inf(-1)}
+}
+
+func (s *summary) Record(value float64) {
+	s.Lock()
+	defer s.Unlock()
+	s.count++
+	s.sum += value
+	s.min = math.Min(s.min, value)
+	s.max = math.Max(s.max, value)
+}
+
+// String implements expvar.Var, returning the summary as JSON.
+func (s *summary) String() string {
+	s.Lock()
+	defer s.Unlock()
+	if s.count == 0 {
+		return `{"count":0}`
+	}
+	return fmt.Sprintf(`{"count":%d,"sum":%g,"min":%g,"max":%g,"mean":%g}`,
+		s.count, s.sum, s.min, s.max, s.sum/float64(s.count))
+}
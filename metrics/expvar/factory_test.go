@@ -0,0 +1,112 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/config"
+)
+
+var testVars int64
+
+// uniqueName returns a name that is not published yet, so that the tests can run more than once
+// in the same process, e.g. with -count=2.
+func uniqueName(prefix string) string {
+	return fmt.Sprintf("%s_%d", prefix, atomic.AddInt64(&testVars, 1))
+}
+
+func snapshot(t *testing.T, name string) map[string]interface{} {
+	var vars map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &vars))
+	return vars
+}
+
+func TestFactory(t *testing.T) {
+	name := uniqueName("test_factory")
+	f := NewFactory(name)
+	ns := f.Namespace(metrics.NSOptions{Name: "jaeger", Tags: map[string]string{"lib": "go"}})
+
+	ns.Counter(metrics.Options{Name: "spans", Tags: map[string]string{"result": "ok"}}).Inc(2)
+	ns.Counter(metrics.Options{Name: "spans", Tags: map[string]string{"result": "ok"}}).Inc(3)
+	ns.Gauge(metrics.Options{Name: "queue_length"}).Update(7)
+	timer := ns.Timer(metrics.TimerOptions{Name: "latency"})
+	timer.Record(time.Millisecond)
+	timer.Record(3 * time.Millisecond)
+	ns.Histogram(metrics.HistogramOptions{Name: "size"})
+	f.Namespace(metrics.NSOptions{}).Counter(metrics.Options{Name: "root"}).Inc(1)
+
+	assert.Equal(t, map[string]interface{}{
+		"jaeger.spans|lib=go|result=ok": float64(5),
+		"jaeger.queue_length|lib=go":    float64(7),
+		"jaeger.latency|lib=go": map[string]interface{}{
+			"count": float64(2), "sum": float64(4), "min": float64(1), "max": float64(3), "mean": float64(2),
+		},
+		"jaeger.size|lib=go": map[string]interface{}{"count": float64(0)},
+		"root":               float64(1),
+	}, snapshot(t, name))
+
+	// the map is shared by the factories with the same name
+	NewFactory(name).Counter(metrics.Options{Name: "root"}).Inc(1)
+	assert.Equal(t, float64(2), snapshot(t, name)["root"])
+}
+
+// TestFactorySameNamespace ensures that the tracers publishing under the same name share the variables.
+func TestFactorySameNamespace(t *testing.T) {
+	name := uniqueName("test_same_namespace")
+	for i := 0; i < 2; i++ {
+		cfg := config.Configuration{
+			ServiceName: "svc",
+			Sampler:     &config.SamplerConfig{Type: jaeger.SamplerTypeConst, Param: 1},
+		}
+		tracer, closer, err := cfg.NewTracer(
+			config.Metrics(NewFactory(name)),
+			config.Reporter(jaeger.NewNullReporter()),
+		)
+		require.NoError(t, err)
+		tracer.StartSpan("op").Finish()
+		require.NoError(t, closer.Close())
+	}
+	assert.Equal(t, float64(2), snapshot(t, name)["jaeger.tracer.started_spans|sampled=y"])
+}
+
+func TestFactoryNotAMap(t *testing.T) {
+	if expvar.Get("test_not_a_map") == nil {
+		expvar.NewInt("test_not_a_map")
+	}
+	assert.Panics(t, func() { NewFactory("test_not_a_map") })
+}
+
+// TestTracerMetrics ensures that the metrics of the tracer can be published under expvar.
+func TestTracerMetrics(t *testing.T) {
+	name := uniqueName("test_tracer")
+	m := jaeger.NewMetrics(NewFactory(name), nil)
+	m.ReporterQueueLength.Update(10)
+	m.SpansStartedSampled.Inc(1)
+
+	vars := snapshot(t, name)
+	assert.Equal(t, float64(10), vars["jaeger.tracer.reporter_queue_length"])
+	assert.Equal(t, float64(1), vars["jaeger.tracer.started_spans|sampled=y"])
+}
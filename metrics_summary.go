@@ -0,0 +1,114 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+// spanCounter is a metrics.Counter that also counts the spans for the metrics summary,
+// which does not depend on the metrics backend.
+type spanCounter struct {
+	metrics.Counter
+	count *int64
+}
+
+func (c spanCounter) Inc(delta int64) {
+	atomic.AddInt64(c.count, delta)
+	c.Counter.Inc(delta)
+}
+
+// metricsSummaryLogger periodically logs a one-line summary of the tracer's own metrics through
+// the logger of the tracer, see TracerOptions.LogMetricsSummary.
+type metricsSummaryLogger struct {
+	// These fields must be first in the struct because `sync/atomic` expects 64-bit alignment.
+	spansStarted  int64
+	spansFinished int64
+
+	tracer   *Tracer
+	interval time.Duration
+	// counts at the time of the previous summary
+	lastStarted  int64
+	lastFinished int64
+	lastReporter ReporterStats
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  sync.WaitGroup
+}
+
+// newMetricsSummaryLogger counts the spans started and finished by the tracer, and starts logging
+// the summary every interval. It must be called before the tracer starts any spans.
+func newMetricsSummaryLogger(tracer *Tracer, interval time.Duration) *metricsSummaryLogger {
+	l := &metricsSummaryLogger{
+		tracer:   tracer,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+	m := &tracer.metrics
+	m.SpansStartedSampled = spanCounter{Counter: m.SpansStartedSampled, count: &l.spansStarted}
+	m.SpansStartedNotSampled = spanCounter{Counter: m.SpansStartedNotSampled, count: &l.spansStarted}
+	m.SpansFinished = spanCounter{Counter: m.SpansFinished, count: &l.spansFinished}
+	l.stopped.Add(1)
+	go l.run()
+	return l
+}
+
+func (l *metricsSummaryLogger) run() {
+	defer l.stopped.Done()
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.tracer.logger.Infof("%s", l.summary())
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// summary returns the summary of the tracer's metrics since the previous summary.
+func (l *metricsSummaryLogger) summary() string {
+	started := atomic.LoadInt64(&l.spansStarted)
+	finished := atomic.LoadInt64(&l.spansFinished)
+	stats := l.tracer.ReporterStats()
+	s := fmt.Sprintf("Jaeger tracer summary for the last %v: spans started=%d finished=%d submitted=%d dropped=%d failed=%d, queue length=%d",
+		l.interval,
+		started-l.lastStarted,
+		finished-l.lastFinished,
+		stats.SpansSubmitted-l.lastReporter.SpansSubmitted,
+		stats.SpansDropped-l.lastReporter.SpansDropped,
+		stats.SpansFailed-l.lastReporter.SpansFailed,
+		stats.QueueLength,
+	)
+	if stats.LastError != nil {
+		s += fmt.Sprintf(", last export error at %s: %v", stats.LastErrorTime.Format(time.RFC3339), stats.LastError)
+	}
+	l.lastStarted, l.lastFinished, l.lastReporter = started, finished, stats
+	return s
+}
+
+func (l *metricsSummaryLogger) close() {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+	l.stopped.Wait()
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestMetricsSummary(t *testing.T) {
+	factory := metricstest.NewFactory(0)
+	sender := &fakeSender{bufferSize: 1, flushErr: errors.New("collector unavailable")}
+	reporter := NewRemoteReporter(sender)
+	tr, closer := NewTracer("x", NewConstSampler(true), reporter,
+		TracerOptions.Metrics(NewMetrics(factory, nil)),
+		TracerOptions.LogMetricsSummary(time.Hour),
+	)
+	defer closer.Close()
+	tracer := tr.(*Tracer)
+
+	tracer.StartSpan("a").Finish()
+	tracer.StartSpan("b")
+	for i := 0; i < 1000 && tracer.ReporterStats().SpansFailed == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	summary := tracer.metricsSummary.summary()
+	assert.True(t, strings.HasPrefix(summary,
+		"Jaeger tracer summary for the last 1h0m0s: spans started=2 finished=1 submitted=0 dropped=0 failed=1, queue length=0, last export error at "),
+		summary)
+	assert.True(t, strings.HasSuffix(summary, ": collector unavailable"), summary)
+	factory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.started_spans", Tags: map[string]string{"sampled": "y"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.finished_spans", Value: 1},
+	)
+
+	// the counts are since the previous summary
+	summary = tracer.metricsSummary.summary()
+	assert.True(t, strings.HasPrefix(summary,
+		"Jaeger tracer summary for the last 1h0m0s: spans started=0 finished=0 submitted=0 dropped=0 failed=0, queue length=0"),
+		summary)
+}
+
+func TestMetricsSummaryLogging(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("x", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.Logger(logger),
+		TracerOptions.LogMetricsSummary(time.Millisecond),
+	)
+	tracer.StartSpan("a").Finish()
+	for i := 0; i < 1000 && !strings.Contains(logger.String(), "Jaeger tracer summary"); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, closer.Close())
+	assert.Contains(t, logger.String(), "INFO: Jaeger tracer summary for the last 1ms: spans started=")
+}
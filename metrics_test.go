@@ -17,6 +17,7 @@ package jaeger
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 )
@@ -45,3 +46,12 @@ func TestNewMetrics(t *testing.T) {
 		},
 	)
 }
+
+func TestNewMetricsSpanLatencyHistogram(t *testing.T) {
+	factory := metricstest.NewFactory(0)
+	m := NewMetrics(factory, nil)
+
+	m.ReporterSpanLatency.Record(2)
+	_, gauges := factory.Snapshot()
+	assert.EqualValues(t, 2, gauges["jaeger.tracer.reporter_span_latency.P99"])
+}
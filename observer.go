@@ -42,6 +42,9 @@ type compositeObserver struct {
 // compositeSpanObserver is a dispatcher to other span observers
 type compositeSpanObserver struct {
 	observers []ContribSpanObserver
+
+	// observers that also implement SnapshotSpanObserver
+	snapshotObservers []SnapshotSpanObserver
 }
 
 // noopSpanObserver is used when there are no observers registered
@@ -54,6 +57,7 @@ func (o *compositeObserver) append(contribObserver ContribObserver) {
 
 func (o *compositeObserver) OnStartSpan(sp opentracing.Span, operationName string, options opentracing.StartSpanOptions) ContribSpanObserver {
 	var spanObservers []ContribSpanObserver
+	var snapshotObservers []SnapshotSpanObserver
 	for _, obs := range o.observers {
 		spanObs, ok := obs.OnStartSpan(sp, operationName, options)
 		if ok {
@@ -61,12 +65,15 @@ func (o *compositeObserver) OnStartSpan(sp opentracing.Span, operationName strin
 				spanObservers = make([]ContribSpanObserver, 0, len(o.observers))
 			}
 			spanObservers = append(spanObservers, spanObs)
+			if snapshotObs, ok := spanObs.(SnapshotSpanObserver); ok {
+				snapshotObservers = append(snapshotObservers, snapshotObs)
+			}
 		}
 	}
 	if len(spanObservers) == 0 {
 		return noopSpanObserver
 	}
-	return &compositeSpanObserver{observers: spanObservers}
+	return &compositeSpanObserver{observers: spanObservers, snapshotObservers: snapshotObservers}
 }
 
 func (o *compositeSpanObserver) OnSetOperationName(operationName string) {
@@ -86,3 +93,9 @@ func (o *compositeSpanObserver) OnFinish(options opentracing.FinishOptions) {
 		obs.OnFinish(options)
 	}
 }
+
+func (o *compositeSpanObserver) OnFinishWithOptions(span SpanSnapshot, options opentracing.FinishOptions) {
+	for _, obs := range o.snapshotObservers {
+		obs.OnFinishWithOptions(span, options)
+	}
+}
@@ -16,10 +16,13 @@ package jaeger
 
 import (
 	"testing"
+	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEmptyObserver(t *testing.T) {
@@ -107,3 +110,52 @@ func (o *testSpanObserver) OnSetTag(key string, value interface{}) {
 func (o *testSpanObserver) OnFinish(options opentracing.FinishOptions) {
 	o.finished = true
 }
+
+type snapshotObserver struct{}
+
+type snapshotSpanObserver struct {
+	testSpanObserver
+	snapshots []SpanSnapshot
+}
+
+func (o snapshotObserver) OnStartSpan(operationName string, options opentracing.StartSpanOptions) SpanObserver {
+	return &snapshotSpanObserver{testSpanObserver: testSpanObserver{tags: map[string]interface{}{}}}
+}
+
+func (o *snapshotSpanObserver) OnFinishWithOptions(span SpanSnapshot, options opentracing.FinishOptions) {
+	o.snapshots = append(o.snapshots, span)
+}
+
+func TestSnapshotObserver(t *testing.T) {
+	tracer, closer := NewTracer(
+		"test",
+		NewConstSampler(true),
+		NewInMemoryReporter(),
+		TracerOptions.Observer(testObserver{}),
+		TracerOptions.Observer(snapshotObserver{}),
+	)
+	defer closer.Close()
+
+	start := time.Now()
+	s := tracer.StartSpan("test", opentracing.StartTime(start))
+	s.SetTag("bender", "rodriguez")
+	s.LogKV("event", "hello")
+	composite := s.(*Span).observer.(*compositeSpanObserver)
+	assert.Len(t, composite.observers, 2)
+	require.Len(t, composite.snapshotObservers, 1)
+	obs := composite.snapshotObservers[0].(*snapshotSpanObserver)
+
+	s.FinishWithOptions(opentracing.FinishOptions{
+		FinishTime: start.Add(time.Second),
+		LogRecords: []opentracing.LogRecord{{Timestamp: start, Fields: []log.Field{log.String("k", "v")}}},
+	})
+	assert.True(t, obs.finished)
+	require.Len(t, obs.snapshots, 1)
+	snapshot := obs.snapshots[0]
+	assert.Equal(t, "test", snapshot.OperationName)
+	assert.Equal(t, start, snapshot.StartTime)
+	assert.Equal(t, time.Second, snapshot.Duration)
+	assert.Equal(t, "rodriguez", snapshot.Tags["bender"])
+	assert.Len(t, snapshot.Logs, 2)
+	assert.True(t, snapshot.Context.IsValid())
+}
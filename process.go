@@ -14,6 +14,12 @@
 
 package jaeger
 
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
 // Process holds process specific metadata that's relevant to this client.
 type Process struct {
 	Service string
@@ -27,3 +33,26 @@ type Process struct {
 type ProcessSetter interface {
 	SetProcess(process Process)
 }
+
+// processUUID returns the UUID of the process: the instance ID if given, else the UUID stored in
+// the instance ID file, else a new random UUID, which is then stored in the file for the next restarts.
+func (t *Tracer) processUUID() string {
+	if t.options.instanceID != "" {
+		return t.options.instanceID
+	}
+	path := t.options.instanceIDFile
+	if path != "" {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			if uuid := strings.TrimSpace(string(data)); uuid != "" {
+				return uuid
+			}
+		}
+	}
+	uuid := strconv.FormatUint(t.randomNumber(), 16)
+	if path != "" {
+		if err := ioutil.WriteFile(path, []byte(uuid+"\n"), 0644); err != nil {
+			t.logger.Error("Unable to store the process UUID: " + err.Error())
+		}
+	}
+	return uuid
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+// SetProcessTag adds a process tag to the tracer, or updates the value of an existing one,
+// e.g. after a leader election or when the version of the configuration changes.
+// It is safe to call concurrently with the tracer being used. The tag is reported with
+// the spans sent in subsequent batches; the spans already buffered by the reporter
+// may be sent with the previous process tags.
+func (t *Tracer) SetProcessTag(key string, value interface{}) {
+	t.processLock.Lock()
+	// the slice is copied, because the previous one may be in use by the readers of the tags
+	tags := make([]Tag, len(t.tags), len(t.tags)+1)
+	copy(tags, t.tags)
+	updated := false
+	for i := range tags {
+		if tags[i].key == key {
+			tags[i].value = value
+			updated = true
+		}
+	}
+	if !updated {
+		tags = append(tags, Tag{key: key, value: value})
+	}
+	t.tags = tags
+	t.process.Tags = tags
+	t.processVersion++
+	process := t.process
+	t.processLock.Unlock()
+
+	if throttler, ok := t.debugThrottler.(ProcessSetter); ok {
+		throttler.SetProcess(process)
+	}
+}
+
+// processTags returns the process tags and their version, which is incremented each time
+// the tags are changed. The returned slice must not be modified.
+func (t *Tracer) processTags() ([]Tag, uint64) {
+	t.processLock.RLock()
+	defer t.processLock.RUnlock()
+	return t.tags, t.processVersion
+}
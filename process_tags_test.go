@@ -0,0 +1,149 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/testutils"
+	"github.com/uber/jaeger-client-go/utils"
+)
+
+type processRecorder struct {
+	processes []Process
+}
+
+func (r *processRecorder) IsAllowed(operation string) bool { return true }
+
+func (r *processRecorder) SetProcess(process Process) {
+	r.processes = append(r.processes, process)
+}
+
+func TestSetProcessTag(t *testing.T) {
+	throttler := &processRecorder{}
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Tag("role", "follower"),
+		TracerOptions.DebugThrottler(throttler),
+	)
+	defer closer.Close()
+	jTracer := tracer.(*Tracer)
+	_, version := jTracer.processTags()
+
+	tags := jTracer.Tags()
+	jTracer.SetProcessTag("role", "leader")
+	jTracer.SetProcessTag("config.version", 2)
+
+	assert.Contains(t, tags, opentracing.Tag{Key: "role", Value: "follower"}, "previous tags must not be modified")
+	assert.Contains(t, jTracer.Tags(), opentracing.Tag{Key: "role", Value: "leader"})
+	assert.Contains(t, jTracer.Tags(), opentracing.Tag{Key: "config.version", Value: 2})
+	assert.Len(t, jTracer.Tags(), len(tags)+1)
+	_, newVersion := jTracer.processTags()
+	assert.Equal(t, version+2, newVersion)
+
+	require.Len(t, throttler.processes, 3)
+	assert.Equal(t, jTracer.process, throttler.processes[2])
+
+	sp := tracer.StartSpan("op").(*Span)
+	process := BuildJaegerProcessThrift(sp)
+	assert.Equal(t, "leader", *findJaegerTag("role", process.Tags).VStr)
+	assert.EqualValues(t, 2, *findJaegerTag("config.version", process.Tags).VLong)
+}
+
+func TestSetProcessTagConcurrently(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+	jTracer := tracer.(*Tracer)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			jTracer.SetProcessTag("counter", i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			sp := tracer.StartSpan("op").(*Span)
+			BuildJaegerProcessThrift(sp)
+			BuildZipkinThrift(sp)
+		}
+	}()
+	wg.Wait()
+	assert.Contains(t, jTracer.Tags(), opentracing.Tag{Key: "counter", Value: 99})
+}
+
+func TestUDPSenderProcessTagsChanged(t *testing.T) {
+	agent, err := testutils.StartMockAgent()
+	require.NoError(t, err)
+	defer agent.Close()
+
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+	sender, err := NewUDPTransport(agent.SpanServerAddr(), 0)
+	require.NoError(t, err)
+	defer sender.Close()
+
+	_, err = sender.Append(tracer.StartSpan("before").(*Span))
+	require.NoError(t, err)
+	tracer.(*Tracer).SetProcessTag("role", "leader")
+	n, err := sender.Append(tracer.StartSpan("after").(*Span))
+	require.NoError(t, err)
+	assert.Equal(t, 0, n, "the span reported before the change is parked until the next flush")
+	n, err = sender.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	for i := 0; i < 10000 && len(agent.GetJaegerBatches()) < 2; i++ {
+		time.Sleep(1 * time.Millisecond)
+	}
+	batches := agent.GetJaegerBatches()
+	require.Len(t, batches, 2)
+	assert.Nil(t, findJaegerTag("role", batches[0].Process.Tags))
+	assert.Equal(t, "before", batches[0].Spans[0].OperationName)
+	require.NotNil(t, findJaegerTag("role", batches[1].Process.Tags))
+	assert.Equal(t, "after", batches[1].Spans[0].OperationName)
+}
+
+func TestUDPSenderProcessTagsChangedHugeSpan(t *testing.T) {
+	agent, err := testutils.StartMockAgent()
+	require.NoError(t, err)
+	defer agent.Close()
+
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+	sender, err := NewUDPTransport(agent.SpanServerAddr(), 0)
+	require.NoError(t, err)
+	defer sender.Close()
+
+	_, err = sender.Append(tracer.StartSpan("before").(*Span))
+	require.NoError(t, err)
+	tracer.(*Tracer).SetProcessTag("role", "leader")
+	n, err := sender.Append(tracer.StartSpan(strings.Repeat("x", utils.UDPPacketMaxLength)).(*Span))
+	assert.Equal(t, errSpanTooLarge, err)
+	assert.Equal(t, 1, n, "only the rejected span is counted as failed")
+
+	n, err = sender.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n, "the span reported before the change is emitted")
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestProcessUUID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jaeger-process-uuid")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "uuid")
+
+	newProcess := func(options ...TracerOption) Process {
+		tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(), options...)
+		defer closer.Close()
+		return tracer.(*Tracer).process
+	}
+
+	first := newProcess(TracerOptions.InstanceIDFile(path))
+	assert.NotEmpty(t, first.UUID)
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, first.UUID+"\n", string(data))
+
+	assert.Equal(t, first.UUID, newProcess(TracerOptions.InstanceIDFile(path)).UUID, "the UUID is reused on restart")
+	assert.NotEqual(t, first.UUID, newProcess().UUID)
+	assert.Equal(t, "pod-1", newProcess(TracerOptions.InstanceID("pod-1"), TracerOptions.InstanceIDFile(path)).UUID)
+
+	logger := &log.BytesBufferLogger{}
+	process := newProcess(TracerOptions.InstanceIDFile(filepath.Join(dir, "missing", "uuid")), TracerOptions.Logger(logger))
+	assert.NotEmpty(t, process.UUID)
+	assert.Contains(t, logger.String(), "Unable to store the process UUID")
+}
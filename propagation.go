@@ -105,6 +105,11 @@ func NewBinaryPropagator(tracer *Tracer) *BinaryPropagator {
 	}
 }
 
+// PropagationFormat implements NamedPropagationFormat.
+func (p *TextMapPropagator) PropagationFormat() string {
+	return "jaeger"
+}
+
 // Inject implements Injector of TextMapPropagator
 func (p *TextMapPropagator) Inject(
 	sc SpanContext,
@@ -173,6 +178,11 @@ func (p *TextMapPropagator) Extract(abstractCarrier interface{}) (SpanContext, e
 	return ctx, nil
 }
 
+// PropagationFormat implements NamedPropagationFormat.
+func (p *BinaryPropagator) PropagationFormat() string {
+	return "binary"
+}
+
 // Inject implements Injector of BinaryPropagator
 func (p *BinaryPropagator) Inject(
 	sc SpanContext,
@@ -0,0 +1,126 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+const otherPropagationFormat = "other"
+
+// NamedPropagationFormat is implemented by the Injectors and Extractors that name their propagation format,
+// e.g. "b3", in the tags of the span_context_encodings and span_context_decodings metrics. The metrics
+// of the other Injectors and Extractors are tagged with the format "other".
+type NamedPropagationFormat interface {
+	PropagationFormat() string
+}
+
+// propagationFormat returns the name of the propagation format of the Injector or Extractor.
+func propagationFormat(propagator interface{}) string {
+	if named, ok := propagator.(NamedPropagationFormat); ok {
+		return named.PropagationFormat()
+	}
+	return otherPropagationFormat
+}
+
+// propagationResult classifies the result of injecting or extracting a span context for the metrics,
+// "ok", "not_found" or the type of the error, e.g. "malformed_trace_id".
+func propagationResult(err error) string {
+	switch e := err.(type) {
+	case nil:
+		return "ok"
+	case *IDParseError:
+		if e.ID == "TraceID" {
+			return "malformed_trace_id"
+		}
+		return "malformed_span_id"
+	case *flagsParseError:
+		return "bad_flags"
+	}
+	switch err {
+	case opentracing.ErrSpanContextNotFound:
+		return "not_found"
+	case opentracing.ErrInvalidCarrier:
+		return "invalid_carrier"
+	case opentracing.ErrSpanContextCorrupted, errEmptyTracerStateString, errMalformedTracerStateString:
+		return "malformed"
+	}
+	return "other"
+}
+
+type propagationKey struct {
+	encoding bool
+	format   string
+	result   string
+}
+
+// propagationMetrics counts the span contexts injected and extracted by the tracer, by propagation format
+// and result. The number of formats and results is bounded, so the metrics are created on demand.
+type propagationMetrics struct {
+	factory  metrics.Factory
+	mux      sync.RWMutex
+	counters map[propagationKey]metrics.Counter
+}
+
+func newPropagationMetrics(factory metrics.Factory) *propagationMetrics {
+	if factory == nil {
+		return nil
+	}
+	return &propagationMetrics{
+		factory:  factory.Namespace(metrics.NSOptions{Name: "jaeger"}).Namespace(metrics.NSOptions{Name: "tracer"}),
+		counters: make(map[propagationKey]metrics.Counter),
+	}
+}
+
+func (m *propagationMetrics) injected(injector Injector, err error) {
+	if m != nil {
+		m.counter(propagationKey{encoding: true, format: propagationFormat(injector), result: propagationResult(err)}).Inc(1)
+	}
+}
+
+func (m *propagationMetrics) extracted(extractor Extractor, err error) {
+	if m != nil {
+		m.counter(propagationKey{format: propagationFormat(extractor), result: propagationResult(err)}).Inc(1)
+	}
+}
+
+func (m *propagationMetrics) counter(key propagationKey) metrics.Counter {
+	m.mux.RLock()
+	c := m.counters[key]
+	m.mux.RUnlock()
+	if c != nil {
+		return c
+	}
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if c, ok := m.counters[key]; ok {
+		return c
+	}
+	options := metrics.Options{
+		Name: "span_context_decodings",
+		Tags: map[string]string{"format": key.format, "result": key.result},
+		Help: "Number of span contexts extracted by propagation format and result",
+	}
+	if key.encoding {
+		options.Name = "span_context_encodings"
+		options.Help = "Number of span contexts injected by propagation format and result"
+	}
+	c = m.factory.Counter(options)
+	m.counters[key] = c
+	return c
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestPropagationMetrics(t *testing.T) {
+	factory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.MetricsFactory(factory),
+		TracerOptions.Injector("custom", fakeCustomPropagator{}),
+		TracerOptions.Extractor("custom", fakeCustomPropagator{}),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op")
+	defer sp.Finish()
+	require.NoError(t, tracer.Inject(sp.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier{}))
+	require.NoError(t, tracer.Inject(sp.Context(), opentracing.Binary, &bytes.Buffer{}))
+	assert.Error(t, tracer.Inject(sp.Context(), opentracing.TextMap, "not a carrier"))
+	assert.Error(t, tracer.Inject(sp.Context(), "custom", nil))
+
+	for _, value := range []string{"1:2:0:1", "x:2:0:1", "1:x:0:1", "1:2:0:x", "1:2"} {
+		tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier{"uber-trace-id": []string{value}})
+	}
+	tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier{})
+	tracer.Extract(opentracing.Binary, bytes.NewBufferString("x"))
+
+	encodings := func(format, result string, value int) metricstest.ExpectedMetric {
+		return metricstest.ExpectedMetric{
+			Name:  "jaeger.tracer.span_context_encodings",
+			Tags:  map[string]string{"format": format, "result": result},
+			Value: value,
+		}
+	}
+	decodings := func(format, result string, value int) metricstest.ExpectedMetric {
+		return metricstest.ExpectedMetric{
+			Name:  "jaeger.tracer.span_context_decodings",
+			Tags:  map[string]string{"format": format, "result": result},
+			Value: value,
+		}
+	}
+	factory.AssertCounterMetrics(t,
+		encodings("jaeger", "ok", 1),
+		encodings("binary", "ok", 1),
+		encodings("jaeger", "invalid_carrier", 1),
+		encodings("other", "other", 1),
+		decodings("jaeger", "ok", 1),
+		decodings("jaeger", "malformed_trace_id", 1),
+		decodings("jaeger", "malformed_span_id", 1),
+		decodings("jaeger", "bad_flags", 1),
+		decodings("jaeger", "malformed", 1),
+		decodings("jaeger", "not_found", 1),
+		decodings("binary", "malformed", 1),
+	)
+}
+
+func TestPropagationMetricsWithoutMetricsFactory(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+	assert.Nil(t, tracer.(*Tracer).propagationMetrics)
+	_, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier{})
+	assert.Equal(t, opentracing.ErrSpanContextNotFound, err)
+}
+
+type fakeCustomPropagator struct{}
+
+func (fakeCustomPropagator) Inject(SpanContext, interface{}) error {
+	return errors.New("custom error")
+}
+
+func (fakeCustomPropagator) Extract(interface{}) (SpanContext, error) {
+	return SpanContext{}, errors.New("custom error")
+}
@@ -114,6 +114,8 @@ func TestSpanPropagator(t *testing.T) {
 		sp.logs = exp.logs
 		sp.operationName = op
 		sp.references = exp.references
+		sp.samplingFinalized = exp.samplingFinalized
+		sp.startMonotonic = exp.startMonotonic
 		// Compare the rest of the fields
 		assert.Equal(t, exp, sp, formatName)
 	}
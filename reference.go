@@ -21,3 +21,26 @@ type Reference struct {
 	Type    opentracing.SpanReferenceType
 	Context SpanContext
 }
+
+// AddReference adds a reference to another span after the span has started, e.g. a FollowsFrom
+// reference to the span of a message whose ID only becomes known while processing the request.
+// The reference is reported with the span, after the references given when the span was started;
+// it does not change the parent of the span. References to invalid span contexts are ignored,
+// as are the references added to spans that are not sampled.
+func (s *Span) AddReference(refType opentracing.SpanReferenceType, ctx SpanContext) {
+	if !ctx.IsValid() || s.usedAfterFinish("AddReference") {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.context.IsSampled() {
+		s.references = append(s.references, Reference{Type: refType, Context: ctx})
+	}
+}
+
+// References returns the references of the span.
+func (s *Span) References() []Reference {
+	s.RLock()
+	defer s.RUnlock()
+	return append([]Reference(nil), s.references...)
+}
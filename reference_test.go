@@ -0,0 +1,77 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+)
+
+func TestSpanAddReference(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+
+	parent := tracer.StartSpan("parent").Context().(SpanContext)
+	trigger := tracer.StartSpan("trigger").Context().(SpanContext)
+
+	sp := tracer.StartSpan("consumer", opentracing.ChildOf(parent)).(*Span)
+	sp.AddReference(opentracing.FollowsFromRef, trigger)
+	sp.AddReference(opentracing.FollowsFromRef, SpanContext{}) // invalid references are ignored
+
+	assert.Equal(t, parent.SpanID(), sp.context.ParentID())
+	assert.Equal(t, []Reference{
+		{Type: opentracing.ChildOfRef, Context: parent},
+		{Type: opentracing.FollowsFromRef, Context: trigger},
+	}, sp.References())
+
+	jSpan := BuildJaegerThrift(sp)
+	require.Len(t, jSpan.References, 2)
+	assert.Equal(t, j.SpanRefType_FOLLOWS_FROM, jSpan.References[1].RefType)
+	assert.Equal(t, int64(trigger.SpanID()), jSpan.References[1].SpanId)
+}
+
+func TestSpanAddReferenceConcurrently(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+
+	trigger := tracer.StartSpan("trigger").Context().(SpanContext)
+	sp := tracer.StartSpan("consumer").(*Span)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sp.AddReference(opentracing.FollowsFromRef, trigger)
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, sp.References(), 10)
+}
+
+func TestSpanAddReferenceNotSampled(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter())
+	defer closer.Close()
+
+	trigger := tracer.StartSpan("trigger").Context().(SpanContext)
+	sp := tracer.StartSpan("consumer").(*Span)
+	sp.AddReference(opentracing.FollowsFromRef, trigger)
+	assert.Empty(t, sp.References())
+}
@@ -15,7 +15,6 @@
 package jaeger
 
 import (
-	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -156,6 +155,29 @@ func (r *compositeReporter) Close() {
 	}
 }
 
+// CheckHealth implements HealthChecker by checking each underlying reporter that supports it.
+func (r *compositeReporter) CheckHealth() error {
+	for _, reporter := range r.reporters {
+		if checker, ok := reporter.(HealthChecker); ok {
+			if err := checker.CheckHealth(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Stats returns the combined runtime statistics of the underlying reporters.
+func (r *compositeReporter) Stats() ReporterStats {
+	var stats ReporterStats
+	for _, reporter := range r.reporters {
+		if reporter, ok := reporter.(reporterWithStats); ok {
+			stats = stats.add(reporter.Stats())
+		}
+	}
+	return stats
+}
+
 // ------------- REMOTE REPORTER -----------------
 
 type reporterQueueItemType int
@@ -172,6 +194,7 @@ type reporterQueueItem struct {
 	itemType reporterQueueItemType
 	span     *Span
 	close    *sync.WaitGroup
+	enqueued time.Time
 }
 
 type remoteReporter struct {
@@ -179,6 +202,9 @@ type remoteReporter struct {
 	// Cf. https://github.com/uber/jaeger-client-go/issues/155, https://goo.gl/zW7dgq
 	queueLength int64
 	closed      int64 // 0 - not closed, 1 - closed
+	stats       reporterStats
+	// overflowing is 1 while the spans are dropped because the queue is full
+	overflowing int32
 
 	reporterOptions
 
@@ -218,17 +244,28 @@ func NewRemoteReporter(sender Transport, opts ...ReporterOption) Reporter {
 
 // Report implements Report() method of Reporter.
 // It passes the span to a background go-routine for submission to Jaeger backend.
-// If the internal queue is full, the span is dropped and metrics.ReporterDropped counter is incremented.
+// If the internal queue is full, the span is dropped, metrics.ReporterDropped counter is incremented
+// and the DroppedSpanCallback, if any, is invoked.
 // If Report() is called after the reporter has been Close()-ed, the additional spans will not be
 // sent to the backend, but the metrics.ReporterDropped counter may not reflect them correctly,
 // because some of them may still be successfully added to the queue.
 func (r *remoteReporter) Report(span *Span) {
 	select {
 	// Need to retain the span otherwise it will be released
-	case r.queue <- reporterQueueItem{itemType: reporterQueueItemSpan, span: span.Retain()}:
-		atomic.AddInt64(&r.queueLength, 1)
+	case r.queue <- reporterQueueItem{itemType: reporterQueueItemSpan, span: span.Retain(), enqueued: time.Now()}:
+		r.metrics.ReporterQueueLength.Update(atomic.AddInt64(&r.queueLength, 1))
+		if atomic.LoadInt32(&r.overflowing) == 1 && atomic.CompareAndSwapInt32(&r.overflowing, 1, 0) {
+			r.diagnosticsCallback.publish(DiagnosticQueueOverflowEnded, "the reporter queue accepts spans again", nil)
+		}
 	default:
+		if atomic.CompareAndSwapInt32(&r.overflowing, 0, 1) {
+			r.diagnosticsCallback.publish(DiagnosticQueueOverflowStarted, "the reporter queue is full, spans are dropped", nil)
+		}
 		r.metrics.ReporterDropped.Inc(1)
+		r.stats.dropped(1)
+		if r.droppedSpanCallback != nil {
+			r.droppedSpanCallback(span.OperationName(), SpanDropReasonQueueFull)
+		}
 	}
 }
 
@@ -242,56 +279,73 @@ func (r *remoteReporter) Close() {
 	r.sender.Close()
 }
 
+// CheckHealth implements HealthChecker by delegating to the sender, if it supports health checks.
+func (r *remoteReporter) CheckHealth() error {
+	if checker, ok := r.sender.(HealthChecker); ok {
+		return checker.CheckHealth()
+	}
+	return nil
+}
+
+// Stats returns the runtime statistics of the reporter.
+func (r *remoteReporter) Stats() ReporterStats {
+	stats := r.stats.snapshot()
+	stats.QueueLength = atomic.LoadInt64(&r.queueLength)
+	return stats
+}
+
 func (r *remoteReporter) sendCloseEvent() {
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 	item := reporterQueueItem{itemType: reporterQueueItemClose, close: wg}
 
 	r.queue <- item // if the queue is full we will block until there is space
-	atomic.AddInt64(&r.queueLength, 1)
+	r.metrics.ReporterQueueLength.Update(atomic.AddInt64(&r.queueLength, 1))
 	wg.Wait()
 }
 
 // processQueue reads spans from the queue, converts them to Thrift, and stores them in an internal buffer.
-// When the buffer length reaches batchSize, it is flushed by submitting the accumulated spans to Jaeger.
-// Buffer also gets flushed automatically every batchFlushInterval seconds, just in case the tracer stopped
-// reporting new spans.
+// The buffer is flushed by submitting the accumulated spans to Jaeger when any of the following happens:
+// the Transport's buffer is full, the buffer accumulates bufferFlushMaxSpans spans or bufferFlushMaxBytes
+// bytes, no new spans arrive for bufferFlushIdleTimeout, or every bufferFlushInterval, just in case the
+// tracer stopped reporting new spans.
 func (r *remoteReporter) processQueue() {
-	// flush causes the Sender to flush its accumulated spans and clear the buffer
-	flush := func() {
-		if flushed, err := r.sender.Flush(); err != nil {
-			r.metrics.ReporterFailure.Inc(int64(flushed))
-			r.logger.Error(fmt.Sprintf("error when flushing the buffer: %s", err.Error()))
-		} else if flushed > 0 {
-			r.metrics.ReporterSuccess.Inc(int64(flushed))
-		}
-	}
-
+	buffer := newReporterBuffer(r)
 	timer := time.NewTicker(r.bufferFlushInterval)
 	for {
 		select {
 		case <-timer.C:
-			flush()
+			buffer.flush()
+			buffer.errorLogger.tick()
+		case <-buffer.idle():
+			if buffer.spans > 0 {
+				buffer.flush()
+			}
 		case item := <-r.queue:
-			atomic.AddInt64(&r.queueLength, -1)
+			r.metrics.ReporterQueueLength.Update(atomic.AddInt64(&r.queueLength, -1))
 			switch item.itemType {
 			case reporterQueueItemSpan:
-				span := item.span
-				if flushed, err := r.sender.Append(span); err != nil {
-					r.metrics.ReporterFailure.Inc(int64(flushed))
-					r.logger.Error(fmt.Sprintf("error reporting span %q: %s", span.OperationName(), err.Error()))
-				} else if flushed > 0 {
-					r.metrics.ReporterSuccess.Inc(int64(flushed))
-					// to reduce the number of gauge stats, we only emit queue length on flush
-					r.metrics.ReporterQueueLength.Update(atomic.LoadInt64(&r.queueLength))
-				}
-				span.Release()
+				buffer.append(item.span, item.enqueued)
 			case reporterQueueItemClose:
 				timer.Stop()
-				flush()
+				buffer.close()
 				item.close.Done()
 				return
 			}
 		}
 	}
 }
+
+// updateBatchSize updates the gauges of the size of the last batch emitted by the Sender, as told by
+// the Sender if it implements BatchSizer, or the number of spans it flushed otherwise.
+func (r *remoteReporter) updateBatchSize(flushed int) {
+	sizer, ok := r.sender.(BatchSizer)
+	if !ok {
+		r.metrics.ReporterBatchSize.Update(int64(flushed))
+		return
+	}
+	if spans, bytes := sizer.LastBatchSize(); spans > 0 {
+		r.metrics.ReporterBatchSize.Update(int64(spans))
+		r.metrics.ReporterBatchSizeBytes.Update(int64(bytes))
+	}
+}
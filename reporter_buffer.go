@@ -0,0 +1,235 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"time"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+// reporterBuffer keeps track of the spans appended to the Transport of the remote reporter since it was
+// last flushed. It decides when the Transport must be flushed, and reports the outcome of the flushes
+// to the metrics, statistics, DroppedSpanCallback, DiagnosticsCallback and logs of the reporter.
+//
+// The Transport emits the oldest spans first, so the spans it reports as flushed are the oldest pending ones.
+//
+// It is only used from the reporter's background go-routine and is not thread-safe.
+type reporterBuffer struct {
+	reporter    *remoteReporter
+	errorLogger *reporterErrorLogger
+	debugLogger log.DebugLogger
+
+	// spans and bytes are the number of pending spans and their serialized size,
+	// the size is only measured if bufferFlushMaxBytes is set
+	spans int
+	bytes int
+	// operations are the operation names of the pending spans, only tracked if droppedSpanCallback is set
+	operations []string
+	// enqueued are the times when the pending spans were reported, to measure their latency until emitted
+	enqueued []time.Time
+
+	// failing is true after a batch failed to be sent, until a batch is sent successfully
+	failing bool
+
+	// idleTimer is created when the first span arrives, if bufferFlushIdleTimeout is set
+	idleTimer *time.Timer
+}
+
+func newReporterBuffer(reporter *remoteReporter) *reporterBuffer {
+	return &reporterBuffer{
+		reporter:    reporter,
+		errorLogger: newReporterErrorLogger(reporter.logger, reporter.errorLogInterval),
+		debugLogger: log.DebugLogAdapter(reporter.logger),
+	}
+}
+
+// append appends the span to the Transport, and flushes the Transport if the pending spans
+// reached bufferFlushMaxSpans or bufferFlushMaxBytes.
+func (b *reporterBuffer) append(span *Span, enqueued time.Time) {
+	r := b.reporter
+	spanSize := 0
+	if r.bufferFlushMaxBytes > 0 {
+		spanSize = int(spanThriftSize(span))
+	}
+	if flushed, err := r.sender.Append(span); err == errSpanTooLarge {
+		b.tooLarge(span, flushed, err)
+	} else {
+		b.appended(span, enqueued, spanSize, flushed, err)
+	}
+	span.Release()
+
+	if (r.bufferFlushMaxSpans > 0 && b.spans >= r.bufferFlushMaxSpans) ||
+		(r.bufferFlushMaxBytes > 0 && b.bytes >= r.bufferFlushMaxBytes) {
+		b.flush()
+	}
+	b.resetIdleTimer()
+}
+
+// appended records the span added to the buffer of the Transport, and the outcome of the flush
+// of the Transport, if appending the span caused one.
+func (b *reporterBuffer) appended(span *Span, enqueued time.Time, spanSize int, flushed int, err error) {
+	if b.reporter.droppedSpanCallback != nil {
+		b.operations = append(b.operations, span.OperationName())
+	}
+	b.enqueued = append(b.enqueued, enqueued)
+	b.spans++
+	b.bytes += spanSize
+	if err != nil {
+		b.failed(flushed, err, "error reporting span",
+			log.String("operation", span.OperationName()), log.Int("spans", flushed))
+	} else if flushed > 0 {
+		b.emitted(flushed)
+	}
+	b.updateHealth(flushed, err)
+	if flushed > 0 {
+		b.reporter.updateBatchSize(flushed)
+		// the Transport flushed its buffer, possibly keeping the latest span
+		if b.spans -= flushed; b.spans > 0 {
+			b.bytes = spanSize
+		} else {
+			b.spans, b.bytes = 0, 0
+		}
+	}
+}
+
+// tooLarge records the span rejected by the Transport without being added to its buffer.
+func (b *reporterBuffer) tooLarge(span *Span, flushed int, err error) {
+	r := b.reporter
+	r.metrics.ReporterFailure.Inc(int64(flushed))
+	r.metrics.ReporterSpanTooLarge.Inc(1)
+	r.stats.failed(flushed, err)
+	if r.droppedSpanCallback != nil {
+		r.droppedSpanCallback(span.OperationName(), SpanDropReasonTooLarge)
+	}
+	b.errorLogger.logError("error reporting span", err,
+		log.String("operation", span.OperationName()), log.Int("spans", flushed))
+}
+
+// flush causes the Transport to flush its accumulated spans and clear the buffer.
+func (b *reporterBuffer) flush() {
+	r := b.reporter
+	b.spans, b.bytes = 0, 0
+	flushed, err := r.sender.Flush()
+	if flushed > 0 {
+		r.updateBatchSize(flushed)
+	}
+	b.updateHealth(flushed, err)
+	if err != nil {
+		// the Transport drops its buffer when it fails to send it
+		r.metrics.ReporterFailure.Inc(int64(flushed))
+		r.metrics.failureCounter(err).Inc(int64(flushed))
+		r.stats.failed(flushed, err)
+		b.forget(len(b.enqueued), SpanDropReasonSendFailure, false)
+		b.errorLogger.logError("error when flushing the buffer", err, log.Int("spans", flushed))
+	} else if flushed > 0 {
+		b.emitted(flushed)
+	}
+	b.forget(len(b.enqueued), "", false)
+}
+
+// close stops the idle timer, flushes the Transport and logs the summary of the suppressed errors.
+func (b *reporterBuffer) close() {
+	if b.idleTimer != nil {
+		b.idleTimer.Stop()
+	}
+	b.flush()
+	b.errorLogger.flush()
+}
+
+// emitted reports the n oldest pending spans as emitted successfully.
+func (b *reporterBuffer) emitted(n int) {
+	r := b.reporter
+	r.metrics.ReporterSuccess.Inc(int64(n))
+	r.stats.submitted(n)
+	b.forget(n, "", true)
+	b.debugLogger.Debugf("Emitted a batch of %d spans", n)
+}
+
+// failed reports the n oldest pending spans as failed to be sent.
+func (b *reporterBuffer) failed(n int, err error, msg string, fields ...log.Field) {
+	r := b.reporter
+	r.metrics.ReporterFailure.Inc(int64(n))
+	r.metrics.failureCounter(err).Inc(int64(n))
+	r.stats.failed(n, err)
+	b.forget(n, SpanDropReasonSendFailure, false)
+	b.errorLogger.logError(msg, err, fields...)
+}
+
+// forget removes the n oldest pending spans, reporting them to droppedSpanCallback if reason
+// is not empty, and recording their latency if they were emitted.
+func (b *reporterBuffer) forget(n int, reason SpanDropReason, emitted bool) {
+	if n > len(b.enqueued) {
+		n = len(b.enqueued)
+	}
+	if emitted {
+		now := time.Now()
+		for _, enqueued := range b.enqueued[:n] {
+			b.reporter.metrics.ReporterSpanLatency.Record(now.Sub(enqueued).Seconds())
+		}
+	}
+	if b.reporter.droppedSpanCallback != nil && reason != "" {
+		for _, operationName := range b.operations[:n] {
+			b.reporter.droppedSpanCallback(operationName, reason)
+		}
+	}
+	if n == len(b.enqueued) {
+		// reuse the slices once all the pending spans are forgotten
+		b.enqueued, b.operations = b.enqueued[:0], b.operations[:0]
+	} else {
+		b.enqueued = b.enqueued[n:]
+		if b.reporter.droppedSpanCallback != nil {
+			b.operations = b.operations[n:]
+		}
+	}
+}
+
+// updateHealth publishes the diagnostics events when the Transport starts failing and recovers.
+func (b *reporterBuffer) updateHealth(flushed int, err error) {
+	if err != nil && !b.failing {
+		b.failing = true
+		b.reporter.diagnosticsCallback.publish(DiagnosticReporterFailing, "failed to send a batch of spans", err)
+	} else if err == nil && flushed > 0 && b.failing {
+		b.failing = false
+		b.reporter.diagnosticsCallback.publish(DiagnosticReporterRecovered, "sent a batch of spans", nil)
+	}
+}
+
+// resetIdleTimer restarts the idle timer after a span was appended, if bufferFlushIdleTimeout is set.
+func (b *reporterBuffer) resetIdleTimer() {
+	timeout := b.reporter.bufferFlushIdleTimeout
+	if timeout <= 0 {
+		return
+	}
+	if b.idleTimer == nil {
+		b.idleTimer = time.NewTimer(timeout)
+		return
+	}
+	if !b.idleTimer.Stop() {
+		select {
+		case <-b.idleTimer.C:
+		default:
+		}
+	}
+	b.idleTimer.Reset(timeout)
+}
+
+// idle returns the channel of the idle timer, or nil if there is none, which blocks forever.
+func (b *reporterBuffer) idle() <-chan time.Time {
+	if b.idleTimer == nil {
+		return nil
+	}
+	return b.idleTimer.C
+}
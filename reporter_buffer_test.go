@@ -0,0 +1,71 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReporterBufferPendingSpans(t *testing.T) {
+	var dropped []string
+	sender := &fakeSender{bufferSize: 3}
+	reporter := &remoteReporter{
+		reporterOptions: reporterOptions{
+			metrics:             NewNullMetrics(),
+			logger:              NullLogger,
+			bufferFlushMaxSpans: 5,
+			droppedSpanCallback: func(operationName string, reason SpanDropReason) {
+				dropped = append(dropped, fmt.Sprintf("%s:%s", operationName, reason))
+			},
+		},
+		sender: sender,
+	}
+	buffer := newReporterBuffer(reporter)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+	appendSpan := func(operationName string) {
+		buffer.append(tracer.StartSpan(operationName).(*Span), time.Now())
+	}
+
+	// the Transport flushes the three oldest spans when its buffer is full
+	for i := 0; i < 4; i++ {
+		appendSpan(fmt.Sprintf("sp%d", i))
+	}
+	assert.Equal(t, 1, buffer.spans)
+	assert.Equal(t, []string{"sp3"}, buffer.operations)
+	assert.Len(t, buffer.enqueued, 1)
+	assert.Empty(t, dropped)
+
+	// the pending spans are reported as dropped when the flush fails
+	sender.flushErr = errors.New("connection refused")
+	appendSpan("sp4")
+	buffer.flush()
+	assert.Equal(t, 0, buffer.spans)
+	assert.Empty(t, buffer.operations)
+	assert.Empty(t, buffer.enqueued)
+	assert.Equal(t, []string{"sp3:send_failure", "sp4:send_failure"}, dropped)
+	assert.True(t, buffer.failing)
+
+	sender.flushErr = nil
+	appendSpan("sp5")
+	buffer.flush()
+	assert.False(t, buffer.failing)
+	assert.Nil(t, buffer.idle(), "there is no idle timer without bufferFlushIdleTimeout")
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SpanDropReason describes why the reporter dropped a span.
+type SpanDropReason string
+
+const (
+	// SpanDropReasonQueueFull means the span was dropped because the reporter queue was full.
+	SpanDropReasonQueueFull SpanDropReason = "queue_full"
+
+	// SpanDropReasonSendFailure means the Transport failed to send the batch containing the span.
+	SpanDropReasonSendFailure SpanDropReason = "send_failure"
+
+	// SpanDropReasonTooLarge means the span was too large to be sent by the Transport.
+	SpanDropReasonTooLarge SpanDropReason = "too_large"
+)
+
+// DroppedSpanCallback is invoked by the reporter for every span it drops. Spans dropped because
+// of a full queue are reported from the go-routine that finished the span, so the callback must be
+// thread-safe and return quickly.
+type DroppedSpanCallback func(operationName string, reason SpanDropReason)
+
+// NewLoggingDroppedSpanCallback returns a DroppedSpanCallback that logs dropped spans, but at most
+// once per logInterval. Each message includes the number of spans dropped since the previous message.
+func NewLoggingDroppedSpanCallback(logger Logger, logInterval time.Duration) DroppedSpanCallback {
+	l := &droppedSpanLogger{
+		logger:      logger,
+		logInterval: logInterval,
+		timeNow:     time.Now,
+	}
+	return l.onDroppedSpan
+}
+
+type droppedSpanLogger struct {
+	sync.Mutex
+
+	logger      Logger
+	logInterval time.Duration
+	lastLog     time.Time
+	suppressed  int
+
+	timeNow func() time.Time
+}
+
+func (l *droppedSpanLogger) onDroppedSpan(operationName string, reason SpanDropReason) {
+	l.Lock()
+	defer l.Unlock()
+	now := l.timeNow()
+	if now.Sub(l.lastLog) < l.logInterval {
+		l.suppressed++
+		return
+	}
+	l.lastLog = now
+	l.logger.Error(fmt.Sprintf("dropped span %q, reason: %s (%d more spans dropped since the last message)",
+		operationName, reason, l.suppressed))
+	l.suppressed = 0
+}
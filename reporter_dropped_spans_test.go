@@ -0,0 +1,110 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+type droppedSpansRecorder struct {
+	sync.Mutex
+	dropped []string
+}
+
+func (r *droppedSpansRecorder) onDroppedSpan(operationName string, reason SpanDropReason) {
+	r.Lock()
+	defer r.Unlock()
+	r.dropped = append(r.dropped, operationName+":"+string(reason))
+}
+
+func (r *droppedSpansRecorder) assertDropped(t *testing.T, expected ...string) {
+	get := func() []string {
+		r.Lock()
+		defer r.Unlock()
+		return append([]string(nil), r.dropped...)
+	}
+	for i := 0; i < 1000 && len(get()) < len(expected); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, expected, get())
+}
+
+func TestDroppedSpanCallbackQueueFull(t *testing.T) {
+	recorder := &droppedSpansRecorder{}
+	s := makeReporterSuite(t, ReporterOptions.QueueSize(1), ReporterOptions.DroppedSpanCallback(recorder.onDroppedSpan))
+	defer s.close()
+
+	s.reporter.sendCloseEvent()       // manually shut down the worker
+	s.tracer.StartSpan("s1").Finish() // this span should be added to the queue
+	s.tracer.StartSpan("s2").Finish() // this span should be dropped since the queue is full
+	recorder.assertDropped(t, "s2:queue_full")
+
+	go s.reporter.processQueue() // restart the worker so that Close() doesn't deadlock
+}
+
+func TestDroppedSpanCallbackSendFailure(t *testing.T) {
+	recorder := &droppedSpansRecorder{}
+	s := makeReporterSuiteWithSender(t,
+		&fakeSender{bufferSize: 2, flushErr: errors.New("flush error")},
+		ReporterOptions.DroppedSpanCallback(recorder.onDroppedSpan))
+	s.tracer.StartSpan("s1").Finish()
+	s.tracer.StartSpan("s2").Finish()
+	recorder.assertDropped(t, "s1:send_failure", "s2:send_failure")
+
+	s.tracer.StartSpan("s3").Finish()
+	s.close() // explicit flush also fails
+	recorder.assertDropped(t, "s1:send_failure", "s2:send_failure", "s3:send_failure")
+}
+
+func TestDroppedSpanCallbackTooLarge(t *testing.T) {
+	recorder := &droppedSpansRecorder{}
+	s := makeReporterSuiteWithSender(t,
+		&fakeSender{bufferSize: 5, appendErr: errSpanTooLarge},
+		ReporterOptions.DroppedSpanCallback(recorder.onDroppedSpan))
+	defer s.close()
+	s.tracer.StartSpan("s1").Finish()
+	recorder.assertDropped(t, "s1:too_large")
+}
+
+func TestLoggingDroppedSpanCallback(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	callback := NewLoggingDroppedSpanCallback(logger, time.Hour)
+	callback("s1", SpanDropReasonQueueFull)
+	callback("s2", SpanDropReasonQueueFull)
+	assert.Equal(t, "ERROR: dropped span \"s1\", reason: queue_full (0 more spans dropped since the last message)\n", logger.String())
+}
+
+func TestDroppedSpanLoggerInterval(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	now := time.Unix(1000, 0)
+	l := &droppedSpanLogger{logger: logger, logInterval: time.Minute, timeNow: func() time.Time { return now }}
+
+	l.onDroppedSpan("s1", SpanDropReasonQueueFull)
+	l.onDroppedSpan("s2", SpanDropReasonQueueFull)
+	l.onDroppedSpan("s3", SpanDropReasonSendFailure)
+	assert.Equal(t, "ERROR: dropped span \"s1\", reason: queue_full (0 more spans dropped since the last message)\n", logger.String())
+
+	logger.Flush()
+	now = now.Add(time.Minute)
+	l.onDroppedSpan("s4", SpanDropReasonTooLarge)
+	assert.Equal(t, "ERROR: dropped span \"s4\", reason: too_large (2 more spans dropped since the last message)\n", logger.String())
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"time"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+// reporterErrorLogger logs the errors of the remote reporter. If logInterval is greater than zero,
+// only the first error is logged right away, and the errors that follow within logInterval are
+// aggregated into a single summary message, e.g.
+// `errors when reporting spans failures=1200 interval=1m0s error="connection refused"`,
+// so that an unreachable agent does not flood the application logs.
+//
+// It is only used from the reporter's background go-routine and is not thread-safe.
+type reporterErrorLogger struct {
+	logger      Logger
+	logInterval time.Duration
+
+	// windowStart is when the current aggregation window started, zero if there is none
+	windowStart time.Time
+	// failures is the number of errors suppressed in the current window
+	failures int
+	// lastErr is the most recent suppressed error
+	lastErr error
+
+	timeNow func() time.Time
+}
+
+func newReporterErrorLogger(logger Logger, logInterval time.Duration) *reporterErrorLogger {
+	return &reporterErrorLogger{
+		logger:      logger,
+		logInterval: logInterval,
+		timeNow:     time.Now,
+	}
+}
+
+// logError logs the description of the failed operation with its context and the error,
+// or counts it towards the next summary message.
+func (l *reporterErrorLogger) logError(msg string, err error, fields ...log.Field) {
+	fields = append(fields, log.Err(err))
+	if l.logInterval <= 0 {
+		log.ErrorWithFields(l.logger, msg, fields...)
+		return
+	}
+	now := l.timeNow()
+	l.logSummaryIfElapsed(now)
+	if l.windowStart.IsZero() {
+		log.ErrorWithFields(l.logger, msg, fields...)
+		l.windowStart = now
+		return
+	}
+	l.failures++
+	l.lastErr = err
+}
+
+// tick logs the summary of the suppressed errors if the current window has elapsed.
+// It is called periodically, so that the summary is logged even if the errors stop.
+func (l *reporterErrorLogger) tick() {
+	if l.logInterval > 0 {
+		l.logSummaryIfElapsed(l.timeNow())
+	}
+}
+
+func (l *reporterErrorLogger) logSummaryIfElapsed(now time.Time) {
+	if l.windowStart.IsZero() || now.Sub(l.windowStart) < l.logInterval {
+		return
+	}
+	l.logSummary(now)
+}
+
+// logSummary logs the summary of the suppressed errors, if any, and starts a new window.
+// If no errors were suppressed, the next error will be logged right away.
+func (l *reporterErrorLogger) logSummary(now time.Time) {
+	if l.failures == 0 {
+		l.windowStart = time.Time{}
+		return
+	}
+	log.ErrorWithFields(l.logger, "errors when reporting spans",
+		log.Int("failures", l.failures),
+		log.Duration("interval", now.Sub(l.windowStart).Round(time.Second)),
+		log.Err(l.lastErr))
+	l.windowStart = now
+	l.failures = 0
+	l.lastErr = nil
+}
+
+// flush logs the summary of the suppressed errors, if any.
+func (l *reporterErrorLogger) flush() {
+	if l.failures > 0 {
+		l.logSummary(l.timeNow())
+	}
+}
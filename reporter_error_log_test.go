@@ -0,0 +1,90 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestReporterErrorLoggerWithoutInterval(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	l := newReporterErrorLogger(logger, 0)
+	l.logError("error 1", errors.New("connection refused"))
+	l.logError("error 2", errors.New("connection refused"))
+	l.tick()
+	l.flush()
+	assert.Equal(t, "ERROR: error 1 error=\"connection refused\"\nERROR: error 2 error=\"connection refused\"\n", logger.String())
+}
+
+func TestReporterErrorLoggerAggregation(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	now := time.Unix(1000, 0)
+	l := newReporterErrorLogger(logger, time.Minute)
+	l.timeNow = func() time.Time { return now }
+
+	l.logError("error 1", errors.New("connection refused"))
+	for i := 0; i < 1199; i++ {
+		l.logError("error 2", errors.New("timeout"))
+	}
+	l.logError("error 3", errors.New("connection refused"))
+	assert.Equal(t, "ERROR: error 1 error=\"connection refused\"\n", logger.String())
+
+	// the summary is not logged before the interval elapses
+	now = now.Add(30 * time.Second)
+	l.tick()
+	assert.Equal(t, "ERROR: error 1 error=\"connection refused\"\n", logger.String())
+
+	logger.Flush()
+	now = now.Add(30 * time.Second)
+	l.tick()
+	assert.Equal(t, "ERROR: errors when reporting spans failures=1200 interval=1m0s error=\"connection refused\"\n", logger.String())
+
+	// errors that follow a summary are aggregated into the next window
+	logger.Flush()
+	l.logError("error 4", errors.New("timeout"))
+	now = now.Add(time.Minute)
+	l.tick()
+	assert.Equal(t, "ERROR: errors when reporting spans failures=1 interval=1m0s error=timeout\n", logger.String())
+
+	// after a window without errors, the next error is logged right away
+	logger.Flush()
+	now = now.Add(time.Minute)
+	l.tick()
+	assert.Equal(t, "", logger.String())
+	l.logError("error 5", errors.New("timeout"))
+	assert.Equal(t, "ERROR: error 5 error=timeout\n", logger.String())
+}
+
+func TestReporterErrorLoggerFlush(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	now := time.Unix(1000, 0)
+	l := newReporterErrorLogger(logger, time.Minute)
+	l.timeNow = func() time.Time { return now }
+
+	l.flush()
+	assert.Equal(t, "", logger.String())
+
+	l.logError("error 1", errors.New("connection refused"))
+	l.logError("error 2", errors.New("connection refused"))
+	now = now.Add(10 * time.Second)
+	l.flush()
+	assert.Equal(t, "ERROR: error 1 error=\"connection refused\"\nERROR: errors when reporting spans failures=1 interval=10s error=\"connection refused\"\n", logger.String())
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/uber/jaeger-client-go/thrift"
+	"github.com/uber/jaeger-client-go/utils"
+)
+
+// failureCounter returns the counter of failed spans matching the cause of the Sender error.
+// The spans rejected because they are too large are counted by ReporterSpanTooLarge instead.
+func (m *Metrics) failureCounter(err error) metrics.Counter {
+	for err != nil {
+		if err == utils.ErrUDPConnNotInitialized {
+			return m.ReporterFailureResolveError
+		}
+		if _, ok := err.(*net.DNSError); ok {
+			return m.ReporterFailureResolveError
+		}
+		if errno, ok := err.(syscall.Errno); ok && errno == syscall.ECONNREFUSED {
+			return m.ReporterFailureConnectionRefused
+		}
+		if errno, ok := err.(syscall.Errno); ok && errno == syscall.EMSGSIZE {
+			return m.ReporterFailureBatchTooLarge
+		}
+		if _, ok := err.(*utils.PacketTooLargeError); ok {
+			return m.ReporterFailureBatchTooLarge
+		}
+		if statusErr, ok := err.(interface{ StatusCode() int }); ok &&
+			statusErr.StatusCode() == http.StatusRequestEntityTooLarge {
+			return m.ReporterFailureBatchTooLarge
+		}
+		if _, ok := err.(thrift.TProtocolException); ok {
+			return m.ReporterFailureSerialization
+		}
+		if _, ok := err.(thrift.TTransportException); ok {
+			// the spans are serialized into thrift.TMemoryBuffer, the network errors are not thrift errors
+			return m.ReporterFailureSerialization
+		}
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return m.ReporterFailureTimeout
+		}
+		err = unwrapError(err)
+	}
+	return m.ReporterFailureOther
+}
+
+// unwrapError returns the error wrapped by err, or nil if there is none.
+func unwrapError(err error) error {
+	switch e := err.(type) {
+	case *net.OpError:
+		return e.Err
+	case *os.SyscallError:
+		return e.Err
+	case *url.Error:
+		return e.Err
+	case interface{ Cause() error }:
+		return e.Cause()
+	case interface{ Unwrap() error }:
+		return e.Unwrap()
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	pkgErrors "github.com/pkg/errors"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/uber/jaeger-client-go/thrift"
+	"github.com/uber/jaeger-client-go/utils"
+)
+
+type statusError int
+
+func (e statusError) Error() string   { return "error from collector" }
+func (e statusError) StatusCode() int { return int(e) }
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestMetricsFailureCounter(t *testing.T) {
+	connRefused := &net.OpError{Op: "write", Net: "udp", Err: os.NewSyscallError("write", syscall.ECONNREFUSED)}
+	tests := []struct {
+		err   error
+		cause string
+	}{
+		{err: utils.ErrUDPConnNotInitialized, cause: "resolve_error"},
+		{err: &net.DNSError{Err: "no such host", Name: "jaeger-agent"}, cause: "resolve_error"},
+		{err: &url.Error{Op: "Post", URL: "http://collector", Err: &net.OpError{Op: "dial", Err: &net.DNSError{}}}, cause: "resolve_error"},
+		{err: connRefused, cause: "connection_refused"},
+		{err: pkgErrors.Wrap(connRefused, "failed to send"), cause: "connection_refused"},
+		{err: &url.Error{Op: "Post", URL: "http://collector", Err: timeoutError{}}, cause: "timeout"},
+		{err: &net.OpError{Op: "write", Net: "udp", Err: os.NewSyscallError("write", syscall.EMSGSIZE)}, cause: "batch_too_large"},
+		{err: &utils.PacketTooLargeError{Size: 70000, MaxSize: 65000, Spans: 10}, cause: "batch_too_large"},
+		{err: statusError(413), cause: "batch_too_large"},
+		{err: statusError(503), cause: "other"},
+		{err: thrift.NewTProtocolException(errors.New("invalid data")), cause: "serialization"},
+		{err: thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "short write"), cause: "serialization"},
+		{err: errors.New("error from collector: 503"), cause: "other"},
+	}
+	for _, test := range tests {
+		t.Run(test.err.Error(), func(t *testing.T) {
+			factory := metricstest.NewFactory(0)
+			m := NewMetrics(factory, nil)
+			m.failureCounter(test.err).Inc(1)
+			factory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+				Name:  "jaeger.tracer.reporter_failures",
+				Tags:  map[string]string{"cause": test.cause},
+				Value: 1,
+			})
+		})
+	}
+}
@@ -30,10 +30,30 @@ type reporterOptions struct {
 	queueSize int
 	// bufferFlushInterval is how often the buffer is force-flushed, even if it's not full
 	bufferFlushInterval time.Duration
+	// bufferFlushMaxSpans is the number of buffered spans that triggers a flush
+	bufferFlushMaxSpans int
+	// bufferFlushMaxBytes is the serialized size of buffered spans that triggers a flush
+	bufferFlushMaxBytes int
+	// bufferFlushIdleTimeout is how long after the last reported span the buffer is flushed
+	bufferFlushIdleTimeout time.Duration
 	// logger is used to log errors of span submissions
 	logger Logger
+	// errorLogInterval is the interval over which errors of span submissions are aggregated
+	errorLogInterval time.Duration
 	// metrics is used to record runtime stats
 	metrics *Metrics
+	// droppedSpanCallback is invoked for every span dropped by the reporter
+	droppedSpanCallback DroppedSpanCallback
+	// diagnosticsCallback is invoked for the notable events of the reporter
+	diagnosticsCallback DiagnosticsCallback
+}
+
+// DiagnosticsCallback creates a ReporterOption that sets the callback invoked when the reporter starts
+// failing to send spans or recovers, and when its queue starts or stops overflowing.
+func (reporterOptions) DiagnosticsCallback(callback DiagnosticsCallback) ReporterOption {
+	return func(r *reporterOptions) {
+		r.diagnosticsCallback = callback
+	}
 }
 
 // QueueSize creates a ReporterOption that sets the size of the internal queue where
@@ -60,6 +80,32 @@ func (reporterOptions) BufferFlushInterval(bufferFlushInterval time.Duration) Re
 	}
 }
 
+// BufferFlushMaxSpans creates a ReporterOption that flushes the buffer as soon as it
+// accumulates the given number of spans, even if the Transport could buffer more.
+func (reporterOptions) BufferFlushMaxSpans(maxSpans int) ReporterOption {
+	return func(r *reporterOptions) {
+		r.bufferFlushMaxSpans = maxSpans
+	}
+}
+
+// BufferFlushMaxBytes creates a ReporterOption that flushes the buffer as soon as the
+// Thrift-serialized size of the accumulated spans reaches the given number of bytes.
+// Measuring the size requires serializing each span one more time.
+func (reporterOptions) BufferFlushMaxBytes(maxBytes int) ReporterOption {
+	return func(r *reporterOptions) {
+		r.bufferFlushMaxBytes = maxBytes
+	}
+}
+
+// BufferFlushIdleTimeout creates a ReporterOption that flushes the buffer when no new spans
+// have been reported for the given duration, so that low-traffic services do not have to
+// wait for the full BufferFlushInterval.
+func (reporterOptions) BufferFlushIdleTimeout(idleTimeout time.Duration) ReporterOption {
+	return func(r *reporterOptions) {
+		r.bufferFlushIdleTimeout = idleTimeout
+	}
+}
+
 // Logger creates a ReporterOption that initializes the logger used to log
 // errors of span submissions.
 func (reporterOptions) Logger(logger Logger) ReporterOption {
@@ -67,3 +113,22 @@ func (reporterOptions) Logger(logger Logger) ReporterOption {
 		r.logger = logger
 	}
 }
+
+// ErrorLogInterval creates a ReporterOption that aggregates the errors of span submissions:
+// the first error is logged right away, and the errors that follow within the interval are
+// logged as a single summary message with their count and the most recent error.
+// By default, every error is logged.
+func (reporterOptions) ErrorLogInterval(interval time.Duration) ReporterOption {
+	return func(r *reporterOptions) {
+		r.errorLogInterval = interval
+	}
+}
+
+// DroppedSpanCallback creates a ReporterOption that sets the callback invoked for every span
+// dropped by the reporter, either because the internal queue is full or because the Transport
+// failed to send it. See NewLoggingDroppedSpanCallback for a default implementation.
+func (reporterOptions) DroppedSpanCallback(callback DroppedSpanCallback) ReporterOption {
+	return func(r *reporterOptions) {
+		r.droppedSpanCallback = callback
+	}
+}
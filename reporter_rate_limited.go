@@ -0,0 +1,137 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/uber/jaeger-client-go/thrift"
+	"github.com/uber/jaeger-client-go/utils"
+)
+
+// rateLimitedReporter is a Reporter that limits the rate of spans passed to the underlying reporter.
+type rateLimitedReporter struct {
+	// spansDropped must be first in the struct because `sync/atomic` expects 64-bit alignment.
+	spansDropped int64
+	reporter     Reporter
+	rateLimiter  utils.RateLimiter
+	spanCost     func(span *Span) (float64, error)
+	metrics      *Metrics
+}
+
+// NewRateLimitedReporter creates a reporter that passes at most maxSpansPerSecond spans per second
+// to the underlying reporter, with bursts of up to maxSpansPerSecond spans, or 1 span if the rate is
+// positive but lower. Spans in excess of the limit are dropped and counted by metrics.ReporterRateLimited.
+// This protects the network and jaeger-agent from being saturated by a runaway loop that creates spans.
+func NewRateLimitedReporter(reporter Reporter, maxSpansPerSecond float64, metrics *Metrics) Reporter {
+	maxBurstSpans := maxSpansPerSecond
+	if maxSpansPerSecond > 0 {
+		maxBurstSpans = math.Max(1, maxSpansPerSecond)
+	}
+	return newRateLimitedReporter(reporter, maxSpansPerSecond, maxBurstSpans, func(*Span) (float64, error) { return 1, nil }, metrics)
+}
+
+// NewBytesRateLimitedReporter creates a reporter that passes at most maxBytesPerSecond bytes of spans
+// per second to the underlying reporter, with bursts of up to maxBytesPerSecond bytes bounded by the max
+// size of a UDP packet, utils.UDPPacketMaxLength. The size of a span is measured as the size of its Thrift
+// representation, which requires serializing each span one more time. Spans in excess of the limit, and
+// spans that cannot be serialized, are dropped and counted by metrics.ReporterRateLimited.
+func NewBytesRateLimitedReporter(reporter Reporter, maxBytesPerSecond float64, metrics *Metrics) Reporter {
+	maxBurstBytes := math.Min(maxBytesPerSecond, utils.UDPPacketMaxLength)
+	return NewBytesRateLimitedReporterWithBurst(reporter, maxBytesPerSecond, maxBurstBytes, metrics)
+}
+
+// NewBytesRateLimitedReporterWithBurst is like NewBytesRateLimitedReporter, with bursts of up to maxBurstBytes
+// bytes of spans. The spans larger than maxBurstBytes are always dropped.
+func NewBytesRateLimitedReporterWithBurst(
+	reporter Reporter,
+	maxBytesPerSecond float64,
+	maxBurstBytes float64,
+	metrics *Metrics,
+) Reporter {
+	return newRateLimitedReporter(reporter, maxBytesPerSecond, maxBurstBytes, spanThriftCost, metrics)
+}
+
+func newRateLimitedReporter(
+	reporter Reporter,
+	creditsPerSecond float64,
+	maxBalance float64,
+	spanCost func(span *Span) (float64, error),
+	metrics *Metrics,
+) *rateLimitedReporter {
+	if metrics == nil {
+		metrics = NewNullMetrics()
+	}
+	return &rateLimitedReporter{
+		reporter:    reporter,
+		rateLimiter: utils.NewRateLimiter(creditsPerSecond, maxBalance),
+		spanCost:    spanCost,
+		metrics:     metrics,
+	}
+}
+
+// Report implements Report() method of Reporter by passing the span to the underlying reporter
+// if it is within the rate limit, or dropping it otherwise.
+func (r *rateLimitedReporter) Report(span *Span) {
+	if cost, err := r.spanCost(span); err != nil || !r.rateLimiter.CheckCredit(cost) {
+		r.metrics.ReporterRateLimited.Inc(1)
+		atomic.AddInt64(&r.spansDropped, 1)
+		return
+	}
+	r.reporter.Report(span)
+}
+
+// Close implements Close() method of Reporter by closing the underlying reporter.
+func (r *rateLimitedReporter) Close() {
+	r.reporter.Close()
+}
+
+// CheckHealth implements HealthChecker by delegating to the underlying reporter.
+func (r *rateLimitedReporter) CheckHealth() error {
+	if checker, ok := r.reporter.(HealthChecker); ok {
+		return checker.CheckHealth()
+	}
+	return nil
+}
+
+// Stats returns the runtime statistics of the underlying reporter, including the spans
+// dropped because of the rate limit.
+func (r *rateLimitedReporter) Stats() ReporterStats {
+	var stats ReporterStats
+	if reporter, ok := r.reporter.(reporterWithStats); ok {
+		stats = reporter.Stats()
+	}
+	stats.SpansDropped += atomic.LoadInt64(&r.spansDropped)
+	return stats
+}
+
+// spanThriftSize returns the size in bytes of the span serialized with Thrift compact protocol,
+// or 0 if the span cannot be serialized.
+func spanThriftSize(span *Span) float64 {
+	size, _ := spanThriftCost(span)
+	return size
+}
+
+// spanThriftCost returns the size in bytes of the span serialized with Thrift compact protocol,
+// or an error if the span cannot be serialized.
+func spanThriftCost(span *Span) (float64, error) {
+	buffer := thrift.NewTMemoryBuffer()
+	protocol := thrift.NewTCompactProtocolFactory().GetProtocol(buffer)
+	if err := BuildJaegerThrift(span).Write(protocol); err != nil {
+		return 0, err
+	}
+	return float64(buffer.Len()), nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/uber/jaeger-client-go/utils"
+)
+
+func TestRateLimitedReporter(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	inMemory := NewInMemoryReporter()
+	reporter := NewRateLimitedReporter(inMemory, 2, NewMetrics(metricsFactory, nil))
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter)
+	defer closer.Close()
+
+	for i := 0; i < 5; i++ {
+		tracer.StartSpan("sp").Finish()
+	}
+	assert.Equal(t, 2, inMemory.SpansSubmitted())
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "jaeger.tracer.reporter_spans",
+		Tags:  map[string]string{"result": "rate_limited"},
+		Value: 3,
+	})
+}
+
+func TestBytesRateLimitedReporter(t *testing.T) {
+	inMemory := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), inMemory)
+	defer closer.Close()
+
+	span := tracer.StartSpan("sp").(*Span)
+	spanSize := spanThriftSize(span)
+	assert.True(t, spanSize > 0)
+
+	metricsFactory := metricstest.NewFactory(0)
+	reporter := NewBytesRateLimitedReporter(inMemory, 2.5*spanSize, NewMetrics(metricsFactory, nil))
+	for i := 0; i < 4; i++ {
+		reporter.Report(span)
+	}
+	assert.Equal(t, 2, inMemory.SpansSubmitted())
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "jaeger.tracer.reporter_spans",
+		Tags:  map[string]string{"result": "rate_limited"},
+		Value: 2,
+	})
+}
+
+func TestBytesRateLimitedReporterBurst(t *testing.T) {
+	inMemory := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), inMemory)
+	defer closer.Close()
+
+	span := tracer.StartSpan("sp").(*Span)
+	spanSize := spanThriftSize(span)
+	reporter := NewBytesRateLimitedReporterWithBurst(inMemory, 10*spanSize, 1.5*spanSize, nil)
+	for i := 0; i < 4; i++ {
+		reporter.Report(span)
+	}
+	assert.Equal(t, 1, inMemory.SpansSubmitted())
+}
+
+func TestBytesRateLimitedReporterMaxBurst(t *testing.T) {
+	inMemory := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), inMemory)
+	defer closer.Close()
+
+	large := tracer.StartSpan("large").(*Span)
+	for i := 0; i < 300; i++ {
+		large.SetTag("payload"+strconv.Itoa(i), strings.Repeat("x", 256))
+	}
+	require.True(t, spanThriftSize(large) > utils.UDPPacketMaxLength)
+	reporter := NewBytesRateLimitedReporter(inMemory, 1e9, nil)
+	reporter.Report(large)
+	assert.Equal(t, 0, inMemory.SpansSubmitted(), "the burst is bounded by the max packet size")
+	reporter.Report(tracer.StartSpan("small").(*Span))
+	assert.Equal(t, 1, inMemory.SpansSubmitted())
+}
+
+func TestRateLimitedReporterNilMetrics(t *testing.T) {
+	inMemory := NewInMemoryReporter()
+	reporter := NewRateLimitedReporter(inMemory, 1, nil)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter)
+	tracer.StartSpan("sp1").Finish()
+	tracer.StartSpan("sp2").Finish()
+	assert.Equal(t, 1, inMemory.SpansSubmitted())
+
+	closer.Close() // closes the underlying reporter, which resets it
+	assert.Equal(t, 0, inMemory.SpansSubmitted())
+}
+
+func TestRateLimitedReporterBelowOneSpanPerSecond(t *testing.T) {
+	inMemory := NewInMemoryReporter()
+	reporter := NewRateLimitedReporter(inMemory, 0.5, nil)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter)
+	defer closer.Close()
+
+	tracer.StartSpan("sp1").Finish()
+	tracer.StartSpan("sp2").Finish()
+	assert.Equal(t, 1, inMemory.SpansSubmitted(), "the burst is at least one span")
+}
+
+func TestRateLimitedReporterSpanCostError(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	inMemory := NewInMemoryReporter()
+	reporter := newRateLimitedReporter(inMemory, 1e9, 1e9, func(*Span) (float64, error) {
+		return 0, errors.New("cannot serialize")
+	}, NewMetrics(metricsFactory, nil))
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter)
+	defer closer.Close()
+
+	tracer.StartSpan("sp").Finish()
+	assert.Equal(t, 0, inMemory.SpansSubmitted(), "the spans that cannot be measured are not reported for free")
+	assert.EqualValues(t, 1, reporter.Stats().SpansDropped)
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "jaeger.tracer.reporter_spans",
+		Tags:  map[string]string{"result": "rate_limited"},
+		Value: 1,
+	})
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// RingBufferReporter keeps snapshots of the most recently finished spans in memory, so that they can
+// be inspected without a backend, e.g. when debugging locally. It implements http.Handler to list
+// the spans as JSON, the most recent first. The spans can be filtered with the query parameters:
+//   - traceID: only the spans of the trace with the given ID
+//   - operation: only the spans with the given operation name
+//   - error: if true, only the spans with the error tag set
+//   - limit: the maximum number of spans returned
+//
+// The reporter is usually combined with another one with NewCompositeReporter.
+type RingBufferReporter struct {
+	lock  sync.Mutex
+	spans []SpanSnapshot
+	next  int
+	full  bool
+}
+
+// NewRingBufferReporter creates a reporter that keeps the last size finished spans.
+func NewRingBufferReporter(size int) *RingBufferReporter {
+	if size <= 0 {
+		size = 1
+	}
+	return &RingBufferReporter{spans: make([]SpanSnapshot, size)}
+}
+
+// Report implements Report() method of Reporter by storing a snapshot of the span.
+// The start records and partial snapshots of spans are ignored.
+func (r *RingBufferReporter) Report(span *Span) {
+	if span.isRecord {
+		return
+	}
+	span.RLock()
+	snapshot := span.snapshotNoLocking()
+	span.RUnlock()
+	r.lock.Lock()
+	r.spans[r.next] = snapshot
+	r.next++
+	if r.next == len(r.spans) {
+		r.next = 0
+		r.full = true
+	}
+	r.lock.Unlock()
+}
+
+// Close implements Close() method of Reporter.
+func (r *RingBufferReporter) Close() {}
+
+// SpanFilter selects the spans returned by RingBufferReporter.Spans.
+type SpanFilter struct {
+	// TraceID, if valid, selects the spans of the trace.
+	TraceID TraceID
+	// OperationName, if not empty, selects the spans with the operation name.
+	OperationName string
+	// ErrorsOnly, if true, selects the spans with the error tag set.
+	ErrorsOnly bool
+	// Limit, if positive, is the maximum number of spans returned.
+	Limit int
+}
+
+func (f SpanFilter) matches(span *SpanSnapshot) bool {
+	if f.TraceID.IsValid() && f.TraceID != span.Context.TraceID() {
+		return false
+	}
+	if f.OperationName != "" && f.OperationName != span.OperationName {
+		return false
+	}
+	if f.ErrorsOnly && !isErrorTagValue(span.Tags[string(ext.Error)]) {
+		return false
+	}
+	return true
+}
+
+// Spans returns the snapshots of the spans selected by the filter, the most recent first.
+func (r *RingBufferReporter) Spans(filter SpanFilter) []SpanSnapshot {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	count := r.next
+	if r.full {
+		count = len(r.spans)
+	}
+	var spans []SpanSnapshot
+	for i := 0; i < count; i++ {
+		span := &r.spans[(r.next-1-i+len(r.spans))%len(r.spans)]
+		if !filter.matches(span) {
+			continue
+		}
+		spans = append(spans, *span)
+		if filter.Limit > 0 && len(spans) == filter.Limit {
+			break
+		}
+	}
+	return spans
+}
+
+// ServeHTTP implements http.Handler by listing the spans as JSON.
+func (r *RingBufferReporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	var filter SpanFilter
+	if traceID := query.Get("traceID"); traceID != "" {
+		id, err := TraceIDFromString(traceID)
+		if err != nil {
+			http.Error(w, "invalid traceID: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.TraceID = id
+	}
+	filter.OperationName = query.Get("operation")
+	if errorsOnly := query.Get("error"); errorsOnly != "" {
+		value, err := strconv.ParseBool(errorsOnly)
+		if err != nil {
+			http.Error(w, "invalid error: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.ErrorsOnly = value
+	}
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			http.Error(w, "invalid limit: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = value
+	}
+	spans := r.Spans(filter)
+	views := make([]spanView, len(spans))
+	for i := range spans {
+		views[i] = newSpanView(&spans[i])
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+// spanView is the JSON representation of a span snapshot.
+type spanView struct {
+	TraceID       string                 `json:"traceID"`
+	SpanID        string                 `json:"spanID"`
+	ParentID      string                 `json:"parentID,omitempty"`
+	ServiceName   string                 `json:"serviceName"`
+	OperationName string                 `json:"operationName"`
+	StartTime     time.Time              `json:"startTime"`
+	Duration      string                 `json:"duration"`
+	Sampled       bool                   `json:"sampled"`
+	Tags          map[string]interface{} `json:"tags,omitempty"`
+	Logs          []logView              `json:"logs,omitempty"`
+}
+
+type logView struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Fields    map[string]interface{} `json:"fields"`
+}
+
+func newSpanView(span *SpanSnapshot) spanView {
+	view := spanView{
+		TraceID:       span.Context.TraceID().String(),
+		SpanID:        span.Context.SpanID().String(),
+		ServiceName:   span.ServiceName,
+		OperationName: span.OperationName,
+		StartTime:     span.StartTime,
+		Duration:      span.Duration.String(),
+		Sampled:       span.Context.IsSampled(),
+		Tags:          span.Tags,
+	}
+	if parentID := span.Context.ParentID(); parentID != 0 {
+		view.ParentID = parentID.String()
+	}
+	for _, record := range span.Logs {
+		fields := make(fieldValues, len(record.Fields))
+		for _, field := range record.Fields {
+			field.Marshal(fields)
+		}
+		view.Logs = append(view.Logs, logView{Timestamp: record.Timestamp, Fields: fields})
+	}
+	for _, event := range span.Events {
+		fields := make(fieldValues, len(event.Attributes)+1)
+		fields[EventNameFieldKey] = event.Name
+		for _, field := range event.Attributes {
+			field.Marshal(fields)
+		}
+		view.Logs = append(view.Logs, logView{Timestamp: event.Timestamp, Fields: fields})
+	}
+	return view
+}
+
+// fieldValues collects the values of log fields by key, evaluating the lazy fields.
+type fieldValues map[string]interface{}
+
+func (f fieldValues) EmitString(key, value string)             { f[key] = value }
+func (f fieldValues) EmitBool(key string, value bool)          { f[key] = value }
+func (f fieldValues) EmitInt(key string, value int)            { f[key] = value }
+func (f fieldValues) EmitInt32(key string, value int32)        { f[key] = value }
+func (f fieldValues) EmitInt64(key string, value int64)        { f[key] = value }
+func (f fieldValues) EmitUint32(key string, value uint32)      { f[key] = value }
+func (f fieldValues) EmitUint64(key string, value uint64)      { f[key] = value }
+func (f fieldValues) EmitFloat32(key string, value float32)    { f[key] = value }
+func (f fieldValues) EmitFloat64(key string, value float64)    { f[key] = value }
+func (f fieldValues) EmitObject(key string, value interface{}) { f[key] = value }
+func (f fieldValues) EmitLazyLogger(value log.LazyLogger)      { value(f) }
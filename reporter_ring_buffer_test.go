@@ -0,0 +1,117 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingBufferReporter(t *testing.T) {
+	reporter := NewRingBufferReporter(3)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter)
+	defer closer.Close()
+
+	assert.Empty(t, reporter.Spans(SpanFilter{}))
+
+	root := tracer.StartSpan("root")
+	for i := 0; i < 3; i++ {
+		child := tracer.StartSpan(fmt.Sprintf("child-%d", i), opentracing.ChildOf(root.Context()))
+		if i == 1 {
+			ext.Error.Set(child, true)
+		}
+		child.Finish()
+	}
+	root.Finish()
+
+	spans := reporter.Spans(SpanFilter{})
+	require.Len(t, spans, 3, "the oldest span is overwritten")
+	assert.Equal(t, "root", spans[0].OperationName)
+	assert.Equal(t, "child-2", spans[1].OperationName)
+	assert.Equal(t, "child-1", spans[2].OperationName)
+
+	traceID := root.Context().(SpanContext).TraceID()
+	assert.Len(t, reporter.Spans(SpanFilter{TraceID: traceID}), 3)
+	assert.Empty(t, reporter.Spans(SpanFilter{TraceID: TraceID{Low: 1}}))
+	assert.Len(t, reporter.Spans(SpanFilter{OperationName: "child-2"}), 1)
+	assert.Len(t, reporter.Spans(SpanFilter{Limit: 2}), 2)
+	errors := reporter.Spans(SpanFilter{ErrorsOnly: true})
+	require.Len(t, errors, 1)
+	assert.Equal(t, "child-1", errors[0].OperationName)
+}
+
+func TestRingBufferReporterHandler(t *testing.T) {
+	reporter := NewRingBufferReporter(10)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root")
+	root.LogKV("event", "hello")
+	root.LogFields(log.Lazy(func(fv log.Encoder) {
+		fv.EmitInt("count", 3)
+	}))
+	child := tracer.StartSpan("child", opentracing.ChildOf(root.Context()))
+	ext.Error.Set(child, true)
+	child.Finish()
+	root.Finish()
+	tracer.StartSpan("other").Finish()
+
+	server := httptest.NewServer(reporter)
+	defer server.Close()
+
+	get := func(query string) (int, []spanView) {
+		resp, err := http.Get(server.URL + "?" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var views []spanView
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&views))
+		}
+		return resp.StatusCode, views
+	}
+
+	traceID := root.Context().(SpanContext).TraceID().String()
+	code, views := get("traceID=" + traceID)
+	assert.Equal(t, http.StatusOK, code)
+	require.Len(t, views, 2)
+	assert.Equal(t, "root", views[0].OperationName)
+	assert.Equal(t, "DOOP", views[0].ServiceName)
+	assert.Equal(t, traceID, views[0].TraceID)
+	require.Len(t, views[0].Logs, 2)
+	assert.Equal(t, "hello", views[0].Logs[0].Fields["event"])
+	assert.EqualValues(t, 3, views[0].Logs[1].Fields["count"])
+	assert.Equal(t, views[0].SpanID, views[1].ParentID)
+
+	_, views = get("error=true")
+	require.Len(t, views, 1)
+	assert.Equal(t, "child", views[0].OperationName)
+
+	_, views = get("operation=other&limit=5")
+	require.Len(t, views, 1)
+
+	for _, query := range []string{"traceID=xyz", "error=maybe", "limit=all"} {
+		code, _ = get(query)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
+}
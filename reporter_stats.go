@@ -0,0 +1,110 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReporterStats is a snapshot of the runtime statistics of a reporter. It duplicates some of
+// the reporter metrics, so that applications can expose them on their own health endpoints.
+type ReporterStats struct {
+	// SpansSubmitted is the number of spans successfully submitted to the backend.
+	SpansSubmitted int64
+
+	// SpansDropped is the number of spans dropped before being sent, e.g. due to queue overflow.
+	SpansDropped int64
+
+	// SpansFailed is the number of spans that the Transport failed to send.
+	SpansFailed int64
+
+	// LastError is the most recent error returned by the Transport, or nil.
+	LastError error
+
+	// LastErrorTime is the time of the most recent error returned by the Transport.
+	LastErrorTime time.Time
+
+	// QueueLength is the current number of spans in the reporter queue.
+	QueueLength int64
+}
+
+// reporterWithStats is implemented by reporters that collect ReporterStats.
+type reporterWithStats interface {
+	Stats() ReporterStats
+}
+
+// ReporterStats returns the runtime statistics of the tracer's reporter. Reporters that do not
+// collect statistics, such as NullReporter, result in an empty ReporterStats.
+func (t *Tracer) ReporterStats() ReporterStats {
+	if r, ok := t.reporter.(reporterWithStats); ok {
+		return r.Stats()
+	}
+	return ReporterStats{}
+}
+
+// reporterStats collects ReporterStats in a thread-safe manner.
+type reporterStats struct {
+	// These fields must be first in the struct because `sync/atomic` expects 64-bit alignment.
+	spansSubmitted int64
+	spansDropped   int64
+	spansFailed    int64
+
+	errMtx        sync.Mutex
+	lastError     error
+	lastErrorTime time.Time
+}
+
+func (s *reporterStats) submitted(n int) {
+	atomic.AddInt64(&s.spansSubmitted, int64(n))
+}
+
+func (s *reporterStats) dropped(n int) {
+	atomic.AddInt64(&s.spansDropped, int64(n))
+}
+
+func (s *reporterStats) failed(n int, err error) {
+	atomic.AddInt64(&s.spansFailed, int64(n))
+	s.errMtx.Lock()
+	s.lastError = err
+	s.lastErrorTime = time.Now()
+	s.errMtx.Unlock()
+}
+
+func (s *reporterStats) snapshot() ReporterStats {
+	s.errMtx.Lock()
+	defer s.errMtx.Unlock()
+	return ReporterStats{
+		SpansSubmitted: atomic.LoadInt64(&s.spansSubmitted),
+		SpansDropped:   atomic.LoadInt64(&s.spansDropped),
+		SpansFailed:    atomic.LoadInt64(&s.spansFailed),
+		LastError:      s.lastError,
+		LastErrorTime:  s.lastErrorTime,
+	}
+}
+
+// add accumulates other stats into s, keeping the most recent error.
+func (s ReporterStats) add(other ReporterStats) ReporterStats {
+	s.SpansSubmitted += other.SpansSubmitted
+	s.SpansDropped += other.SpansDropped
+	s.SpansFailed += other.SpansFailed
+	s.QueueLength += other.QueueLength
+	if other.LastError != nil && other.LastErrorTime.After(s.LastErrorTime) {
+		s.LastError = other.LastError
+		s.LastErrorTime = other.LastErrorTime
+	}
+	return s
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracerReporterStats(t *testing.T) {
+	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 2})
+	defer s.close()
+	tracer := s.tracer.(*Tracer)
+
+	assert.Equal(t, ReporterStats{}, tracer.ReporterStats())
+
+	s.tracer.StartSpan("sp1").Finish()
+	s.tracer.StartSpan("sp2").Finish()
+	s.sender.assertFlushedSpans(t, 2)
+	for i := 0; i < 1000 && tracer.ReporterStats().SpansSubmitted != 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	stats := tracer.ReporterStats()
+	assert.EqualValues(t, 2, stats.SpansSubmitted)
+	assert.EqualValues(t, 0, stats.SpansFailed)
+	assert.NoError(t, stats.LastError)
+}
+
+func TestTracerReporterStatsFailures(t *testing.T) {
+	flushErr := errors.New("flush error")
+	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 2, flushErr: flushErr})
+	defer s.close()
+	tracer := s.tracer.(*Tracer)
+
+	s.tracer.StartSpan("sp1").Finish()
+	s.tracer.StartSpan("sp2").Finish()
+	s.sender.assertFlushedSpans(t, 2)
+	for i := 0; i < 1000 && tracer.ReporterStats().SpansFailed != 2; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	stats := tracer.ReporterStats()
+	assert.EqualValues(t, 2, stats.SpansFailed)
+	assert.Equal(t, flushErr, stats.LastError)
+	assert.False(t, stats.LastErrorTime.IsZero())
+}
+
+func TestTracerReporterStatsDropped(t *testing.T) {
+	s := makeReporterSuite(t, ReporterOptions.QueueSize(1))
+	defer s.close()
+	tracer := s.tracer.(*Tracer)
+
+	s.reporter.sendCloseEvent()       // manually shut down the worker
+	s.tracer.StartSpan("s3").Finish() // this span should be added to the queue
+	s.tracer.StartSpan("s4").Finish() // this span should be dropped since the queue is full
+	stats := tracer.ReporterStats()
+	assert.EqualValues(t, 1, stats.SpansDropped)
+	assert.EqualValues(t, 1, stats.QueueLength)
+
+	go s.reporter.processQueue() // restart the worker so that Close() doesn't deadlock
+}
+
+func TestCompositeReporterStats(t *testing.T) {
+	err1, err2 := errors.New("err1"), errors.New("err2")
+	now := time.Now()
+	r1 := &remoteReporter{queueLength: 1}
+	r1.stats.failed(1, err1)
+	r1.stats.lastErrorTime = now
+	r1.stats.submitted(3)
+	r2 := &remoteReporter{queueLength: 2}
+	r2.stats.failed(2, err2)
+	r2.stats.lastErrorTime = now.Add(time.Second)
+	r2.stats.dropped(4)
+
+	rateLimited := NewRateLimitedReporter(r2, 0, nil)
+	rateLimited.Report(&Span{}) // dropped by the rate limiter without reaching r2
+
+	reporter := NewCompositeReporter(r1, NewNullReporter(), rateLimited)
+	stats := reporter.(reporterWithStats).Stats()
+	assert.Equal(t, ReporterStats{
+		SpansSubmitted: 3,
+		SpansDropped:   5,
+		SpansFailed:    3,
+		LastError:      err2,
+		LastErrorTime:  now.Add(time.Second),
+		QueueLength:    3,
+	}, stats)
+}
+
+func TestTracerReporterStatsWithoutStats(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+	require.Equal(t, ReporterStats{}, tracer.(*Tracer).ReporterStats())
+}
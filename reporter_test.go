@@ -16,6 +16,7 @@ package jaeger
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"sync"
@@ -131,16 +132,222 @@ func TestRemoteReporterFlushViaAppend(t *testing.T) {
 	s.assertCounter(t, "jaeger.tracer.reporter_spans", map[string]string{"result": "err"}, 0)
 }
 
+func TestRemoteReporterFlushOnMaxSpans(t *testing.T) {
+	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 100}, ReporterOptions.BufferFlushMaxSpans(2))
+	defer s.close()
+	s.tracer.StartSpan("sp1").Finish()
+	s.sender.assertBufferedSpans(t, 1)
+	s.tracer.StartSpan("sp2").Finish()
+	s.sender.assertFlushedSpans(t, 2)
+	s.tracer.StartSpan("sp3").Finish()
+	s.sender.assertBufferedSpans(t, 1)
+	s.assertCounter(t, "jaeger.tracer.reporter_spans", map[string]string{"result": "ok"}, 2)
+}
+
+func TestRemoteReporterFlushOnMaxBytes(t *testing.T) {
+	span := jaegerTracer.StartSpan("sp").(*Span)
+	spanSize := int(spanThriftSize(span))
+	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 100}, ReporterOptions.BufferFlushMaxBytes(2*spanSize-1))
+	defer s.close()
+	s.reporter.Report(span)
+	s.sender.assertBufferedSpans(t, 1)
+	s.reporter.Report(span)
+	s.sender.assertFlushedSpans(t, 2)
+}
+
+func TestRemoteReporterFlushOnMaxBytesIgnoresSpansTooLarge(t *testing.T) {
+	span := jaegerTracer.StartSpan("sp").(*Span)
+	spanSize := int(spanThriftSize(span))
+	large := jaegerTracer.StartSpan(strings.Repeat("x", 2*spanSize)).(*Span)
+	sender := &fakeSender{bufferSize: 100, tooLarge: func(sp *Span) bool { return sp == large }}
+	s := makeReporterSuiteWithSender(t, sender, ReporterOptions.BufferFlushMaxBytes(2*spanSize-1))
+	defer s.close()
+	s.reporter.Report(span)
+	s.reporter.Report(large)
+	s.assertCounter(t, "jaeger.tracer.reporter_spans_too_large", nil, 1)
+	s.sender.assertBufferedSpans(t, 1)
+	assert.Empty(t, s.sender.FlushedSpans())
+	s.reporter.Report(span)
+	s.sender.assertFlushedSpans(t, 2)
+}
+
+func TestRemoteReporterFlushOnIdleTimeout(t *testing.T) {
+	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 100}, ReporterOptions.BufferFlushIdleTimeout(10*time.Millisecond))
+	defer s.close()
+	s.tracer.StartSpan("sp1").Finish()
+	s.sender.assertFlushedSpans(t, 1)
+	s.tracer.StartSpan("sp2").Finish()
+	s.sender.assertFlushedSpans(t, 2)
+	s.assertCounter(t, "jaeger.tracer.reporter_spans", map[string]string{"result": "ok"}, 2)
+}
+
+func TestRemoteReporterFlushViaAppendResetsPendingSpans(t *testing.T) {
+	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 2}, ReporterOptions.BufferFlushMaxSpans(3))
+	defer s.close()
+	for i := 0; i < 4; i++ {
+		s.tracer.StartSpan("sp").Finish()
+	}
+	// the sender flushes every 2 spans on its own, so the reporter never reaches 3 pending spans
+	s.sender.assertFlushedSpans(t, 4)
+	s.tracer.StartSpan("sp").Finish()
+	s.sender.assertBufferedSpans(t, 1)
+}
+
 func TestRemoteReporterFailedFlushViaAppend(t *testing.T) {
 	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 2, flushErr: errors.New("flush error")}, ReporterOptions.BufferFlushInterval(100*time.Second))
 	s.tracer.StartSpan("sp1").Finish()
 	s.tracer.StartSpan("sp2").Finish()
 	s.sender.assertFlushedSpans(t, 2)
-	s.assertLogs(t, "ERROR: error reporting span \"sp2\": flush error\n")
+	s.assertLogs(t, "ERROR: error reporting span operation=sp2 spans=2 error=\"flush error\"\n")
 	s.assertCounter(t, "jaeger.tracer.reporter_spans", map[string]string{"result": "err"}, 2)
 	s.assertCounter(t, "jaeger.tracer.reporter_spans", map[string]string{"result": "ok"}, 0)
+	s.assertCounter(t, "jaeger.tracer.reporter_failures", map[string]string{"cause": "other"}, 2)
 	s.close() // causes explicit flush that also fails with the same error
-	s.assertLogs(t, "ERROR: error reporting span \"sp2\": flush error\nERROR: error when flushing the buffer: flush error\n")
+	s.assertLogs(t, "ERROR: error reporting span operation=sp2 spans=2 error=\"flush error\"\n"+
+		"ERROR: error when flushing the buffer spans=0 error=\"flush error\"\n")
+}
+
+type recordingHistogram struct {
+	sync.Mutex
+	values []float64
+}
+
+func (r *recordingHistogram) Record(v float64) {
+	r.Lock()
+	defer r.Unlock()
+	r.values = append(r.values, v)
+}
+
+func (r *recordingHistogram) assertRecorded(t *testing.T, count int) {
+	getCount := func() int {
+		r.Lock()
+		defer r.Unlock()
+		return len(r.values)
+	}
+	for i := 0; i < 1000 && getCount() != count; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, count, getCount())
+}
+
+func TestRemoteReporterSpanLatency(t *testing.T) {
+	tests := []struct {
+		name     string
+		sender   *fakeSender
+		recorded []int // after 2 and 3 spans, and after Close
+	}{
+		{name: "success", sender: &fakeSender{bufferSize: 2}, recorded: []int{2, 2, 3}},
+		{name: "failure", sender: &fakeSender{bufferSize: 2, flushErr: errors.New("flush error")}, recorded: []int{0, 0, 0}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			histogram := &recordingHistogram{}
+			m := NewMetrics(metricstest.NewFactory(0), nil)
+			m.ReporterSpanLatency = histogram
+			s := makeReporterSuiteWithSender(t, tc.sender, ReporterOptions.Metrics(m))
+			s.tracer.StartSpan("sp1").Finish()
+			time.Sleep(5 * time.Millisecond)
+			s.tracer.StartSpan("sp2").Finish()
+			s.sender.assertFlushedSpans(t, 2)
+			histogram.assertRecorded(t, tc.recorded[0])
+			s.tracer.StartSpan("sp3").Finish()
+			s.sender.assertBufferedSpans(t, 1)
+			histogram.assertRecorded(t, tc.recorded[1])
+			s.close()
+			histogram.assertRecorded(t, tc.recorded[2])
+			if tc.recorded[0] > 0 {
+				assert.True(t, histogram.values[0] >= (5*time.Millisecond).Seconds(), "the latency of sp1 includes the time waiting for sp2")
+			}
+		})
+	}
+}
+
+// sizedSender is a fakeSender that blocks in Append until released, and reports 100 bytes per span.
+type sizedSender struct {
+	*fakeSender
+	release chan struct{}
+}
+
+func (s *sizedSender) Append(span *Span) (int, error) {
+	<-s.release
+	return s.fakeSender.Append(span)
+}
+
+func (s *sizedSender) LastBatchSize() (int, int) {
+	n := len(s.FlushedSpans())
+	return n, 100 * n
+}
+
+func (s *reporterSuite) assertGauge(t *testing.T, name string, expectedValue int64) {
+	getValue := func() int64 {
+		_, gauges := s.metricsFactory.Snapshot()
+		return gauges[name]
+	}
+	for i := 0; i < 1000; i++ {
+		if getValue() == expectedValue {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, expectedValue, getValue(), "expected gauge: name=%s", name)
+}
+
+func TestRemoteReporterQueueLengthAndBatchSize(t *testing.T) {
+	sender := &sizedSender{fakeSender: &fakeSender{bufferSize: 3}, release: make(chan struct{})}
+	s := &reporterSuite{metricsFactory: metricstest.NewFactory(0)}
+	reporter := NewRemoteReporter(sender,
+		ReporterOptions.Metrics(NewMetrics(s.metricsFactory, nil)),
+		ReporterOptions.BufferFlushInterval(100*time.Second),
+	)
+	tracer, closer := NewTracer("reporter-test-service", NewConstSampler(true), reporter)
+	defer closer.Close()
+
+	// the first span blocks the reporter in Append, the other spans wait in the queue
+	for i := 0; i < 3; i++ {
+		tracer.StartSpan("sp").Finish()
+	}
+	s.assertGauge(t, "jaeger.tracer.reporter_queue_length", 2)
+
+	for i := 0; i < 3; i++ {
+		sender.release <- struct{}{}
+	}
+	sender.assertFlushedSpans(t, 3)
+	s.assertGauge(t, "jaeger.tracer.reporter_queue_length", 0)
+	s.assertGauge(t, "jaeger.tracer.reporter_batch_size", 3)
+	s.assertGauge(t, "jaeger.tracer.reporter_batch_size_bytes", 300)
+}
+
+func TestRemoteReporterBatchSizeWithoutBatchSizer(t *testing.T) {
+	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 3})
+	defer s.close()
+	for i := 0; i < 3; i++ {
+		s.tracer.StartSpan("sp").Finish()
+	}
+	s.sender.assertFlushedSpans(t, 3)
+	s.assertGauge(t, "jaeger.tracer.reporter_batch_size", 3)
+	_, gauges := s.metricsFactory.Snapshot()
+	assert.NotContains(t, gauges, "jaeger.tracer.reporter_batch_size_bytes")
+}
+
+func TestRemoteReporterAggregatedErrorLogs(t *testing.T) {
+	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 1, flushErr: errors.New("connection refused")},
+		ReporterOptions.ErrorLogInterval(time.Hour))
+	for i := 0; i < 5; i++ {
+		s.tracer.StartSpan(fmt.Sprintf("sp%d", i)).Finish()
+	}
+	s.sender.assertFlushedSpans(t, 5)
+	s.assertLogs(t, "ERROR: error reporting span operation=sp0 spans=1 error=\"connection refused\"\n")
+	s.close() // the explicit flush also fails, then the summary of the suppressed errors is logged
+	s.assertLogs(t, "ERROR: error reporting span operation=sp0 spans=1 error=\"connection refused\"\n"+
+		"ERROR: errors when reporting spans failures=5 interval=0s error=\"connection refused\"\n")
+}
+
+func TestRemoteReporterSpanTooLarge(t *testing.T) {
+	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 5, appendErr: errSpanTooLarge})
+	defer s.close()
+	s.tracer.StartSpan("sp1").Finish()
+	s.assertCounter(t, "jaeger.tracer.reporter_spans_too_large", nil, 1)
+	s.assertLogs(t, "ERROR: error reporting span operation=sp1 spans=0 error=\"Span is too large\"\n")
 }
 
 func TestRemoteReporterAppendWithPoolAllocator(t *testing.T) {
@@ -186,6 +393,19 @@ func TestRemoteReporterDoubleClose(t *testing.T) {
 	assert.Equal(t, "ERROR: Repeated attempt to close the reporter is ignored\n", logger.String())
 }
 
+func TestRemoteReporterDebugLogsEmittedBatches(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	sender := &fakeSender{bufferSize: 2}
+	reporter := NewRemoteReporter(sender, ReporterOptions.Logger(logger))
+	tracer, closer := NewTracer("reporter-test-service", NewConstSampler(true), reporter)
+	for i := 0; i < 3; i++ {
+		tracer.StartSpan("leela").Finish()
+	}
+	closer.Close() // flushes the last span
+
+	assert.Equal(t, "DEBUG: Emitted a batch of 2 spans\nDEBUG: Emitted a batch of 1 spans\n", logger.String())
+}
+
 func TestRemoteReporterReportAfterClose(t *testing.T) {
 	s := makeReporterSuite(t)
 	span := s.tracer.StartSpan("leela")
@@ -302,6 +522,8 @@ type fakeSender struct {
 	bufferSize int
 	appendErr  error
 	flushErr   error
+	// tooLarge rejects the spans with errSpanTooLarge without adding them to the buffer
+	tooLarge func(span *Span) bool
 
 	spans   []*Span
 	flushed []*Span
@@ -312,6 +534,9 @@ func (s *fakeSender) Append(span *Span) (int, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	if s.tooLarge != nil && s.tooLarge(span) {
+		return 1, errSpanTooLarge
+	}
 	s.spans = append(s.spans, span)
 	if n := len(s.spans); n == s.bufferSize {
 		return s.flushNoLock()
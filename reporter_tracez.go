@@ -0,0 +1,230 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// TracezLatencyBuckets are the upper bounds of the latency buckets of TracezReporter.
+// The spans longer than the last bound fall into an additional bucket.
+var TracezLatencyBuckets = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+	100 * time.Second,
+}
+
+// TracezReporter aggregates the finished spans by operation name and latency bucket, and keeps the most
+// recent spans of each bucket as examples, similar to the /tracez page of OpenCensus zPages. It implements
+// http.Handler to render a summary of the operations, and the examples of a bucket when the operation
+// and bucket query parameters are given (bucket is the index in TracezLatencyBuckets, or "error").
+// The reporter is usually combined with another one with NewCompositeReporter.
+type TracezReporter struct {
+	examples int
+
+	lock       sync.Mutex
+	operations map[string]*tracezOperation
+}
+
+type tracezOperation struct {
+	counts   []int64 // per latency bucket
+	errors   int64
+	examples [][]SpanSnapshot // per latency bucket, followed by the errors
+}
+
+// NewTracezReporter creates a reporter that keeps the given number of examples per latency bucket.
+func NewTracezReporter(examplesPerBucket int) *TracezReporter {
+	return &TracezReporter{
+		examples:   examplesPerBucket,
+		operations: make(map[string]*tracezOperation),
+	}
+}
+
+func tracezBucket(duration time.Duration) int {
+	for i, bound := range TracezLatencyBuckets {
+		if duration < bound {
+			return i
+		}
+	}
+	return len(TracezLatencyBuckets)
+}
+
+// Report implements Report() method of Reporter by aggregating the span.
+// The start records and partial snapshots of spans are ignored, see TracerOptions.ReportSpanStart
+// and TracerOptions.PartialSpanReporting, so that each span is counted once.
+func (r *TracezReporter) Report(span *Span) {
+	if span.isRecord {
+		return
+	}
+	span.RLock()
+	snapshot := span.snapshotNoLocking()
+	span.RUnlock()
+
+	bucket := tracezBucket(snapshot.Duration)
+	isError := isErrorTagValue(snapshot.Tags[string(ext.Error)])
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	op, ok := r.operations[snapshot.OperationName]
+	if !ok {
+		op = &tracezOperation{
+			counts:   make([]int64, len(TracezLatencyBuckets)+1),
+			examples: make([][]SpanSnapshot, len(TracezLatencyBuckets)+2),
+		}
+		r.operations[snapshot.OperationName] = op
+	}
+	op.counts[bucket]++
+	r.addExample(op, bucket, snapshot)
+	if isError {
+		op.errors++
+		r.addExample(op, len(op.counts), snapshot)
+	}
+}
+
+func (r *TracezReporter) addExample(op *tracezOperation, bucket int, snapshot SpanSnapshot) {
+	if r.examples <= 0 {
+		return
+	}
+	examples := op.examples[bucket]
+	if len(examples) == r.examples {
+		copy(examples, examples[1:])
+		examples = examples[:len(examples)-1]
+	}
+	op.examples[bucket] = append(examples, snapshot)
+}
+
+// Close implements Close() method of Reporter.
+func (r *TracezReporter) Close() {}
+
+// TracezSummary is the aggregate of the spans of an operation, see TracezReporter.Summary.
+type TracezSummary struct {
+	OperationName string
+	// Counts holds the number of spans per latency bucket, see TracezLatencyBuckets.
+	Counts []int64
+	Errors int64
+}
+
+// Summary returns the aggregates of the spans per operation, sorted by operation name.
+func (r *TracezReporter) Summary() []TracezSummary {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	summary := make([]TracezSummary, 0, len(r.operations))
+	for name, op := range r.operations {
+		counts := make([]int64, len(op.counts))
+		copy(counts, op.counts)
+		summary = append(summary, TracezSummary{OperationName: name, Counts: counts, Errors: op.errors})
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		return summary[i].OperationName < summary[j].OperationName
+	})
+	return summary
+}
+
+// Examples returns the most recent spans of the operation in the latency bucket, the most recent first.
+// The bucket is the index in TracezLatencyBuckets, or len(TracezLatencyBuckets)+1 for the spans with errors.
+func (r *TracezReporter) Examples(operationName string, bucket int) []SpanSnapshot {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	op, ok := r.operations[operationName]
+	if !ok || bucket < 0 || bucket >= len(op.examples) {
+		return nil
+	}
+	examples := make([]SpanSnapshot, len(op.examples[bucket]))
+	for i, example := range op.examples[bucket] {
+		examples[len(examples)-1-i] = example
+	}
+	return examples
+}
+
+// ServeHTTP implements http.Handler by rendering the summary, or the examples of a bucket.
+func (r *TracezReporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	operation := query.Get("operation")
+	if operation == "" {
+		tracezSummaryTemplate.Execute(w, tracezSummaryPage{
+			Buckets:    tracezBucketLabels(),
+			Operations: r.Summary(),
+		})
+		return
+	}
+	bucket := len(TracezLatencyBuckets) + 1
+	if value := query.Get("bucket"); value != "error" {
+		var err error
+		if bucket, err = strconv.Atoi(value); err != nil || bucket < 0 || bucket > len(TracezLatencyBuckets) {
+			http.Error(w, "invalid bucket: "+value, http.StatusBadRequest)
+			return
+		}
+	}
+	examples := r.Examples(operation, bucket)
+	views := make([]spanView, len(examples))
+	for i := range examples {
+		views[i] = newSpanView(&examples[i])
+	}
+	tracezExamplesTemplate.Execute(w, tracezExamplesPage{Operation: operation, Spans: views})
+}
+
+func tracezBucketLabels() []string {
+	labels := make([]string, 0, len(TracezLatencyBuckets)+1)
+	var lower time.Duration
+	for _, bound := range TracezLatencyBuckets {
+		labels = append(labels, "["+lower.String()+", "+bound.String()+")")
+		lower = bound
+	}
+	return append(labels, ">="+lower.String())
+}
+
+type tracezSummaryPage struct {
+	Buckets    []string
+	Operations []TracezSummary
+}
+
+type tracezExamplesPage struct {
+	Operation string
+	Spans     []spanView
+}
+
+var tracezSummaryTemplate = template.Must(template.New("summary").Parse(`<!DOCTYPE html>
+<html><head><title>tracez</title></head><body>
+<h1>Spans by operation and latency</h1>
+<table border="1">
+<tr><th>Operation</th>{{range .Buckets}}<th>{{.}}</th>{{end}}<th>Errors</th></tr>
+{{range .Operations}}{{$op := .OperationName}}<tr><td>{{$op}}</td>{{range $i, $count := .Counts}}<td><a href="?operation={{$op}}&amp;bucket={{$i}}">{{$count}}</a></td>{{end}}<td><a href="?operation={{$op}}&amp;bucket=error">{{.Errors}}</a></td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+var tracezExamplesTemplate = template.Must(template.New("examples").Parse(`<!DOCTYPE html>
+<html><head><title>tracez: {{.Operation}}</title></head><body>
+<h1>{{.Operation}}</h1>
+<table border="1">
+<tr><th>Trace ID</th><th>Span ID</th><th>Parent ID</th><th>Start</th><th>Duration</th><th>Tags</th><th>Logs</th></tr>
+{{range .Spans}}<tr><td>{{.TraceID}}</td><td>{{.SpanID}}</td><td>{{.ParentID}}</td><td>{{.StartTime}}</td><td>{{.Duration}}</td><td>{{range $k, $v := .Tags}}{{$k}}={{$v}}<br>{{end}}</td><td>{{range .Logs}}{{.Timestamp}}: {{range $k, $v := .Fields}}{{$k}}={{$v}} {{end}}<br>{{end}}</td></tr>
+{{end}}</table>
+<a href="?">back</a>
+</body></html>
+`))
@@ -0,0 +1,130 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracezReporter(t *testing.T) {
+	reporter := NewTracezReporter(2)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter)
+	defer closer.Close()
+
+	start := time.Now()
+	finish := func(operation string, duration time.Duration, isError bool) {
+		sp := tracer.StartSpan(operation, opentracing.StartTime(start))
+		if isError {
+			ext.Error.Set(sp, true)
+		}
+		sp.FinishWithOptions(opentracing.FinishOptions{FinishTime: start.Add(duration)})
+	}
+	for i := 0; i < 3; i++ {
+		finish("fast", time.Duration(i+1)*time.Microsecond, false)
+	}
+	finish("slow", 2*time.Second, true)
+	finish("slow", time.Hour, false)
+
+	summary := reporter.Summary()
+	require.Len(t, summary, 2)
+	assert.Equal(t, "fast", summary[0].OperationName)
+	assert.Equal(t, []int64{3, 0, 0, 0, 0, 0, 0, 0, 0}, summary[0].Counts)
+	assert.EqualValues(t, 0, summary[0].Errors)
+	assert.Equal(t, "slow", summary[1].OperationName)
+	assert.Equal(t, []int64{0, 0, 0, 0, 0, 0, 1, 0, 1}, summary[1].Counts)
+	assert.EqualValues(t, 1, summary[1].Errors)
+
+	examples := reporter.Examples("fast", 0)
+	require.Len(t, examples, 2, "only the most recent examples are kept")
+	assert.Equal(t, 3*time.Microsecond, examples[0].Duration)
+	assert.Equal(t, 2*time.Microsecond, examples[1].Duration)
+
+	errors := reporter.Examples("slow", len(TracezLatencyBuckets)+1)
+	require.Len(t, errors, 1)
+	assert.Equal(t, 2*time.Second, errors[0].Duration)
+	assert.Empty(t, reporter.Examples("slow", 100))
+	assert.Empty(t, reporter.Examples("unknown", 0))
+}
+
+func TestTracezReporterHandler(t *testing.T) {
+	reporter := NewTracezReporter(5)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("<op>")
+	ext.Error.Set(sp, true)
+	sp.Finish()
+	traceID := sp.Context().(SpanContext).TraceID().String()
+
+	server := httptest.NewServer(reporter)
+	defer server.Close()
+
+	get := func(query string) (int, string) {
+		resp, err := http.Get(server.URL + "?" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	code, body := get("")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "&lt;op&gt;")
+	assert.Contains(t, body, "[0s, 10µs)")
+	assert.Contains(t, body, "bucket=error")
+
+	code, body = get("operation=%3Cop%3E&bucket=error")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, traceID)
+
+	code, _ = get("operation=%3Cop%3E&bucket=x")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestTracezReporterIgnoresSpanRecords(t *testing.T) {
+	tracezReporter := NewTracezReporter(2)
+	ringBufferReporter := NewRingBufferReporter(5)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true),
+		NewCompositeReporter(tracezReporter, ringBufferReporter),
+		TracerOptions.ReportSpanStart(true))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	record := tracer.(*Tracer).newSpanRecord(sp)
+	tracer.(*Tracer).reporter.Report(record)
+	record.Release()
+	sp.Finish()
+	// user spans are counted even if they set the tags of the records
+	tracer.StartSpan("op", opentracing.Tags{PartialSpanTagKey: true, SpanPhaseTagKey: SpanPhaseStarted}).Finish()
+
+	summary := tracezReporter.Summary()
+	require.Len(t, summary, 1)
+	var count int64
+	for _, c := range summary[0].Counts {
+		count += c
+	}
+	assert.EqualValues(t, 2, count, "only the finished spans are counted")
+	assert.Len(t, ringBufferReporter.Spans(SpanFilter{}), 2)
+}
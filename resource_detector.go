@@ -0,0 +1,276 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+const (
+	// K8sPodNameTagKey is the process tag with the name of the Kubernetes pod.
+	K8sPodNameTagKey = "k8s.pod.name"
+
+	// K8sNamespaceTagKey is the process tag with the Kubernetes namespace of the pod.
+	K8sNamespaceTagKey = "k8s.namespace.name"
+
+	// K8sNodeNameTagKey is the process tag with the name of the Kubernetes node running the pod.
+	K8sNodeNameTagKey = "k8s.node.name"
+
+	// ECSInstanceIDTagKey is the process tag with the ID of the Aliyun ECS instance.
+	ECSInstanceIDTagKey = "ecs.instance-id"
+
+	// ECSRegionIDTagKey is the process tag with the region of the Aliyun ECS instance.
+	ECSRegionIDTagKey = "ecs.region-id"
+
+	// ECSZoneIDTagKey is the process tag with the availability zone of the Aliyun ECS instance.
+	ECSZoneIDTagKey = "ecs.zone-id"
+
+	// FCServiceNameTagKey is the process tag with the name of the Aliyun Function Compute service.
+	FCServiceNameTagKey = "fc.service.name"
+
+	// FCFunctionNameTagKey is the process tag with the name of the Aliyun Function Compute function.
+	FCFunctionNameTagKey = "fc.function.name"
+
+	// FCQualifierTagKey is the process tag with the version or alias of the function.
+	FCQualifierTagKey = "fc.qualifier"
+
+	// FCMemoryTagKey is the process tag with the memory size of the function instance, in MB.
+	FCMemoryTagKey = "fc.request.memory"
+
+	// FCRegionTagKey is the process tag with the region of the function.
+	FCRegionTagKey = "fc.region"
+
+	// BuildModuleTagKey is the process tag with the path of the main module of the binary.
+	BuildModuleTagKey = "build.module"
+
+	// BuildVersionTagKey is the process tag with the version of the main module of the binary.
+	BuildVersionTagKey = "build.version"
+
+	// BuildVCSRevisionTagKey is the process tag with the VCS revision the binary was built from.
+	BuildVCSRevisionTagKey = "build.vcs.revision"
+
+	// BuildVCSModifiedTagKey is the process tag reporting whether the binary was built from
+	// a working tree with uncommitted changes.
+	BuildVCSModifiedTagKey = "build.vcs.modified"
+
+	// GoVersionTagKey is the process tag with the version of Go the binary was built with.
+	GoVersionTagKey = "go.version"
+
+	// ProcessPIDTagKey is the process tag with the ID of the process.
+	ProcessPIDTagKey = "process.pid"
+
+	// DefaultECSMetadataURL is the address of the metadata service of Aliyun ECS instances.
+	DefaultECSMetadataURL = "http://100.100.100.200/latest/meta-data/"
+
+	// DefaultResourceDetectionTimeout is the default timeout of the requests to metadata services.
+	DefaultResourceDetectionTimeout = time.Second
+
+	k8sNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// ecsProductNameFile holds the product name of the machine, "Alibaba Cloud ECS" on Aliyun ECS instances.
+var ecsProductNameFile = "/sys/class/dmi/id/product_name"
+
+// ResourceDetector detects the environment the process runs in, and returns the tags
+// describing it. The tags are added to the process tags by the tracer, see TracerOptions.ResourceDetectors.
+// A detector returns no tags and no error if the process does not run in its environment.
+type ResourceDetector interface {
+	Detect() ([]opentracing.Tag, error)
+}
+
+// ResourceDetectorFunc wraps a function into ResourceDetector.
+type ResourceDetectorFunc func() ([]opentracing.Tag, error)
+
+// Detect implements ResourceDetector.
+func (f ResourceDetectorFunc) Detect() ([]opentracing.Tag, error) {
+	return f()
+}
+
+// KubernetesResourceDetector returns a ResourceDetector that reports the pod, namespace and node
+// of the process running in Kubernetes. The pod name defaults to the hostname, the namespace is read
+// from the service account; both can be overridden, along with the node name, by the POD_NAME,
+// POD_NAMESPACE and NODE_NAME environment variables, usually populated with the downward API.
+func KubernetesResourceDetector() ResourceDetector {
+	return ResourceDetectorFunc(func() ([]opentracing.Tag, error) {
+		if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+			return nil, nil
+		}
+		podName := os.Getenv("POD_NAME")
+		if podName == "" {
+			podName, _ = os.Hostname()
+		}
+		namespace := os.Getenv("POD_NAMESPACE")
+		if namespace == "" {
+			if b, err := ioutil.ReadFile(k8sNamespaceFile); err == nil {
+				namespace = strings.TrimSpace(string(b))
+			}
+		}
+		var tags []opentracing.Tag
+		tags = appendTagIfSet(tags, K8sPodNameTagKey, podName)
+		tags = appendTagIfSet(tags, K8sNamespaceTagKey, namespace)
+		tags = appendTagIfSet(tags, K8sNodeNameTagKey, os.Getenv("NODE_NAME"))
+		return tags, nil
+	})
+}
+
+// FCResourceDetector returns a ResourceDetector that reports the service, function, qualifier,
+// memory size and region of an Aliyun Function Compute instance, from the environment variables
+// set by the runtime.
+func FCResourceDetector() ResourceDetector {
+	return ResourceDetectorFunc(func() ([]opentracing.Tag, error) {
+		functionName := os.Getenv("FC_FUNCTION_NAME")
+		if functionName == "" {
+			return nil, nil
+		}
+		var tags []opentracing.Tag
+		tags = appendTagIfSet(tags, FCServiceNameTagKey, os.Getenv("FC_SERVICE_NAME"))
+		tags = appendTagIfSet(tags, FCFunctionNameTagKey, functionName)
+		tags = appendTagIfSet(tags, FCQualifierTagKey, os.Getenv("FC_QUALIFIER"))
+		tags = appendTagIfSet(tags, FCMemoryTagKey, os.Getenv("FC_FUNCTION_MEMORY_SIZE"))
+		tags = appendTagIfSet(tags, FCRegionTagKey, os.Getenv("FC_REGION"))
+		return tags, nil
+	})
+}
+
+// ECSResourceDetector returns a ResourceDetector that reports the instance, region and zone of
+// an Aliyun ECS instance, read from the metadata service at the given URL (DefaultECSMetadataURL
+// if empty). Each request is limited by the timeout (DefaultResourceDetectionTimeout if zero).
+// With the default URL, the metadata service is only queried if the product name of the machine
+// is the one of ECS instances, so that no tags and no error are returned elsewhere. An error is
+// returned if the metadata service cannot be reached.
+func ECSResourceDetector(metadataURL string, timeout time.Duration) ResourceDetector {
+	if metadataURL == "" {
+		metadataURL = DefaultECSMetadataURL
+	}
+	checkProductName := metadataURL == DefaultECSMetadataURL
+	if !strings.HasSuffix(metadataURL, "/") {
+		metadataURL += "/"
+	}
+	if timeout == 0 {
+		timeout = DefaultResourceDetectionTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	return ResourceDetectorFunc(func() ([]opentracing.Tag, error) {
+		if checkProductName && !isECSInstance() {
+			return nil, nil
+		}
+		instanceID, err := getMetadata(client, metadataURL+"instance-id")
+		if err != nil {
+			return nil, err
+		}
+		var tags []opentracing.Tag
+		tags = appendTagIfSet(tags, ECSInstanceIDTagKey, instanceID)
+		regionID, err := getMetadata(client, metadataURL+"region-id")
+		if err != nil {
+			return nil, err
+		}
+		tags = appendTagIfSet(tags, ECSRegionIDTagKey, regionID)
+		zoneID, err := getMetadata(client, metadataURL+"zone-id")
+		if err != nil {
+			return nil, err
+		}
+		tags = appendTagIfSet(tags, ECSZoneIDTagKey, zoneID)
+		return tags, nil
+	})
+}
+
+// BuildInfoResourceDetector returns a ResourceDetector that reports the Go version the binary
+// was built with, the ID of the process, and, when the binary embeds build information, the path
+// and version of the main module and the VCS revision it was built from.
+func BuildInfoResourceDetector() ResourceDetector {
+	return ResourceDetectorFunc(func() ([]opentracing.Tag, error) {
+		tags := []opentracing.Tag{
+			{Key: GoVersionTagKey, Value: runtime.Version()},
+			{Key: ProcessPIDTagKey, Value: os.Getpid()},
+		}
+		if info, ok := readBuildInfo(); ok {
+			tags = append(tags, info.tags()...)
+		}
+		return tags, nil
+	})
+}
+
+// buildInfo is the subset of the build information embedded in the binary reported as process tags.
+type buildInfo struct {
+	module      string
+	version     string
+	vcsRevision string
+	vcsModified string
+}
+
+func (info buildInfo) tags() []opentracing.Tag {
+	var tags []opentracing.Tag
+	tags = appendTagIfSet(tags, BuildModuleTagKey, info.module)
+	if info.version != "(devel)" {
+		tags = appendTagIfSet(tags, BuildVersionTagKey, info.version)
+	}
+	tags = appendTagIfSet(tags, BuildVCSRevisionTagKey, info.vcsRevision)
+	tags = appendTagIfSet(tags, BuildVCSModifiedTagKey, info.vcsModified)
+	return tags
+}
+
+// isECSInstance returns true if the product name of the machine is the one of Aliyun ECS instances.
+func isECSInstance() bool {
+	b, err := ioutil.ReadFile(ecsProductNameFile)
+	return err == nil && strings.HasPrefix(strings.TrimSpace(string(b)), "Alibaba Cloud")
+}
+
+func getMetadata(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("StatusCode: %d, Body: %s", resp.StatusCode, body)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+func appendTagIfSet(tags []opentracing.Tag, key, value string) []opentracing.Tag {
+	if value == "" {
+		return tags
+	}
+	return append(tags, opentracing.Tag{Key: key, Value: value})
+}
+
+// detectResources adds the tags of the resource detectors to the process tags,
+// unless they are already set.
+func (t *Tracer) detectResources() {
+	for _, detector := range t.options.resourceDetectors {
+		tags, err := detector.Detect()
+		if err != nil {
+			t.logger.Error("Unable to detect process resources: " + err.Error())
+			continue
+		}
+		for _, tag := range tags {
+			if _, ok := t.getTag(tag.Key); !ok {
+				t.tags = append(t.tags, Tag{key: tag.Key, value: tag.Value})
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func setEnv(t *testing.T, env map[string]string) func() {
+	for k, v := range env {
+		require.NoError(t, os.Setenv(k, v))
+	}
+	return func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+func TestKubernetesResourceDetector(t *testing.T) {
+	tags, err := KubernetesResourceDetector().Detect()
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	defer setEnv(t, map[string]string{
+		"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+		"POD_NAME":                "pod-1",
+		"POD_NAMESPACE":           "default",
+		"NODE_NAME":               "node-1",
+	})()
+	tags, err = KubernetesResourceDetector().Detect()
+	require.NoError(t, err)
+	assert.Equal(t, []opentracing.Tag{
+		{Key: K8sPodNameTagKey, Value: "pod-1"},
+		{Key: K8sNamespaceTagKey, Value: "default"},
+		{Key: K8sNodeNameTagKey, Value: "node-1"},
+	}, tags)
+}
+
+func TestFCResourceDetector(t *testing.T) {
+	tags, err := FCResourceDetector().Detect()
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	defer setEnv(t, map[string]string{
+		"FC_SERVICE_NAME":         "svc",
+		"FC_FUNCTION_NAME":        "fn",
+		"FC_QUALIFIER":            "LATEST",
+		"FC_FUNCTION_MEMORY_SIZE": "512",
+	})()
+	tags, err = FCResourceDetector().Detect()
+	require.NoError(t, err)
+	assert.Equal(t, []opentracing.Tag{
+		{Key: FCServiceNameTagKey, Value: "svc"},
+		{Key: FCFunctionNameTagKey, Value: "fn"},
+		{Key: FCQualifierTagKey, Value: "LATEST"},
+		{Key: FCMemoryTagKey, Value: "512"},
+	}, tags)
+}
+
+func TestECSResourceDetector(t *testing.T) {
+	metadata := map[string]string{
+		"/latest/meta-data/instance-id": "i-123",
+		"/latest/meta-data/region-id":   "cn-hangzhou",
+		"/latest/meta-data/zone-id":     "cn-hangzhou-h",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, ok := metadata[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(value + "\n"))
+	}))
+	defer server.Close()
+
+	tags, err := ECSResourceDetector(server.URL+"/latest/meta-data", 0).Detect()
+	require.NoError(t, err)
+	assert.Equal(t, []opentracing.Tag{
+		{Key: ECSInstanceIDTagKey, Value: "i-123"},
+		{Key: ECSRegionIDTagKey, Value: "cn-hangzhou"},
+		{Key: ECSZoneIDTagKey, Value: "cn-hangzhou-h"},
+	}, tags)
+
+	_, err = ECSResourceDetector(server.URL+"/missing/", 0).Detect()
+	assert.Error(t, err)
+}
+
+func TestECSResourceDetectorNotOnECS(t *testing.T) {
+	productName, err := ioutil.TempFile("", "product_name")
+	require.NoError(t, err)
+	defer os.Remove(productName.Name())
+	productName.WriteString("Standard PC (i440FX + PIIX, 1996)\n")
+	productName.Close()
+	defer func(file string) { ecsProductNameFile = file }(ecsProductNameFile)
+
+	for _, file := range []string{productName.Name(), productName.Name() + ".missing"} {
+		ecsProductNameFile = file
+		tags, err := ECSResourceDetector("", time.Nanosecond).Detect()
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+	}
+}
+
+func TestBuildInfoResourceDetector(t *testing.T) {
+	tags, err := BuildInfoResourceDetector().Detect()
+	require.NoError(t, err)
+	require.True(t, len(tags) >= 2)
+	assert.Equal(t, opentracing.Tag{Key: GoVersionTagKey, Value: runtime.Version()}, tags[0])
+	assert.Equal(t, opentracing.Tag{Key: ProcessPIDTagKey, Value: os.Getpid()}, tags[1])
+
+	info := buildInfo{
+		module:      "github.com/example/app",
+		version:     "v1.2.3",
+		vcsRevision: "0123abcd",
+		vcsModified: "false",
+	}
+	assert.Equal(t, []opentracing.Tag{
+		{Key: BuildModuleTagKey, Value: "github.com/example/app"},
+		{Key: BuildVersionTagKey, Value: "v1.2.3"},
+		{Key: BuildVCSRevisionTagKey, Value: "0123abcd"},
+		{Key: BuildVCSModifiedTagKey, Value: "false"},
+	}, info.tags())
+
+	info = buildInfo{module: "github.com/example/app", version: "(devel)"}
+	assert.Equal(t, []opentracing.Tag{
+		{Key: BuildModuleTagKey, Value: "github.com/example/app"},
+	}, info.tags())
+}
+
+func TestTracerResourceDetectors(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Logger(logger),
+		TracerOptions.Tag("fc.qualifier", "prod"),
+		TracerOptions.ResourceDetectors(
+			ResourceDetectorFunc(func() ([]opentracing.Tag, error) {
+				return []opentracing.Tag{
+					{Key: FCFunctionNameTagKey, Value: "fn"},
+					{Key: FCQualifierTagKey, Value: "LATEST"},
+				}, nil
+			}),
+			ResourceDetectorFunc(func() ([]opentracing.Tag, error) {
+				return nil, errors.New("no metadata")
+			}),
+		),
+	)
+	defer closer.Close()
+
+	tags := tracer.(*Tracer).Tags()
+	assert.Contains(t, tags, opentracing.Tag{Key: FCFunctionNameTagKey, Value: "fn"})
+	assert.Contains(t, tags, opentracing.Tag{Key: FCQualifierTagKey, Value: "prod"})
+	assert.NotContains(t, tags, opentracing.Tag{Key: FCQualifierTagKey, Value: "LATEST"})
+	assert.Contains(t, logger.String(), "ERROR: Unable to detect process resources: no metadata")
+}
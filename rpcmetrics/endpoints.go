@@ -14,38 +14,61 @@
 
 package rpcmetrics
 
-import "sync"
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
 
-// normalizedEndpoints is a cache for endpointName -> safeName mappings.
+// normalizedEndpoints is a cache for endpointName -> safeName mappings. The cache keeps
+// approximately the maxCacheSize most recently used endpoint names, and limits the number
+// of distinct safe names, i.e. the endpoints with metrics, to maxSize.
 type normalizedEndpoints struct {
-	names       map[string]string
-	maxSize     int
-	defaultName string
-	normalizer  NameNormalizer
-	mux         sync.RWMutex
+	names        map[string]*list.Element
+	lru          *list.List
+	safeNames    map[string]struct{}
+	maxSize      int
+	maxCacheSize int
+	normalizer   NameNormalizer
+	mux          sync.RWMutex
+}
+
+type normalizedEndpoint struct {
+	name     string
+	safeName string
+	// used is set to 1 when the name is looked up, see evictOldest
+	used int32
 }
 
 func newNormalizedEndpoints(maxSize int, normalizer NameNormalizer) *normalizedEndpoints {
+	return newNormalizedEndpointsWithCacheSize(maxSize, maxSize, normalizer)
+}
+
+func newNormalizedEndpointsWithCacheSize(maxSize, maxCacheSize int, normalizer NameNormalizer) *normalizedEndpoints {
+	if maxCacheSize < maxSize {
+		maxCacheSize = maxSize
+	}
 	return &normalizedEndpoints{
-		maxSize:    maxSize,
-		normalizer: normalizer,
-		names:      make(map[string]string, maxSize),
+		maxSize:      maxSize,
+		maxCacheSize: maxCacheSize,
+		normalizer:   normalizer,
+		names:        make(map[string]*list.Element, maxCacheSize),
+		lru:          list.New(),
+		safeNames:    make(map[string]struct{}, maxSize),
 	}
 }
 
 // normalize looks up the name in the cache, if not found it uses normalizer
-// to convert the name to a safe name. If called with more than maxSize unique
-// names it returns "" for all other names beyond those already cached.
+// to convert the name to a safe name. If the name converts to a new safe name
+// when there are already maxSize safe names, it returns "".
 func (n *normalizedEndpoints) normalize(name string) string {
 	n.mux.RLock()
-	norm, ok := n.names[name]
-	l := len(n.names)
+	e, ok := n.names[name]
 	n.mux.RUnlock()
 	if ok {
-		return norm
-	}
-	if l >= n.maxSize {
-		return ""
+		endpoint := e.Value.(*normalizedEndpoint)
+		atomic.StoreInt32(&endpoint.used, 1)
+		return endpoint.safeName
 	}
 	return n.normalizeWithLock(name)
 }
@@ -54,10 +77,34 @@ func (n *normalizedEndpoints) normalizeWithLock(name string) string {
 	norm := n.normalizer.Normalize(name)
 	n.mux.Lock()
 	defer n.mux.Unlock()
-	// cache may have grown while we were not holding the lock
-	if len(n.names) >= n.maxSize {
+	// cache may have been updated while we were not holding the lock
+	if e, ok := n.names[name]; ok {
+		return e.Value.(*normalizedEndpoint).safeName
+	}
+	if _, ok := n.safeNames[norm]; !ok && len(n.safeNames) >= n.maxSize {
 		return ""
 	}
-	n.names[name] = norm
+	if len(n.names) >= n.maxCacheSize {
+		n.evictOldest()
+	}
+	n.names[name] = n.lru.PushFront(&normalizedEndpoint{name: name, safeName: norm})
+	n.safeNames[norm] = struct{}{}
 	return norm
 }
+
+// evictOldest removes the least recently used name from the cache. Its safe name is kept,
+// since the metrics of the endpoint have already been created. The lookups only take the read
+// lock and mark the names as used instead of moving them in the list, so the oldest names that
+// were used since they were last checked are moved to the front instead of being evicted.
+func (n *normalizedEndpoints) evictOldest() {
+	for i := n.lru.Len(); i > 0; i-- {
+		e := n.lru.Back()
+		if !atomic.CompareAndSwapInt32(&e.Value.(*normalizedEndpoint).used, 1, 0) {
+			break
+		}
+		n.lru.MoveToFront(e)
+	}
+	if e := n.lru.Back(); e != nil {
+		delete(n.names, n.lru.Remove(e).(*normalizedEndpoint).name)
+	}
+}
@@ -15,6 +15,8 @@
 package rpcmetrics
 
 import (
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -41,3 +43,38 @@ func TestNormalizedEndpointsDoubleLocking(t *testing.T) {
 	assert.Equal(t, "ab-cd", n.normalize("ab^cd"), "fill out the cache")
 	assert.Equal(t, "", n.normalizeWithLock("xys"), "cache overflow")
 }
+
+func TestNormalizedEndpointsLRU(t *testing.T) {
+	n := newNormalizedEndpointsWithCacheSize(2, 3, &RewriteNameNormalizer{Rules: []RewriteRule{CollapsePathParams}})
+
+	assert.Equal(t, "/users/{id}", n.normalize("/users/1"))
+	assert.Equal(t, "/users/{id}", n.normalize("/users/2"))
+	assert.Equal(t, "/items/{id}", n.normalize("/items/1"))
+	assert.Equal(t, "", n.normalize("/orders/1"), "too many endpoints")
+	assert.Len(t, n.names, 3)
+
+	// the least recently used names are evicted, but the endpoints are kept
+	assert.Equal(t, "/users/{id}", n.normalize("/users/1"))
+	assert.Equal(t, "/users/{id}", n.normalize("/users/3"))
+	assert.Len(t, n.names, 3)
+	_, ok := n.names["/users/2"]
+	assert.False(t, ok, "evicted")
+	assert.Equal(t, "/items/{id}", n.normalize("/items/2"))
+	assert.Equal(t, "", n.normalize("/orders/1"), "too many endpoints")
+}
+
+func TestNormalizedEndpointsConcurrent(t *testing.T) {
+	n := newNormalizedEndpointsWithCacheSize(10, 10, DefaultNameNormalizer)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				n.normalize(strconv.Itoa((i * j) % 20))
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.True(t, len(n.names) <= 10)
+}
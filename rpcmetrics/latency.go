@@ -0,0 +1,80 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmetrics
+
+import (
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+// DefaultLatencyBuckets are the bucket boundaries of the latency histograms when Options.LatencyBuckets
+// is not set, from 5ms to 10s like the default buckets of the Prometheus histograms.
+var DefaultLatencyBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// latencyFactory is a metrics.Factory that applies the latency options to the timers and histograms
+// created by metrics.Init for the Metrics of the endpoints. The histograms are opt-in.
+type latencyFactory struct {
+	metrics.Factory
+	buckets    []time.Duration
+	histograms bool
+	timers     bool
+}
+
+func newLatencyFactory(factory metrics.Factory, opts options) metrics.Factory {
+	return &latencyFactory{
+		Factory:    factory,
+		buckets:    opts.latencyBuckets,
+		histograms: opts.latencyHistograms,
+		timers:     !opts.noLatencyTimers,
+	}
+}
+
+func (f *latencyFactory) Timer(options metrics.TimerOptions) metrics.Timer {
+	if !f.timers {
+		return metrics.NullTimer
+	}
+	if f.buckets != nil {
+		options.Buckets = f.buckets
+	}
+	return f.Factory.Timer(options)
+}
+
+func (f *latencyFactory) Histogram(options metrics.HistogramOptions) metrics.Histogram {
+	if !f.histograms {
+		return metrics.NullHistogram
+	}
+	buckets := f.buckets
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+	options.Buckets = make([]float64, len(buckets))
+	for i, b := range buckets {
+		options.Buckets[i] = b.Seconds()
+	}
+	return f.Factory.Histogram(options)
+}
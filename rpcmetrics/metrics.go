@@ -15,7 +15,9 @@
 package rpcmetrics
 
 import (
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/uber/jaeger-lib/metrics"
 )
@@ -23,8 +25,15 @@ import (
 const (
 	otherEndpointsPlaceholder = "other"
 	endpointNameMetricTag     = "endpoint"
+	otherHTTPMethod           = "other"
 )
 
+// httpMethods are the HTTP methods used as the tags of the metrics, the other methods are tagged "other"
+// to keep the number of metrics bounded.
+var httpMethods = map[string]struct{}{
+	"GET": {}, "HEAD": {}, "POST": {}, "PUT": {}, "PATCH": {}, "DELETE": {}, "OPTIONS": {}, "CONNECT": {}, "TRACE": {},
+}
+
 // Metrics is a collection of metrics for an endpoint describing
 // throughput, success, errors, and performance.
 type Metrics struct {
@@ -40,6 +49,14 @@ type Metrics struct {
 	// RequestLatencyFailures is a latency histogram of failed requests.
 	RequestLatencyFailures metrics.Timer `metric:"request_latency" tags:"error=true"`
 
+	// RequestLatencySecondsSuccess is a latency histogram in seconds of successful requests,
+	// only emitted with Options.LatencyHistograms.
+	RequestLatencySecondsSuccess metrics.Histogram `metric:"request_latency_seconds" tags:"error=false"`
+
+	// RequestLatencySecondsFailures is a latency histogram in seconds of failed requests,
+	// only emitted with Options.LatencyHistograms.
+	RequestLatencySecondsFailures metrics.Histogram `metric:"request_latency_seconds" tags:"error=true"`
+
 	// HTTPStatusCode2xx is a counter of the total number of requests with HTTP status code 200-299
 	HTTPStatusCode2xx metrics.Counter `metric:"http_requests" tags:"status_code=2xx"`
 
@@ -53,14 +70,49 @@ type Metrics struct {
 	HTTPStatusCode5xx metrics.Counter `metric:"http_requests" tags:"status_code=5xx"`
 }
 
+// httpStatusClass returns the class of the HTTP status code, e.g. "2xx", or "" if it is not valid.
+func httpStatusClass(statusCode uint16) string {
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return "2xx"
+	case statusCode >= 300 && statusCode < 400:
+		return "3xx"
+	case statusCode >= 400 && statusCode < 500:
+		return "4xx"
+	case statusCode >= 500 && statusCode < 600:
+		return "5xx"
+	}
+	return ""
+}
+
+// normalizeHTTPMethod returns the uppercased HTTP method if it is standard, or "other".
+func normalizeHTTPMethod(method string) string {
+	method = strings.ToUpper(method)
+	if _, ok := httpMethods[method]; ok {
+		return method
+	}
+	return otherHTTPMethod
+}
+
+func (m *Metrics) recordLatency(latency time.Duration, failed bool) {
+	if failed {
+		m.RequestLatencyFailures.Record(latency)
+		m.RequestLatencySecondsFailures.Record(latency.Seconds())
+	} else {
+		m.RequestLatencySuccess.Record(latency)
+		m.RequestLatencySecondsSuccess.Record(latency.Seconds())
+	}
+}
+
 func (m *Metrics) recordHTTPStatusCode(statusCode uint16) {
-	if statusCode >= 200 && statusCode < 300 {
+	switch httpStatusClass(statusCode) {
+	case "2xx":
 		m.HTTPStatusCode2xx.Inc(1)
-	} else if statusCode >= 300 && statusCode < 400 {
+	case "3xx":
 		m.HTTPStatusCode3xx.Inc(1)
-	} else if statusCode >= 400 && statusCode < 500 {
+	case "4xx":
 		m.HTTPStatusCode4xx.Inc(1)
-	} else if statusCode >= 500 && statusCode < 600 {
+	case "5xx":
 		m.HTTPStatusCode5xx.Inc(1)
 	}
 }
@@ -72,18 +124,29 @@ type MetricsByEndpoint struct {
 	metricsFactory    metrics.Factory
 	endpoints         *normalizedEndpoints
 	metricsByEndpoint map[string]*Metrics
+	httpRequests      map[httpRequestsKey]metrics.Counter
 	mux               sync.RWMutex
 }
 
+// httpRequestsKey identifies the counter of the HTTP requests by span kind, method and status class.
+type httpRequestsKey struct {
+	endpoint    string
+	kind        string
+	method      string
+	statusClass string
+}
+
 func newMetricsByEndpoint(
 	metricsFactory metrics.Factory,
 	normalizer NameNormalizer,
 	maxNumberOfEndpoints int,
+	maxCachedNames int,
 ) *MetricsByEndpoint {
 	return &MetricsByEndpoint{
 		metricsFactory:    metricsFactory,
-		endpoints:         newNormalizedEndpoints(maxNumberOfEndpoints, normalizer),
+		endpoints:         newNormalizedEndpointsWithCacheSize(maxNumberOfEndpoints, maxCachedNames, normalizer),
 		metricsByEndpoint: make(map[string]*Metrics, maxNumberOfEndpoints+1), // +1 for "other"
+		httpRequests:      make(map[httpRequestsKey]metrics.Counter),
 	}
 }
 
@@ -122,3 +185,37 @@ func (m *MetricsByEndpoint) getWithWriteLock(safeName string) *Metrics {
 	m.metricsByEndpoint[safeName] = met
 	return met
 }
+
+// httpRequestCounter returns the counter of the HTTP requests of the endpoint with the given span kind,
+// "server" or "client", HTTP method and status class.
+func (m *MetricsByEndpoint) httpRequestCounter(endpoint, kind, method, statusClass string) metrics.Counter {
+	safeName := m.endpoints.normalize(endpoint)
+	if safeName == "" {
+		safeName = otherEndpointsPlaceholder
+	}
+	key := httpRequestsKey{endpoint: safeName, kind: kind, method: normalizeHTTPMethod(method), statusClass: statusClass}
+	m.mux.RLock()
+	counter := m.httpRequests[key]
+	m.mux.RUnlock()
+	if counter != nil {
+		return counter
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if counter, ok := m.httpRequests[key]; ok {
+		return counter
+	}
+	counter = m.metricsFactory.Counter(metrics.Options{
+		Name: "http_requests_by_method",
+		Tags: map[string]string{
+			endpointNameMetricTag: key.endpoint,
+			"kind":                key.kind,
+			"method":              key.method,
+			"status_code":         key.statusClass,
+		},
+		Help: "Number of HTTP requests by span kind, HTTP method and status code class",
+	})
+	m.httpRequests[key] = counter
+	return counter
+}
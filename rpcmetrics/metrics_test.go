@@ -36,7 +36,7 @@ func endpointTags(endpoint string, kv ...string) map[string]string {
 
 func TestMetricsByEndpoint(t *testing.T) {
 	met := metricstest.NewFactory(0)
-	mbe := newMetricsByEndpoint(met, DefaultNameNormalizer, 2)
+	mbe := newMetricsByEndpoint(met, DefaultNameNormalizer, 2, 2)
 
 	m1 := mbe.get("abc1")
 	m2 := mbe.get("abc1")               // from cache
@@ -26,20 +26,20 @@ import (
 	jaeger "github.com/uber/jaeger-client-go"
 )
 
-const defaultMaxNumberOfEndpoints = 200
-
 // Observer is an observer that can emit RPC metrics.
 type Observer struct {
 	metricsByEndpoint *MetricsByEndpoint
 }
 
 // NewObserver creates a new observer that can emit RPC metrics.
-func NewObserver(metricsFactory metrics.Factory, normalizer NameNormalizer) *Observer {
+func NewObserver(metricsFactory metrics.Factory, normalizer NameNormalizer, options ...Option) *Observer {
+	opts := applyOptions(options...)
 	return &Observer{
 		metricsByEndpoint: newMetricsByEndpoint(
-			metricsFactory,
+			newLatencyFactory(metricsFactory, opts),
 			normalizer,
-			defaultMaxNumberOfEndpoints,
+			opts.maxEndpoints,
+			opts.maxCachedNames,
 		),
 	}
 }
@@ -72,6 +72,7 @@ type SpanObserver struct {
 	mux               sync.Mutex
 	kind              SpanKind
 	httpStatusCode    uint16
+	httpMethod        string
 	err               bool
 }
 
@@ -95,6 +96,7 @@ func NewSpanObserver(
 // handleTags watches for special tags
 // - SpanKind
 // - HttpStatusCode
+// - HttpMethod
 // - Error
 func (so *SpanObserver) handleTagInLock(key string, value interface{}) {
 	if key == string(ext.SpanKind) {
@@ -122,6 +124,12 @@ func (so *SpanObserver) handleTagInLock(key string, value interface{}) {
 		}
 		return
 	}
+	if key == string(ext.HTTPMethod) {
+		if v, ok := value.(string); ok {
+			so.httpMethod = v
+		}
+		return
+	}
 	if key == string(ext.Error) {
 		if v, ok := value.(bool); ok {
 			so.err = v
@@ -135,12 +143,23 @@ func (so *SpanObserver) handleTagInLock(key string, value interface{}) {
 }
 
 // OnFinish emits the RPC metrics. It only has an effect when operation name
-// is not blank, and the span kind is an RPC server.
+// is not blank, and the span kind is an RPC server, except for the HTTP requests
+// by method and status code class which are also emitted for RPC clients.
 func (so *SpanObserver) OnFinish(options opentracing.FinishOptions) {
 	so.mux.Lock()
 	defer so.mux.Unlock()
 
-	if so.operationName == "" || so.kind != Inbound {
+	if so.operationName == "" || so.kind == Local {
+		return
+	}
+	if statusClass := httpStatusClass(so.httpStatusCode); statusClass != "" {
+		kind := "server"
+		if so.kind == Outbound {
+			kind = "client"
+		}
+		so.metricsByEndpoint.httpRequestCounter(so.operationName, kind, so.httpMethod, statusClass).Inc(1)
+	}
+	if so.kind != Inbound {
 		return
 	}
 
@@ -148,11 +167,10 @@ func (so *SpanObserver) OnFinish(options opentracing.FinishOptions) {
 	latency := options.FinishTime.Sub(so.startTime)
 	if so.err {
 		mets.RequestCountFailures.Inc(1)
-		mets.RequestLatencyFailures.Record(latency)
 	} else {
 		mets.RequestCountSuccess.Inc(1)
-		mets.RequestLatencySuccess.Record(latency)
 	}
+	mets.recordLatency(latency, so.err)
 	mets.recordHTTPStatusCode(so.httpStatusCode)
 }
 
@@ -16,11 +16,13 @@ package rpcmetrics
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics"
 	u "github.com/uber/jaeger-lib/metrics/metricstest"
 
 	"github.com/opentracing/opentracing-go/ext"
@@ -173,3 +175,150 @@ func TestTags(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPRequestsByMethod(t *testing.T) {
+	withTestTracer(func(testTracer *testTracer) {
+		requests := []struct {
+			kind       opentracing.StartSpanOption
+			method     string
+			statusCode int
+		}{
+			{kind: ext.SpanKindRPCServer, method: "GET", statusCode: 200},
+			{kind: ext.SpanKindRPCServer, method: "get", statusCode: 204},
+			{kind: ext.SpanKindRPCServer, method: "POST", statusCode: 503},
+			{kind: ext.SpanKindRPCClient, method: "GET", statusCode: 404},
+			{kind: ext.SpanKindRPCClient, method: "PROPFIND", statusCode: 302},
+			{kind: ext.SpanKindRPCClient, method: "GET"},
+			{kind: opentracing.Tag{Key: "x", Value: "y"}, method: "GET", statusCode: 200},
+		}
+		for _, r := range requests {
+			span := testTracer.tracer.StartSpan("span", r.kind)
+			ext.HTTPMethod.Set(span, r.method)
+			if r.statusCode != 0 {
+				ext.HTTPStatusCode.Set(span, uint16(r.statusCode))
+			}
+			span.Finish()
+		}
+
+		byMethod := func(kind, method, statusClass string, value int) u.ExpectedMetric {
+			return u.ExpectedMetric{Name: "http_requests_by_method", Value: value, Tags: map[string]string{
+				"endpoint": "span", "kind": kind, "method": method, "status_code": statusClass,
+			}}
+		}
+		testTracer.metrics.AssertCounterMetrics(t,
+			byMethod("server", "GET", "2xx", 2),
+			byMethod("server", "POST", "5xx", 1),
+			byMethod("client", "GET", "4xx", 1),
+			byMethod("client", "other", "3xx", 1),
+			u.ExpectedMetric{Name: "requests", Tags: endpointTags("span", "error", "false"), Value: 3},
+			u.ExpectedMetric{Name: "http_requests", Tags: endpointTags("span", "status_code", "4xx"), Value: 0},
+		)
+		c, _ := testTracer.metrics.Snapshot()
+		n := 0
+		for k := range c {
+			if strings.HasPrefix(k, "http_requests_by_method|") {
+				n++
+			}
+		}
+		assert.Equal(t, 4, n, "no metrics for local spans or missing status codes")
+	})
+}
+
+func TestObserverOptions(t *testing.T) {
+	metricsFactory := u.NewFactory(0)
+	observer := NewObserver(metricsFactory, &RewriteNameNormalizer{Rules: []RewriteRule{CollapsePathParams}},
+		Options.MaxEndpoints(1),
+		Options.MaxCachedNames(5),
+	)
+	assert.Equal(t, 1, observer.metricsByEndpoint.endpoints.maxSize)
+	assert.Equal(t, 5, observer.metricsByEndpoint.endpoints.maxCacheSize)
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewInMemoryReporter(),
+		jaeger.TracerOptions.Observer(observer))
+	defer closer.Close()
+
+	for _, name := range []string{"GET /users/1", "GET /users/2", "GET /items/1"} {
+		tracer.StartSpan(name, ext.SpanKindRPCServer).Finish()
+	}
+	metricsFactory.AssertCounterMetrics(t,
+		u.ExpectedMetric{Name: "requests", Tags: endpointTags("GET-/users/{id}", "error", "false"), Value: 2},
+		u.ExpectedMetric{Name: "requests", Tags: endpointTags("other", "error", "false"), Value: 1},
+	)
+}
+
+// bucketsFactory records the buckets of the timers and histograms.
+type bucketsFactory struct {
+	*u.Factory
+	timerBuckets     map[string][]time.Duration
+	histogramBuckets map[string][]float64
+}
+
+func (f *bucketsFactory) Timer(options metrics.TimerOptions) metrics.Timer {
+	f.timerBuckets[options.Name] = options.Buckets
+	return f.Factory.Timer(options)
+}
+
+func (f *bucketsFactory) Histogram(options metrics.HistogramOptions) metrics.Histogram {
+	f.histogramBuckets[options.Name] = options.Buckets
+	return f.Factory.Histogram(options)
+}
+
+func TestLatencyHistograms(t *testing.T) {
+	tests := []struct {
+		name             string
+		options          []Option
+		timers           bool
+		timerBuckets     []time.Duration
+		histograms       bool
+		histogramBuckets []float64
+	}{
+		{name: "default", timers: true},
+		{
+			name:         "timer buckets",
+			options:      []Option{Options.LatencyBuckets(time.Millisecond, time.Second)},
+			timers:       true,
+			timerBuckets: []time.Duration{time.Millisecond, time.Second},
+		},
+		{
+			name:             "histograms",
+			options:          []Option{Options.LatencyHistograms(true)},
+			timers:           true,
+			histograms:       true,
+			histogramBuckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		{
+			name:             "histograms instead of timers",
+			options:          []Option{Options.LatencyHistograms(false), Options.LatencyBuckets(time.Millisecond, time.Second)},
+			histograms:       true,
+			histogramBuckets: []float64{0.001, 1},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			metricsFactory := &bucketsFactory{
+				Factory:          u.NewFactory(0),
+				timerBuckets:     make(map[string][]time.Duration),
+				histogramBuckets: make(map[string][]float64),
+			}
+			observer := NewObserver(metricsFactory, DefaultNameNormalizer, tc.options...)
+			tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewInMemoryReporter(),
+				jaeger.TracerOptions.Observer(observer))
+			defer closer.Close()
+
+			start := time.Now()
+			tracer.StartSpan("get-user", ext.SpanKindRPCServer, opentracing.StartTime(start)).
+				FinishWithOptions(opentracing.FinishOptions{FinishTime: start.Add(100 * time.Millisecond)})
+
+			_, gauges := metricsFactory.Snapshot()
+			_, timer := gauges["request_latency|endpoint=get-user|error=false.P99"]
+			assert.Equal(t, tc.timers, timer)
+			_, histogram := gauges["request_latency_seconds|endpoint=get-user|error=false.P99"]
+			assert.Equal(t, tc.histograms, histogram)
+			if tc.timers {
+				assert.Equal(t, tc.timerBuckets, metricsFactory.timerBuckets["request_latency"])
+			}
+			if tc.histograms {
+				assert.Equal(t, tc.histogramBuckets, metricsFactory.histogramBuckets["request_latency_seconds"])
+			}
+		})
+	}
+}
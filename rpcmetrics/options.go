@@ -0,0 +1,86 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmetrics
+
+import "time"
+
+const (
+	defaultMaxNumberOfEndpoints = 200
+	defaultMaxCachedNamesFactor = 10
+)
+
+// Option is a function that sets some option on the Observer
+type Option func(*options)
+
+// Options is a factory for all available options
+var Options options
+
+type options struct {
+	maxEndpoints      int
+	maxCachedNames    int
+	latencyBuckets    []time.Duration
+	latencyHistograms bool
+	noLatencyTimers   bool
+}
+
+// MaxEndpoints creates an Option that limits the number of endpoints with their own metrics,
+// the metrics of the other endpoints are tagged with the endpoint "other". The default is 200.
+func (options) MaxEndpoints(maxEndpoints int) Option {
+	return func(o *options) {
+		o.maxEndpoints = maxEndpoints
+	}
+}
+
+// MaxCachedNames creates an Option that limits the number of endpoint names whose normalized names
+// are cached, evicting the least recently used names. The default is 10 times MaxEndpoints.
+func (options) MaxCachedNames(maxCachedNames int) Option {
+	return func(o *options) {
+		o.maxCachedNames = maxCachedNames
+	}
+}
+
+// LatencyBuckets creates an Option that sets the bucket boundaries of the request latency
+// timers and histograms, e.g. to match the latency objectives of the service. The default
+// buckets are chosen by the metrics backend, see DefaultLatencyBuckets for the histograms.
+func (options) LatencyBuckets(buckets ...time.Duration) Option {
+	return func(o *options) {
+		o.latencyBuckets = buckets
+	}
+}
+
+// LatencyHistograms creates an Option that emits the request latency as "request_latency_seconds"
+// histograms in seconds with the LatencyBuckets, so that the percentiles can be aggregated across
+// instances by the backends like Prometheus. If timers is false, the "request_latency" timers are
+// not emitted, and the histograms replace them.
+func (options) LatencyHistograms(timers bool) Option {
+	return func(o *options) {
+		o.latencyHistograms = true
+		o.noLatencyTimers = !timers
+	}
+}
+
+func applyOptions(o ...Option) options {
+	opts := options{}
+	for _, option := range o {
+		option(&opts)
+	}
+	if opts.maxEndpoints <= 0 {
+		opts.maxEndpoints = defaultMaxNumberOfEndpoints
+	}
+	if opts.maxCachedNames <= 0 {
+		opts.maxCachedNames = defaultMaxCachedNamesFactor * opts.maxEndpoints
+	}
+	return opts
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmetrics
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// PathNameNormalizer is like DefaultNameNormalizer, but also keeps the braces of the
+// path parameters like "{id}" produced by the RewriteRules.
+var PathNameNormalizer = &SimpleNameNormalizer{
+	SafeSets: append(append([]SafeCharacterSet{}, DefaultNameNormalizer.SafeSets...),
+		&Char{'{'},
+		&Char{'}'},
+	),
+	Replacement: '-',
+}
+
+// RewriteRule rewrites an endpoint name, e.g. to collapse the parameters of REST paths into a template.
+type RewriteRule interface {
+	// Rewrite returns the rewritten name and true if the rule matches the name.
+	Rewrite(name string) (string, bool)
+}
+
+// RewriteNameNormalizer is a NameNormalizer that rewrites the endpoint names with the first matching
+// rule, before converting them to safe names with the Next normalizer, so that the endpoints like
+// "/users/123" and "/users/456" share the metrics of "/users/{id}".
+type RewriteNameNormalizer struct {
+	Rules []RewriteRule
+	// Next converts the rewritten names to safe names, PathNameNormalizer if nil
+	Next NameNormalizer
+}
+
+// Normalize implements NameNormalizer.
+func (n *RewriteNameNormalizer) Normalize(name string) string {
+	for _, rule := range n.Rules {
+		if rewritten, ok := rule.Rewrite(name); ok {
+			name = rewritten
+			break
+		}
+	}
+	if n.Next == nil {
+		return PathNameNormalizer.Normalize(name)
+	}
+	return n.Next.Normalize(name)
+}
+
+type regexpRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// RegexpRule returns a RewriteRule replacing the matches of the regular expression with the replacement,
+// which can refer to the submatches as in regexp.Regexp.ReplaceAllString, e.g. `^/v\d+/` to "/".
+func RegexpRule(pattern, replacement string) (RewriteRule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &regexpRule{pattern: re, replacement: replacement}, nil
+}
+
+func (r *regexpRule) Rewrite(name string) (string, bool) {
+	if !r.pattern.MatchString(name) {
+		return name, false
+	}
+	return r.pattern.ReplaceAllString(name, r.replacement), true
+}
+
+type globRule struct {
+	pattern     string
+	replacement string
+}
+
+// GlobRule returns a RewriteRule replacing the names matching the glob pattern, in the syntax
+// of path.Match, with the replacement, e.g. "/static/*" to "/static".
+func GlobRule(pattern, replacement string) (RewriteRule, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return &globRule{pattern: pattern, replacement: replacement}, nil
+}
+
+func (r *globRule) Rewrite(name string) (string, bool) {
+	if matched, _ := path.Match(r.pattern, name); matched {
+		return r.replacement, true
+	}
+	return name, false
+}
+
+type pathTemplateRule struct {
+	template string
+	segments []string
+}
+
+// PathTemplateRule returns a RewriteRule replacing the paths matching the template, e.g. "/users/{id}/orders",
+// with the template. The parameters in braces match any single path segment.
+func PathTemplateRule(template string) RewriteRule {
+	return &pathTemplateRule{template: template, segments: strings.Split(template, "/")}
+}
+
+func (r *pathTemplateRule) Rewrite(name string) (string, bool) {
+	segments := strings.Split(name, "/")
+	if len(segments) != len(r.segments) {
+		return name, false
+	}
+	for i, s := range r.segments {
+		if s != segments[i] && !isPathParameter(s) {
+			return name, false
+		}
+	}
+	return r.template, true
+}
+
+func isPathParameter(segment string) bool {
+	return len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}'
+}
+
+// CollapsePathParams is a RewriteRule replacing the path segments that look like identifiers,
+// i.e. numbers, UUIDs and hex strings of at least 16 characters, with "{id}".
+var CollapsePathParams RewriteRule = collapsePathParams{}
+
+type collapsePathParams struct{}
+
+func (collapsePathParams) Rewrite(name string) (string, bool) {
+	segments := strings.Split(name, "/")
+	collapsed := false
+	for i, s := range segments {
+		if isIdentifier(s) {
+			segments[i] = "{id}"
+			collapsed = true
+		}
+	}
+	if !collapsed {
+		return name, false
+	}
+	return strings.Join(segments, "/"), true
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	digits := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+		case (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F'):
+			digits = false
+		case c == '-' && len(s) == 36 && (i == 8 || i == 13 || i == 18 || i == 23):
+			digits = false // UUID
+		default:
+			return false
+		}
+	}
+	return digits || len(s) >= 16
+}
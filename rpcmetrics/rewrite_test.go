@@ -0,0 +1,64 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpcmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteNameNormalizer(t *testing.T) {
+	versionRule, err := RegexpRule(`^(GET|POST) /v\d+/`, "$1 /")
+	require.NoError(t, err)
+	staticRule, err := GlobRule("GET /static/*", "GET /static")
+	require.NoError(t, err)
+	n := &RewriteNameNormalizer{
+		Rules: []RewriteRule{
+			staticRule,
+			PathTemplateRule("GET /users/{id}/orders/{orderID}"),
+			versionRule,
+			CollapsePathParams,
+		},
+	}
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "GET /static/app.js", expected: "GET-/static"},
+		{name: "GET /users/alice/orders/o-1", expected: "GET-/users/{id}/orders/{orderID}"},
+		{name: "GET /users/alice/orders", expected: "GET-/users/alice/orders"},
+		{name: "POST /v2/users", expected: "POST-/users"},
+		{name: "GET /items/12345", expected: "GET-/items/{id}"},
+		{name: "GET /items/8f14e45fceea167a5a36dedd4bea2543/parts/7", expected: "GET-/items/{id}/parts/{id}"},
+		{name: "GET /items/123e4567-e89b-12d3-a456-426614174000", expected: "GET-/items/{id}"},
+		{name: "GET /items/cafe", expected: "GET-/items/cafe"},
+		{name: "GET /items/a^b", expected: "GET-/items/a-b"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.expected, n.Normalize(tc.name), tc.name)
+	}
+
+	n.Next = DefaultNameNormalizer
+	assert.Equal(t, "GET-/items/-id-", n.Normalize("GET /items/1"))
+}
+
+func TestRewriteRuleErrors(t *testing.T) {
+	_, err := RegexpRule("(", "")
+	assert.Error(t, err)
+	_, err = GlobRule("[", "")
+	assert.Error(t, err)
+}
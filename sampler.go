@@ -386,6 +386,8 @@ type RemotelyControlledSampler struct {
 	serviceName string
 	manager     sampling.SamplingManager
 	doneChan    chan *sync.WaitGroup
+	// lastStrategy is the last sampling strategy applied, to publish only the changes of the strategy
+	lastStrategy string
 }
 
 type httpSamplingManager struct {
@@ -516,21 +518,33 @@ func (s *RemotelyControlledSampler) updateSampler() {
 		s.logger.Infof("Unable to query sampling strategy: %v", err)
 		return
 	}
-	s.Lock()
-	defer s.Unlock()
-
 	s.metrics.SamplerRetrieved.Inc(1)
+
+	// the logger and the diagnostics callback are user code, so they are called without holding the lock
+	s.Lock()
 	if strategies := res.GetOperationSampling(); strategies != nil {
 		s.updateAdaptiveSampler(strategies)
 	} else {
 		err = s.updateRateLimitingOrProbabilisticSampler(res)
 	}
+	strategy := res.String()
+	changed := err == nil && strategy != s.lastStrategy
+	if changed {
+		s.lastStrategy = strategy
+	}
+	s.Unlock()
+
 	if err != nil {
 		s.metrics.SamplerUpdateFailure.Inc(1)
 		s.logger.Infof("Unable to handle sampling strategy response %+v. Got error: %v", res, err)
 		return
 	}
 	s.metrics.SamplerUpdated.Inc(1)
+	s.metrics.SamplerLastUpdate.Update(time.Now().Unix())
+	log.DebugLogAdapter(s.logger).Debugf("Updated sampling strategy: %v", res)
+	if changed {
+		s.diagnosticsCallback.publish(DiagnosticSamplingStrategyApplied, strategy, nil)
+	}
 }
 
 // NB: this function should only be called while holding a Write lock
@@ -31,6 +31,7 @@ type samplerOptions struct {
 	logger                  Logger
 	samplingServerURL       string
 	samplingRefreshInterval time.Duration
+	diagnosticsCallback     DiagnosticsCallback
 }
 
 // Metrics creates a SamplerOption that initializes Metrics on the sampler,
@@ -64,6 +65,14 @@ func (samplerOptions) Logger(logger Logger) SamplerOption {
 	}
 }
 
+// DiagnosticsCallback creates a SamplerOption that sets the callback invoked with
+// a DiagnosticSamplingStrategyApplied event whenever the sampler applies a new sampling strategy.
+func (samplerOptions) DiagnosticsCallback(callback DiagnosticsCallback) SamplerOption {
+	return func(o *samplerOptions) {
+		o.diagnosticsCallback = callback
+	}
+}
+
 // SamplingServerURL creates a SamplerOption that sets the sampling server url
 // of the local agent that contains the sampling strategies.
 func (samplerOptions) SamplingServerURL(samplingServerURL string) SamplerOption {
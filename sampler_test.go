@@ -313,13 +313,18 @@ func TestRemotelyControlledSampler(t *testing.T) {
 	initSampler, ok := remoteSampler.getSampler().(*ProbabilisticSampler)
 	assert.True(t, ok)
 
+	logger := &log.BytesBufferLogger{}
+	remoteSampler.logger = logger
 	agent.AddSamplingStrategy("client app",
 		getSamplingStrategyResponse(sampling.SamplingStrategyType_PROBABILISTIC, testDefaultSamplingProbability))
 	remoteSampler.updateSampler()
+	assert.Contains(t, logger.String(), "DEBUG: Updated sampling strategy: SamplingStrategyResponse({StrategyType:PROBABILISTIC")
 	metricsFactory.AssertCounterMetrics(t, []mTestutils.ExpectedMetric{
 		{Name: "jaeger.tracer.sampler_queries", Tags: map[string]string{"result": "ok"}, Value: 1},
 		{Name: "jaeger.tracer.sampler_updates", Tags: map[string]string{"result": "ok"}, Value: 1},
 	}...)
+	_, gauges := metricsFactory.Snapshot()
+	assert.InDelta(t, time.Now().Unix(), gauges["jaeger.tracer.sampler_last_update_timestamp"], 1)
 	s1, ok := remoteSampler.getSampler().(*ProbabilisticSampler)
 	assert.True(t, ok)
 	assert.NotEqual(t, initSampler, s1, "Sampler should have been updated")
@@ -15,6 +15,7 @@
 package jaeger
 
 import (
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -45,9 +46,26 @@ type Span struct {
 	// and the ingress spans when the process joins another trace.
 	firstInProcess bool
 
+	// samplingFinalized, if false, indicates that the sampling decision of this span can still be
+	// changed by SetOperationName. Only the root spans of new traces start with non-final decisions,
+	// which become final with the sampling.priority tag, or when the context of the span is used.
+	samplingFinalized bool
+
+	// samplingDeferred, if true, indicates that the sampling decision of this root span is deferred
+	// until its first tag is set or its context is used, see TracerOptions.DelayedSampling.
+	samplingDeferred bool
+
+	// service overrides the service name of the tracer, if not empty, see ServiceName.
+	service string
+
 	// startTime is the timestamp indicating when the span began, with microseconds precision.
 	startTime time.Time
 
+	// startMonotonic is the monotonic clock reading when the span began, if hasStartMonotonic is true.
+	// It is unknown when the start time is provided explicitly or the tracer has no monotonic clock.
+	startMonotonic    time.Duration
+	hasStartMonotonic bool
+
 	// duration returns duration of the span with microseconds precision.
 	// Zero value means duration is unknown.
 	duration time.Duration
@@ -55,12 +73,53 @@ type Span struct {
 	// tags attached to this span
 	tags []Tag
 
+	// number of tags dropped because of the MaxTagsPerSpan limit
+	droppedTags int
+
 	// The span's "micro-log"
 	logs []opentracing.LogRecord
 
 	// references for this span
 	references []Reference
 
+	// number of logs dropped because of the LogRetentionPolicy
+	droppedLogs int
+
+	// the rate limit of the logs, see TracerOptions.MaxLogsPerSecond: the start of the current
+	// one second window, the number of logs in the window, and the number of logs suppressed
+	logWindowStart time.Time
+	logsInWindow   int
+	suppressedLogs int
+
+	// links to spans of other traces
+	links []Link
+
+	// structured events, see AddEvent
+	events []SpanEvent
+
+	// number of events dropped because of the MaxEventsPerSpan limit
+	droppedEvents int
+
+	// warnings about the data of the span, in addition to the ones derived from the counters above
+	warnings []string
+
+	// the scope of the unfinished children of the span, and the scope of its parent it belongs to,
+	// see TracerOptions.AutoFinishChildren
+	childScope  *spanScope
+	parentScope *spanScope
+
+	// autoFinished is 1 once the span is finished by its parent and retained for the user code
+	// that may still hold it, and 2 once that user code has called Finish and released it
+	autoFinished int32
+
+	// finished is true once the span is finished, only tracked with TracerOptions.DetectUseAfterFinish
+	finished bool
+	// the stack trace of the call finishing the span, see TracerOptions.FinishStackTraces
+	finishStack string
+
+	// isRecord is true for the start records and partial snapshots of other spans, see Tracer.newSpanRecord
+	isRecord bool
+
 	observer ContribSpanObserver
 }
 
@@ -72,26 +131,63 @@ type Tag struct {
 }
 
 // SetOperationName sets or changes the operation name.
+// If the span is the root of a new trace that was not sampled, and its sampling decision was not
+// made final, the sampler is asked again using the new operation name. The decision becomes final
+// with the sampling.priority tag, and once the context of the span is used, e.g. by Context() to
+// start a child span or to inject it, so that the span and its descendants share the same decision.
 func (s *Span) SetOperationName(operationName string) opentracing.Span {
+	if s.usedAfterFinish("SetOperationName") {
+		return s
+	}
+	if normalize := s.tracer.options.operationNameNormalizer; normalize != nil {
+		operationName = normalize(operationName)
+	}
 	s.Lock()
-	defer s.Unlock()
+	resampled := false
+	if !s.context.IsSampled() && !s.samplingFinalized {
+		if sampled, tags := s.tracer.isSampled(s.context.traceID, operationName, nil); sampled {
+			s.context.flags |= flagSampled
+			s.tags = append(s.tags, tags...)
+			resampled = true
+		}
+	}
 	if s.context.IsSampled() {
 		s.operationName = operationName
 	}
+	s.Unlock()
+	if resampled {
+		s.tracer.onSpanResampled(s)
+	}
 	s.observer.OnSetOperationName(operationName)
 	return s
 }
 
 // SetTag implements SetTag() of opentracing.Span
 func (s *Span) SetTag(key string, value interface{}) opentracing.Span {
+	if s.usedAfterFinish("SetTag") {
+		return s
+	}
 	s.observer.OnSetTag(key, value)
 	if key == string(ext.SamplingPriority) && !setSamplingPriority(s, value) {
 		return s
 	}
 	s.Lock()
-	defer s.Unlock()
+	if key == FirehoseTagKey && isFirehoseTagValue(value) {
+		s.enableFirehoseNoLocking()
+	}
 	if s.context.IsSampled() {
 		s.setTagNoLocking(key, value)
+		if key == string(ext.Error) && isErrorTagValue(value) {
+			s.logStackTraceNoLocking()
+		}
+	}
+	decided := s.samplingDeferred
+	if decided {
+		s.decideSamplingNoLocking()
+	}
+	s.Unlock()
+	if decided {
+		s.tracer.onSamplingDecided(s)
 	}
 	return s
 }
@@ -99,8 +195,19 @@ func (s *Span) SetTag(key string, value interface{}) opentracing.Span {
 // SpanContext returns span context
 func (s *Span) SpanContext() SpanContext {
 	s.Lock()
-	defer s.Unlock()
-	return s.context
+	decided := s.samplingDeferred
+	if decided {
+		s.decideSamplingNoLocking()
+	}
+	// once the context is handed out, e.g. to start a child span or to be injected,
+	// the sampling decision is propagated and cannot change anymore
+	s.samplingFinalized = true
+	ctx := s.context
+	s.Unlock()
+	if decided {
+		s.tracer.onSamplingDecided(s)
+	}
+	return ctx
 }
 
 // StartTime returns span start time
@@ -129,17 +236,39 @@ func (s *Span) Tags() opentracing.Tags {
 }
 
 func (s *Span) setTagNoLocking(key string, value interface{}) {
+	if s.tracer.tagRedactor.matches(key) {
+		value = RedactedTagValue
+	}
+	if max := s.tracer.options.maxTagsPerSpan; max > 0 && len(s.tags) >= max {
+		// once the limit is reached, existing tags can still be updated
+		for i := len(s.tags) - 1; i >= 0; i-- {
+			if s.tags[i].key == key {
+				s.tags[i].value = value
+				return
+			}
+		}
+		s.droppedTags++
+		s.tracer.metrics.SpanTagsDropped.Inc(1)
+		return
+	}
 	s.tags = append(s.tags, Tag{key: key, value: value})
 }
 
-// LogFields implements opentracing.Span API
+// LogFields implements opentracing.Span API.
+// The log.Lazy fields are not evaluated when they are logged, but only when a sampled span is
+// serialized by the reporter, so they have no cost for the spans that are not sampled.
 func (s *Span) LogFields(fields ...log.Field) {
+	if s.usedAfterFinish("LogFields") {
+		return
+	}
 	s.Lock()
-	defer s.Unlock()
 	if !s.context.IsSampled() {
+		s.Unlock()
 		return
 	}
 	s.logFieldsNoLocking(fields...)
+	s.Unlock()
+	s.tagErrorFromLog(fields)
 }
 
 // this function should only be called while holding a Write lock
@@ -153,6 +282,9 @@ func (s *Span) logFieldsNoLocking(fields ...log.Field) {
 
 // LogKV implements opentracing.Span API
 func (s *Span) LogKV(alternatingKeyValues ...interface{}) {
+	if s.usedAfterFinish("LogKV") {
+		return
+	}
 	s.RLock()
 	sampled := s.context.IsSampled()
 	s.RUnlock()
@@ -161,10 +293,14 @@ func (s *Span) LogKV(alternatingKeyValues ...interface{}) {
 	}
 	fields, err := log.InterleavedKVToFields(alternatingKeyValues...)
 	if err != nil {
-		s.LogFields(log.Error(err), log.String("function", "LogKV"))
+		// not using LogFields, an invalid call to LogKV does not make the span an error
+		s.Lock()
+		s.logFieldsNoLocking(log.Error(err), log.String("function", "LogKV"))
+		s.Unlock()
 		return
 	}
 	s.LogFields(fields...)
+	s.tagErrorFromKV(alternatingKeyValues)
 }
 
 // LogEvent implements opentracing.Span API
@@ -179,6 +315,9 @@ func (s *Span) LogEventWithPayload(event string, payload interface{}) {
 
 // Log implements opentracing.Span API
 func (s *Span) Log(ld opentracing.LogData) {
+	if s.usedAfterFinish("Log") {
+		return
+	}
 	s.Lock()
 	defer s.Unlock()
 	if s.context.IsSampled() {
@@ -189,14 +328,11 @@ func (s *Span) Log(ld opentracing.LogData) {
 	}
 }
 
-// this function should only be called while holding a Write lock
-func (s *Span) appendLog(lr opentracing.LogRecord) {
-	// TODO add logic to limit number of logs per span (issue #46)
-	s.logs = append(s.logs, lr)
-}
-
 // SetBaggageItem implements SetBaggageItem() of opentracing.SpanContext
 func (s *Span) SetBaggageItem(key, value string) opentracing.Span {
+	if s.usedAfterFinish("SetBaggageItem") {
+		return s
+	}
 	s.Lock()
 	defer s.Unlock()
 	s.tracer.setBaggage(s, key, value)
@@ -205,6 +341,9 @@ func (s *Span) SetBaggageItem(key, value string) opentracing.Span {
 
 // BaggageItem implements BaggageItem() of opentracing.SpanContext
 func (s *Span) BaggageItem(key string) string {
+	if s.tracer.baggageSetter.hidden(key) {
+		return ""
+	}
 	s.RLock()
 	defer s.RUnlock()
 	return s.context.baggage[key]
@@ -219,13 +358,34 @@ func (s *Span) Finish() {
 
 // FinishWithOptions implements opentracing.Span API
 func (s *Span) FinishWithOptions(options opentracing.FinishOptions) {
+	if scopes := s.tracer.spanScopes; scopes != nil && !scopes.claim(s) {
+		// already finished by the parent span, which retained it until this call
+		if atomic.CompareAndSwapInt32(&s.autoFinished, 1, 2) {
+			s.Release()
+		}
+		return
+	}
+	if !s.markFinished("Finish") {
+		return
+	}
+	s.finishWithOptions(options)
+}
+
+func (s *Span) finishWithOptions(options opentracing.FinishOptions) {
+	var finishMonotonic time.Duration
+	var hasFinishMonotonic bool
 	if options.FinishTime.IsZero() {
 		options.FinishTime = s.tracer.timeNow()
+		finishMonotonic, hasFinishMonotonic = s.tracer.monotonicNow()
 	}
 	s.observer.OnFinish(options)
 	s.Lock()
+	decided := s.samplingDeferred
+	if decided {
+		s.decideSamplingNoLocking()
+	}
+	s.duration = s.elapsedNoLocking(options.FinishTime, finishMonotonic, hasFinishMonotonic)
 	if s.context.IsSampled() {
-		s.duration = options.FinishTime.Sub(s.startTime)
 		// Note: bulk logs are not subject to maxLogsPerSpan limit
 		if options.LogRecords != nil {
 			s.logs = append(s.logs, options.LogRecords...)
@@ -235,6 +395,18 @@ func (s *Span) FinishWithOptions(options opentracing.FinishOptions) {
 		}
 	}
 	s.Unlock()
+	if decided {
+		s.tracer.onSamplingDecided(s)
+	}
+	if observer, ok := s.observer.(*compositeSpanObserver); ok && len(observer.snapshotObservers) > 0 {
+		s.RLock()
+		snapshot := s.snapshotNoLocking()
+		s.RUnlock()
+		observer.OnFinishWithOptions(snapshot, options)
+	}
+	if s.tracer.spanScopes != nil {
+		s.tracer.spanScopes.finishChildren(s, options.FinishTime)
+	}
 	// call reportSpan even for non-sampled traces, to return span to the pool
 	// and update metrics counter
 	s.tracer.reportSpan(s)
@@ -242,8 +414,14 @@ func (s *Span) FinishWithOptions(options opentracing.FinishOptions) {
 
 // Context implements opentracing.Span API
 func (s *Span) Context() opentracing.SpanContext {
-	s.Lock()
-	defer s.Unlock()
+	return s.SpanContext()
+}
+
+// peekContext returns the context of the span without deciding a deferred sampling decision
+// or finalizing it, unlike SpanContext.
+func (s *Span) peekContext() SpanContext {
+	s.RLock()
+	defer s.RUnlock()
 	return s.context
 }
 
@@ -258,6 +436,12 @@ func (s *Span) String() string {
 	return s.context.String()
 }
 
+// ServiceName returns the name of the service that emitted the span, which is the service name
+// of the tracer unless it was overridden with the ServiceName option.
+func (s *Span) ServiceName() string {
+	return s.serviceName()
+}
+
 // OperationName allows retrieving current operation name.
 func (s *Span) OperationName() string {
 	s.RLock()
@@ -274,7 +458,7 @@ func (s *Span) Retain() *Span {
 // Release decrements object counter and return to the
 // allocator manager  when counter will below zero
 func (s *Span) Release() {
-	if atomic.AddInt32(&s.referenceCounter, -1) == -1 {
+	if atomic.AddInt32(&s.referenceCounter, -1) == -1 && !s.finished {
 		s.tracer.spanAllocator.Put(s)
 	}
 }
@@ -282,21 +466,42 @@ func (s *Span) Release() {
 // reset span state and release unused data
 func (s *Span) reset() {
 	s.firstInProcess = false
+	s.samplingFinalized = false
+	s.samplingDeferred = false
 	s.context = emptyContext
 	s.operationName = ""
+	s.service = ""
 	s.tracer = nil
 	s.startTime = time.Time{}
+	s.startMonotonic = 0
+	s.hasStartMonotonic = false
 	s.duration = 0
 	s.observer = nil
 	atomic.StoreInt32(&s.referenceCounter, 0)
 
 	// Note: To reuse memory we can save the pointers on the heap
 	s.tags = s.tags[:0]
+	s.droppedTags = 0
 	s.logs = s.logs[:0]
+	s.droppedLogs = 0
+	s.logWindowStart = time.Time{}
+	s.logsInWindow = 0
+	s.suppressedLogs = 0
 	s.references = s.references[:0]
+	s.links = s.links[:0]
+	s.events = s.events[:0]
+	s.droppedEvents = 0
+	s.warnings = s.warnings[:0]
+	s.childScope = nil
+	s.parentScope = nil
+	atomic.StoreInt32(&s.autoFinished, 0)
+	s.isRecord = false
 }
 
 func (s *Span) serviceName() string {
+	if s.service != "" {
+		return s.service
+	}
 	return s.tracer.serviceName
 }
 
@@ -308,10 +513,22 @@ func setSamplingPriority(s *Span, value interface{}) bool {
 	}
 	s.Lock()
 	defer s.Unlock()
+	s.samplingFinalized = true
 	if val == 0 {
 		s.context.flags = s.context.flags & (^flagSampled)
 		return true
 	}
+	if val > 1 {
+		// escalated debug level, see DebugLevelBaggageKey
+		if !s.tracer.isDebugLevelAllowed(val, s.operationName) {
+			return false
+		}
+		s.context.flags |= s.tracer.debugFlags()
+		// subject to the baggage restrictions and budget like any other baggage item
+		s.tracer.baggageSetter.setBaggage(s, DebugLevelBaggageKey, strconv.Itoa(int(val)))
+		s.setTagNoLocking(DebugLevelTagKey, val)
+		return true
+	}
 	if s.tracer.options.noDebugFlagOnForcedSampling {
 		s.context.flags = s.context.flags | flagSampled
 		return true
@@ -322,9 +539,10 @@ func setSamplingPriority(s *Span, value interface{}) bool {
 	return false
 }
 
-// EnableFirehose enables firehose flag on the span context
+// EnableFirehose enables firehose flag on the span context. The span becomes sampled,
+// and the flag is propagated to its children started afterwards, see Firehose.
 func EnableFirehose(s *Span) {
 	s.Lock()
 	defer s.Unlock()
-	s.context.flags |= flagFirehose
+	s.enableFirehoseNoLocking()
 }
@@ -0,0 +1,47 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+// setBaggageTagsNoLocking sets the tags of the span with the values of the baggage items
+// promoted to tags, see TracerOptions.BaggageTags.
+// this function should only be called while holding a Write lock
+func (s *Span) setBaggageTagsNoLocking() {
+	if len(s.context.baggage) == 0 || !s.context.IsSampled() {
+		return
+	}
+	for _, key := range s.tracer.options.baggageTags {
+		if s.tracer.baggageSetter.hidden(key) {
+			continue
+		}
+		if value, ok := s.context.baggage[key]; ok {
+			s.setTagNoLocking(key, value)
+		}
+	}
+}
+
+// setBaggageTagNoLocking sets the tag of the span with the value of the baggage item
+// if it is promoted to a tag, see TracerOptions.BaggageTags.
+// this function should only be called while holding a Write lock
+func (s *Span) setBaggageTagNoLocking(key, value string) {
+	if !s.context.IsSampled() || s.tracer.baggageSetter.hidden(key) {
+		return
+	}
+	for _, k := range s.tracer.options.baggageTags {
+		if k == key {
+			s.setTagNoLocking(key, value)
+			return
+		}
+	}
+}
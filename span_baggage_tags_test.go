@@ -0,0 +1,56 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaggageTags(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.BaggageTags("tenant-id", "request-source"),
+	)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root").(*Span)
+	root.SetBaggageItem("tenant-id", "t1")
+	root.SetBaggageItem("user", "u1")
+	assert.Equal(t, "t1", root.Tags()["tenant-id"])
+	assert.NotContains(t, root.Tags(), "user")
+
+	child := tracer.StartSpan("child", opentracing.ChildOf(root.Context())).(*Span)
+	assert.Equal(t, "t1", child.Tags()["tenant-id"])
+	assert.NotContains(t, child.Tags(), "request-source")
+	assert.NotContains(t, child.Tags(), "user")
+
+	remote := NewSpanContext(TraceID{Low: 1}, 2, 0, true, map[string]string{"request-source": "mobile"})
+	server := tracer.StartSpan("server", opentracing.ChildOf(remote)).(*Span)
+	assert.Equal(t, "mobile", server.Tags()["request-source"])
+}
+
+func TestBaggageTagsNotSampled(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.BaggageTags("tenant-id"),
+	)
+	defer closer.Close()
+
+	remote := NewSpanContext(TraceID{Low: 1}, 2, 0, false, map[string]string{"tenant-id": "t1"})
+	sp := tracer.StartSpan("server", opentracing.ChildOf(remote)).(*Span)
+	sp.SetBaggageItem("tenant-id", "t2")
+	assert.Empty(t, sp.Tags())
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strconv"
+)
+
+const (
+	// DebugLevelBaggageKey is the baggage item propagating the debug level of a trace, set by
+	// setting the sampling.priority tag of a span to a value greater than 1. It is set like any other
+	// baggage item, subject to the baggage restrictions and TracerOptions.MaxBaggageSize, and it is
+	// propagated even if not listed in TracerOptions.PropagatedBaggage.
+	DebugLevelBaggageKey = "jaeger-debug-level"
+
+	// DebugLevelTagKey reports the debug level of the spans of traces with a debug level greater than 1.
+	DebugLevelTagKey = "jaeger.debug_level"
+)
+
+// Debug levels are set with the sampling.priority tag:
+//   - 0 makes the span and its children unsampled;
+//   - 1 makes the span and its children sampled and marked as debug, like in previous versions;
+//   - 2 and higher also propagate the level in the DebugLevelBaggageKey baggage item, which makes the
+//     tracers of downstream services force their spans to be sampled and marked as debug, even if
+//     the flags of their parent were lost or reset, e.g. by a proxy.
+//
+// Each level can be throttled separately with TracerOptions.DebugLevelThrottler; the levels without
+// a throttler of their own are throttled by the debug throttler of the tracer.
+
+// debugLevel returns the debug level propagated in the baggage of the context, or 0 if none.
+func debugLevel(ctx SpanContext) uint16 {
+	value, ok := ctx.baggage[DebugLevelBaggageKey]
+	if !ok {
+		return 0
+	}
+	level, err := strconv.ParseUint(value, 10, 16)
+	if err != nil {
+		return 0
+	}
+	return uint16(level)
+}
+
+// isDebugLevelAllowed returns true if the throttler of the debug level allows a debug span
+// with the given operation name.
+func (t *Tracer) isDebugLevelAllowed(level uint16, operation string) bool {
+	if throttler, ok := t.debugLevelThrottlers[level]; ok {
+		return throttler.IsAllowed(operation)
+	}
+	return t.isDebugAllowed(operation)
+}
+
+// debugFlags returns the flags of the spans forced to be sampled by a debug level.
+func (t *Tracer) debugFlags() byte {
+	if t.options.noDebugFlagOnForcedSampling {
+		return flagSampled
+	}
+	return flagDebug | flagSampled
+}
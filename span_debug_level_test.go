@@ -0,0 +1,171 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestDebugLevel(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter())
+	defer closer.Close()
+
+	root := tracer.StartSpan("root").(*Span)
+	ext.SamplingPriority.Set(root, 2)
+	assert.True(t, root.context.IsDebug())
+	assert.Equal(t, "2", root.BaggageItem(DebugLevelBaggageKey))
+	assert.Equal(t, uint16(2), root.Tags()[DebugLevelTagKey])
+
+	// the flags of the context are lost, e.g. by a proxy, but the debug level is propagated
+	carrier := opentracing.TextMapCarrier{}
+	assert.NoError(t, tracer.Inject(root.Context(), opentracing.TextMap, carrier))
+	ctx, err := tracer.Extract(opentracing.TextMap, carrier)
+	assert.NoError(t, err)
+	parent := ctx.(SpanContext)
+	parent.flags = 0
+
+	child := tracer.StartSpan("child", opentracing.ChildOf(parent)).(*Span)
+	assert.True(t, child.context.IsSampled())
+	assert.True(t, child.context.IsDebug())
+	assert.Equal(t, uint16(2), child.Tags()[DebugLevelTagKey])
+
+	// level 1 keeps the previous behavior
+	other := tracer.StartSpan("other").(*Span)
+	ext.SamplingPriority.Set(other, 1)
+	assert.True(t, other.context.IsDebug())
+	assert.Empty(t, other.BaggageItem(DebugLevelBaggageKey))
+	assert.NotContains(t, other.Tags(), DebugLevelTagKey)
+}
+
+func TestDebugLevelBaggage(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.PropagatedBaggage("tenant"),
+	)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root")
+	root.SetBaggageItem("user", "alice")
+	ext.SamplingPriority.Set(root, 2)
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "jaeger.tracer.baggage_updates", Tags: map[string]string{"result": "ok"}, Value: 2,
+	})
+
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(root.Context(), opentracing.TextMap, carrier))
+	ctx, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{DebugLevelBaggageKey: "2"}, ctx.(SpanContext).baggage,
+		"the debug level is propagated even if not in PropagatedBaggage")
+}
+
+func TestDebugLevelBaggageRestricted(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.BaggageRestrictionManager(restrictKey{key: DebugLevelBaggageKey}),
+	)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root").(*Span)
+	ext.SamplingPriority.Set(root, 2)
+	assert.True(t, root.context.IsDebug())
+	assert.Empty(t, root.BaggageItem(DebugLevelBaggageKey))
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "jaeger.tracer.baggage_updates", Tags: map[string]string{"result": "err"}, Value: 1,
+	})
+}
+
+func TestDebugLevelThrottler(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.DebugThrottler(testThrottler{allowAll: true}),
+		TracerOptions.DebugLevelThrottler(3, testThrottler{allowAll: false}),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("root").(*Span)
+	ext.SamplingPriority.Set(sp, 3)
+	assert.False(t, sp.context.IsSampled(), "level 3 is throttled")
+	assert.Empty(t, sp.BaggageItem(DebugLevelBaggageKey))
+
+	sp = tracer.StartSpan("root").(*Span)
+	ext.SamplingPriority.Set(sp, 2)
+	assert.True(t, sp.context.IsDebug(), "level 2 uses the debug throttler")
+
+	parent := NewSpanContext(TraceID{Low: 1}, 2, 0, false, map[string]string{DebugLevelBaggageKey: "3"})
+	parent.remote = true
+	child := tracer.StartSpan("child", opentracing.ChildOf(parent)).(*Span)
+	assert.False(t, child.context.IsSampled(), "level 3 is throttled in child spans")
+}
+
+type countingThrottler struct {
+	calls int
+}
+
+func (t *countingThrottler) IsAllowed(operation string) bool {
+	t.calls++
+	return t.calls == 1
+}
+
+func TestDebugLevelThrottledOncePerProcess(t *testing.T) {
+	throttler := &countingThrottler{}
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.DebugLevelThrottler(2, throttler),
+	)
+	defer closer.Close()
+
+	parent := NewSpanContext(TraceID{Low: 1}, 2, 0, false, map[string]string{DebugLevelBaggageKey: "2"})
+	parent.remote = true
+	server := tracer.StartSpan("server", opentracing.ChildOf(parent)).(*Span)
+	assert.True(t, server.context.IsDebug())
+	for i := 0; i < 3; i++ {
+		child := tracer.StartSpan("child", opentracing.ChildOf(server.Context())).(*Span)
+		assert.True(t, child.context.IsDebug(), "local children inherit the decision")
+		assert.NotContains(t, child.Tags(), DebugLevelTagKey)
+	}
+	assert.Equal(t, 1, throttler.calls, "only the span entering the process uses a credit")
+
+	throttled := NewSpanContext(TraceID{Low: 2}, 2, 0, false, map[string]string{DebugLevelBaggageKey: "2"})
+	throttled.remote = true
+	server = tracer.StartSpan("server", opentracing.ChildOf(throttled)).(*Span)
+	assert.False(t, server.context.IsSampled())
+	child := tracer.StartSpan("child", opentracing.ChildOf(server.Context())).(*Span)
+	assert.False(t, child.context.IsSampled())
+	assert.Equal(t, 2, throttler.calls)
+}
+
+func TestDebugLevelNoDebugFlag(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.NoDebugFlagOnForcedSampling(true),
+	)
+	defer closer.Close()
+
+	parent := NewSpanContext(TraceID{Low: 1}, 2, 0, false, map[string]string{DebugLevelBaggageKey: "2"})
+	parent.remote = true
+	child := tracer.StartSpan("child", opentracing.ChildOf(parent)).(*Span)
+	assert.True(t, child.context.IsSampled())
+	assert.False(t, child.context.IsDebug())
+
+	parent = NewSpanContext(TraceID{Low: 1}, 2, 0, false, map[string]string{DebugLevelBaggageKey: "x"})
+	child = tracer.StartSpan("child", opentracing.ChildOf(parent)).(*Span)
+	assert.False(t, child.context.IsSampled())
+}
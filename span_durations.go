@@ -0,0 +1,191 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/uber/jaeger-lib/metrics"
+)
+
+const (
+	// maxSpanDurationOperations is the maximum number of operations with their own span duration
+	// histograms, the histograms of the other operations are tagged with otherSpanDurationOperations.
+	maxSpanDurationOperations   = 500
+	otherSpanDurationOperations = "other"
+	internalSpanKind            = "internal"
+)
+
+// defaultSpanDurationBuckets are the bucket boundaries of the span duration histograms when
+// TracerOptions.SpanDurationHistograms is given no buckets.
+var defaultSpanDurationBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// spanDurationKey identifies the span duration histogram of an operation, span kind and error.
+type spanDurationKey struct {
+	operation string
+	kind      string
+	err       bool
+}
+
+// spanDurationObserver is a ContribObserver recording the durations of all spans, sampled or not,
+// in histograms per operation, span kind and error, see TracerOptions.SpanDurationHistograms.
+type spanDurationObserver struct {
+	factory    metrics.Factory
+	buckets    []float64
+	mux        sync.RWMutex
+	operations map[string]struct{}
+	histograms map[spanDurationKey]metrics.Histogram
+}
+
+func newSpanDurationObserver(factory metrics.Factory, buckets []time.Duration) *spanDurationObserver {
+	if factory == nil {
+		factory = metrics.NullFactory
+	}
+	if len(buckets) == 0 {
+		buckets = defaultSpanDurationBuckets
+	}
+	o := &spanDurationObserver{
+		factory:    factory.Namespace(metrics.NSOptions{Name: "jaeger"}).Namespace(metrics.NSOptions{Name: "tracer"}),
+		buckets:    make([]float64, len(buckets)),
+		operations: make(map[string]struct{}),
+		histograms: make(map[spanDurationKey]metrics.Histogram),
+	}
+	for i, b := range buckets {
+		o.buckets[i] = b.Seconds()
+	}
+	return o
+}
+
+func (o *spanDurationObserver) OnStartSpan(
+	sp opentracing.Span,
+	operationName string,
+	options opentracing.StartSpanOptions,
+) (ContribSpanObserver, bool) {
+	return &spanDurationSpanObserver{
+		observer:      o,
+		operationName: operationName,
+		startTime:     options.StartTime,
+		kind:          internalSpanKind,
+	}, true
+}
+
+func (o *spanDurationObserver) histogram(key spanDurationKey) metrics.Histogram {
+	o.mux.RLock()
+	h := o.histograms[key]
+	o.mux.RUnlock()
+	if h != nil {
+		return h
+	}
+	o.mux.Lock()
+	defer o.mux.Unlock()
+	if h, ok := o.histograms[key]; ok {
+		return h
+	}
+	if _, ok := o.operations[key.operation]; !ok {
+		if len(o.operations) >= maxSpanDurationOperations {
+			key.operation = otherSpanDurationOperations
+			if h, ok := o.histograms[key]; ok {
+				return h
+			}
+		} else {
+			o.operations[key.operation] = struct{}{}
+		}
+	}
+	errTag := "false"
+	if key.err {
+		errTag = "true"
+	}
+	h = o.factory.Histogram(metrics.HistogramOptions{
+		Name: "span_duration_seconds",
+		Tags: map[string]string{
+			"operation": key.operation,
+			"kind":      key.kind,
+			"error":     errTag,
+		},
+		Help:    "Duration of the spans in seconds, sampled or not",
+		Buckets: o.buckets,
+	})
+	o.histograms[key] = h
+	return h
+}
+
+// spanDurationSpanObserver tracks the operation name, span kind and error of a span until it is finished.
+type spanDurationSpanObserver struct {
+	observer      *spanDurationObserver
+	mux           sync.Mutex
+	operationName string
+	startTime     time.Time
+	kind          string
+	err           bool
+}
+
+func (so *spanDurationSpanObserver) OnSetOperationName(operationName string) {
+	so.mux.Lock()
+	so.operationName = operationName
+	so.mux.Unlock()
+}
+
+func (so *spanDurationSpanObserver) OnSetTag(key string, value interface{}) {
+	switch key {
+	case string(ext.SpanKind):
+		so.mux.Lock()
+		so.kind = spanKindName(value)
+		so.mux.Unlock()
+	case string(ext.Error):
+		so.mux.Lock()
+		so.err = isErrorTagValue(value)
+		so.mux.Unlock()
+	}
+}
+
+func (so *spanDurationSpanObserver) OnFinish(options opentracing.FinishOptions) {
+	so.mux.Lock()
+	key := spanDurationKey{operation: so.operationName, kind: so.kind, err: so.err}
+	duration := options.FinishTime.Sub(so.startTime)
+	so.mux.Unlock()
+	so.observer.histogram(key).Record(duration.Seconds())
+}
+
+// spanKindName returns the value of the span.kind tag if it is a standard span kind, or "internal".
+func spanKindName(value interface{}) string {
+	var kind string
+	switch v := value.(type) {
+	case string:
+		kind = v
+	case ext.SpanKindEnum:
+		kind = string(v)
+	}
+	switch ext.SpanKindEnum(kind) {
+	case ext.SpanKindRPCClientEnum, ext.SpanKindRPCServerEnum, ext.SpanKindProducerEnum, ext.SpanKindConsumerEnum:
+		return kind
+	}
+	return internalSpanKind
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func spanDurationGauge(gauges map[string]int64, operation, kind, err string) (int64, bool) {
+	name := metrics.GetKey("jaeger.tracer.span_duration_seconds",
+		map[string]string{"operation": operation, "kind": kind, "error": err}, "|", "=")
+	v, ok := gauges[name+".P50"]
+	return v, ok
+}
+
+func TestSpanDurationHistograms(t *testing.T) {
+	factory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("x", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.MetricsFactory(factory),
+		TracerOptions.SpanDurationHistograms(),
+		TracerOptions.MinimalUnsampledSpans(true),
+	)
+	defer closer.Close()
+
+	start := time.Now()
+	finish := opentracing.FinishOptions{FinishTime: start.Add(2 * time.Second)}
+
+	sp := tracer.StartSpan("get-user", ext.SpanKindRPCServer, opentracing.StartTime(start))
+	_, ok := sp.(*Span)
+	assert.True(t, ok, "minimal unsampled spans are disabled")
+	sp.FinishWithOptions(finish)
+
+	sp = tracer.StartSpan("query", opentracing.StartTime(start))
+	sp.SetOperationName("select")
+	ext.Error.Set(sp, true)
+	sp.FinishWithOptions(finish)
+
+	sp = tracer.StartSpan("send", opentracing.Tag{Key: string(ext.SpanKind), Value: "unknown"}, opentracing.StartTime(start))
+	sp.FinishWithOptions(finish)
+
+	_, gauges := factory.Snapshot()
+	v, ok := spanDurationGauge(gauges, "get-user", "server", "false")
+	assert.True(t, ok)
+	assert.EqualValues(t, 2, v)
+	_, ok = spanDurationGauge(gauges, "select", "internal", "true")
+	assert.True(t, ok)
+	_, ok = spanDurationGauge(gauges, "query", "internal", "true")
+	assert.False(t, ok)
+	_, ok = spanDurationGauge(gauges, "send", "internal", "false")
+	assert.True(t, ok)
+}
+
+func TestSpanDurationHistogramsMaxOperations(t *testing.T) {
+	factory := metricstest.NewFactory(0)
+	observer := newSpanDurationObserver(factory, []time.Duration{time.Second})
+	assert.Equal(t, []float64{1}, observer.buckets)
+
+	for i := 0; i < maxSpanDurationOperations+10; i++ {
+		observer.histogram(spanDurationKey{operation: "op" + strconv.Itoa(i), kind: "client"}).Record(1)
+	}
+	assert.Len(t, observer.operations, maxSpanDurationOperations)
+	_, gauges := factory.Snapshot()
+	_, ok := spanDurationGauge(gauges, "other", "client", "false")
+	assert.True(t, ok)
+	_, ok = spanDurationGauge(gauges, "op"+strconv.Itoa(maxSpanDurationOperations), "client", "false")
+	assert.False(t, ok)
+}
+
+func TestSpanKindName(t *testing.T) {
+	assert.Equal(t, "client", spanKindName(ext.SpanKindRPCClientEnum))
+	assert.Equal(t, "consumer", spanKindName("consumer"))
+	assert.Equal(t, "internal", spanKindName("gateway"))
+	assert.Equal(t, "internal", spanKindName(42))
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+const defaultStackTraceMaxDepth = 32
+
+// RecordError marks the span as failed by setting the error tag, and logs the "error" event with the
+// error object, the given fields and, if enabled by TracerOptions.StackTraceOnError, the stack trace
+// of the caller.
+func (s *Span) RecordError(err error, fields ...log.Field) {
+	if err == nil || s.usedAfterFinish("RecordError") {
+		return
+	}
+	s.observer.OnSetTag(string(ext.Error), true)
+	s.Lock()
+	defer s.Unlock()
+	if !s.context.IsSampled() {
+		return
+	}
+	if !s.hasErrorTagNoLocking() {
+		s.setTagNoLocking(string(ext.Error), true)
+	}
+	logFields := make([]log.Field, 0, 3+len(fields))
+	logFields = append(logFields, log.String("event", "error"), log.Error(err))
+	logFields = append(logFields, fields...)
+	if stack := s.captureStackTrace(); stack != "" {
+		logFields = append(logFields, log.String(StackTraceFieldKey, stack))
+	}
+	s.logFieldsNoLocking(logFields...)
+}
+
+// logStackTraceNoLocking logs the stack trace of the caller after the error tag is set,
+// if enabled by TracerOptions.StackTraceOnError.
+// this function should only be called while holding a Write lock
+func (s *Span) logStackTraceNoLocking() {
+	if stack := s.captureStackTrace(); stack != "" {
+		s.logFieldsNoLocking(log.String("event", "error"), log.String(StackTraceFieldKey, stack))
+	}
+}
+
+// captureStackTrace returns the stack trace of the code using the span, without the frames of the
+// Span methods, or an empty string if stack traces are disabled or exceed the rate limit.
+func (s *Span) captureStackTrace() string {
+	options := &s.tracer.options
+	if !options.stackTraceOnError {
+		return ""
+	}
+	if options.stackTraceRateLimiter != nil && !options.stackTraceRateLimiter.CheckCredit(1.0) {
+		return ""
+	}
+	// capture a few more frames to make up for the trimmed ones
+	pcs := make([]uintptr, options.stackTraceMaxDepth+8)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var sb strings.Builder
+	depth := 0
+	trimming := true
+	for depth < options.stackTraceMaxDepth {
+		frame, more := frames.Next()
+		if trimming && strings.Contains(frame.Function, "jaeger-client-go.(*Span).") {
+			if !more {
+				break
+			}
+			continue
+		}
+		trimming = false
+		fmt.Fprintf(&sb, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		depth++
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// isErrorTagValue returns true if the value of the error tag marks the span as failed.
+func isErrorTagValue(value interface{}) bool {
+	return value == true || value == "true"
+}
+
+// tagErrorFromLog sets the error tag on the span if the log fields describe an error,
+// see TracerOptions.TagErrorsFromLogs. It must be called without holding the span lock.
+func (s *Span) tagErrorFromLog(fields []log.Field) {
+	if s.tracer.options.tagErrorsFromLogs && isErrorLog(fields) {
+		s.tagError()
+	}
+}
+
+// tagErrorFromKV is like tagErrorFromLog for the arguments of LogKV, which converts errors to strings.
+func (s *Span) tagErrorFromKV(alternatingKeyValues []interface{}) {
+	if s.tracer.options.tagErrorsFromLogs && isErrorKV(alternatingKeyValues) {
+		s.tagError()
+	}
+}
+
+// tagError sets the error tag on the span, unless it is already set.
+func (s *Span) tagError() {
+	s.RLock()
+	tagged := s.hasErrorTagNoLocking()
+	s.RUnlock()
+	if !tagged {
+		s.SetTag(string(ext.Error), true)
+	}
+}
+
+// this function should only be called while holding a Read or Write lock
+func (s *Span) hasErrorTagNoLocking() bool {
+	for _, tag := range s.tags {
+		if tag.key == string(ext.Error) && tag.value == true {
+			return true
+		}
+	}
+	return false
+}
+
+// isErrorLog returns true if the log fields contain an error object or the "error" event,
+// following the OpenTracing semantic conventions for logging errors.
+func isErrorLog(fields []log.Field) bool {
+	for _, field := range fields {
+		switch field.Key() {
+		case "error", "error.object":
+			if _, ok := field.Value().(error); ok {
+				return true
+			}
+		case "event":
+			if field.Value() == "error" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isErrorKV(alternatingKeyValues []interface{}) bool {
+	for i := 0; i+1 < len(alternatingKeyValues); i += 2 {
+		if key, ok := alternatingKeyValues[i].(string); ok && (key == "error" || key == "error.object") {
+			if _, ok := alternatingKeyValues[i+1].(error); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
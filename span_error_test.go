@@ -0,0 +1,166 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagErrorsFromLogs(t *testing.T) {
+	tests := []struct {
+		name   string
+		log    func(sp *Span)
+		tagged bool
+	}{
+		{name: "error field", log: func(sp *Span) { sp.LogFields(log.Error(errors.New("boom"))) }, tagged: true},
+		{name: "error KV", log: func(sp *Span) { sp.LogKV("error", errors.New("boom")) }, tagged: true},
+		{name: "error.object KV", log: func(sp *Span) { sp.LogKV("error.object", errors.New("boom")) }, tagged: true},
+		{name: "error event", log: func(sp *Span) { sp.LogKV("event", "error", "message", "boom") }, tagged: true},
+		{name: "error string", log: func(sp *Span) { sp.LogKV("error", "boom") }, tagged: false},
+		{name: "other event", log: func(sp *Span) { sp.LogKV("event", "retry") }, tagged: false},
+		{name: "nil error", log: func(sp *Span) { sp.LogFields(log.Error(nil)) }, tagged: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(), TracerOptions.TagErrorsFromLogs(true))
+			defer closer.Close()
+
+			sp := tracer.StartSpan("op").(*Span)
+			test.log(sp)
+			test.log(sp)
+			var errorTags int
+			for _, tag := range sp.tags {
+				if tag.key == string(ext.Error) {
+					errorTags++
+				}
+			}
+			if test.tagged {
+				assert.Equal(t, 1, errorTags, "the error tag is set once")
+				assert.Equal(t, true, sp.Tags()[string(ext.Error)])
+			} else {
+				assert.Zero(t, errorTags)
+			}
+		})
+	}
+}
+
+func TestTagErrorsFromLogsDisabled(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.LogFields(log.Error(errors.New("boom")))
+	assert.NotContains(t, sp.Tags(), string(ext.Error))
+}
+
+func TestTagErrorsFromLogsInvalidKV(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(), TracerOptions.TagErrorsFromLogs(true))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.LogKV("odd number of arguments")
+	assert.Len(t, sp.logs, 1)
+	assert.NotContains(t, sp.Tags(), string(ext.Error))
+}
+
+func stackTraceOf(lr opentracing.LogRecord) string {
+	for _, field := range lr.Fields {
+		if field.Key() == StackTraceFieldKey {
+			return field.Value().(string)
+		}
+	}
+	return ""
+}
+
+func TestStackTraceOnErrorTag(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(), TracerOptions.StackTraceOnError(2, 0))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.SetTag("error", false)
+	assert.Empty(t, sp.logs)
+
+	ext.Error.Set(sp, true)
+	require.Len(t, sp.logs, 1)
+	stack := stackTraceOf(sp.logs[0])
+	lines := strings.Split(strings.TrimSpace(stack), "\n")
+	assert.Len(t, lines, 4, "two frames, two lines each")
+	assert.Contains(t, lines[0], "opentracing-go/ext.", "the frames of the Span methods are trimmed")
+	assert.Contains(t, lines[2], "TestStackTraceOnErrorTag")
+}
+
+func TestStackTraceOnErrorDisabled(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.SetTag("error", true)
+	assert.Empty(t, sp.logs)
+
+	sp.RecordError(errors.New("boom"))
+	require.Len(t, sp.logs, 1)
+	assert.Empty(t, stackTraceOf(sp.logs[0]))
+}
+
+func TestStackTraceOnErrorRateLimit(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(), TracerOptions.StackTraceOnError(0, 0.001))
+	defer closer.Close()
+	assert.Equal(t, defaultStackTraceMaxDepth, tracer.(*Tracer).options.stackTraceMaxDepth)
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.SetTag("error", "true")
+	sp.SetTag("error", true)
+	require.Len(t, sp.logs, 1, "the second stack trace exceeds the rate limit")
+	assert.NotEmpty(t, stackTraceOf(sp.logs[0]))
+}
+
+func TestRecordError(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(), TracerOptions.StackTraceOnError(1, 0))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.RecordError(nil)
+	assert.Empty(t, sp.logs)
+
+	err := errors.New("boom")
+	sp.RecordError(err, log.String("message", "failed to connect"))
+	sp.RecordError(err)
+	assert.Equal(t, true, sp.Tags()[string(ext.Error)])
+	assert.Len(t, sp.tags, 3, "the sampler tags and a single error tag")
+	require.Len(t, sp.logs, 2)
+	fields := sp.logs[0].Fields
+	require.Len(t, fields, 4)
+	assert.Equal(t, "event", fields[0].Key())
+	assert.Equal(t, "error", fields[0].Value())
+	assert.Equal(t, err, fields[1].Value())
+	assert.Equal(t, "failed to connect", fields[2].Value())
+	assert.Contains(t, stackTraceOf(sp.logs[0]), "TestRecordError")
+
+	// not sampled
+	tracer, closer = NewTracer("DOOP", NewConstSampler(false), NewNullReporter())
+	defer closer.Close()
+	sp = tracer.StartSpan("op").(*Span)
+	sp.RecordError(err)
+	assert.Empty(t, sp.logs)
+	assert.Empty(t, sp.tags)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"time"
+
+	"github.com/opentracing/opentracing-go/log"
+
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+	"github.com/uber/jaeger-client-go/utils"
+)
+
+// SpanEvent is a named event that happened during the span, with typed attributes.
+// Unlike the log records, whose fields are free-form, events map directly to the events
+// of OpenTelemetry and newer backends, and are limited separately by MaxEventsPerSpan.
+//
+// Events are reported as span logs whose first field is EventNameFieldKey with the name of the event,
+// followed by the attributes. If events were dropped, DroppedEventsTagKey holds their number.
+type SpanEvent struct {
+	Name       string
+	Timestamp  time.Time
+	Attributes []log.Field
+}
+
+// AddEvent records an event with the given name and attributes at the current time.
+func (s *Span) AddEvent(name string, attributes ...log.Field) {
+	s.AddEventWithTimestamp(name, s.tracer.timeNow(), attributes...)
+}
+
+// AddEventWithTimestamp records an event with the given name and attributes at the given time.
+// If the span already has MaxEventsPerSpan events, the event is dropped and counted.
+func (s *Span) AddEventWithTimestamp(name string, timestamp time.Time, attributes ...log.Field) {
+	if s.usedAfterFinish("AddEvent") {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if !s.context.IsSampled() {
+		return
+	}
+	if max := s.tracer.options.maxEventsPerSpan; max > 0 && len(s.events) >= max {
+		s.droppedEvents++
+		return
+	}
+	s.events = append(s.events, SpanEvent{Name: name, Timestamp: timestamp, Attributes: attributes})
+}
+
+// Events returns the events recorded on the span.
+func (s *Span) Events() []SpanEvent {
+	s.RLock()
+	defer s.RUnlock()
+	return append([]SpanEvent(nil), s.events...)
+}
+
+// fields returns the event as log fields.
+func (e SpanEvent) fields() []log.Field {
+	fields := make([]log.Field, 0, 1+len(e.Attributes))
+	fields = append(fields, log.String(EventNameFieldKey, e.Name))
+	return append(fields, e.Attributes...)
+}
+
+func buildEventLogs(events []SpanEvent) []*j.Log {
+	jLogs := make([]*j.Log, 0, len(events))
+	for _, event := range events {
+		jLogs = append(jLogs, &j.Log{
+			Timestamp: utils.TimeToMicrosecondsSinceEpochInt64(event.Timestamp),
+			Fields:    ConvertLogsToJaegerTags(event.fields()),
+		})
+	}
+	return jLogs
+}
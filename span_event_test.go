@@ -0,0 +1,110 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/utils"
+)
+
+func TestSpanAddEvent(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.TimeNow(func() time.Time { return now }))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.LogKV("k", "v")
+	sp.AddEvent("cache.miss", log.String("key", "user:1"), log.Int("attempt", 2))
+	sp.AddEventWithTimestamp("retry", now.Add(time.Second))
+
+	assert.Equal(t, []SpanEvent{
+		{Name: "cache.miss", Timestamp: now, Attributes: []log.Field{log.String("key", "user:1"), log.Int("attempt", 2)}},
+		{Name: "retry", Timestamp: now.Add(time.Second)},
+	}, sp.Events())
+	assert.Len(t, sp.logs, 1, "events are kept separately from logs")
+
+	jSpan := BuildJaegerThrift(sp)
+	require.Len(t, jSpan.Logs, 3)
+	event := jSpan.Logs[1]
+	assert.Equal(t, utils.TimeToMicrosecondsSinceEpochInt64(now), event.Timestamp)
+	require.Len(t, event.Fields, 3)
+	assert.Equal(t, EventNameFieldKey, event.Fields[0].Key)
+	assert.Equal(t, "cache.miss", *event.Fields[0].VStr)
+	assert.Equal(t, "key", event.Fields[1].Key)
+	assert.Equal(t, "attempt", event.Fields[2].Key)
+	assert.Equal(t, int64(2), *event.Fields[2].VLong)
+	require.Len(t, jSpan.Logs[2].Fields, 1)
+	assert.Equal(t, "retry", *jSpan.Logs[2].Fields[0].VStr)
+
+	zSpan := BuildZipkinThrift(sp)
+	require.Len(t, zSpan.Annotations, 3)
+	assert.Equal(t, `{"attempt":"2","event":"cache.miss","key":"user:1"}`, zSpan.Annotations[1].Value)
+}
+
+func TestSpanMaxEventsPerSpan(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(), TracerOptions.MaxEventsPerSpan(2))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	for i := 0; i < 5; i++ {
+		sp.AddEvent("event")
+	}
+	assert.Len(t, sp.Events(), 2)
+
+	jSpan := BuildJaegerThrift(sp)
+	assert.Len(t, jSpan.Logs, 2)
+	var dropped *int64
+	for _, tag := range jSpan.Tags {
+		if tag.Key == DroppedEventsTagKey {
+			dropped = tag.VLong
+		}
+	}
+	require.NotNil(t, dropped)
+	assert.Equal(t, int64(3), *dropped)
+
+	sp.reset()
+	assert.Empty(t, sp.Events())
+	assert.Zero(t, sp.droppedEvents)
+}
+
+func TestSpanMaxEventsPerSpanDefaults(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+	assert.Equal(t, DefaultMaxEventsPerSpan, tracer.(*Tracer).options.maxEventsPerSpan)
+
+	tracer, closer = NewTracer("DOOP", NewConstSampler(true), NewNullReporter(), TracerOptions.MaxEventsPerSpan(-1))
+	defer closer.Close()
+	sp := tracer.StartSpan("op").(*Span)
+	for i := 0; i < DefaultMaxEventsPerSpan+1; i++ {
+		sp.AddEvent("event")
+	}
+	assert.Len(t, sp.Events(), DefaultMaxEventsPerSpan+1)
+}
+
+func TestSpanAddEventNotSampled(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter())
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.AddEvent("event")
+	assert.Empty(t, sp.Events())
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import "github.com/opentracing/opentracing-go"
+
+// Firehose returns a StartSpanOption that enables the firehose flag on the span, equivalent to
+// setting the FirehoseTagKey tag to true. The span is sampled regardless of the sampler's decision,
+// and the flag is propagated to its children, local and remote, which are sampled as well.
+func Firehose() opentracing.StartSpanOption {
+	return opentracing.Tag{Key: FirehoseTagKey, Value: true}
+}
+
+// isFirehoseTagValue returns true if the value of the FirehoseTagKey tag enables the firehose flag.
+func isFirehoseTagValue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// enableFirehoseNoLocking sets the firehose flag on the span context, along with the sampled flag.
+// this function should only be called while holding a Write lock
+func (s *Span) enableFirehoseNoLocking() {
+	s.context.flags |= flagFirehose | flagSampled
+	s.samplingFinalized = true
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirehoseStartSpanOption(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), reporter,
+		TracerOptions.MinimalUnsampledSpans(true))
+	defer closer.Close()
+
+	root := tracer.StartSpan("root", Firehose()).(*Span)
+	assert.True(t, root.context.IsFirehose())
+	assert.True(t, root.context.IsSampled())
+	assert.True(t, root.samplingFinalized)
+	assert.NotContains(t, root.Tags(), SamplerTypeTagKey, "the sampler must not be asked")
+
+	child := tracer.StartSpan("child", opentracing.ChildOf(root.Context())).(*Span)
+	assert.True(t, child.context.IsFirehose())
+	assert.True(t, child.context.IsSampled())
+
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(child.Context(), opentracing.TextMap, carrier))
+	remoteCtx, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	assert.True(t, remoteCtx.(SpanContext).IsFirehose())
+
+	// a remote context with the firehose flag but without the sampled flag
+	unsampled := SpanContext{traceID: TraceID{Low: 1}, spanID: 1, flags: flagFirehose}
+	remote := tracer.StartSpan("remote", opentracing.ChildOf(unsampled)).(*Span)
+	assert.True(t, remote.context.IsSampled())
+
+	remote.Finish()
+	child.Finish()
+	root.Finish()
+	assert.Equal(t, 3, reporter.SpansSubmitted())
+}
+
+func TestFirehoseTag(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), reporter)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("root").(*Span)
+	assert.False(t, sp.context.IsSampled())
+	sp.SetTag(FirehoseTagKey, "true")
+	assert.True(t, sp.context.IsFirehose())
+	assert.True(t, sp.context.IsSampled())
+	assert.Equal(t, "true", sp.Tags()[FirehoseTagKey])
+
+	child := tracer.StartSpan("child", opentracing.ChildOf(sp.Context())).(*Span)
+	assert.True(t, child.context.IsFirehose())
+
+	other := tracer.StartSpan("other").(*Span)
+	other.SetTag(FirehoseTagKey, 1)
+	assert.False(t, other.context.IsFirehose())
+
+	other.Finish()
+	child.Finish()
+	sp.Finish()
+	assert.Equal(t, 2, reporter.SpansSubmitted())
+}
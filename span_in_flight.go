@@ -0,0 +1,132 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// InFlightSpan describes a span that was started but not finished yet, see Tracer.InFlightSpans.
+type InFlightSpan struct {
+	Context       SpanContext
+	OperationName string
+	StartTime     time.Time
+	Age           time.Duration
+}
+
+// inFlightSpanTracker keeps track of the spans between Start and Finish, and periodically
+// updates the gauges with their number and the age of the oldest one, to make leaked spans visible.
+type inFlightSpanTracker struct {
+	tracer   *Tracer
+	interval time.Duration
+
+	sync.Mutex
+	spans map[*Span]struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  sync.WaitGroup
+}
+
+func newInFlightSpanTracker(tracer *Tracer, interval time.Duration) *inFlightSpanTracker {
+	r := &inFlightSpanTracker{
+		tracer:   tracer,
+		interval: interval,
+		spans:    make(map[*Span]struct{}),
+		stop:     make(chan struct{}),
+	}
+	r.stopped.Add(1)
+	go r.run()
+	return r
+}
+
+// spanStarted starts tracking the span.
+func (r *inFlightSpanTracker) spanStarted(sp *Span) {
+	r.Lock()
+	r.spans[sp] = struct{}{}
+	r.Unlock()
+}
+
+// spanFinished stops tracking the span. It must be called before the span is released.
+func (r *inFlightSpanTracker) spanFinished(sp *Span) {
+	r.Lock()
+	delete(r.spans, sp)
+	r.Unlock()
+}
+
+func (r *inFlightSpanTracker) run() {
+	defer r.stopped.Done()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.updateGauges()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *inFlightSpanTracker) updateGauges() {
+	spans := r.inFlightSpans()
+	r.tracer.metrics.InFlightSpans.Update(int64(len(spans)))
+	var oldest time.Duration
+	if len(spans) > 0 {
+		oldest = spans[0].Age
+	}
+	r.tracer.metrics.InFlightSpansOldestAge.Update(int64(oldest / time.Millisecond))
+}
+
+// inFlightSpans returns the spans being tracked, the oldest first.
+func (r *inFlightSpanTracker) inFlightSpans() []InFlightSpan {
+	now := r.tracer.timeNow()
+	monotonicNow, hasMonotonic := r.tracer.monotonicNow()
+	r.Lock()
+	spans := make([]InFlightSpan, 0, len(r.spans))
+	for sp := range r.spans {
+		sp.RLock()
+		spans = append(spans, InFlightSpan{
+			Context:       sp.context,
+			OperationName: sp.operationName,
+			StartTime:     sp.startTime,
+			Age:           sp.elapsedNoLocking(now, monotonicNow, hasMonotonic),
+		})
+		sp.RUnlock()
+	}
+	r.Unlock()
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].Age > spans[j].Age
+	})
+	return spans
+}
+
+func (r *inFlightSpanTracker) close() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	r.stopped.Wait()
+}
+
+// InFlightSpans returns the spans that were started but not finished yet, the oldest first,
+// or nil if the tracer does not track them, see TracerOptions.TrackInFlightSpans.
+func (t *Tracer) InFlightSpans() []InFlightSpan {
+	if t.inFlightSpans == nil {
+		return nil
+	}
+	return t.inFlightSpans.inFlightSpans()
+}
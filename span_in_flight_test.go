@@ -0,0 +1,83 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestInFlightSpans(t *testing.T) {
+	var mux sync.Mutex
+	now := time.Unix(1000, 0)
+	timeNow := func() time.Time {
+		mux.Lock()
+		defer mux.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mux.Lock()
+		now = now.Add(d)
+		mux.Unlock()
+	}
+
+	metricsFactory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.TimeNow(timeNow),
+		TracerOptions.TrackInFlightSpans(time.Hour),
+	)
+	defer closer.Close()
+	jTracer := tracer.(*Tracer)
+	require.NotNil(t, jTracer.inFlightSpans)
+	assert.Empty(t, jTracer.InFlightSpans())
+
+	leaked := tracer.StartSpan("leaked").(*Span)
+	advance(time.Minute)
+	finished := tracer.StartSpan("finished")
+	advance(time.Second)
+
+	spans := jTracer.InFlightSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, InFlightSpan{
+		Context:       leaked.context,
+		OperationName: "leaked",
+		StartTime:     time.Unix(1000, 0),
+		Age:           time.Minute + time.Second,
+	}, spans[0])
+	assert.Equal(t, "finished", spans[1].OperationName)
+
+	finished.Finish()
+	jTracer.inFlightSpans.updateGauges()
+	metricsFactory.AssertGaugeMetrics(t,
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.in_flight_spans", Value: 1},
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.in_flight_spans_oldest_age", Value: 61000},
+	)
+
+	leaked.Finish()
+	assert.Empty(t, jTracer.InFlightSpans())
+}
+
+func TestInFlightSpansDisabled(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+	tracer.StartSpan("op")
+	assert.Nil(t, tracer.(*Tracer).InFlightSpans())
+}
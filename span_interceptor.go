@@ -0,0 +1,67 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"github.com/opentracing/opentracing-go"
+)
+
+// noopSpanContext is the context of the spans vetoed by a StartSpanInterceptor. References to it
+// are ignored, so the children of a vetoed span start new traces.
+var noopSpanContext = opentracing.NoopTracer{}.StartSpan("").Context()
+
+// StartSpanFunc starts a span with the given operation name and options.
+type StartSpanFunc func(operationName string, options opentracing.StartSpanOptions) opentracing.Span
+
+// StartSpanInterceptor intercepts the spans started by the Tracer, e.g. to enforce org-wide policies
+// in one place. An interceptor can rewrite the operation name and the options before passing them to
+// next, or veto the span by returning a noop span, e.g. opentracing.NoopTracer{}.StartSpan(operationName),
+// without calling next.
+//
+// The options.Tags map may be shared with the caller and must be copied before it is modified,
+// see DefaultTagsInterceptor.
+type StartSpanInterceptor func(
+	operationName string,
+	options opentracing.StartSpanOptions,
+	next StartSpanFunc,
+) opentracing.Span
+
+// DefaultTagsInterceptor returns a StartSpanInterceptor that adds the given tags to every span started
+// without them. The tags given to StartSpan take precedence.
+func DefaultTagsInterceptor(tags opentracing.Tags) StartSpanInterceptor {
+	return func(operationName string, options opentracing.StartSpanOptions, next StartSpanFunc) opentracing.Span {
+		merged := make(opentracing.Tags, len(tags)+len(options.Tags))
+		for k, v := range tags {
+			merged[k] = v
+		}
+		for k, v := range options.Tags {
+			merged[k] = v
+		}
+		options.Tags = merged
+		return next(operationName, options)
+	}
+}
+
+// chainStartSpanInterceptors returns the StartSpanFunc calling the interceptors in the order
+// they were given, and the start function last.
+func chainStartSpanInterceptors(interceptors []StartSpanInterceptor, start StartSpanFunc) StartSpanFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], start
+		start = func(operationName string, options opentracing.StartSpanOptions) opentracing.Span {
+			return interceptor(operationName, options, next)
+		}
+	}
+	return start
+}
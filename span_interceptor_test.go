@@ -0,0 +1,83 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestStartSpanInterceptors(t *testing.T) {
+	var calls []string
+	record := func(name string) StartSpanInterceptor {
+		return func(operationName string, options opentracing.StartSpanOptions, next StartSpanFunc) opentracing.Span {
+			calls = append(calls, name)
+			return next(operationName, options)
+		}
+	}
+	rename := func(operationName string, options opentracing.StartSpanOptions, next StartSpanFunc) opentracing.Span {
+		return next(strings.ToLower(operationName), options)
+	}
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("x", NewConstSampler(true), reporter,
+		TracerOptions.StartSpanInterceptors(record("first"), DefaultTagsInterceptor(opentracing.Tags{"team": "fc", "env": "dev"})),
+		TracerOptions.StartSpanInterceptors(rename, record("last")))
+	defer closer.Close()
+
+	tags := opentracing.Tags{"env": "prod"}
+	sp := tracer.StartSpan("GET", tags).(*Span)
+	sp.Finish()
+
+	assert.Equal(t, []string{"first", "last"}, calls)
+	assert.Equal(t, "get", sp.OperationName())
+	assert.Equal(t, "fc", sp.Tags()["team"])
+	assert.Equal(t, "prod", sp.Tags()["env"])
+	assert.Equal(t, opentracing.Tags{"env": "prod"}, tags, "the tags of the caller must not be modified")
+	assert.Equal(t, 1, reporter.SpansSubmitted())
+}
+
+func TestStartSpanInterceptorVeto(t *testing.T) {
+	veto := func(operationName string, options opentracing.StartSpanOptions, next StartSpanFunc) opentracing.Span {
+		if strings.HasPrefix(operationName, "health") {
+			return opentracing.NoopTracer{}.StartSpan(operationName)
+		}
+		return next(operationName, options)
+	}
+	logger := &log.BytesBufferLogger{}
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("x", NewConstSampler(true), reporter,
+		TracerOptions.StartSpanInterceptors(veto),
+		TracerOptions.Logger(logger))
+	defer closer.Close()
+
+	vetoed := tracer.StartSpan("healthcheck")
+	_, ok := vetoed.(*Span)
+	assert.False(t, ok)
+
+	child, ok := tracer.StartSpan("query", opentracing.ChildOf(vetoed.Context())).(*Span)
+	require.True(t, ok)
+	assert.Equal(t, SpanID(0), child.context.ParentID(), "children of vetoed spans start new traces")
+	child.Finish()
+	vetoed.Finish()
+
+	assert.Equal(t, 1, reporter.SpansSubmitted())
+	assert.Empty(t, logger.String())
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strconv"
+
+	"github.com/opentracing/opentracing-go"
+
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+)
+
+// Link associates a span with a span of another trace, e.g. a batch consumer span with the spans
+// of the producers of the processed messages. Unlike ChildOf and FollowsFrom references, links do
+// not make the linked span a parent of the span, and they may carry attributes describing the link.
+//
+// Links are reported as FOLLOWS_FROM references, following the regular references of the span,
+// and as tags: LinkTagKeyPrefix+"<n>" containing the linked span context, and
+// LinkTagKeyPrefix+"<n>.<key>" for each attribute, where n is the index of the link.
+type Link struct {
+	Context    SpanContext
+	Attributes []opentracing.Tag
+}
+
+// LinkTo creates a StartSpanOption that links the new span to the given span context.
+// Links to invalid span contexts are ignored.
+func LinkTo(ctx SpanContext, attributes ...opentracing.Tag) opentracing.SpanReference {
+	return opentracing.SpanReference{
+		Type:              linkRefType,
+		ReferencedContext: linkContext{Link{Context: ctx, Attributes: attributes}},
+	}
+}
+
+// linkContext carries a Link through opentracing.StartSpanOptions.References.
+type linkContext struct {
+	link Link
+}
+
+// ForeachBaggageItem implements opentracing.SpanContext.
+func (c linkContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	c.link.Context.ForeachBaggageItem(handler)
+}
+
+// AddLink links the span to the given span context after the span has started.
+// Links to invalid span contexts are ignored.
+func (s *Span) AddLink(ctx SpanContext, attributes ...opentracing.Tag) {
+	if !ctx.IsValid() || s.usedAfterFinish("AddLink") {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.context.IsSampled() {
+		s.links = append(s.links, Link{Context: ctx, Attributes: attributes})
+	}
+}
+
+// Links returns the links of the span.
+func (s *Span) Links() []Link {
+	s.RLock()
+	defer s.RUnlock()
+	return append([]Link(nil), s.links...)
+}
+
+func buildLinkReferences(links []Link) []*j.SpanRef {
+	refs := make([]*j.SpanRef, 0, len(links))
+	for _, link := range links {
+		refs = append(refs, spanRef(link.Context, j.SpanRefType_FOLLOWS_FROM))
+	}
+	return refs
+}
+
+func buildLinkTags(links []Link, maxTagValueLength int) []*j.Tag {
+	var jTags []*j.Tag
+	for i, link := range links {
+		prefix := LinkTagKeyPrefix + strconv.Itoa(i)
+		jTags = append(jTags, buildTag(&Tag{key: prefix, value: link.Context.String()}, maxTagValueLength))
+		for _, attr := range link.Attributes {
+			jTags = append(jTags, buildTag(&Tag{key: prefix + "." + attr.Key, value: attr.Value}, maxTagValueLength))
+		}
+	}
+	return jTags
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+)
+
+func TestSpanLinks(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+
+	parent := tracer.StartSpan("parent")
+	producer1 := tracer.StartSpan("producer1").Context().(SpanContext)
+	producer2 := tracer.StartSpan("producer2").Context().(SpanContext)
+
+	sp := tracer.StartSpan("consumer",
+		opentracing.ChildOf(parent.Context()),
+		LinkTo(producer1, opentracing.Tag{Key: "message.id", Value: "m1"}),
+		LinkTo(SpanContext{}), // invalid links are ignored
+	).(*Span)
+	sp.AddLink(producer2)
+	sp.AddLink(SpanContext{})
+
+	// links do not affect the parent of the span
+	assert.Equal(t, parent.Context().(SpanContext).TraceID(), sp.context.TraceID())
+	assert.Equal(t, parent.Context().(SpanContext).SpanID(), sp.context.ParentID())
+	require.Len(t, sp.references, 1)
+
+	assert.Equal(t, []Link{
+		{Context: producer1, Attributes: []opentracing.Tag{{Key: "message.id", Value: "m1"}}},
+		{Context: producer2},
+	}, sp.Links())
+
+	jSpan := BuildJaegerThrift(sp)
+	require.Len(t, jSpan.References, 3)
+	assert.Equal(t, j.SpanRefType_CHILD_OF, jSpan.References[0].RefType)
+	assert.Equal(t, j.SpanRefType_FOLLOWS_FROM, jSpan.References[1].RefType)
+	assert.Equal(t, int64(producer1.SpanID()), jSpan.References[1].SpanId)
+	assert.Equal(t, j.SpanRefType_FOLLOWS_FROM, jSpan.References[2].RefType)
+	assert.Equal(t, int64(producer2.SpanID()), jSpan.References[2].SpanId)
+
+	tags := make(map[string]string)
+	for _, tag := range jSpan.Tags {
+		if tag.VStr != nil {
+			tags[tag.Key] = *tag.VStr
+		}
+	}
+	assert.Equal(t, producer1.String(), tags["link.0"])
+	assert.Equal(t, "m1", tags["link.0.message.id"])
+	assert.Equal(t, producer2.String(), tags["link.1"])
+}
+
+func TestSpanLinksNotSampled(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter())
+	defer closer.Close()
+
+	producer := tracer.StartSpan("producer").Context().(SpanContext)
+	sp := tracer.StartSpan("consumer", LinkTo(producer)).(*Span)
+	sp.AddLink(producer)
+	assert.Empty(t, sp.Links())
+	assert.Empty(t, sp.references)
+}
+
+func TestSpanLinksReleased(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(), TracerOptions.PoolSpans(true))
+	defer closer.Close()
+
+	producer := tracer.StartSpan("producer").Context().(SpanContext)
+	sp := tracer.StartSpan("consumer", LinkTo(producer)).(*Span)
+	sp.reset()
+	assert.Empty(t, sp.Links())
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"time"
+)
+
+// allowLogNoLocking returns true if a log with the given timestamp can be recorded on the span
+// without exceeding the MaxLogsPerSecond limit of the tracer, counting the suppressed logs otherwise.
+// The logs are counted in fixed one second windows, starting with the timestamp of the first log
+// of each window.
+// this function should only be called while holding a Write lock
+func (s *Span) allowLogNoLocking(timestamp time.Time) bool {
+	max := s.tracer.options.maxLogsPerSecond
+	if max <= 0 {
+		return true
+	}
+	if s.logWindowStart.IsZero() || timestamp.Sub(s.logWindowStart) >= time.Second {
+		s.logWindowStart = timestamp
+		s.logsInWindow = 0
+	}
+	if s.logsInWindow < max {
+		s.logsInWindow++
+		return true
+	}
+	s.suppressedLogs++
+	s.tracer.metrics.SpanLogsSuppressed.Inc(1)
+	return false
+}
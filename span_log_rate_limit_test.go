@@ -0,0 +1,69 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestSpanMaxLogsPerSecond(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.MaxLogsPerSecond(3),
+	)
+	defer closer.Close()
+
+	start := time.Unix(1500000000, 0)
+	sp := tracer.StartSpan("op").(*Span)
+	for i := 0; i < 5; i++ {
+		sp.Log(opentracing.LogData{Timestamp: start.Add(time.Duration(i) * time.Millisecond), Event: "retry"})
+	}
+	// the next window starts one second after the first log of the previous one
+	sp.Log(opentracing.LogData{Timestamp: start.Add(time.Second), Event: "retry"})
+	sp.LogKV("event", "now")
+
+	assert.Len(t, sp.logs, 5)
+	assert.Equal(t, 2, sp.suppressedLogs)
+	suppressed := findJaegerTag(SuppressedLogsTagKey, BuildJaegerThrift(sp).Tags)
+	require.NotNil(t, suppressed)
+	assert.EqualValues(t, 2, *suppressed.VLong)
+	assert.Contains(t, sp.Warnings(), "2 logs suppressed because the span exceeded the limit of 3 logs per second")
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "jaeger.tracer.span_logs_suppressed", Value: 2,
+	})
+
+	sp.reset()
+	assert.Zero(t, sp.suppressedLogs)
+	assert.True(t, sp.logWindowStart.IsZero())
+}
+
+func TestSpanMaxLogsPerSecondDisabled(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	for i := 0; i < 100; i++ {
+		sp.LogKV("i", i)
+	}
+	assert.Len(t, sp.logs, 100)
+	assert.Nil(t, findJaegerTag(SuppressedLogsTagKey, BuildJaegerThrift(sp).Tags))
+}
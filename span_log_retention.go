@@ -0,0 +1,81 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"github.com/opentracing/opentracing-go"
+)
+
+// LogRetention selects which logs a span keeps once it reaches LogRetentionPolicy.MaxLogs.
+type LogRetention string
+
+const (
+	// LogRetentionKeepFirst keeps the first MaxLogs logs and drops the newer ones.
+	LogRetentionKeepFirst LogRetention = "keep_first"
+
+	// LogRetentionKeepLast keeps the last MaxLogs logs, dropping the oldest ones.
+	LogRetentionKeepLast LogRetention = "keep_last"
+
+	// LogRetentionHeadTail keeps the first half and the last half of MaxLogs logs,
+	// dropping the ones in the middle.
+	LogRetentionHeadTail LogRetention = "head_tail"
+)
+
+// LogRetentionPolicy limits the number of logs recorded on a span. The number of dropped logs
+// is reported in the DroppedLogsTagKey tag. Bulk logs passed to FinishWithOptions are not limited.
+type LogRetentionPolicy struct {
+	// MaxLogs is the max number of logs kept per span. Zero or a negative value disables the limit.
+	MaxLogs int
+
+	// Retention selects which logs are kept, defaults to LogRetentionKeepFirst.
+	Retention LogRetention
+}
+
+func (p LogRetentionPolicy) isValid() bool {
+	switch p.Retention {
+	case "", LogRetentionKeepFirst, LogRetentionKeepLast, LogRetentionHeadTail:
+		return true
+	}
+	return false
+}
+
+// this function should only be called while holding a Write lock
+func (s *Span) appendLog(lr opentracing.LogRecord) {
+	if !s.allowLogNoLocking(lr.Timestamp) {
+		return
+	}
+	policy := s.tracer.options.logRetentionPolicy
+	max := policy.MaxLogs
+	if max <= 0 || len(s.logs) < max {
+		s.logs = append(s.logs, lr)
+		return
+	}
+	s.droppedLogs++
+	// the index of the first log that is shifted out to make room for the new one
+	var tailStart int
+	switch policy.Retention {
+	case LogRetentionKeepLast:
+		tailStart = 0
+	case LogRetentionHeadTail:
+		tailStart = (max + 1) / 2
+		if tailStart == max {
+			return
+		}
+	default:
+		return
+	}
+	copy(s.logs[tailStart:], s.logs[tailStart+1:])
+	s.logs[max-1] = lr
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestSpanLogRetention(t *testing.T) {
+	tests := []struct {
+		policy   LogRetentionPolicy
+		expected []int
+	}{
+		{policy: LogRetentionPolicy{}, expected: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{policy: LogRetentionPolicy{MaxLogs: 4}, expected: []int{0, 1, 2, 3}},
+		{policy: LogRetentionPolicy{MaxLogs: 4, Retention: LogRetentionKeepFirst}, expected: []int{0, 1, 2, 3}},
+		{policy: LogRetentionPolicy{MaxLogs: 4, Retention: LogRetentionKeepLast}, expected: []int{6, 7, 8, 9}},
+		{policy: LogRetentionPolicy{MaxLogs: 4, Retention: LogRetentionHeadTail}, expected: []int{0, 1, 8, 9}},
+		{policy: LogRetentionPolicy{MaxLogs: 5, Retention: LogRetentionHeadTail}, expected: []int{0, 1, 2, 8, 9}},
+		{policy: LogRetentionPolicy{MaxLogs: 1, Retention: LogRetentionHeadTail}, expected: []int{0}},
+		{policy: LogRetentionPolicy{MaxLogs: 20, Retention: LogRetentionKeepLast}, expected: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+	}
+	for _, test := range tests {
+		t.Run(string(test.policy.Retention), func(t *testing.T) {
+			tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+				TracerOptions.LogRetentionPolicy(test.policy))
+			defer closer.Close()
+
+			sp := tracer.StartSpan("op").(*Span)
+			for i := 0; i < 10; i++ {
+				sp.LogKV("i", i)
+			}
+			var actual []int
+			for _, lr := range sp.logs {
+				actual = append(actual, lr.Fields[0].Value().(int))
+			}
+			assert.Equal(t, test.expected, actual)
+
+			var dropped *int64
+			for _, tag := range BuildJaegerThrift(sp).Tags {
+				if tag.Key == DroppedLogsTagKey {
+					dropped = tag.VLong
+				}
+			}
+			if len(test.expected) == 10 {
+				assert.Nil(t, dropped)
+			} else {
+				require.NotNil(t, dropped)
+				assert.Equal(t, int64(10-len(test.expected)), *dropped)
+			}
+		})
+	}
+}
+
+func TestSpanLogRetentionInvalid(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Logger(logger),
+		TracerOptions.LogRetentionPolicy(LogRetentionPolicy{MaxLogs: 1, Retention: "random"}))
+	defer closer.Close()
+
+	assert.Contains(t, logger.String(), `Unknown log retention "random"`)
+	assert.Equal(t, LogRetentionKeepFirst, tracer.(*Tracer).options.logRetentionPolicy.Retention)
+}
+
+func TestSpanLogRetentionReset(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.LogRetentionPolicy(LogRetentionPolicy{MaxLogs: 1}))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.LogKV("i", 0)
+	sp.LogKV("i", 1)
+	assert.Equal(t, 1, sp.droppedLogs)
+	sp.reset()
+	assert.Zero(t, sp.droppedLogs)
+}
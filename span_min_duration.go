@@ -0,0 +1,27 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+// isTooShort returns true if the finished span is shorter than the min duration of the spans reported
+// by the tracer, see TracerOptions.MinSpanDuration. The local roots and the failed spans are never too short.
+func (s *Span) isTooShort() bool {
+	minDuration := s.tracer.options.minSpanDuration
+	if minDuration <= 0 {
+		return false
+	}
+	s.RLock()
+	defer s.RUnlock()
+	return s.duration < minDuration && !s.firstInProcess && !s.hasErrorTagNoLocking()
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestMinSpanDuration(t *testing.T) {
+	clock := &steppedClock{wall: time.Unix(1500000000, 0)}
+	reporter := NewInMemoryReporter()
+	metricsFactory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter,
+		TracerOptions.Clock(clock),
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.MinSpanDuration(time.Millisecond),
+	)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root")
+	short := tracer.StartSpan("short", opentracing.ChildOf(root.Context()))
+	clock.advance(time.Microsecond, 0)
+	short.Finish()
+
+	failed := tracer.StartSpan("failed", opentracing.ChildOf(root.Context()))
+	ext.Error.Set(failed, true)
+	failed.Finish()
+
+	long := tracer.StartSpan("long", opentracing.ChildOf(root.Context()))
+	clock.advance(time.Millisecond, 0)
+	long.Finish()
+
+	server := tracer.StartSpan("server", opentracing.ChildOf(root.Context()), ext.SpanKindRPCServer)
+	server.Finish()
+	root.Finish()
+
+	var names []string
+	for _, span := range reporter.GetSpans() {
+		names = append(names, span.(*Span).OperationName())
+	}
+	assert.Equal(t, []string{"failed", "long", "server", "root"}, names)
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "jaeger.tracer.spans_dropped_too_short", Value: 1,
+	})
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"github.com/opentracing/opentracing-go"
+)
+
+// UpstreamTraceIDTagKey is the tag with the ID of the unsampled upstream trace, set on the root spans
+// of the traces started by TracerOptions.SampleOrphans.
+const UpstreamTraceIDTagKey = "jaeger.upstream_trace_id"
+
+// sampleOrphan runs the sampler for a span whose parent was extracted from an unsampled upstream
+// trace, see TracerOptions.SampleOrphans. It returns true with the ID of the new trace and the tags
+// of the sampler if the span should start a new sampled trace instead of joining the upstream one.
+func (t *Tracer) sampleOrphan(parent SpanContext, operation string, spanTags opentracing.Tags) (TraceID, []Tag, bool) {
+	if !t.options.sampleOrphans || !parent.remote || parent.IsSampled() {
+		return TraceID{}, nil, false
+	}
+	traceID := t.idGenerator.NextTraceID()
+	sampled, tags := t.isSampled(traceID, operation, spanTags)
+	if !sampled {
+		return TraceID{}, nil, false
+	}
+	tags = append(tags, Tag{key: UpstreamTraceIDTagKey, value: parent.traceID.String()})
+	return traceID, tags, true
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleOrphans(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(), TracerOptions.SampleOrphans(true))
+	defer closer.Close()
+
+	upstream := NewSpanContext(TraceID{Low: 1}, 2, 0, false, map[string]string{"k": "v"})
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(upstream, opentracing.TextMap, carrier))
+	parent, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+
+	sp := tracer.StartSpan("orphan", opentracing.ChildOf(parent)).(*Span)
+	defer sp.Finish()
+	assert.True(t, sp.context.IsSampled())
+	assert.NotEqual(t, upstream.TraceID(), sp.context.TraceID())
+	assert.Equal(t, SpanID(0), sp.context.ParentID())
+	assert.Equal(t, "1", sp.Tags()[UpstreamTraceIDTagKey])
+	assert.Empty(t, sp.References())
+	require.Len(t, sp.links, 1)
+	assert.True(t, upstream.Equal(sp.links[0].Context))
+	assert.Equal(t, "v", sp.BaggageItem("k"))
+
+	child := tracer.StartSpan("child", opentracing.ChildOf(sp.Context())).(*Span)
+	defer child.Finish()
+	assert.Equal(t, sp.context.TraceID(), child.context.TraceID(), "only remote parents start new traces")
+
+	local := tracer.StartSpan("local", opentracing.ChildOf(upstream)).(*Span)
+	defer local.Finish()
+	assert.Equal(t, upstream.TraceID(), local.context.TraceID(), "only remote parents start new traces")
+	assert.False(t, local.context.IsSampled())
+}
+
+func TestSampleOrphansNotSampled(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(false), NewNullReporter(), TracerOptions.SampleOrphans(true))
+	defer closer.Close()
+
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(NewSpanContext(TraceID{Low: 1}, 2, 0, false, nil), opentracing.TextMap, carrier))
+	parent, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+
+	sp := tracer.StartSpan("orphan", opentracing.ChildOf(parent)).(*Span)
+	defer sp.Finish()
+	assert.False(t, sp.context.IsSampled())
+	assert.Equal(t, TraceID{Low: 1}, sp.context.TraceID())
+	assert.Equal(t, SpanID(2), sp.context.ParentID())
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync"
+	"time"
+)
+
+// partialSpanReporter periodically reports snapshots of sampled spans that have been
+// running for longer than minAge, so that long-running spans are visible before they
+// finish and survive crashes of the process.
+type partialSpanReporter struct {
+	tracer   *Tracer
+	minAge   time.Duration
+	interval time.Duration
+
+	sync.Mutex
+	spans map[*Span]struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  sync.WaitGroup
+}
+
+func newPartialSpanReporter(tracer *Tracer, minAge, interval time.Duration) *partialSpanReporter {
+	r := &partialSpanReporter{
+		tracer:   tracer,
+		minAge:   minAge,
+		interval: interval,
+		spans:    make(map[*Span]struct{}),
+		stop:     make(chan struct{}),
+	}
+	r.stopped.Add(1)
+	go r.run()
+	return r
+}
+
+// spanStarted starts tracking the span.
+func (r *partialSpanReporter) spanStarted(sp *Span) {
+	r.Lock()
+	r.spans[sp] = struct{}{}
+	r.Unlock()
+}
+
+// spanFinished stops tracking the span. It must be called before the span is released,
+// so that no snapshot is taken of a span returned to the pool.
+func (r *partialSpanReporter) spanFinished(sp *Span) {
+	r.Lock()
+	delete(r.spans, sp)
+	r.Unlock()
+}
+
+func (r *partialSpanReporter) run() {
+	defer r.stopped.Done()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.reportPartialSpans()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *partialSpanReporter) reportPartialSpans() {
+	now := r.tracer.timeNow()
+	monotonicNow, hasMonotonic := r.tracer.monotonicNow()
+	var snapshots []*Span
+	r.Lock()
+	for sp := range r.spans {
+		if snapshot := r.snapshot(sp, now, monotonicNow, hasMonotonic); snapshot != nil {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	r.Unlock()
+	for _, snapshot := range snapshots {
+		r.tracer.reporter.Report(snapshot)
+		snapshot.Release()
+	}
+}
+
+// snapshot returns a copy of the span marked with the PartialSpanTagKey tag,
+// or nil if the span is not old enough.
+func (r *partialSpanReporter) snapshot(sp *Span, now time.Time, monotonicNow time.Duration, hasMonotonic bool) *Span {
+	sp.RLock()
+	defer sp.RUnlock()
+	age := sp.elapsedNoLocking(now, monotonicNow, hasMonotonic)
+	if age < r.minAge {
+		return nil
+	}
+	snapshot := r.tracer.newSpanRecord(sp)
+	snapshot.duration = age
+	snapshot.logs = append(snapshot.logs, sp.logs...)
+	snapshot.droppedLogs = sp.droppedLogs
+	snapshot.suppressedLogs = sp.suppressedLogs
+	snapshot.droppedTags = sp.droppedTags
+	snapshot.events = append(snapshot.events, sp.events...)
+	snapshot.droppedEvents = sp.droppedEvents
+	snapshot.warnings = append(snapshot.warnings, sp.warnings...)
+	snapshot.tags = append(snapshot.tags, Tag{key: PartialSpanTagKey, value: true})
+	return snapshot
+}
+
+func (r *partialSpanReporter) close() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	r.stopped.Wait()
+}
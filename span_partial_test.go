@@ -0,0 +1,114 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialSpanReporting(t *testing.T) {
+	var mux sync.Mutex
+	now := time.Unix(1000, 0)
+	timeNow := func() time.Time {
+		mux.Lock()
+		defer mux.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mux.Lock()
+		now = now.Add(d)
+		mux.Unlock()
+	}
+
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter,
+		TracerOptions.TimeNow(timeNow),
+		TracerOptions.PartialSpanReporting(5*time.Minute, time.Hour),
+	)
+	defer closer.Close()
+	partialSpans := tracer.(*Tracer).partialSpans
+	require.NotNil(t, partialSpans)
+
+	span := tracer.StartSpan("op", opentracing.Tag{Key: "k", Value: "v"}).(*Span)
+	span.LogKV("event", "started")
+
+	// young spans are not reported
+	advance(time.Minute)
+	partialSpans.reportPartialSpans()
+	assert.Equal(t, 0, reporter.SpansSubmitted())
+
+	advance(5 * time.Minute)
+	partialSpans.reportPartialSpans()
+	spans := reporter.GetSpans()
+	require.Len(t, spans, 1)
+	snapshot := spans[0].(*Span)
+	assert.Equal(t, span.context, snapshot.context)
+	assert.Equal(t, "op", snapshot.OperationName())
+	assert.Equal(t, span.StartTime(), snapshot.StartTime())
+	assert.Equal(t, 6*time.Minute, snapshot.Duration())
+	assert.Equal(t, "v", snapshot.Tags()["k"])
+	assert.Equal(t, true, snapshot.Tags()[PartialSpanTagKey])
+	assert.Len(t, snapshot.logs, 1)
+	assert.NotContains(t, span.Tags(), PartialSpanTagKey)
+
+	// finished spans are no longer snapshotted
+	span.Finish()
+	reporter.Reset()
+	advance(time.Hour)
+	partialSpans.reportPartialSpans()
+	assert.Equal(t, 0, reporter.SpansSubmitted())
+	assert.Empty(t, partialSpans.spans)
+}
+
+func TestPartialSpanReportingInBackground(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter,
+		TracerOptions.PartialSpanReporting(time.Nanosecond, time.Millisecond),
+	)
+	span := tracer.StartSpan("op")
+	for i := 0; i < 1000 && reporter.SpansSubmitted() == 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	require.NotZero(t, reporter.SpansSubmitted())
+	assert.Equal(t, true, reporter.GetSpans()[0].(*Span).Tags()[PartialSpanTagKey])
+	span.Finish()
+	closer.Close()
+}
+
+func TestPartialSpanReportingUnsampled(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), reporter,
+		TracerOptions.PartialSpanReporting(time.Nanosecond, time.Hour),
+	)
+	defer closer.Close()
+	span := tracer.StartSpan("op")
+	partialSpans := tracer.(*Tracer).partialSpans
+	partialSpans.reportPartialSpans()
+	assert.Equal(t, 0, reporter.SpansSubmitted())
+	assert.Empty(t, partialSpans.spans)
+	span.Finish()
+}
+
+func TestPartialSpanReportingDisabled(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+	assert.Nil(t, tracer.(*Tracer).partialSpans)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestRedactTags(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.RedactTags("password", "http.header.*"),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("s1", opentracing.Tag{Key: "password", Value: "secret"}).(*Span)
+	sp.SetTag("http.header.authorization", "Bearer token")
+	sp.SetTag("http.method", "GET")
+
+	tags := sp.Tags()
+	assert.Equal(t, RedactedTagValue, tags["password"])
+	assert.Equal(t, RedactedTagValue, tags["http.header.authorization"])
+	assert.Equal(t, "GET", tags["http.method"])
+}
+
+func TestRedactTagsInvalidPattern(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Logger(logger),
+		TracerOptions.RedactTags("password", "[invalid"),
+	)
+	defer closer.Close()
+	assert.Contains(t, logger.String(), "ERROR: Invalid pattern of redacted tags")
+
+	sp := tracer.StartSpan("s1").(*Span)
+	sp.SetTag("password", "secret")
+	assert.Equal(t, "secret", sp.Tags()["password"])
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+)
+
+// suspendedSpan is the serialized state of a span suspended with Span.Suspend.
+// The tags and logs are encoded like in the Jaeger Thrift model, to preserve the types of their values.
+type suspendedSpan struct {
+	Context       string            `json:"context"`
+	Baggage       map[string]string `json:"baggage,omitempty"`
+	OperationName string            `json:"operationName"`
+	ServiceName   string            `json:"serviceName,omitempty"`
+	StartTime     time.Time         `json:"startTime"`
+	Tags          []*j.Tag          `json:"tags,omitempty"`
+	Logs          []*j.Log          `json:"logs,omitempty"`
+}
+
+// Suspend serializes the span, including its operation name, start time, tags, logs and baggage,
+// so that it can be resumed as the same span with Tracer.ResumeSpan in another process or invocation,
+// e.g. in asynchronous workflows where one logical operation spans several function invocations.
+// The span is not reported, and it must not be used after it is suspended. It is counted as finished,
+// since the resumed span is counted as started again. Only the parent of the span is preserved from
+// its references and links.
+func (s *Span) Suspend() ([]byte, error) {
+	if !s.markFinished("Suspend") {
+		return nil, errors.New("the span is already finished")
+	}
+	s.RLock()
+	state := suspendedSpan{
+		Context:       s.context.String(),
+		OperationName: s.operationName,
+		ServiceName:   s.service,
+		StartTime:     s.startTime,
+		Tags:          s.tracer.buildTags(s.tags),
+		Logs:          buildLogs(s.logs),
+	}
+	// the signed baggage items are verified again by ResumeSpan, like by Tracer.Extract
+	if baggage := s.tracer.baggageSigner.sign(s.context).baggage; len(baggage) > 0 {
+		state.Baggage = make(map[string]string, len(baggage))
+		for k, v := range baggage {
+			state.Baggage[k] = v
+		}
+	}
+	s.RUnlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	s.tracer.metrics.SpansFinished.Inc(1)
+	if s.tracer.partialSpans != nil {
+		s.tracer.partialSpans.spanFinished(s)
+	}
+	if s.tracer.inFlightSpans != nil {
+		s.tracer.inFlightSpans.spanFinished(s)
+	}
+	if s.tracer.spanScopes != nil {
+		// the span is no longer finished by its parent, nor does it finish its children
+		s.tracer.spanScopes.claim(s)
+		s.tracer.spanScopes.spanFinished(s, false)
+	}
+	s.Release()
+	return data, nil
+}
+
+// ResumeSpan resumes the span serialized with Span.Suspend, with the same IDs, operation name, start time,
+// tags, logs and baggage. The resumed span is finished and reported like any other span.
+// The data is not trusted: the baggage is verified like the one of Extract, see TracerOptions.SignedBaggage
+// and TracerOptions.MaxBaggageSize, and the logs are subject to the log limits of the tracer.
+func (t *Tracer) ResumeSpan(data []byte) (opentracing.Span, error) {
+	var state suspendedSpan
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	ctx, err := ContextFromString(state.Context)
+	if err != nil {
+		return nil, err
+	}
+	ctx.baggage = state.Baggage
+	ctx = t.acceptBaggage(ctx)
+	var serviceName opentracing.Tags
+	if state.ServiceName != "" {
+		serviceName = opentracing.Tags{ServiceNameTagKey: state.ServiceName}
+	}
+	span := t.StartSpan(state.OperationName, SelfRef(ctx), opentracing.StartTime(state.StartTime), serviceName)
+	sp, ok := span.(*Span)
+	if !ok {
+		return span, nil
+	}
+	// the tags keep their order and duplicates, and are not interpreted again, e.g. sampling.priority
+	tags := make([]Tag, 0, len(state.Tags))
+	for _, jTag := range state.Tags {
+		tag := Tag{key: jTag.Key, value: tagValue(jTag)}
+		sp.observer.OnSetTag(tag.key, tag.value)
+		tags = append(tags, tag)
+	}
+	sp.Lock()
+	if len(tags) > 0 {
+		// the suspended tags include the ones set by StartSpan, e.g. the baggage tags
+		sp.tags = sp.tags[:0]
+		for _, tag := range tags {
+			sp.setTagNoLocking(tag.key, tag.value)
+		}
+	}
+	// the logs are subject to the same limits as the ones of LogFields, but keep their timestamps
+	for _, jLog := range state.Logs {
+		record := opentracing.LogRecord{
+			Timestamp: time.Unix(0, jLog.Timestamp*int64(time.Microsecond/time.Nanosecond)),
+			Fields:    make([]log.Field, 0, len(jLog.Fields)),
+		}
+		for _, field := range jLog.Fields {
+			record.Fields = append(record.Fields, logField(field))
+		}
+		sp.appendLog(record)
+	}
+	sp.Unlock()
+	return span, nil
+}
+
+// tagValue returns the value of the Jaeger Thrift tag.
+func tagValue(tag *j.Tag) interface{} {
+	switch tag.VType {
+	case j.TagType_BOOL:
+		return tag.GetVBool()
+	case j.TagType_LONG:
+		return tag.GetVLong()
+	case j.TagType_DOUBLE:
+		return tag.GetVDouble()
+	case j.TagType_BINARY:
+		return tag.GetVBinary()
+	default:
+		return tag.GetVStr()
+	}
+}
+
+// logField converts the Jaeger Thrift tag into a log field.
+func logField(tag *j.Tag) log.Field {
+	switch tag.VType {
+	case j.TagType_BOOL:
+		return log.Bool(tag.Key, tag.GetVBool())
+	case j.TagType_LONG:
+		return log.Int64(tag.Key, tag.GetVLong())
+	case j.TagType_DOUBLE:
+		return log.Float64(tag.Key, tag.GetVDouble())
+	case j.TagType_BINARY:
+		return log.Object(tag.Key, tag.GetVBinary())
+	default:
+		return log.String(tag.Key, tag.GetVStr())
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestSpanSuspendResume(t *testing.T) {
+	tracer1, closer1 := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer1.Close()
+
+	root := tracer1.StartSpan("root")
+	sp := tracer1.StartSpan("workflow", opentracing.ChildOf(root.Context()), ServiceName("worker")).(*Span)
+	sp.SetBaggageItem("tenant", "t1")
+	sp.SetTag("step", 1)
+	sp.SetTag("ratio", 0.5)
+	sp.SetTag("async", true)
+	sp.LogFields(log.String("event", "enqueued"), log.Int("attempt", 1))
+	ctx := sp.SpanContext()
+	startTime := sp.StartTime()
+
+	data, err := sp.Suspend()
+	require.NoError(t, err)
+
+	reporter := NewInMemoryReporter()
+	tracer2, closer2 := NewTracer("DOOP", NewConstSampler(false), reporter)
+	defer closer2.Close()
+
+	resumed, err := tracer2.(*Tracer).ResumeSpan(data)
+	require.NoError(t, err)
+	resumed.SetTag("step", 2)
+	resumed.Finish()
+
+	require.Len(t, reporter.GetSpans(), 1, "the sampling decision of the suspended span is kept")
+	span := reporter.GetSpans()[0].(*Span)
+	assert.Equal(t, ctx.TraceID(), span.context.TraceID())
+	assert.Equal(t, ctx.SpanID(), span.context.SpanID())
+	assert.Equal(t, ctx.ParentID(), span.context.ParentID())
+	assert.Equal(t, "t1", span.BaggageItem("tenant"))
+	assert.Equal(t, "workflow", span.OperationName())
+	assert.Equal(t, "worker", span.ServiceName())
+	assert.True(t, startTime.Equal(span.StartTime()))
+
+	tags := span.Tags()
+	assert.EqualValues(t, 2, tags["step"])
+	assert.Equal(t, 0.5, tags["ratio"])
+	assert.Equal(t, true, tags["async"])
+
+	logs := span.logs
+	require.Len(t, logs, 2, "the suspended log and the baggage log")
+	assert.Equal(t, "enqueued", logs[1].Fields[0].Value())
+	assert.EqualValues(t, 1, logs[1].Fields[1].Value())
+}
+
+func TestResumeSpanInvalid(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+
+	_, err := tracer.(*Tracer).ResumeSpan([]byte("not json"))
+	assert.Error(t, err)
+	_, err = tracer.(*Tracer).ResumeSpan([]byte(`{"context":"invalid"}`))
+	assert.Error(t, err)
+}
+
+func TestResumeSpanVerifiesBaggage(t *testing.T) {
+	secret := []byte("s3cr3t")
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.SignedBaggage(secret, "tenant"),
+		TracerOptions.MaxBaggageSize(100),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("workflow").(*Span)
+	sp.SetBaggageItem("tenant", "acme")
+	data, err := sp.Suspend()
+	require.NoError(t, err)
+	resumed, err := tracer.(*Tracer).ResumeSpan(data)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", resumed.BaggageItem("tenant"), "the signed baggage of Suspend is verified")
+	assert.Empty(t, resumed.BaggageItem("tenant"+BaggageSignatureSuffix))
+
+	forged := strings.Replace(string(data), `"acme"`, `"evil"`, 1)
+	resumed, err = tracer.(*Tracer).ResumeSpan([]byte(forged))
+	require.NoError(t, err)
+	assert.Empty(t, resumed.BaggageItem("tenant"), "the forged baggage item is dropped")
+
+	ctx := sp.SpanContext()
+	oversized := `{"context":"` + ctx.String() + `","baggage":{"user":"` + strings.Repeat("x", 100) + `"}}`
+	resumed, err = tracer.(*Tracer).ResumeSpan([]byte(oversized))
+	require.NoError(t, err)
+	assert.Empty(t, resumed.BaggageItem("user"), "the baggage exceeding the budget is dropped")
+}
+
+func TestResumeSpanLogLimits(t *testing.T) {
+	tracer1, closer1 := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer1.Close()
+	sp := tracer1.StartSpan("workflow").(*Span)
+	for i := 0; i < 5; i++ {
+		sp.LogFields(log.Int("i", i))
+	}
+	data, err := sp.Suspend()
+	require.NoError(t, err)
+
+	reporter := NewInMemoryReporter()
+	tracer2, closer2 := NewTracer("DOOP", NewConstSampler(true), reporter,
+		TracerOptions.LogRetentionPolicy(LogRetentionPolicy{MaxLogs: 2}),
+	)
+	defer closer2.Close()
+	resumed, err := tracer2.(*Tracer).ResumeSpan(data)
+	require.NoError(t, err)
+	resumed.Finish()
+
+	require.Len(t, reporter.GetSpans(), 1)
+	span := reporter.GetSpans()[0].(*Span)
+	require.Len(t, span.logs, 2)
+	assert.EqualValues(t, 0, span.logs[0].Fields[0].Value())
+	assert.Equal(t, 3, span.droppedLogs)
+}
+
+func TestResumeSpanKeepsTagOrder(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("workflow").(*Span)
+	sp.SetTag("step", "enqueued")
+	sp.SetTag("attempt", int64(1))
+	sp.SetTag("step", "started")
+	expected := append([]Tag(nil), sp.tags...)
+	data, err := sp.Suspend()
+	require.NoError(t, err)
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "jaeger.tracer.finished_spans", Value: 1,
+	})
+
+	resumed, err := tracer.(*Tracer).ResumeSpan(data)
+	require.NoError(t, err)
+	assert.Equal(t, expected, resumed.(*Span).tags, "the tags keep their order and duplicates")
+	resumed.Finish()
+	metricsFactory.AssertCounterMetrics(t, []metricstest.ExpectedMetric{
+		{Name: "jaeger.tracer.started_spans", Tags: map[string]string{"sampled": "y"}, Value: 2},
+		{Name: "jaeger.tracer.finished_spans", Value: 2},
+	}...)
+}
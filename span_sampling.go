@@ -0,0 +1,86 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"github.com/opentracing/opentracing-go"
+)
+
+// TagAwareSampler is a Sampler that can also base its decision on the tags of the root span,
+// such as http.url or http.method. The tracer calls IsSampledWithTags instead of IsSampled
+// whenever the tags are known, which with TracerOptions.DelayedSampling includes the tags
+// set on the root span after it has started.
+type TagAwareSampler interface {
+	Sampler
+
+	// IsSampledWithTags is like Sampler.IsSampled, with the tags of the root span.
+	IsSampledWithTags(id TraceID, operation string, spanTags opentracing.Tags) (sampled bool, tags []Tag)
+}
+
+// isSampled asks the sampler whether to sample a new trace, passing the tags of the root span to
+// TagAwareSampler.
+func (t *Tracer) isSampled(id TraceID, operation string, spanTags opentracing.Tags) (bool, []Tag) {
+	if sampler, ok := t.sampler.(TagAwareSampler); ok && len(spanTags) > 0 {
+		return sampler.IsSampledWithTags(id, operation, spanTags)
+	}
+	return t.sampler.IsSampled(id, operation)
+}
+
+// decideSamplingNoLocking makes the sampling decision deferred by TracerOptions.DelayedSampling,
+// based on the tags set so far. Until then, the span records its data as if it was sampled;
+// if it turns out not to be, the data is discarded. The caller must call Tracer.onSamplingDecided
+// after releasing the lock.
+// this function should only be called while holding a Write lock
+func (s *Span) decideSamplingNoLocking() {
+	s.samplingDeferred = false
+	if s.samplingFinalized {
+		// already decided by the sampling.priority tag
+		return
+	}
+	spanTags := make(opentracing.Tags, len(s.tags))
+	for _, tag := range s.tags {
+		spanTags[tag.key] = tag.value
+	}
+	if sampled, samplerTags := s.tracer.isSampled(s.context.traceID, s.operationName, spanTags); sampled {
+		tags := make([]Tag, 0, len(samplerTags)+len(s.tags))
+		tags = append(tags, samplerTags...)
+		s.tags = append(tags, s.tags...)
+		return
+	}
+	s.context.flags &^= flagSampled
+	s.tags = s.tags[:0]
+	s.droppedTags = 0
+	s.logs = s.logs[:0]
+	s.droppedLogs = 0
+	s.suppressedLogs = 0
+	s.events = s.events[:0]
+	s.droppedEvents = 0
+	s.warnings = s.warnings[:0]
+	s.links = s.links[:0]
+}
+
+// onSamplingDecided is called after the deferred sampling decision of a span is made.
+func (t *Tracer) onSamplingDecided(sp *Span) {
+	t.emitStartMetrics(sp.context.IsSampled(), true, sp.firstInProcess)
+	if !sp.context.IsSampled() {
+		return
+	}
+	if t.options.reportSpanStart {
+		t.reportSpanStart(sp)
+	}
+	if t.partialSpans != nil {
+		t.partialSpans.spanStarted(sp)
+	}
+}
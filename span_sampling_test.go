@@ -0,0 +1,157 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+// urlSampler samples the traces whose root span has the http.url tag with the given prefix.
+type urlSampler struct {
+	operationNameSampler
+	prefix string
+	calls  int
+}
+
+func (s *urlSampler) IsSampledWithTags(id TraceID, operation string, spanTags opentracing.Tags) (bool, []Tag) {
+	s.calls++
+	url, _ := spanTags[string(ext.HTTPUrl)].(string)
+	return strings.HasPrefix(url, s.prefix), []Tag{{key: SamplerTypeTagKey, value: "url"}}
+}
+
+func TestTagAwareSamplerWithStartTags(t *testing.T) {
+	sampler := &urlSampler{prefix: "/api"}
+	tracer, closer := NewTracer("DOOP", sampler, NewNullReporter())
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op", opentracing.Tag{Key: string(ext.HTTPUrl), Value: "/api/users"}).(*Span)
+	assert.True(t, sp.context.IsSampled())
+	assert.Equal(t, "url", sp.Tags()[SamplerTypeTagKey])
+
+	sp = tracer.StartSpan("op", opentracing.Tag{Key: string(ext.HTTPUrl), Value: "/health"}).(*Span)
+	assert.False(t, sp.context.IsSampled())
+
+	// without tags, the sampler is used as a regular Sampler
+	sp = tracer.StartSpan("op").(*Span)
+	assert.False(t, sp.context.IsSampled())
+	assert.Equal(t, 2, sampler.calls)
+}
+
+func TestDelayedSampling(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	reporter := NewInMemoryReporter()
+	sampler := &urlSampler{prefix: "/api"}
+	tracer, closer := NewTracer("DOOP", sampler, reporter,
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.DelayedSampling(true),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	assert.True(t, sp.samplingDeferred)
+	sp.LogKV("event", "routing")
+	assert.Equal(t, 0, sampler.calls)
+
+	ext.HTTPUrl.Set(sp, "/api/users")
+	assert.False(t, sp.samplingDeferred)
+	assert.Equal(t, 1, sampler.calls)
+	assert.True(t, sp.context.IsSampled())
+	assert.Equal(t, opentracing.Tags{
+		SamplerTypeTagKey:   "url",
+		string(ext.HTTPUrl): "/api/users",
+	}, sp.Tags())
+	assert.Equal(t, SamplerTypeTagKey, sp.tags[0].key, "the sampler tags come first")
+	assert.Len(t, sp.logs, 1, "the logs recorded before the decision are kept")
+
+	ext.HTTPMethod.Set(sp, "GET")
+	assert.Equal(t, 1, sampler.calls, "the decision is made once")
+	sp.Finish()
+	assert.Equal(t, 1, reporter.SpansSubmitted())
+
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.started_spans", Tags: map[string]string{"sampled": "y"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.started_spans", Tags: map[string]string{"sampled": "n"}, Value: 0},
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.traces", Tags: map[string]string{"state": "started", "sampled": "y"}, Value: 1},
+	)
+}
+
+func TestDelayedSamplingNotSampled(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", &urlSampler{prefix: "/api"}, reporter,
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.DelayedSampling(true),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.LogKV("event", "routing")
+	ext.HTTPUrl.Set(sp, "/health")
+	assert.False(t, sp.context.IsSampled())
+	assert.Empty(t, sp.tags, "the data recorded before the decision is discarded")
+	assert.Empty(t, sp.logs)
+	sp.Finish()
+	assert.Equal(t, 0, reporter.SpansSubmitted())
+
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.started_spans", Tags: map[string]string{"sampled": "n"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.traces", Tags: map[string]string{"state": "started", "sampled": "n"}, Value: 1},
+	)
+}
+
+func TestDelayedSamplingDecidedByContext(t *testing.T) {
+	sampler := &urlSampler{operationNameSampler: operationNameSampler{operations: map[string]bool{"op": true}}}
+	tracer, closer := NewTracer("DOOP", sampler, NewNullReporter(), TracerOptions.DelayedSampling(true))
+	defer closer.Close()
+
+	root := tracer.StartSpan("op").(*Span)
+	child := tracer.StartSpan("child", opentracing.ChildOf(root.Context())).(*Span)
+	assert.False(t, root.samplingDeferred)
+	assert.False(t, child.samplingDeferred, "only root spans defer sampling")
+	assert.True(t, root.context.IsSampled())
+	assert.True(t, child.context.IsSampled())
+	assert.Equal(t, 0, sampler.calls, "without tags, the sampler is used as a regular Sampler")
+}
+
+func TestDelayedSamplingDecidedByFinish(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter, TracerOptions.DelayedSampling(true))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	require.True(t, sp.samplingDeferred)
+	sp.Finish()
+	require.Equal(t, 1, reporter.SpansSubmitted())
+	assert.Equal(t, "const", reporter.GetSpans()[0].(*Span).Tags()[SamplerTypeTagKey])
+}
+
+func TestDelayedSamplingWithSamplingPriority(t *testing.T) {
+	sampler := &urlSampler{prefix: "/api"}
+	tracer, closer := NewTracer("DOOP", sampler, NewNullReporter(), TracerOptions.DelayedSampling(true))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	ext.SamplingPriority.Set(sp, 1)
+	assert.False(t, sp.samplingDeferred)
+	assert.True(t, sp.context.IsSampled())
+	assert.Equal(t, 0, sampler.calls, "sampling.priority overrides the sampler")
+}
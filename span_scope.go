@@ -0,0 +1,111 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// AutoFinishedTagKey is the tag set to true on the spans finished by their parent,
+// see TracerOptions.AutoFinishChildren.
+const AutoFinishedTagKey = "jaeger.auto_finished"
+
+// spanScopes keeps track of the unfinished ChildOf children of the unfinished spans,
+// so that finishing a span can finish its leaked children.
+type spanScopes struct {
+	sync.Mutex
+	scopes map[SpanContextKey]*spanScope
+}
+
+// spanScope holds the unfinished children of a span.
+type spanScope struct {
+	children map[*Span]struct{}
+}
+
+func newSpanScopes() *spanScopes {
+	return &spanScopes{scopes: make(map[SpanContextKey]*spanScope)}
+}
+
+// spanStarted registers the span as a child of its parent, if the parent is an unfinished span
+// of this tracer, and opens the scope of its own children. Spans sharing the ID of their parent,
+// see TracerOptions.ZipkinSharedRPCSpan, belong to the scope of the parent.
+func (s *spanScopes) spanStarted(sp *Span, parent SpanContext, childOf bool) {
+	key := sp.context.Key()
+	s.Lock()
+	defer s.Unlock()
+	if childOf && parent.Key() != key {
+		if scope, ok := s.scopes[parent.Key()]; ok {
+			scope.children[sp] = struct{}{}
+			sp.parentScope = scope
+		}
+	}
+	if _, ok := s.scopes[key]; !ok {
+		sp.childScope = &spanScope{children: make(map[*Span]struct{})}
+		s.scopes[key] = sp.childScope
+	}
+}
+
+// claim returns true if the span can be finished by the caller, or false if it has already
+// been finished by its parent.
+func (s *spanScopes) claim(sp *Span) bool {
+	s.Lock()
+	defer s.Unlock()
+	if sp.parentScope == nil {
+		return true
+	}
+	_, ok := sp.parentScope.children[sp]
+	delete(sp.parentScope.children, sp)
+	return ok
+}
+
+// spanFinished closes the scope of the children of the span and returns its unfinished children.
+// If autoFinish is true, the children are retained for the user code that may still hold them,
+// until it calls Finish, see Span.FinishWithOptions. Otherwise the children are detached from the
+// span, and the user code remains responsible for finishing them.
+func (s *spanScopes) spanFinished(sp *Span, autoFinish bool) []*Span {
+	if sp.childScope == nil {
+		return nil
+	}
+	s.Lock()
+	delete(s.scopes, sp.context.Key())
+	children := make([]*Span, 0, len(sp.childScope.children))
+	for child := range sp.childScope.children {
+		if autoFinish {
+			child.Retain()
+			atomic.StoreInt32(&child.autoFinished, 1)
+		} else {
+			child.parentScope = nil
+		}
+		children = append(children, child)
+	}
+	sp.childScope.children = nil
+	s.Unlock()
+	return children
+}
+
+// finishChildren finishes the unfinished children of the span at the finish time of the span.
+// The children are not returned to the pool until the user code calls Finish.
+func (s *spanScopes) finishChildren(sp *Span, finishTime time.Time) {
+	for _, child := range s.spanFinished(sp, true) {
+		child.SetTag(AutoFinishedTagKey, true)
+		if child.markFinished("Finish") {
+			child.finishWithOptions(opentracing.FinishOptions{FinishTime: finishTime})
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestAutoFinishChildren(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("x", NewConstSampler(true), reporter, TracerOptions.AutoFinishChildren(true))
+	defer closer.Close()
+
+	start := time.Now()
+	parent := tracer.StartSpan("parent", opentracing.StartTime(start))
+	finished := tracer.StartSpan("finished", opentracing.ChildOf(parent.Context()))
+	leaked := tracer.StartSpan("leaked", opentracing.ChildOf(parent.Context()))
+	grandchild := tracer.StartSpan("grandchild", opentracing.ChildOf(leaked.Context()))
+	follower := tracer.StartSpan("follower", opentracing.FollowsFrom(parent.Context()))
+
+	finished.Finish()
+	parent.FinishWithOptions(opentracing.FinishOptions{FinishTime: start.Add(time.Second)})
+
+	spans := reporter.GetSpans()
+	require.Len(t, spans, 4)
+	names := map[string]*Span{}
+	for _, sp := range spans {
+		names[sp.(*Span).OperationName()] = sp.(*Span)
+	}
+	assert.Nil(t, names["finished"].Tags()[AutoFinishedTagKey])
+	assert.Nil(t, names["parent"].Tags()[AutoFinishedTagKey])
+	for _, name := range []string{"leaked", "grandchild"} {
+		require.Contains(t, names, name)
+		assert.Equal(t, true, names[name].Tags()[AutoFinishedTagKey], name)
+		assert.WithinDuration(t, start.Add(time.Second), names[name].StartTime().Add(names[name].Duration()), time.Millisecond, name)
+	}
+
+	leaked.Finish()
+	grandchild.Finish()
+	assert.Equal(t, 4, reporter.SpansSubmitted(), "finishing auto-finished spans has no effect")
+	follower.Finish()
+	assert.Equal(t, 5, reporter.SpansSubmitted())
+}
+
+func TestAutoFinishChildrenSuspendedParent(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("x", NewConstSampler(true), reporter, TracerOptions.AutoFinishChildren(true))
+	defer closer.Close()
+
+	parent := tracer.StartSpan("parent")
+	child := tracer.StartSpan("child", opentracing.ChildOf(parent.Context()))
+	_, err := parent.(*Span).Suspend()
+	require.NoError(t, err)
+	assert.Equal(t, 0, reporter.SpansSubmitted())
+
+	child.Finish()
+	spans := reporter.GetSpans()
+	require.Len(t, spans, 1, "the children of a suspended span are finished by the user code")
+	assert.Equal(t, "child", spans[0].(*Span).OperationName())
+	assert.Nil(t, spans[0].(*Span).Tags()[AutoFinishedTagKey])
+}
+
+func TestAutoFinishChildrenSharedSpan(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("x", NewConstSampler(true), reporter,
+		TracerOptions.AutoFinishChildren(true),
+		TracerOptions.ZipkinSharedRPCSpan(true))
+	defer closer.Close()
+
+	client := tracer.StartSpan("client", ext.SpanKindRPCClient)
+	server := tracer.StartSpan("server", ext.RPCServerOption(client.Context()))
+	child := tracer.StartSpan("child", opentracing.ChildOf(server.Context()))
+
+	server.Finish()
+	assert.Equal(t, 1, reporter.SpansSubmitted(), "the children of shared spans belong to the client span")
+	client.Finish()
+	assert.Equal(t, 3, reporter.SpansSubmitted())
+	child.Finish()
+	assert.Equal(t, 3, reporter.SpansSubmitted())
+}
+
+func TestAutoFinishChildrenPoolSpans(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.AutoFinishChildren(true),
+		TracerOptions.PoolSpans(true),
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)))
+	defer closer.Close()
+
+	parent := tracer.StartSpan("parent")
+	leaked := tracer.StartSpan("leaked", opentracing.ChildOf(parent.Context())).(*Span)
+	traceID := leaked.context.traceID
+	parent.Finish()
+
+	// the auto-finished span is not recycled while the user code holds it
+	assert.Equal(t, "leaked", leaked.OperationName())
+	assert.Equal(t, traceID, leaked.context.traceID)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&leaked.referenceCounter))
+
+	leaked.Finish()
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "jaeger.tracer.finished_spans",
+		Value: 2,
+	})
+}
+
+func TestAutoFinishChildrenUseAfterFinish(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.AutoFinishChildren(true),
+		TracerOptions.DetectUseAfterFinish(UseAfterFinishLog),
+		TracerOptions.Logger(logger))
+	defer closer.Close()
+
+	parent := tracer.StartSpan("parent")
+	leaked := tracer.StartSpan("leaked", opentracing.ChildOf(parent.Context()))
+	parent.Finish()
+	assert.Empty(t, logger.String())
+
+	leaked.SetTag("key", "value")
+	assert.Contains(t, logger.String(), "Span.SetTag called after the span")
+	logger.Flush()
+	leaked.Finish()
+	assert.Empty(t, logger.String(), "finishing an auto-finished span is not an error")
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import "github.com/opentracing/opentracing-go"
+
+// ServiceName returns a StartSpanOption that attributes the span to the given service instead of
+// the service of the tracer. The span is reported with a separate process, with the tracer's process
+// tags and the given service name. It is intended for gateways and proxies that represent work done
+// on behalf of logical services. The service name cannot be changed after the span is started.
+func ServiceName(serviceName string) opentracing.StartSpanOption {
+	return opentracing.Tag{Key: ServiceNameTagKey, Value: serviceName}
+}
+
+// setServiceName returns true if the service name of the span was overridden, false otherwise.
+func setServiceName(s *Span, value interface{}) bool {
+	serviceName, ok := value.(string)
+	if !ok || serviceName == "" {
+		return false
+	}
+	s.service = serviceName
+	return true
+}
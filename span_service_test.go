@@ -0,0 +1,48 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpanServiceName(t *testing.T) {
+	tracer, closer := NewTracer("gateway", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+
+	sp := tracer.StartSpan("charge", ServiceName("payments-proxy")).(*Span)
+	assert.Equal(t, "payments-proxy", sp.ServiceName())
+	assert.NotContains(t, sp.Tags(), ServiceNameTagKey)
+
+	child := tracer.StartSpan("child", opentracing.ChildOf(sp.Context())).(*Span)
+	assert.Equal(t, "gateway", child.ServiceName(), "the service name is not inherited")
+
+	process := BuildJaegerProcessThrift(sp)
+	assert.Equal(t, "payments-proxy", process.ServiceName)
+	assert.Equal(t, buildJaegerProcessThrift(tracer.(*Tracer)).Tags, process.Tags)
+	assert.Equal(t, "gateway", BuildJaegerProcessThrift(child).ServiceName)
+
+	zSpan := BuildZipkinThrift(sp)
+	require.NotEmpty(t, zSpan.BinaryAnnotations)
+	assert.Equal(t, "payments-proxy", zSpan.BinaryAnnotations[0].Host.ServiceName)
+
+	invalid := tracer.StartSpan("invalid", opentracing.Tag{Key: ServiceNameTagKey, Value: 42}).(*Span)
+	assert.Equal(t, "gateway", invalid.ServiceName())
+	assert.Equal(t, 42, invalid.Tags()[ServiceNameTagKey])
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// SpanSnapshot is an immutable copy of the data of a finished span,
+// passed to observers implementing SnapshotSpanObserver.
+type SpanSnapshot struct {
+	Context       SpanContext
+	ServiceName   string
+	OperationName string
+	StartTime     time.Time
+	Duration      time.Duration
+	Tags          opentracing.Tags
+	Logs          []opentracing.LogRecord
+	References    []Reference
+	Links         []Link
+	Events        []SpanEvent
+}
+
+// SnapshotSpanObserver is an optional interface that can be implemented by ContribSpanObserver
+// (or the deprecated SpanObserver) to receive a snapshot of the span once it is finished.
+// OnFinishWithOptions is called after OnFinish, when the span has recorded its duration
+// and the finish logs, and before the span is passed to the reporter.
+// For unsampled spans the snapshot only includes the context, operation name and timing.
+type SnapshotSpanObserver interface {
+	OnFinishWithOptions(span SpanSnapshot, options opentracing.FinishOptions)
+}
+
+// snapshotNoLocking returns a copy of the span data. The caller must hold at least the read lock.
+func (s *Span) snapshotNoLocking() SpanSnapshot {
+	snapshot := SpanSnapshot{
+		Context:       s.context,
+		ServiceName:   s.serviceName(),
+		OperationName: s.operationName,
+		StartTime:     s.startTime,
+		Duration:      s.duration,
+	}
+	if len(s.tags) > 0 {
+		snapshot.Tags = make(opentracing.Tags, len(s.tags))
+		for _, tag := range s.tags {
+			snapshot.Tags[tag.key] = tag.value
+		}
+	}
+	if len(s.logs) > 0 {
+		snapshot.Logs = make([]opentracing.LogRecord, len(s.logs))
+		copy(snapshot.Logs, s.logs)
+	}
+	if len(s.references) > 0 {
+		snapshot.References = make([]Reference, len(s.references))
+		copy(snapshot.References, s.references)
+	}
+	if len(s.links) > 0 {
+		snapshot.Links = make([]Link, len(s.links))
+		copy(snapshot.Links, s.links)
+	}
+	if len(s.events) > 0 {
+		snapshot.Events = make([]SpanEvent, len(s.events))
+		copy(snapshot.Events, s.events)
+	}
+	return snapshot
+}
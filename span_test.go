@@ -21,8 +21,10 @@ import (
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
 
 	"github.com/uber/jaeger-client-go/internal/throttler"
 )
@@ -93,6 +95,45 @@ func TestSpanProperties(t *testing.T) {
 	assert.Equal(t, sp1.Tags(), expectedTags)
 }
 
+func TestSpanMaxTagsPerSpan(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.MaxTagsPerSpan(4),
+	)
+	defer closer.Close()
+
+	// the sampler tags count towards the limit
+	sp := tracer.StartSpan("s1", opentracing.Tag{Key: "k0", Value: 0}).(*Span)
+	sp.SetTag("k1", 1)
+	sp.SetTag("k2", 2)
+	sp.SetTag("k3", 3)
+	sp.SetTag("k1", "updated")
+	assert.Equal(t, opentracing.Tags{
+		"sampler.type":  "const",
+		"sampler.param": true,
+		"k0":            0,
+		"k1":            "updated",
+	}, sp.Tags())
+	assert.Equal(t, 2, sp.droppedTags)
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "jaeger.tracer.span_tags_dropped",
+		Value: 2,
+	})
+
+	var dropped *int64
+	for _, tag := range BuildJaegerThrift(sp).Tags {
+		if tag.Key == DroppedTagsTagKey {
+			dropped = tag.VLong
+		}
+	}
+	require.NotNil(t, dropped)
+	assert.Equal(t, int64(2), *dropped)
+
+	sp.reset()
+	assert.Zero(t, sp.droppedTags)
+}
+
 func TestSpanOperationName(t *testing.T) {
 	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
 	defer closer.Close()
@@ -104,6 +145,66 @@ func TestSpanOperationName(t *testing.T) {
 	assert.Equal(t, "s2", sp1.OperationName())
 }
 
+// operationNameSampler samples the traces whose root span has one of the given operation names.
+type operationNameSampler struct {
+	operations map[string]bool
+}
+
+func (s operationNameSampler) IsSampled(id TraceID, operation string) (bool, []Tag) {
+	return s.operations[operation], []Tag{{key: SamplerTypeTagKey, value: "operation"}}
+}
+
+func (s operationNameSampler) Close() {}
+
+func (s operationNameSampler) Equal(other Sampler) bool { return false }
+
+func TestSpanOperationNameResampling(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	reporter := NewInMemoryReporter()
+	sampler := operationNameSampler{operations: map[string]bool{"GET /users": true}}
+	tracer, closer := NewTracer("DOOP", sampler, reporter, TracerOptions.Metrics(NewMetrics(metricsFactory, nil)))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("HTTP GET").(*Span)
+	assert.False(t, sp.context.IsSampled())
+	sp.SetOperationName("GET /health")
+	assert.False(t, sp.context.IsSampled())
+	sp.SetOperationName("GET /users")
+	assert.True(t, sp.context.IsSampled())
+	assert.Equal(t, "GET /users", sp.OperationName())
+	assert.Equal(t, "operation", sp.Tags()[SamplerTypeTagKey])
+	sp.Finish()
+	assert.Equal(t, 1, reporter.SpansSubmitted())
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "jaeger.tracer.resampled_spans",
+		Value: 1,
+	})
+
+	// only the root spans of new traces are resampled
+	root := tracer.StartSpan("HTTP GET")
+	child := tracer.StartSpan("HTTP GET", opentracing.ChildOf(root.Context())).(*Span)
+	child.SetOperationName("GET /users")
+	assert.False(t, child.context.IsSampled())
+
+	// sampling.priority makes the decision final
+	sp = tracer.StartSpan("HTTP GET").(*Span)
+	ext.SamplingPriority.Set(sp, 0)
+	sp.SetOperationName("GET /users")
+	assert.False(t, sp.context.IsSampled())
+
+	// so does handing out the context, to start a child span or to inject it
+	sp = tracer.StartSpan("HTTP GET").(*Span)
+	child = tracer.StartSpan("SQL", opentracing.ChildOf(sp.Context())).(*Span)
+	sp.SetOperationName("GET /users")
+	assert.False(t, sp.context.IsSampled())
+	assert.False(t, child.context.IsSampled())
+
+	sp = tracer.StartSpan("HTTP GET").(*Span)
+	require.NoError(t, tracer.Inject(sp.Context(), opentracing.TextMap, opentracing.TextMapCarrier{}))
+	sp.SetOperationName("GET /users")
+	assert.False(t, sp.context.IsSampled())
+}
+
 func TestSetTag_SamplingPriority(t *testing.T) {
 	type testCase struct {
 		noDebugFlagOnForcedSampling bool
@@ -242,3 +343,29 @@ func TestSpanLifecycle(t *testing.T) {
 	sp1.Release() // Now we will kill the object and return it in the pool
 	assert.True(t, sp1.tracer == nil, "span must be released")
 }
+
+func TestSpanLazyLogFields(t *testing.T) {
+	var evaluated int32
+	lazy := log.Lazy(func(fv log.Encoder) {
+		atomic.AddInt32(&evaluated, 1)
+		fv.EmitString("state", "expensive")
+	})
+
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter())
+	sp := tracer.StartSpan("unsampled").(*Span)
+	sp.LogFields(lazy)
+	sp.Finish()
+	closer.Close()
+	assert.Zero(t, atomic.LoadInt32(&evaluated), "lazy fields of unsampled spans are never evaluated")
+
+	tracer, closer = NewTracer("DOOP", NewConstSampler(true), NewNullReporter())
+	defer closer.Close()
+	sp = tracer.StartSpan("sampled").(*Span)
+	sp.LogFields(lazy)
+	assert.Zero(t, atomic.LoadInt32(&evaluated), "lazy fields are not evaluated when logged")
+
+	jSpan := BuildJaegerThrift(sp)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&evaluated))
+	require.Len(t, jSpan.Logs, 1)
+	assert.Equal(t, "expensive", *findJaegerTag("state", jSpan.Logs[0].Fields).VStr)
+}
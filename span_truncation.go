@@ -0,0 +1,214 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+)
+
+// SpanTruncationStep is a way of reducing the size of a span that is too large to be sent.
+type SpanTruncationStep string
+
+const (
+	// SpanTruncationLogs removes as few logs as possible from the end of the span. The removed logs
+	// are not sent, their number is recorded in the TruncatedLogsTagKey tag and counted by the
+	// reporter_truncated_logs metric.
+	SpanTruncationLogs SpanTruncationStep = "logs"
+
+	// SpanTruncationTagValues shortens string and binary tag values to SpanTruncationPolicy.MaxTagValueLength.
+	// The number of shortened tags is recorded in the TruncatedTagValuesTagKey tag.
+	SpanTruncationTagValues SpanTruncationStep = "tag_values"
+
+	// SpanTruncationTags removes all tags of the span except the error tag and the truncation tags.
+	// The number of removed tags is recorded in the TruncatedTagsTagKey tag.
+	SpanTruncationTags SpanTruncationStep = "tags"
+)
+
+const defaultTruncatedTagValueLength = 64
+
+// SpanTruncationPolicy defines how senders reduce the size of spans that are too large to be sent,
+// before giving up and dropping them.
+type SpanTruncationPolicy struct {
+	// Steps are applied in the given order until the span fits. A policy without steps disables truncation.
+	Steps []SpanTruncationStep
+
+	// MaxTagValueLength is the length that tag values are shortened to by SpanTruncationTagValues, defaults to 64.
+	MaxTagValueLength int
+}
+
+// DefaultSpanTruncationPolicy returns the policy that trims logs first, then long tag values, then other tags.
+func DefaultSpanTruncationPolicy() SpanTruncationPolicy {
+	return SpanTruncationPolicy{
+		Steps:             []SpanTruncationStep{SpanTruncationLogs, SpanTruncationTagValues, SpanTruncationTags},
+		MaxTagValueLength: defaultTruncatedTagValueLength,
+	}
+}
+
+// spanTruncator applies a SpanTruncationPolicy to Thrift spans.
+type spanTruncator struct {
+	policy  SpanTruncationPolicy
+	sizeOf  func(span *j.Span) int
+	metrics *Metrics
+}
+
+func newSpanTruncator(policy SpanTruncationPolicy, sizeOf func(span *j.Span) int, metrics *Metrics) (*spanTruncator, error) {
+	for _, step := range policy.Steps {
+		switch step {
+		case SpanTruncationLogs, SpanTruncationTagValues, SpanTruncationTags:
+		default:
+			return nil, fmt.Errorf("unknown span truncation step %q", step)
+		}
+	}
+	if policy.MaxTagValueLength <= 0 {
+		policy.MaxTagValueLength = defaultTruncatedTagValueLength
+	}
+	if metrics == nil {
+		metrics = NewNullMetrics()
+	}
+	return &spanTruncator{policy: policy, sizeOf: sizeOf, metrics: metrics}, nil
+}
+
+// truncate applies the steps of the policy to a copy of the span until its size does not exceed maxSize.
+// It returns the truncated span and its size, which still exceeds maxSize if all steps were not enough.
+func (t *spanTruncator) truncate(jSpan *j.Span, maxSize int) (*j.Span, int) {
+	size := t.sizeOf(jSpan)
+	if size <= maxSize || len(t.policy.Steps) == 0 {
+		return jSpan, size
+	}
+	truncated := *jSpan
+	truncated.Tags = append([]*j.Tag(nil), jSpan.Tags...)
+	for _, step := range t.policy.Steps {
+		var changed bool
+		switch step {
+		case SpanTruncationLogs:
+			removed := t.truncateLogs(&truncated, maxSize)
+			changed = removed > 0
+			if changed {
+				t.metrics.ReporterSpanTruncatedLogs.Inc(1)
+				t.metrics.ReporterTruncatedLogs.Inc(int64(removed))
+			}
+		case SpanTruncationTagValues:
+			changed = t.truncateTagValues(&truncated)
+			if changed {
+				t.metrics.ReporterSpanTruncatedTagValues.Inc(1)
+			}
+		case SpanTruncationTags:
+			changed = t.truncateTags(&truncated)
+			if changed {
+				t.metrics.ReporterSpanTruncatedTags.Inc(1)
+			}
+		}
+		if changed {
+			if size = t.sizeOf(&truncated); size <= maxSize {
+				break
+			}
+		}
+	}
+	return &truncated, size
+}
+
+// truncateLogs removes as few logs as possible from the end of the span so that it fits into maxSize,
+// and returns the number of removed logs.
+func (t *spanTruncator) truncateLogs(span *j.Span, maxSize int) int {
+	logs := span.Logs
+	if len(logs) == 0 {
+		return 0
+	}
+	tags := span.Tags[:len(span.Tags):len(span.Tags)]
+	withLogs := func(n int) int {
+		span.Logs = logs[:n]
+		span.Tags = append(tags, newTruncationTag(TruncatedLogsTagKey, len(logs)-n))
+		return t.sizeOf(span)
+	}
+	// binary search for the max number of logs that fit, the size grows monotonically with it
+	low, high := 0, len(logs)-1
+	for low < high {
+		mid := (low + high + 1) / 2
+		if withLogs(mid) <= maxSize {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	withLogs(low)
+	return len(logs) - low
+}
+
+// truncateTagValues shortens string and binary tag values longer than MaxTagValueLength.
+func (t *spanTruncator) truncateTagValues(span *j.Span) bool {
+	maxLength := t.policy.MaxTagValueLength
+	count := 0
+	for i, tag := range span.Tags {
+		if tag.VStr != nil && len(*tag.VStr) > maxLength {
+			shortened := *tag
+			value := truncateUTF8(*tag.VStr, maxLength)
+			shortened.VStr = &value
+			span.Tags[i] = &shortened
+			count++
+		} else if len(tag.VBinary) > maxLength {
+			shortened := *tag
+			shortened.VBinary = tag.VBinary[:maxLength]
+			span.Tags[i] = &shortened
+			count++
+		}
+	}
+	if count == 0 {
+		return false
+	}
+	span.Tags = append(span.Tags, newTruncationTag(TruncatedTagValuesTagKey, count))
+	return true
+}
+
+// truncateTags removes all tags except the error tag and the tags recording previous truncations.
+func (t *spanTruncator) truncateTags(span *j.Span) bool {
+	kept := make([]*j.Tag, 0, len(span.Tags)+1)
+	for _, tag := range span.Tags {
+		switch tag.Key {
+		case "error", TruncatedLogsTagKey, TruncatedTagValuesTagKey:
+			kept = append(kept, tag)
+		}
+	}
+	removed := len(span.Tags) - len(kept)
+	if removed == 0 {
+		return false
+	}
+	span.Tags = append(kept, newTruncationTag(TruncatedTagsTagKey, removed))
+	return true
+}
+
+// truncateUTF8 cuts the string to at most maxLength bytes, backing off to the start of the rune
+// at the cut so that a multi-byte character is never split.
+func truncateUTF8(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+	end := maxLength
+	for end > 0 && maxLength-end < utf8.UTFMax && !utf8.RuneStart(value[end]) {
+		end--
+	}
+	if !utf8.RuneStart(value[end]) {
+		// not valid UTF-8, cut at the byte index
+		end = maxLength
+	}
+	return value[:end]
+}
+
+func newTruncationTag(key string, count int) *j.Tag {
+	value := int64(count)
+	return &j.Tag{Key: key, VType: j.TagType_LONG, VLong: &value}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/uber/jaeger-client-go/thrift"
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+)
+
+func thriftSpanSize(span *j.Span) int {
+	buffer := thrift.NewTMemoryBuffer()
+	span.Write(thrift.NewTCompactProtocolFactory().GetProtocol(buffer))
+	return buffer.Len()
+}
+
+func makeLargeThriftSpan() *j.Span {
+	longValue := strings.Repeat("x", 1000)
+	span := &j.Span{
+		OperationName: "op",
+		Tags: []*j.Tag{
+			{Key: "error", VType: j.TagType_BOOL, VBool: new(bool)},
+			{Key: "long", VType: j.TagType_STRING, VStr: &longValue},
+			{Key: "binary", VType: j.TagType_BINARY, VBinary: []byte(longValue)},
+		},
+	}
+	for i := 0; i < 10; i++ {
+		span.Logs = append(span.Logs, &j.Log{Fields: []*j.Tag{{Key: "event", VType: j.TagType_STRING, VStr: &longValue}}})
+	}
+	return span
+}
+
+func findThriftTag(span *j.Span, key string) *j.Tag {
+	for _, tag := range span.Tags {
+		if tag.Key == key {
+			return tag
+		}
+	}
+	return nil
+}
+
+func TestSpanTruncatorDefaultPolicy(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	truncator, err := newSpanTruncator(DefaultSpanTruncationPolicy(), thriftSpanSize, NewMetrics(metricsFactory, nil))
+	require.NoError(t, err)
+
+	span := makeLargeThriftSpan()
+	originalSize := thriftSpanSize(span)
+
+	// fits without truncation
+	truncated, size := truncator.truncate(span, originalSize)
+	assert.True(t, truncated == span)
+	assert.Equal(t, originalSize, size)
+
+	// removing some logs is enough
+	truncated, size = truncator.truncate(span, originalSize-1500)
+	assert.True(t, size <= originalSize-1500)
+	assert.Len(t, truncated.Logs, 8)
+	assert.EqualValues(t, 2, *findThriftTag(truncated, TruncatedLogsTagKey).VLong)
+	assert.Nil(t, findThriftTag(truncated, TruncatedTagValuesTagKey))
+
+	// logs and tag values must be truncated
+	truncated, size = truncator.truncate(span, 500)
+	assert.True(t, size <= 500)
+	assert.Len(t, truncated.Logs, 0)
+	assert.EqualValues(t, 10, *findThriftTag(truncated, TruncatedLogsTagKey).VLong)
+	assert.EqualValues(t, 2, *findThriftTag(truncated, TruncatedTagValuesTagKey).VLong)
+	assert.Len(t, *findThriftTag(truncated, "long").VStr, defaultTruncatedTagValueLength)
+	assert.Nil(t, findThriftTag(truncated, TruncatedTagsTagKey))
+
+	// all steps are needed
+	truncated, size = truncator.truncate(span, 150)
+	assert.True(t, size <= 150, "size %d", size)
+	assert.NotNil(t, findThriftTag(truncated, "error"))
+	assert.Nil(t, findThriftTag(truncated, "long"))
+	assert.EqualValues(t, 2, *findThriftTag(truncated, TruncatedTagsTagKey).VLong)
+
+	// the original span is not modified
+	assert.Len(t, span.Logs, 10)
+	assert.Len(t, span.Tags, 3)
+	assert.Len(t, *span.Tags[1].VStr, 1000)
+
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.reporter_span_truncations", Tags: map[string]string{"reason": "logs"}, Value: 3},
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.reporter_span_truncations", Tags: map[string]string{"reason": "tag_values"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.reporter_span_truncations", Tags: map[string]string{"reason": "tags"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "jaeger.tracer.reporter_truncated_logs", Value: 22},
+	)
+}
+
+func TestSpanTruncatorCustomPolicy(t *testing.T) {
+	truncator, err := newSpanTruncator(SpanTruncationPolicy{
+		Steps:             []SpanTruncationStep{SpanTruncationTagValues},
+		MaxTagValueLength: 10,
+	}, thriftSpanSize, nil)
+	require.NoError(t, err)
+
+	span := makeLargeThriftSpan()
+	span.Logs = nil
+	truncated, size := truncator.truncate(span, 200)
+	assert.True(t, size <= 200)
+	assert.Len(t, *findThriftTag(truncated, "long").VStr, 10)
+	assert.Len(t, findThriftTag(truncated, "binary").VBinary, 10)
+
+	// the logs are never truncated by this policy
+	span = makeLargeThriftSpan()
+	_, size = truncator.truncate(span, 200)
+	assert.True(t, size > 200)
+}
+
+func TestSpanTruncatorTagValuesUTF8(t *testing.T) {
+	truncator, err := newSpanTruncator(SpanTruncationPolicy{
+		Steps:             []SpanTruncationStep{SpanTruncationTagValues},
+		MaxTagValueLength: 10,
+	}, thriftSpanSize, nil)
+	require.NoError(t, err)
+
+	value := strings.Repeat("杭州", 10) // 3 bytes per character
+	span := &j.Span{OperationName: "op", Tags: []*j.Tag{{Key: "city", VType: j.TagType_STRING, VStr: &value}}}
+	truncated, _ := truncator.truncate(span, 50)
+	assert.Equal(t, "杭州杭", *findThriftTag(truncated, "city").VStr)
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	assert.Equal(t, "abc", truncateUTF8("abc", 5))
+	assert.Equal(t, "ab", truncateUTF8("abcd", 2))
+	assert.Equal(t, "a", truncateUTF8("aé", 2))
+	assert.Equal(t, "aé", truncateUTF8("aéb", 3))
+	assert.Equal(t, "", truncateUTF8("😀", 3))
+	assert.Equal(t, "\x80\x80", truncateUTF8("\x80\x80\x80\x80\x80", 2), "invalid UTF-8 is cut at the byte index")
+}
+
+func TestSpanTruncatorDisabled(t *testing.T) {
+	truncator, err := newSpanTruncator(SpanTruncationPolicy{}, thriftSpanSize, nil)
+	require.NoError(t, err)
+	span := makeLargeThriftSpan()
+	truncated, size := truncator.truncate(span, 150)
+	assert.True(t, truncated == span)
+	assert.Equal(t, thriftSpanSize(span), size)
+}
+
+func TestSpanTruncatorUnknownStep(t *testing.T) {
+	_, err := newSpanTruncator(SpanTruncationPolicy{Steps: []SpanTruncationStep{"annotations"}}, thriftSpanSize, nil)
+	assert.EqualError(t, err, `unknown span truncation step "annotations"`)
+}
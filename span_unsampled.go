@@ -0,0 +1,125 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// unsampledSpan is a minimal implementation of opentracing.Span returned for the unsampled spans
+// of unsampled traces, except their roots, when TracerOptions.MinimalUnsampledSpans is enabled.
+// It only keeps the span context, which is needed to propagate the trace, and discards the operation
+// name, tags and logs. Repeated calls to Finish are ignored.
+//
+// The spans are not pooled, unlike *Span with TracerOptions.PoolSpans: the caller keeps the span after
+// Finish, and a late call to Finish would otherwise finish the span of the next owner of the object.
+type unsampledSpan struct {
+	sync.RWMutex
+
+	tracer   *Tracer
+	context  SpanContext
+	finished bool
+}
+
+func (t *Tracer) startUnsampledSpan(ctx SpanContext, newTrace, rpcServer bool) opentracing.Span {
+	sp := &unsampledSpan{tracer: t, context: ctx}
+	t.emitStartMetrics(false, newTrace, rpcServer || ctx.parentID == 0)
+	return sp
+}
+
+// Finish implements opentracing.Span API
+func (s *unsampledSpan) Finish() {
+	s.FinishWithOptions(opentracing.FinishOptions{})
+}
+
+// FinishWithOptions implements opentracing.Span API
+func (s *unsampledSpan) FinishWithOptions(opts opentracing.FinishOptions) {
+	s.Lock()
+	if s.finished {
+		s.Unlock()
+		return
+	}
+	s.finished = true
+	s.Unlock()
+	s.tracer.metrics.SpansFinished.Inc(1)
+}
+
+// Context implements opentracing.Span API
+func (s *unsampledSpan) Context() opentracing.SpanContext {
+	s.RLock()
+	defer s.RUnlock()
+	return s.context
+}
+
+// SetOperationName implements opentracing.Span API. Unlike Span.SetOperationName,
+// it does not re-run the sampler.
+func (s *unsampledSpan) SetOperationName(operationName string) opentracing.Span {
+	return s
+}
+
+// SetTag implements opentracing.Span API. The tags are discarded, including sampling.priority:
+// unlike on *Span, it cannot make the span sampled, because the data of the span was not kept.
+// Only the roots of new traces, which are always *Span, can be sampled with sampling.priority.
+func (s *unsampledSpan) SetTag(key string, value interface{}) opentracing.Span {
+	return s
+}
+
+// LogFields implements opentracing.Span API
+func (s *unsampledSpan) LogFields(fields ...log.Field) {}
+
+// LogKV implements opentracing.Span API
+func (s *unsampledSpan) LogKV(alternatingKeyValues ...interface{}) {}
+
+// SetBaggageItem implements opentracing.Span API
+func (s *unsampledSpan) SetBaggageItem(key, value string) opentracing.Span {
+	s.Lock()
+	defer s.Unlock()
+	update := s.tracer.baggageSetter.updateBaggage(s.tracer.serviceName, s.context, key, value, "", false)
+	s.context = update.context
+	return s
+}
+
+// BaggageItem implements opentracing.Span API
+func (s *unsampledSpan) BaggageItem(key string) string {
+	if s.tracer.baggageSetter.hidden(key) {
+		return ""
+	}
+	s.RLock()
+	defer s.RUnlock()
+	return s.context.baggage[key]
+}
+
+// Tracer implements opentracing.Span API
+func (s *unsampledSpan) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+// LogEvent implements opentracing.Span API
+func (s *unsampledSpan) LogEvent(event string) {}
+
+// LogEventWithPayload implements opentracing.Span API
+func (s *unsampledSpan) LogEventWithPayload(event string, payload interface{}) {}
+
+// Log implements opentracing.Span API
+func (s *unsampledSpan) Log(ld opentracing.LogData) {}
+
+func (s *unsampledSpan) String() string {
+	s.RLock()
+	defer s.RUnlock()
+	return s.context.String()
+}
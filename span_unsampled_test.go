@@ -0,0 +1,194 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestMinimalUnsampledSpans(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), reporter,
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.MinimalUnsampledSpans(true),
+	)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root")
+	assert.IsType(t, &Span{}, root, "the roots of new traces are full spans")
+	root.SetBaggageItem("user", "alice")
+
+	child := tracer.StartSpan("child", opentracing.ChildOf(root.Context()))
+	_, ok := child.(*unsampledSpan)
+	require.True(t, ok, "expecting a minimal span, got %T", child)
+	child.SetTag("k", "v").SetOperationName("renamed").SetBaggageItem("tenant", "acme")
+	child.LogKV("event", "x")
+	assert.Equal(t, "acme", child.BaggageItem("tenant"))
+	assert.Equal(t, tracer, child.Tracer())
+	childCtx := child.Context().(SpanContext)
+	assert.Equal(t, root.Context().(SpanContext).TraceID(), childCtx.TraceID())
+	assert.Equal(t, "alice", child.BaggageItem("user"))
+
+	carrier := opentracing.TextMapCarrier{}
+	require.NoError(t, tracer.Inject(child.Context(), opentracing.TextMap, carrier))
+	extracted, err := tracer.Extract(opentracing.TextMap, carrier)
+	require.NoError(t, err)
+	assert.Equal(t, childCtx.SpanID(), extracted.(SpanContext).SpanID())
+	assert.False(t, extracted.(SpanContext).IsSampled())
+
+	child.Finish()
+	root.Finish()
+	assert.Equal(t, 0, reporter.SpansSubmitted())
+
+	metricsFactory.AssertCounterMetrics(t, []metricstest.ExpectedMetric{
+		{Name: "jaeger.tracer.started_spans", Tags: map[string]string{"sampled": "n"}, Value: 2},
+		{Name: "jaeger.tracer.finished_spans", Value: 2},
+		{Name: "jaeger.tracer.traces", Tags: map[string]string{"sampled": "n", "state": "started"}, Value: 1},
+	}...)
+}
+
+func TestMinimalUnsampledSpansFinishTwice(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.MinimalUnsampledSpans(true),
+	)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root")
+	defer root.Finish()
+	sp := tracer.StartSpan("op", opentracing.ChildOf(root.Context()))
+	require.IsType(t, &unsampledSpan{}, sp)
+	sp.Finish()
+	assert.NotPanics(t, sp.Finish, "repeated Finish is ignored")
+	assert.Equal(t, tracer, sp.Tracer())
+
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "jaeger.tracer.finished_spans", Value: 1,
+	})
+}
+
+func TestMinimalUnsampledSpansFallback(t *testing.T) {
+	t.Run("sampled", func(t *testing.T) {
+		tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+			TracerOptions.MinimalUnsampledSpans(true))
+		defer closer.Close()
+		sp := tracer.StartSpan("op")
+		defer sp.Finish()
+		assert.IsType(t, &Span{}, sp)
+	})
+	t.Run("observers", func(t *testing.T) {
+		tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+			TracerOptions.MinimalUnsampledSpans(true),
+			TracerOptions.ContribObserver(noopContribObserver{}))
+		defer closer.Close()
+		root := tracer.StartSpan("root")
+		defer root.Finish()
+		sp := tracer.StartSpan("op", opentracing.ChildOf(root.Context()))
+		defer sp.Finish()
+		assert.IsType(t, &Span{}, sp)
+	})
+}
+
+func TestMinimalUnsampledSpansSamplingPriority(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), reporter,
+		TracerOptions.MinimalUnsampledSpans(true),
+	)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root")
+	ext.SamplingPriority.Set(root, 1)
+	child := tracer.StartSpan("child", opentracing.ChildOf(root.Context()))
+	assert.IsType(t, &Span{}, child, "the children of sampled spans are full spans")
+	child.Finish()
+	root.Finish()
+	assert.Equal(t, 2, reporter.SpansSubmitted())
+}
+
+type noopContribObserver struct{}
+
+func (noopContribObserver) OnStartSpan(sp opentracing.Span, operationName string, options opentracing.StartSpanOptions) (ContribSpanObserver, bool) {
+	return nil, false
+}
+
+func TestMinimalUnsampledSpansAllocations(t *testing.T) {
+	allocs := func(opts ...TracerOption) float64 {
+		tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(), opts...)
+		defer closer.Close()
+		root := tracer.StartSpan("root")
+		defer root.Finish()
+		return testing.AllocsPerRun(100, func() {
+			sp := tracer.StartSpan("op", opentracing.ChildOf(root.Context()))
+			sp.SetTag("k", "v")
+			sp.Finish()
+		})
+	}
+	assert.True(t, allocs(TracerOptions.MinimalUnsampledSpans(true)) < allocs())
+}
+
+func TestMinimalUnsampledSpansLateFinish(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.Metrics(NewMetrics(metricsFactory, nil)),
+		TracerOptions.MinimalUnsampledSpans(true),
+	)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root")
+	defer root.Finish()
+	first := tracer.StartSpan("first", opentracing.ChildOf(root.Context()))
+	first.Finish()
+	second := tracer.StartSpan("second", opentracing.ChildOf(root.Context()))
+	first.Finish()
+	assert.True(t, second.Context().(SpanContext).IsValid(), "a late Finish of another span does not finish the span")
+	assert.NotEqual(t, first.Context().(SpanContext).SpanID(), second.Context().(SpanContext).SpanID())
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "jaeger.tracer.finished_spans", Value: 1,
+	})
+
+	second.Finish()
+	metricsFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "jaeger.tracer.finished_spans", Value: 2,
+	})
+}
+
+func TestMinimalUnsampledSpansIgnoreSamplingPriority(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), reporter,
+		TracerOptions.MinimalUnsampledSpans(true),
+	)
+	defer closer.Close()
+
+	root := tracer.StartSpan("root")
+	child := tracer.StartSpan("child", opentracing.ChildOf(root.Context()))
+	require.IsType(t, &unsampledSpan{}, child)
+	ext.SamplingPriority.Set(child, 1)
+	assert.False(t, child.Context().(SpanContext).IsSampled(), "sampling.priority is ignored on minimal spans")
+
+	grandchild := tracer.StartSpan("grandchild", opentracing.ChildOf(child.Context()))
+	assert.False(t, grandchild.Context().(SpanContext).IsSampled())
+	grandchild.Finish()
+	child.Finish()
+	root.Finish()
+	assert.Equal(t, 0, reporter.SpansSubmitted())
+}
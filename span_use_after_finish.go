@@ -0,0 +1,81 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// UseAfterFinishAction is what the tracer does when a span is used after it was finished,
+// see TracerOptions.DetectUseAfterFinish.
+type UseAfterFinishAction int
+
+const (
+	// UseAfterFinishIgnore does not detect the use of spans after Finish, which is the default.
+	UseAfterFinishIgnore UseAfterFinishAction = iota
+	// UseAfterFinishLog logs an error with a stack trace and ignores the call.
+	UseAfterFinishLog
+	// UseAfterFinishPanic panics with a stack trace.
+	UseAfterFinishPanic
+)
+
+// markFinished records that the span is finished, and the stack trace of the call finishing it
+// if TracerOptions.FinishStackTraces is set, if the use of spans after Finish is detected.
+// It returns false if the span was already finished.
+func (s *Span) markFinished(operation string) bool {
+	if s.tracer.options.useAfterFinish == UseAfterFinishIgnore {
+		return true
+	}
+	var stack string
+	if s.tracer.options.finishStackTraces {
+		stack = string(debug.Stack())
+	}
+	s.Lock()
+	finished, finishStack := s.finished, s.finishStack
+	if !finished {
+		s.finished, s.finishStack = true, stack
+	}
+	s.Unlock()
+	return !finished || !s.reportUseAfterFinish(operation, finishStack)
+}
+
+// usedAfterFinish returns true if the span was finished and the call should be ignored.
+// It panics instead if the tracer is configured so.
+func (s *Span) usedAfterFinish(operation string) bool {
+	if s.tracer == nil || s.tracer.options.useAfterFinish == UseAfterFinishIgnore {
+		return false
+	}
+	s.RLock()
+	finished, finishStack := s.finished, s.finishStack
+	s.RUnlock()
+	return finished && s.reportUseAfterFinish(operation, finishStack)
+}
+
+// reportUseAfterFinish reports the misuse with the stack trace of Finish if it was recorded,
+// or else with the stack trace of the misuse, which is only captured now.
+func (s *Span) reportUseAfterFinish(operation, finishStack string) bool {
+	var msg string
+	if finishStack != "" {
+		msg = fmt.Sprintf("Span.%s called after the span %s was finished at:\n%s", operation, s.SpanContext(), finishStack)
+	} else {
+		msg = fmt.Sprintf("Span.%s called after the span %s was finished, called at:\n%s", operation, s.SpanContext(), debug.Stack())
+	}
+	if s.tracer.options.useAfterFinish == UseAfterFinishPanic {
+		panic(msg)
+	}
+	s.tracer.logger.Error(msg)
+	return true
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+func TestDetectUseAfterFinishLog(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("x", NewConstSampler(true), reporter,
+		TracerOptions.PoolSpans(true),
+		TracerOptions.DetectUseAfterFinish(UseAfterFinishLog),
+		TracerOptions.FinishStackTraces(true),
+		TracerOptions.Logger(logger))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("x").(*Span)
+	sp.Finish()
+	assert.NotEmpty(t, sp.finishStack)
+	reporter.Reset()
+	assert.Empty(t, logger.String())
+
+	calls := map[string]func(){
+		"SetOperationName": func() { sp.SetOperationName("y") },
+		"SetTag":           func() { sp.SetTag("k", "v") },
+		"LogFields":        func() { sp.LogFields(otlog.String("k", "v")) },
+		"LogKV":            func() { sp.LogKV("k", "v") },
+		"Log":              func() { sp.LogEvent("e") },
+		"SetBaggageItem":   func() { sp.SetBaggageItem("k", "v") },
+		"AddEvent":         func() { sp.AddEvent("e") },
+		"RecordError":      func() { sp.RecordError(errors.New("e")) },
+		"Finish":           func() { sp.Finish() },
+	}
+	for operation, call := range calls {
+		logger.Flush()
+		call()
+		assert.Contains(t, logger.String(), "ERROR: Span."+operation+" called after the span "+sp.String()+" was finished at:\n")
+		assert.Contains(t, logger.String(), "TestDetectUseAfterFinishLog", "the stack trace of Finish is logged")
+	}
+
+	assert.Equal(t, "x", sp.OperationName())
+	assert.Nil(t, sp.Tags()["k"])
+	assert.Empty(t, sp.logs)
+	assert.Equal(t, 0, reporter.SpansSubmitted(), "spans finished twice are not reported again")
+	assert.False(t, sp == tracer.StartSpan("z").(*Span), "finished spans are not returned to the pool")
+}
+
+func TestDetectUseAfterFinishPanic(t *testing.T) {
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.DetectUseAfterFinish(UseAfterFinishPanic))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("x", opentracing.Tag{Key: "k", Value: "v"})
+	sp.SetTag("k2", "v2")
+	sp.Finish()
+	assert.Panics(t, func() { sp.SetTag("k3", "v3") })
+
+	suspended := tracer.StartSpan("suspended").(*Span)
+	_, err := suspended.Suspend()
+	require.NoError(t, err)
+	assert.Panics(t, func() { suspended.Finish() })
+}
+
+func TestDetectUseAfterFinishMisuseStack(t *testing.T) {
+	logger := &log.BytesBufferLogger{}
+	tracer, closer := NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.DetectUseAfterFinish(UseAfterFinishLog),
+		TracerOptions.Logger(logger))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("x").(*Span)
+	sp.Finish()
+	assert.True(t, sp.finished)
+	assert.Empty(t, sp.finishStack, "the stack trace of Finish is not captured by default")
+
+	sp.SetTag("k", "v")
+	assert.Contains(t, logger.String(), "ERROR: Span.SetTag called after the span "+sp.String()+" was finished, called at:\n")
+	assert.Contains(t, logger.String(), "TestDetectUseAfterFinishMisuseStack", "the stack trace of the misuse is logged")
+	assert.Nil(t, sp.Tags()["k"])
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Warnings returns the warnings about the data of the span that was dropped or truncated by the client,
+// e.g. because of the limits of the tracer. They are reported in WarningsTagKey tags.
+func (s *Span) Warnings() []string {
+	s.RLock()
+	defer s.RUnlock()
+	return s.warningsNoLocking()
+}
+
+// addWarningNoLocking records a warning about the span, if it is sampled.
+// this function should only be called while holding a Write lock
+func (s *Span) addWarningNoLocking(format string, args ...interface{}) {
+	if s.context.IsSampled() {
+		s.warnings = append(s.warnings, fmt.Sprintf(format, args...))
+	}
+}
+
+// warningsNoLocking returns the warnings recorded on the span, followed by the warnings
+// derived from the numbers of dropped and truncated data.
+func (s *Span) warningsNoLocking() []string {
+	var warnings []string
+	if len(s.warnings) > 0 {
+		warnings = append(warnings, s.warnings...)
+	}
+	if s.droppedTags > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d tags dropped because the span reached the limit of %d tags",
+			s.droppedTags, s.tracer.options.maxTagsPerSpan))
+	}
+	if s.droppedLogs > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d logs dropped by the %s log retention policy",
+			s.droppedLogs, s.tracer.options.logRetentionPolicy.Retention))
+	}
+	if s.suppressedLogs > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d logs suppressed because the span exceeded the limit of %d logs per second",
+			s.suppressedLogs, s.tracer.options.maxLogsPerSecond))
+	}
+	if s.droppedEvents > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d events dropped because the span reached the limit of %d events",
+			s.droppedEvents, s.tracer.options.maxEventsPerSpan))
+	}
+	// the numbers of truncated tag values by max length, which may differ by tag key
+	var truncated map[int]int
+	for _, tag := range s.tags {
+		length := 0
+		switch value := tag.value.(type) {
+		case string:
+			length = len(value)
+		case []byte:
+			length = len(value)
+		default:
+			continue
+		}
+		if maxLength := s.tracer.maxTagValueLength(tag.key); length > maxLength {
+			if truncated == nil {
+				truncated = make(map[int]int)
+			}
+			truncated[maxLength]++
+		}
+	}
+	maxLengths := make([]int, 0, len(truncated))
+	for maxLength := range truncated {
+		maxLengths = append(maxLengths, maxLength)
+	}
+	sort.Ints(maxLengths)
+	for _, maxLength := range maxLengths {
+		warnings = append(warnings, fmt.Sprintf("%d tag values truncated to %d characters", truncated[maxLength], maxLength))
+	}
+	return warnings
+}
+
+func buildWarningTags(warnings []string) []Tag {
+	tags := make([]Tag, len(warnings))
+	for i, warning := range warnings {
+		tags[i] = Tag{key: WarningsTagKey, value: warning}
+	}
+	return tags
+}
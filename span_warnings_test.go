@@ -0,0 +1,75 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/uber/jaeger-client-go/internal/baggage"
+)
+
+func TestSpanWarnings(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.MaxTagsPerSpan(3),
+		TracerOptions.MaxTagValueLength(5),
+		TracerOptions.MaxEventsPerSpan(1),
+		TracerOptions.LogRetentionPolicy(LogRetentionPolicy{MaxLogs: 1, Retention: LogRetentionKeepLast}),
+		TracerOptions.BaggageRestrictionManager(baggage.NewDefaultRestrictionManager(3)),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	assert.Empty(t, sp.Warnings())
+
+	sp.SetTag("long", strings.Repeat("x", 10))
+	sp.SetTag("dropped", true)
+	sp.SetBaggageItem("user", "alice")
+	sp.LogKV("event", "second")
+	sp.AddEvent("first")
+	sp.AddEvent("second")
+
+	warnings := []string{
+		`baggage item "user" truncated to 3 characters`,
+		"1 tags dropped because the span reached the limit of 3 tags",
+		"1 logs dropped by the keep_last log retention policy",
+		"1 events dropped because the span reached the limit of 1 events",
+		"1 tag values truncated to 5 characters",
+	}
+	assert.Equal(t, warnings, sp.Warnings())
+
+	jSpan := BuildJaegerThrift(sp)
+	var reported []string
+	for _, tag := range jSpan.Tags {
+		if tag.Key == WarningsTagKey {
+			reported = append(reported, *tag.VStr)
+		}
+	}
+	assert.Len(t, reported, len(warnings))
+	assert.Equal(t, warnings[0][:5], reported[0])
+}
+
+func TestSpanWarningsUnsampled(t *testing.T) {
+	tracer, closer := NewTracer("DOOP", NewConstSampler(false), NewNullReporter(),
+		TracerOptions.BaggageRestrictionManager(baggage.NewDefaultRestrictionManager(3)),
+	)
+	defer closer.Close()
+
+	sp := tracer.StartSpan("op").(*Span)
+	sp.SetBaggageItem("user", "alice")
+	assert.Empty(t, sp.Warnings())
+}
@@ -20,12 +20,12 @@ import (
 	"math/rand"
 	"os"
 	"reflect"
-	"strconv"
 	"sync"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
+	"github.com/uber/jaeger-lib/metrics"
 
 	"github.com/uber/jaeger-client-go/internal/baggage"
 	"github.com/uber/jaeger-client-go/internal/throttler"
@@ -43,15 +43,64 @@ type Tracer struct {
 	metrics  Metrics
 	logger   log.Logger
 
+	// metricsFactory creates the metrics with dynamic tags, if provided, see TracerOptions.MetricsFactory
+	metricsFactory metrics.Factory
+
 	timeNow      func() time.Time
+	clock        Clock
 	randomNumber func() uint64
+	idGenerator  IDGenerator
 
 	options struct {
 		gen128Bit                   bool // whether to generate 128bit trace IDs
 		zipkinSharedRPCSpan         bool
 		highTraceIDGenerator        func() uint64 // custom high trace ID generator
 		maxTagValueLength           int
+		maxTagValueLengthByKey      map[string]int
+		redactedTags                []string
+		zipkinSharedMessagingSpan   bool
+		baggageTags                 []string
+		operationNameNormalizer     func(operationName string) string
+		startSpanInterceptors       []StartSpanInterceptor
+		minSpanDuration             time.Duration
+		maxLogsPerSecond            int
+		maxEventsPerSpan            int
+		maxTagsPerSpan              int
+		tagErrorsFromLogs           bool
+		delayedSampling             bool
+		minimalUnsampledSpans       bool
+		resourceDetectors           []ResourceDetector
+		stackTraceOnError           bool
+		stackTraceMaxDepth          int
+		stackTraceRateLimiter       utils.RateLimiter
+		logRetentionPolicy          LogRetentionPolicy
 		noDebugFlagOnForcedSampling bool
+		disableHostTags             bool
+		instanceID                  string
+		instanceIDFile              string
+		autoFinishChildren          bool
+		sampleOrphans               bool
+		useAfterFinish              UseAfterFinishAction
+		finishStackTraces           bool
+		defaultBaggage              map[string]string
+		baggageObservers            []BaggageObserver
+		propagatedBaggage           []string
+		localBaggage                []string
+		maxBaggageSize              int
+		baggageMetricsKeys          []string
+		baggageSigningSecret        []byte
+		signedBaggage               []string
+		baggageTransformers         []keyedBaggageTransformer
+		baggageNamespaces           []*baggageNamespaceRestrictions
+		reportSpanStart             bool // whether to report a record of each span when it starts
+		partialSpanMinAge           time.Duration
+		partialSpanInterval         time.Duration
+		inFlightSpansInterval       time.Duration
+		remoteClock                 RemoteClock
+		clockSkewInterval           time.Duration
+		spanDurationHistograms      bool
+		spanDurationBuckets         []time.Duration
+		metricsSummaryInterval      time.Duration
 		// more options to come
 	}
 	// allocator of Span objects
@@ -62,13 +111,49 @@ type Tracer struct {
 
 	observer compositeObserver
 
-	tags    []Tag
-	process Process
+	// startSpan starts the spans through the chain of StartSpanInterceptors, if any
+	startSpan StartSpanFunc
+
+	// processLock guards tags and process, which can be updated with SetProcessTag
+	processLock    sync.RWMutex
+	tags           []Tag
+	process        Process
+	processVersion uint64
 
 	baggageRestrictionManager baggage.RestrictionManager
 	baggageSetter             *baggageSetter
 
+	// baggagePropagation filters the baggage items injected into carriers, if configured
+	baggagePropagation *baggagePropagationFilter
+
+	// baggageSigner signs and verifies the designated baggage items, if configured
+	baggageSigner *baggageSigner
+
 	debugThrottler throttler.Throttler
+
+	// debugLevelThrottlers throttle the debug levels greater than 1, see TracerOptions.DebugLevelThrottler
+	debugLevelThrottlers map[uint16]throttler.Throttler
+
+	// partialSpans reports snapshots of long-running spans, if enabled
+	partialSpans *partialSpanReporter
+
+	// inFlightSpans tracks the spans started but not finished, if enabled
+	inFlightSpans *inFlightSpanTracker
+
+	// clockSkew reports the skew of the local clock in a process tag, if enabled
+	clockSkew *clockSkewDetector
+
+	// metricsSummary logs the summary of the tracer's metrics, if enabled
+	metricsSummary *metricsSummaryLogger
+
+	// propagationMetrics counts the injected and extracted span contexts, if the tracer has a MetricsFactory
+	propagationMetrics *propagationMetrics
+
+	// spanScopes keeps track of the unfinished children of the spans, if enabled
+	spanScopes *spanScopes
+
+	// tagRedactor replaces the values of sensitive tags, see TracerOptions.RedactTags
+	tagRedactor *keyMatcher
 }
 
 // NewTracer creates Tracer implementation that reports tracing to Jaeger.
@@ -93,6 +178,9 @@ func NewTracer(
 	for _, option := range options {
 		option(t)
 	}
+	if t.logger == nil {
+		t.logger = log.NullLogger
+	}
 
 	// register default injectors/extractors unless they are already provided via options
 	textPropagator := NewTextMapPropagator(getDefaultHeadersConfig(), t.metrics)
@@ -116,9 +204,45 @@ func NewTracer(
 	} else {
 		t.baggageSetter = newBaggageSetter(baggage.NewDefaultRestrictionManager(0), &t.metrics)
 	}
+	t.baggageSetter.keyMetrics = newBaggageMetricsByKey(t.metricsFactory, t.options.baggageMetricsKeys)
+	t.propagationMetrics = newPropagationMetrics(t.metricsFactory)
+	t.baggageSetter.maxBaggageSize = t.options.maxBaggageSize
+	for i := range t.options.baggageTransformers {
+		if err := t.options.baggageTransformers[i].compile(); err != nil {
+			t.logger.Error("Invalid baggage key pattern of a BaggageTransformer, all baggage is rejected: " + err.Error())
+		}
+	}
+	t.baggageSetter.transformers = t.options.baggageTransformers
+	for _, ns := range t.options.baggageNamespaces {
+		if err := ns.compile(); err != nil {
+			t.logger.Error(fmt.Sprintf("Invalid allowed keys of the baggage namespace %q, no baggage is allowed: %s",
+				ns.name, err.Error()))
+		}
+	}
+	sortBaggageNamespaces(t.options.baggageNamespaces)
+	t.baggageSetter.namespaces = t.options.baggageNamespaces
+	filter, err := newBaggagePropagationFilter(t.options.propagatedBaggage, t.options.localBaggage)
+	if err != nil {
+		t.logger.Error("Invalid pattern of propagated or local baggage, no baggage is propagated: " + err.Error())
+	}
+	t.baggagePropagation = filter
+	signer, err := newBaggageSigner(t.options.baggageSigningSecret, t.options.signedBaggage, &t.metrics)
+	if err != nil {
+		t.logger.Error("Invalid signed baggage, the signed baggage items are not propagated: " + err.Error())
+	}
+	t.baggageSigner = signer
+	t.baggageSetter.signer = signer
+	if len(t.options.baggageObservers) == 1 {
+		t.baggageSetter.observer = t.options.baggageObservers[0]
+	} else if len(t.options.baggageObservers) > 1 {
+		t.baggageSetter.observer = compositeBaggageObserver(t.options.baggageObservers)
+	}
 	if t.debugThrottler == nil {
 		t.debugThrottler = throttler.DefaultThrottler{}
 	}
+	if t.options.spanDurationHistograms {
+		t.observer.append(newSpanDurationObserver(t.metricsFactory, t.options.spanDurationBuckets))
+	}
 
 	if t.randomNumber == nil {
 		seedGenerator := utils.NewRand(time.Now().UnixNano())
@@ -136,15 +260,20 @@ func NewTracer(
 		}
 	}
 	if t.timeNow == nil {
-		t.timeNow = time.Now
+		if t.clock == nil {
+			t.clock = newSystemClock()
+		}
+		t.timeNow = t.clock.Now
 	}
-	if t.logger == nil {
-		t.logger = log.NullLogger
+	t.detectResources()
+	if len(t.options.startSpanInterceptors) > 0 {
+		t.startSpan = chainStartSpanInterceptors(t.options.startSpanInterceptors, t.startSpanWithOptions)
 	}
+
 	// Set tracer-level tags
 	t.tags = append(t.tags, Tag{key: JaegerClientVersionTagKey, value: JaegerClientVersion})
 
-	if _, ok := t.getTag(TracerHostnameTagKey); !ok {
+	if _, ok := t.getTag(TracerHostnameTagKey); !ok && !t.options.disableHostTags {
 		if hostname, err := os.Hostname(); err == nil {
 			t.tags = append(t.tags, Tag{key: TracerHostnameTagKey, value: hostname})
 		}
@@ -158,14 +287,21 @@ func NewTracer(
 		} else {
 			t.hostIPv4 = ipv4
 		}
-	} else if ip, err := utils.HostIP(); err == nil {
-		t.tags = append(t.tags, Tag{key: TracerIPTagKey, value: ip.String()})
-		t.hostIPv4 = utils.PackIPAsUint32(ip)
-	} else {
-		t.logger.Error("Unable to determine this host's IP address: " + err.Error())
+	} else if !t.options.disableHostTags {
+		if ip, err := utils.HostIP(); err == nil {
+			t.tags = append(t.tags, Tag{key: TracerIPTagKey, value: ip.String()})
+			t.hostIPv4 = utils.PackIPAsUint32(ip)
+		} else {
+			t.logger.Error("Unable to determine this host's IP address: " + err.Error())
+		}
 	}
 
-	if t.options.gen128Bit {
+	if t.idGenerator != nil {
+		if t.options.gen128Bit || t.options.highTraceIDGenerator != nil {
+			t.logger.Error("The \"Gen128Bit\" and \"HighTraceIDGenerator\" options are ignored " +
+				"when the \"IDGenerator\" option is used")
+		}
+	} else if t.options.gen128Bit {
 		if t.options.highTraceIDGenerator == nil {
 			t.options.highTraceIDGenerator = t.randomNumber
 		}
@@ -173,17 +309,52 @@ func NewTracer(
 		t.logger.Error("Overriding high trace ID generator but not generating " +
 			"128 bit trace IDs, consider enabling the \"Gen128Bit\" option")
 	}
+	if t.idGenerator == nil {
+		t.idGenerator = randomIDGenerator{tracer: t}
+	}
 	if t.options.maxTagValueLength == 0 {
 		t.options.maxTagValueLength = DefaultMaxTagValueLength
 	}
+	if redactor, err := newKeyMatcher(t.options.redactedTags); err != nil {
+		t.logger.Error("Invalid pattern of redacted tags, no tags are redacted: " + err.Error())
+	} else {
+		t.tagRedactor = redactor
+	}
+	if t.options.maxEventsPerSpan == 0 {
+		t.options.maxEventsPerSpan = DefaultMaxEventsPerSpan
+	}
+	if !t.options.logRetentionPolicy.isValid() {
+		t.logger.Error(fmt.Sprintf("Unknown log retention %q, keeping the first logs of the spans instead",
+			t.options.logRetentionPolicy.Retention))
+		t.options.logRetentionPolicy.Retention = LogRetentionKeepFirst
+	}
 	t.process = Process{
 		Service: serviceName,
-		UUID:    strconv.FormatUint(t.randomNumber(), 16),
+		UUID:    t.processUUID(),
 		Tags:    t.tags,
 	}
 	if throttler, ok := t.debugThrottler.(ProcessSetter); ok {
 		throttler.SetProcess(t.process)
 	}
+	if t.options.partialSpanMinAge > 0 {
+		interval := t.options.partialSpanInterval
+		if interval <= 0 {
+			interval = t.options.partialSpanMinAge
+		}
+		t.partialSpans = newPartialSpanReporter(t, t.options.partialSpanMinAge, interval)
+	}
+	if t.options.autoFinishChildren {
+		t.spanScopes = newSpanScopes()
+	}
+	if t.options.inFlightSpansInterval > 0 {
+		t.inFlightSpans = newInFlightSpanTracker(t, t.options.inFlightSpansInterval)
+	}
+	if t.options.remoteClock != nil && t.options.clockSkewInterval > 0 {
+		t.clockSkew = newClockSkewDetector(t, t.options.remoteClock, t.options.clockSkewInterval)
+	}
+	if t.options.metricsSummaryInterval > 0 {
+		t.metricsSummary = newMetricsSummaryLogger(t, t.options.metricsSummaryInterval)
+	}
 
 	return t, t
 }
@@ -207,6 +378,9 @@ func (t *Tracer) StartSpan(
 	for _, o := range options {
 		o.Apply(&sso)
 	}
+	if t.startSpan != nil {
+		return t.startSpan(operationName, sso)
+	}
 	return t.startSpanWithOptions(operationName, sso)
 }
 
@@ -214,8 +388,14 @@ func (t *Tracer) startSpanWithOptions(
 	operationName string,
 	options opentracing.StartSpanOptions,
 ) opentracing.Span {
+	if t.options.operationNameNormalizer != nil {
+		operationName = t.options.operationNameNormalizer(operationName)
+	}
+	var startMonotonic time.Duration
+	var hasStartMonotonic bool
 	if options.StartTime.IsZero() {
 		options.StartTime = t.timeNow()
+		startMonotonic, hasStartMonotonic = t.monotonicNow()
 	}
 
 	// Predicate whether the given span context is a valid reference
@@ -224,14 +404,27 @@ func (t *Tracer) startSpanWithOptions(
 		return ctx.IsValid() || ctx.isDebugIDContainerOnly() || len(ctx.baggage) != 0
 	}
 
-	var references []Reference
+	// the references are collected on the stack and only copied for full spans, the minimal spans drop them
+	var referencesBuf [2]Reference
+	localReferences := referencesBuf[:0]
 	var parent SpanContext
 	var hasParent bool // need this because `parent` is a value, not reference
 	var ctx SpanContext
 	var isSelfRef bool
+	var deferSampling bool
+	var links []Link
 	for _, ref := range options.References {
+		if linkCtx, ok := ref.ReferencedContext.(linkContext); ok {
+			if linkCtx.link.Context.IsValid() {
+				links = append(links, linkCtx.link)
+			}
+			continue
+		}
 		ctxRef, ok := ref.ReferencedContext.(SpanContext)
 		if !ok {
+			if ref.ReferencedContext == noopSpanContext {
+				continue
+			}
 			t.logger.Error(fmt.Sprintf(
 				"Reference contains invalid type of SpanReference: %s",
 				reflect.ValueOf(ref.ReferencedContext)))
@@ -247,13 +440,14 @@ func (t *Tracer) startSpanWithOptions(
 			continue
 		}
 
-		references = append(references, Reference{Type: ref.Type, Context: ctxRef})
+		localReferences = append(localReferences, Reference{Type: ref.Type, Context: ctxRef})
 
 		if !hasParent {
 			parent = ctxRef
 			hasParent = ref.Type == opentracing.ChildOfRef
 		}
 	}
+	childOf := hasParent
 	if !hasParent && isValidReference(parent) {
 		// If ChildOfRef wasn't found but a FollowFromRef exists, use the context from
 		// the FollowFromRef as the parent
@@ -261,40 +455,67 @@ func (t *Tracer) startSpanWithOptions(
 	}
 
 	rpcServer := false
+	consumer := false
 	if v, ok := options.Tags[ext.SpanKindRPCServer.Key]; ok {
 		rpcServer = (v == ext.SpanKindRPCServerEnum || v == string(ext.SpanKindRPCServerEnum))
+		consumer = (v == ext.SpanKindConsumerEnum || v == string(ext.SpanKindConsumerEnum))
 	}
 
 	var samplerTags []Tag
 	newTrace := false
+	firehose := isFirehoseTagValue(options.Tags[FirehoseTagKey])
 	if !isSelfRef {
 		if !hasParent || !parent.IsValid() {
 			newTrace = true
-			ctx.traceID.Low = t.randomID()
-			if t.options.gen128Bit {
-				ctx.traceID.High = t.options.highTraceIDGenerator()
-			}
+			ctx.traceID = t.idGenerator.NextTraceID()
 			ctx.spanID = SpanID(ctx.traceID.Low)
 			ctx.parentID = 0
 			ctx.flags = byte(0)
 			if hasParent && parent.isDebugIDContainerOnly() && t.isDebugAllowed(operationName) {
 				ctx.flags |= (flagSampled | flagDebug)
 				samplerTags = []Tag{{key: JaegerDebugHeader, value: parent.debugID}}
-			} else if sampled, tags := t.sampler.IsSampled(ctx.traceID, operationName); sampled {
+			} else if firehose {
+				ctx.flags |= flagFirehose | flagSampled
+			} else if t.options.delayedSampling && len(options.Tags) == 0 {
+				// provisionally sampled until the first tag is set, see Span.decideSamplingNoLocking
+				ctx.flags |= flagSampled
+				deferSampling = true
+			} else if sampled, tags := t.isSampled(ctx.traceID, operationName, options.Tags); sampled {
 				ctx.flags |= flagSampled
 				samplerTags = tags
 			}
+		} else if traceID, tags, sampled := t.sampleOrphan(parent, operationName, options.Tags); sampled {
+			// start a new trace linked to the unsampled upstream trace, instead of a child span
+			newTrace = true
+			ctx.traceID = traceID
+			ctx.spanID = SpanID(traceID.Low)
+			ctx.parentID = 0
+			ctx.flags = flagSampled
+			samplerTags = tags
+			localReferences = nil
+			links = append(links, Link{Context: parent})
 		} else {
 			ctx.traceID = parent.traceID
-			if rpcServer && t.options.zipkinSharedRPCSpan {
-				// Support Zipkin's one-span-per-RPC model
+			if (rpcServer && t.options.zipkinSharedRPCSpan) ||
+				(consumer && t.options.zipkinSharedMessagingSpan) {
+				// Support Zipkin's one-span-per-RPC (or per-message) model
 				ctx.spanID = parent.spanID
 				ctx.parentID = parent.parentID
 			} else {
-				ctx.spanID = SpanID(t.randomID())
+				ctx.spanID = t.idGenerator.NextSpanID()
 				ctx.parentID = parent.spanID
 			}
 			ctx.flags = parent.flags
+			if level := debugLevel(parent); level > 1 && parent.remote && t.isDebugLevelAllowed(level, operationName) {
+				// the debug level of the trace forces the spans of all services to be sampled,
+				// it is throttled where it enters the process and inherited by the local children
+				ctx.flags |= t.debugFlags()
+				samplerTags = []Tag{{key: DebugLevelTagKey, value: level}}
+			}
+			if firehose || parent.IsFirehose() {
+				// firehose spans are reported regardless of the sampling decision
+				ctx.flags |= flagFirehose | flagSampled
+			}
 		}
 		if hasParent {
 			// copy baggage items
@@ -304,13 +525,28 @@ func (t *Tracer) startSpanWithOptions(
 					ctx.baggage[k] = v
 				}
 			}
+			ctx.baggageMetadata = parent.baggageMetadata
+		}
+		if newTrace {
+			ctx.baggage = t.withDefaultBaggage(ctx.baggage)
 		}
 	}
 
+	// the roots of new traces are full spans, so that sampling.priority or SetOperationName can still sample them
+	if t.options.minimalUnsampledSpans && !ctx.IsSampled() && !newTrace && len(t.observer.observers) == 0 {
+		return t.startUnsampledSpan(ctx, newTrace, rpcServer)
+	}
+
+	references := append([]Reference(nil), localReferences...)
 	sp := t.newSpan()
 	sp.context = ctx
+	sp.samplingDeferred = deferSampling
+	sp.startMonotonic, sp.hasStartMonotonic = startMonotonic, hasStartMonotonic
+	if ctx.IsSampled() {
+		sp.links = append(sp.links, links...)
+	}
 	sp.observer = t.observer.OnStartSpan(sp, operationName, options)
-	return t.startSpanInternal(
+	sp = t.startSpanInternal(
 		sp,
 		operationName,
 		options.StartTime,
@@ -320,6 +556,10 @@ func (t *Tracer) startSpanWithOptions(
 		rpcServer,
 		references,
 	)
+	if t.spanScopes != nil {
+		t.spanScopes.spanStarted(sp, parent, childOf)
+	}
+	return sp
 }
 
 // Inject implements Inject() method of opentracing.Tracer
@@ -329,7 +569,9 @@ func (t *Tracer) Inject(ctx opentracing.SpanContext, format interface{}, carrier
 		return opentracing.ErrInvalidSpanContext
 	}
 	if injector, ok := t.injectors[format]; ok {
-		return injector.Inject(c, carrier)
+		err := injector.Inject(t.baggageSigner.sign(t.baggagePropagation.filter(c)), carrier)
+		t.propagationMetrics.injected(injector, err)
+		return err
 	}
 	return opentracing.ErrUnsupportedFormat
 }
@@ -341,16 +583,37 @@ func (t *Tracer) Extract(
 ) (opentracing.SpanContext, error) {
 	if extractor, ok := t.extractors[format]; ok {
 		spanCtx, err := extractor.Extract(carrier)
+		t.propagationMetrics.extracted(extractor, err)
 		if err != nil {
 			return nil, err // ensure returned spanCtx is nil
 		}
-		return spanCtx, nil
+		spanCtx.remote = true
+		return t.acceptBaggage(spanCtx), nil
 	}
 	return nil, opentracing.ErrUnsupportedFormat
 }
 
+// acceptBaggage applies the baggage signatures and size budget to the baggage
+// of a context received from outside the process, see Extract and ResumeSpan.
+func (t *Tracer) acceptBaggage(ctx SpanContext) SpanContext {
+	ctx = t.baggageSigner.verify(ctx)
+	return t.baggageSetter.enforceBudget(ctx)
+}
+
 // Close releases all resources used by the Tracer and flushes any remaining buffered spans.
 func (t *Tracer) Close() error {
+	if t.partialSpans != nil {
+		t.partialSpans.close()
+	}
+	if t.inFlightSpans != nil {
+		t.inFlightSpans.close()
+	}
+	if t.clockSkew != nil {
+		t.clockSkew.close()
+	}
+	if t.metricsSummary != nil {
+		t.metricsSummary.close()
+	}
 	t.reporter.Close()
 	t.sampler.Close()
 	if mgr, ok := t.baggageRestrictionManager.(io.Closer); ok {
@@ -359,13 +622,35 @@ func (t *Tracer) Close() error {
 	if throttler, ok := t.debugThrottler.(io.Closer); ok {
 		throttler.Close()
 	}
+	for _, levelThrottler := range t.debugLevelThrottlers {
+		if throttler, ok := levelThrottler.(io.Closer); ok {
+			throttler.Close()
+		}
+	}
 	return nil
 }
 
+// Ready checks whether the tracing backend is reachable, so that services can fail their readiness
+// checks or log clearly instead of silently dropping all spans. It returns nil if the reporter does
+// not support health checks, e.g. for in-memory or null reporters.
+func (t *Tracer) Ready() error {
+	if checker, ok := t.reporter.(HealthChecker); ok {
+		return checker.CheckHealth()
+	}
+	return nil
+}
+
+// LastExportError returns the most recent error of sending spans to the tracing backend, or nil.
+// The time of the error is available from ReporterStats.
+func (t *Tracer) LastExportError() error {
+	return t.ReporterStats().LastError
+}
+
 // Tags returns a slice of tracer-level tags.
 func (t *Tracer) Tags() []opentracing.Tag {
-	tags := make([]opentracing.Tag, len(t.tags))
-	for i, tag := range t.tags {
+	processTags, _ := t.processTags()
+	tags := make([]opentracing.Tag, len(processTags))
+	for i, tag := range processTags {
 		tags[i] = opentracing.Tag{Key: tag.key, Value: tag.value}
 	}
 	return tags
@@ -381,6 +666,14 @@ func (t *Tracer) getTag(key string) (interface{}, bool) {
 	return nil, false
 }
 
+// maxTagValueLength returns the max length of the values of the tags with the given key.
+func (t *Tracer) maxTagValueLength(key string) int {
+	if maxLength, ok := t.options.maxTagValueLengthByKey[key]; ok {
+		return maxLength
+	}
+	return t.options.maxTagValueLength
+}
+
 // newSpan returns an instance of a clean Span object.
 // If options.PoolSpans is true, the spans are retrieved from an object pool.
 func (t *Tracer) newSpan() *Span {
@@ -403,6 +696,7 @@ func (t *Tracer) startSpanInternal(
 	sp.duration = 0
 	sp.references = references
 	sp.firstInProcess = rpcServer || sp.context.parentID == 0
+	sp.samplingFinalized = !newTrace || sp.context.IsFirehose()
 	if len(tags) > 0 || len(internalTags) > 0 {
 		sp.tags = make([]Tag, len(internalTags), len(tags)+len(internalTags))
 		copy(sp.tags, internalTags)
@@ -411,11 +705,34 @@ func (t *Tracer) startSpanInternal(
 			if k == string(ext.SamplingPriority) && !setSamplingPriority(sp, v) {
 				continue
 			}
+			if k == ServiceNameTagKey && setServiceName(sp, v) {
+				continue
+			}
 			sp.setTagNoLocking(k, v)
 		}
 	}
-	// emit metrics
-	if sp.context.IsSampled() {
+	if len(t.options.baggageTags) > 0 {
+		sp.setBaggageTagsNoLocking()
+	}
+	if t.inFlightSpans != nil {
+		t.inFlightSpans.spanStarted(sp)
+	}
+	if sp.samplingDeferred {
+		// see onSamplingDecided
+		return sp
+	}
+	t.emitStartMetrics(sp.context.IsSampled(), newTrace, sp.firstInProcess)
+	if t.options.reportSpanStart && sp.context.IsSampled() {
+		t.reportSpanStart(sp)
+	}
+	if t.partialSpans != nil && sp.context.IsSampled() {
+		t.partialSpans.spanStarted(sp)
+	}
+	return sp
+}
+
+func (t *Tracer) emitStartMetrics(sampled, newTrace, firstInProcess bool) {
+	if sampled {
 		t.metrics.SpansStartedSampled.Inc(1)
 		if newTrace {
 			// We cannot simply check for parentID==0 because in Zipkin model the
@@ -423,18 +740,54 @@ func (t *Tracer) startSpanInternal(
 			// calling client-side span, but obviously the server side span is
 			// no longer a root span of the trace.
 			t.metrics.TracesStartedSampled.Inc(1)
-		} else if sp.firstInProcess {
+		} else if firstInProcess {
 			t.metrics.TracesJoinedSampled.Inc(1)
 		}
 	} else {
 		t.metrics.SpansStartedNotSampled.Inc(1)
 		if newTrace {
 			t.metrics.TracesStartedNotSampled.Inc(1)
-		} else if sp.firstInProcess {
+		} else if firstInProcess {
 			t.metrics.TracesJoinedNotSampled.Inc(1)
 		}
 	}
-	return sp
+}
+
+// onSpanResampled is called when a span becomes sampled after it has started, see Span.SetOperationName.
+func (t *Tracer) onSpanResampled(sp *Span) {
+	t.metrics.SpansResampled.Inc(1)
+	if t.partialSpans != nil {
+		t.partialSpans.spanStarted(sp)
+	}
+}
+
+// reportSpanStart reports a copy of the just started span, marked with the SpanPhaseTagKey tag.
+func (t *Tracer) reportSpanStart(sp *Span) {
+	sp.RLock()
+	record := t.newSpanRecord(sp)
+	sp.RUnlock()
+	record.tags = append(record.tags, Tag{key: SpanPhaseTagKey, value: SpanPhaseStarted})
+	t.reporter.Report(record)
+	record.Release()
+}
+
+// newSpanRecord returns a new span with the same context, operation name, start time,
+// references and tags as the given span, to be reported in addition to the span itself.
+// The caller must hold a lock on the span if it can be modified concurrently.
+func (t *Tracer) newSpanRecord(sp *Span) *Span {
+	record := t.newSpan()
+	record.tracer = t
+	record.context = sp.context
+	record.operationName = sp.operationName
+	record.service = sp.service
+	record.startTime = sp.startTime
+	record.startMonotonic, record.hasStartMonotonic = sp.startMonotonic, sp.hasStartMonotonic
+	record.firstInProcess = sp.firstInProcess
+	record.references = append(record.references, sp.references...)
+	record.links = append(record.links, sp.links...)
+	record.tags = append(record.tags, sp.tags...)
+	record.isRecord = true
+	return record
 }
 
 func (t *Tracer) reportSpan(sp *Span) {
@@ -443,8 +796,18 @@ func (t *Tracer) reportSpan(sp *Span) {
 	// Note: if the reporter is processing Span asynchronously need to Retain() it
 	// otherwise, in the racing condition will be rewritten span data before it will be sent
 	// * To remove object use method span.Release()
+	if t.partialSpans != nil {
+		t.partialSpans.spanFinished(sp)
+	}
+	if t.inFlightSpans != nil {
+		t.inFlightSpans.spanFinished(sp)
+	}
 	if sp.context.IsSampled() {
-		t.reporter.Report(sp)
+		if sp.isTooShort() {
+			t.metrics.SpansDroppedTooShort.Inc(1)
+		} else {
+			t.reporter.Report(sp)
+		}
 	}
 
 	sp.Release()
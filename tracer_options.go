@@ -15,12 +15,15 @@
 package jaeger
 
 import (
+	"math"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-lib/metrics"
 
 	"github.com/uber/jaeger-client-go/internal/baggage"
 	"github.com/uber/jaeger-client-go/internal/throttler"
+	"github.com/uber/jaeger-client-go/utils"
 )
 
 // TracerOption is a function that sets some option on the tracer
@@ -39,6 +42,14 @@ func (tracerOptions) Metrics(m *Metrics) TracerOption {
 	}
 }
 
+// MetricsFactory creates a TracerOption that gives the tracer a metrics.Factory, which is used to emit
+// the statistics that are tagged dynamically, e.g. by baggage key.
+func (tracerOptions) MetricsFactory(factory metrics.Factory) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.metricsFactory = factory
+	}
+}
+
 // Logger creates a TracerOption that gives the tracer a Logger.
 func (tracerOptions) Logger(logger Logger) TracerOption {
 	return func(tracer *Tracer) {
@@ -60,10 +71,23 @@ func (tracerOptions) CustomHeaderKeys(headerKeys *HeadersConfig) TracerOption {
 }
 
 // TimeNow creates a TracerOption that gives the tracer a function
-// used to generate timestamps for spans.
+// used to generate timestamps for spans. The durations of spans are then
+// computed from these timestamps; use Clock to also provide a monotonic clock.
 func (tracerOptions) TimeNow(timeNow func() time.Time) TracerOption {
 	return func(tracer *Tracer) {
 		tracer.timeNow = timeNow
+		tracer.clock = nil
+	}
+}
+
+// Clock creates a TracerOption that gives the tracer the clock used to generate
+// timestamps for spans and to measure their durations. By default the tracer uses
+// the monotonic clock reading of time.Now, so that the durations of spans are not
+// affected by the changes of the wall clock, e.g. NTP adjustments.
+func (tracerOptions) Clock(clock Clock) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.timeNow = clock.Now
+		tracer.clock = clock
 	}
 }
 
@@ -120,18 +144,249 @@ func (tracerOptions) ContribObserver(observer ContribObserver) TracerOption {
 	}
 }
 
+// Gen128Bit creates a TracerOption that makes the tracer generate 128-bit trace IDs for new traces.
+// Child spans always inherit the trace ID of their parent, so 128-bit trace IDs extracted from
+// upstream services are preserved and propagated onward even when this option is disabled.
 func (tracerOptions) Gen128Bit(gen128Bit bool) TracerOption {
 	return func(tracer *Tracer) {
 		tracer.options.gen128Bit = gen128Bit
 	}
 }
 
+// MaxEventsPerSpan creates a TracerOption that limits the number of events recorded on a span
+// with Span.AddEvent. Events above the limit are dropped and counted in the DroppedEventsTagKey tag.
+// Zero means DefaultMaxEventsPerSpan, a negative value disables the limit.
+func (tracerOptions) MaxEventsPerSpan(maxEventsPerSpan int) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.maxEventsPerSpan = maxEventsPerSpan
+	}
+}
+
+// MaxTagsPerSpan creates a TracerOption that limits the number of tags of a span. Once a span
+// reaches the limit, its existing tags can still be updated, but new tags are dropped, counted
+// in the DroppedTagsTagKey tag and in the span_tags_dropped metric. Zero disables the limit.
+func (tracerOptions) MaxTagsPerSpan(maxTagsPerSpan int) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.maxTagsPerSpan = maxTagsPerSpan
+	}
+}
+
+// TagErrorsFromLogs creates a TracerOption that sets the error tag on a span when LogFields or LogKV
+// is called with an "error" or "error.object" field holding an error, or with the "error" event.
+func (tracerOptions) TagErrorsFromLogs(tagErrorsFromLogs bool) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.tagErrorsFromLogs = tagErrorsFromLogs
+	}
+}
+
+// StackTraceOnError creates a TracerOption that logs the stack trace of the caller, up to maxDepth
+// frames (32 by default), when the error tag is set on a span to true or "true", or when
+// Span.RecordError is called. Capturing stack traces is expensive, so at most maxPerSecond stack
+// traces are captured per second, unless maxPerSecond is zero.
+func (tracerOptions) StackTraceOnError(maxDepth int, maxPerSecond float64) TracerOption {
+	return func(tracer *Tracer) {
+		if maxDepth <= 0 {
+			maxDepth = defaultStackTraceMaxDepth
+		}
+		tracer.options.stackTraceOnError = true
+		tracer.options.stackTraceMaxDepth = maxDepth
+		tracer.options.stackTraceRateLimiter = nil
+		if maxPerSecond > 0 {
+			tracer.options.stackTraceRateLimiter = utils.NewRateLimiter(maxPerSecond, math.Max(maxPerSecond, 1.0))
+		}
+	}
+}
+
+// DelayedSampling creates a TracerOption that defers the sampling decision of the root spans of new
+// traces started without tags until the first tag is set on them, or until their context is used,
+// e.g. to start a child span or to inject it into a carrier. This allows TagAwareSampler to see
+// tags like http.url or http.method that instrumentation sets after starting the span.
+// Until the decision is made, the span records its data as if it was sampled.
+func (tracerOptions) DelayedSampling(delayedSampling bool) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.delayedSampling = delayedSampling
+	}
+}
+
+// ResourceDetectors creates a TracerOption that adds the tags returned by the resource detectors
+// to the process tags, unless the tags are already set. The detectors run once, in NewTracer;
+// the errors are logged and the tags of the failed detector are ignored.
+func (tracerOptions) ResourceDetectors(detectors ...ResourceDetector) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.resourceDetectors = append(tracer.options.resourceDetectors, detectors...)
+	}
+}
+
+// MinimalUnsampledSpans creates a TracerOption that makes the tracer return a minimal
+// implementation of opentracing.Span for the unsampled spans of unsampled traces, instead of *Span.
+// It only keeps the span context and baggage needed to propagate the trace, and avoids most allocations
+// for the majority of spans when the sampling rate is low. The roots of new traces are still *Span,
+// so that SetOperationName and the sampling.priority tag can sample them. The other minimal spans
+// cannot become sampled: unlike on *Span, the sampling.priority tag is ignored on them, and neither
+// the span nor its children are sampled by it. The option has no effect when observers are registered,
+// because they require *Span.
+func (tracerOptions) MinimalUnsampledSpans(minimalUnsampledSpans bool) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.minimalUnsampledSpans = minimalUnsampledSpans
+	}
+}
+
+// LogRetentionPolicy creates a TracerOption that limits the number of logs recorded on a span,
+// keeping either the first, the last, or the first and the last logs. By default the logs are not limited.
+func (tracerOptions) LogRetentionPolicy(policy LogRetentionPolicy) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.logRetentionPolicy = policy
+	}
+}
+
+// MaxLogsPerSecond creates a TracerOption that limits the number of logs recorded on each span
+// per second, e.g. to keep a retry loop that logs on every iteration from producing huge spans.
+// The logs in excess of the limit are not recorded, their number is reported in the
+// SuppressedLogsTagKey tag. Bulk logs passed to FinishWithOptions are not limited.
+// Zero, the default, disables the limit.
+func (tracerOptions) MaxLogsPerSecond(maxLogsPerSecond int) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.maxLogsPerSecond = maxLogsPerSecond
+	}
+}
+
 func (tracerOptions) NoDebugFlagOnForcedSampling(noDebugFlagOnForcedSampling bool) TracerOption {
 	return func(tracer *Tracer) {
 		tracer.options.noDebugFlagOnForcedSampling = noDebugFlagOnForcedSampling
 	}
 }
 
+// DisableHostTags creates a TracerOption that stops the tracer from adding the hostname and ip tags
+// to the process, and from looking up the IP address of the host, which can be slow or meaningless
+// in sandboxed environments. The tags can still be set explicitly with the Tag option.
+func (tracerOptions) DisableHostTags(disableHostTags bool) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.disableHostTags = disableHostTags
+	}
+}
+
+// InstanceID creates a TracerOption that sets the UUID of the process reported with the spans to the given
+// stable instance ID, e.g. the name of the pod, instead of a random UUID, so the restarts of the same
+// instance are identifiable in the backend.
+func (tracerOptions) InstanceID(instanceID string) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.instanceID = instanceID
+	}
+}
+
+// InstanceIDFile creates a TracerOption that persists the UUID of the process to the given file,
+// so the restarts of the same instance reuse the UUID generated on the first start.
+// The InstanceID option takes precedence.
+func (tracerOptions) InstanceIDFile(path string) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.instanceIDFile = path
+	}
+}
+
+// AutoFinishChildren creates a TracerOption that makes finishing a span also finish its unfinished
+// ChildOf children started by the same tracer, with the AutoFinishedTagKey tag, to prevent leaked
+// spans when handlers return early or panic. The children are finished at the finish time of the
+// parent, and finishing them later has no effect.
+func (tracerOptions) AutoFinishChildren(autoFinishChildren bool) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.autoFinishChildren = autoFinishChildren
+	}
+}
+
+// SampleOrphans creates a TracerOption that runs the sampler of the tracer for the spans whose parent
+// was extracted from an unsampled upstream trace. When the sampler samples such a span, it starts
+// a new sampled trace linked to the upstream one and tagged with UpstreamTraceIDTagKey, so services
+// behind aggressively sampling upstreams still get visibility.
+func (tracerOptions) SampleOrphans(sampleOrphans bool) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.sampleOrphans = sampleOrphans
+	}
+}
+
+// DetectUseAfterFinish creates a TracerOption that detects the spans modified or finished again after
+// Finish, which corrupts the spans reused from the pool, see PoolSpans. The action logs an error or panics
+// with the stack trace of the misuse, or of the call to Finish with FinishStackTraces. This is a debugging
+// aid: it disables span pooling, since the finished spans must not be reused to be detected, so PoolSpans
+// has no effect.
+func (tracerOptions) DetectUseAfterFinish(action UseAfterFinishAction) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.useAfterFinish = action
+	}
+}
+
+// FinishStackTraces creates a TracerOption that makes DetectUseAfterFinish report the stack trace of
+// the call to Finish instead of the one of the misuse. It captures a stack trace on each Finish, which is
+// expensive.
+func (tracerOptions) FinishStackTraces(finishStackTraces bool) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.finishStackTraces = finishStackTraces
+	}
+}
+
+// ReportSpanStart creates a TracerOption that makes the tracer report a lightweight record of each
+// sampled span as soon as it starts, in addition to the full span when it is finished. The start
+// record has the same IDs, operation name, start time and initial tags as the span, zero duration,
+// and the SpanPhaseTagKey tag set to "started". It allows backends to show in-flight requests and
+// to recover partial traces when the process crashes before the spans are finished.
+func (tracerOptions) ReportSpanStart(reportSpanStart bool) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.reportSpanStart = reportSpanStart
+	}
+}
+
+// PartialSpanReporting creates a TracerOption that makes the tracer periodically report snapshots
+// of sampled spans that have been running for longer than minAge, every interval (which defaults
+// to minAge). The snapshots contain the tags and logs recorded so far, the duration up to the time
+// of the snapshot, and the PartialSpanTagKey tag set to true. They make traces of long-running
+// batch jobs visible before completion and preserve them if the process crashes.
+func (tracerOptions) PartialSpanReporting(minAge, interval time.Duration) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.partialSpanMinAge = minAge
+		tracer.options.partialSpanInterval = interval
+	}
+}
+
+// TrackInFlightSpans creates a TracerOption that makes the tracer keep track of the spans that were
+// started but not finished yet, which are listed by Tracer.InFlightSpans. Every interval, the tracer
+// updates the in_flight_spans gauge with their number, and the in_flight_spans_oldest_age gauge with
+// the age of the oldest one, which helps finding spans that are never finished. The spans returned
+// by the MinimalUnsampledSpans option are not tracked.
+func (tracerOptions) TrackInFlightSpans(interval time.Duration) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.inFlightSpansInterval = interval
+	}
+}
+
+// ClockSkewDetection creates a TracerOption that makes the tracer compare the local time against
+// the remote clock, e.g. HTTPDateClock with the endpoint of the collector, at start and then every
+// interval. The measured offset is reported in the ClockSkewTagKey process tag, so that the skew
+// of drifting hosts can be corrected by the backend.
+func (tracerOptions) ClockSkewDetection(clock RemoteClock, interval time.Duration) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.remoteClock = clock
+		tracer.options.clockSkewInterval = interval
+	}
+}
+
+// LogMetricsSummary creates a TracerOption that makes the tracer log a one-line summary of its own
+// metrics every interval through its logger: the spans started, finished, submitted, dropped and
+// failed to send since the previous summary, the length of the reporter queue and the last error
+// of the reporter. It is meant for the environments where no metrics backend is wired up.
+func (tracerOptions) LogMetricsSummary(interval time.Duration) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.metricsSummaryInterval = interval
+	}
+}
+
+// IDGenerator creates a TracerOption that gives the tracer the generator of the IDs of new traces
+// and spans, e.g. to embed routing information in the IDs. It replaces the default random generator,
+// so the Gen128Bit, HighTraceIDGenerator and RandomNumber options no longer affect the IDs.
+func (tracerOptions) IDGenerator(idGenerator IDGenerator) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.idGenerator = idGenerator
+	}
+}
+
 func (tracerOptions) HighTraceIDGenerator(highTraceIDGenerator func() uint64) TracerOption {
 	return func(tracer *Tracer) {
 		tracer.options.highTraceIDGenerator = highTraceIDGenerator
@@ -144,12 +399,189 @@ func (tracerOptions) MaxTagValueLength(maxTagValueLength int) TracerOption {
 	}
 }
 
+// MaxTagValueLengthByKey creates a TracerOption that overrides the max length of the values of the tags
+// with the given keys, e.g. to allow long "db.statement" tags while keeping a lower MaxTagValueLength
+// for all other tags.
+func (tracerOptions) MaxTagValueLengthByKey(maxTagValueLengthByKey map[string]int) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.maxTagValueLengthByKey = make(map[string]int, len(maxTagValueLengthByKey))
+		for key, maxLength := range maxTagValueLengthByKey {
+			tracer.options.maxTagValueLengthByKey[key] = maxLength
+		}
+	}
+}
+
+// RedactTags creates a TracerOption that replaces the values of the span tags with the given keys
+// with RedactedTagValue, so that sensitive values are never reported. A key can be a glob pattern
+// in the syntax of path.Match, e.g. "http.header.*". The tags are redacted when they are set on
+// the span, observers still receive their original values.
+func (tracerOptions) RedactTags(keys ...string) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.redactedTags = append(tracer.options.redactedTags, keys...)
+	}
+}
+
+// MinSpanDuration creates a TracerOption that stops the tracer from reporting the sampled spans shorter
+// than the given duration, except the first spans of the traces in the process (the local roots) and the
+// spans tagged as errors. The parents of the spans still reported may be missing from their traces.
+// By default all sampled spans are reported.
+func (tracerOptions) MinSpanDuration(minSpanDuration time.Duration) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.minSpanDuration = minSpanDuration
+	}
+}
+
 func (tracerOptions) ZipkinSharedRPCSpan(zipkinSharedRPCSpan bool) TracerOption {
 	return func(tracer *Tracer) {
 		tracer.options.zipkinSharedRPCSpan = zipkinSharedRPCSpan
 	}
 }
 
+// ZipkinSharedMessagingSpan creates a TracerOption that makes consumer spans share the span ID of
+// their parent producer span, like the server spans share the span ID of their client span with
+// the ZipkinSharedRPCSpan option, for backends modelling a message as a single Zipkin span.
+// It is configured separately from ZipkinSharedRPCSpan.
+func (tracerOptions) ZipkinSharedMessagingSpan(zipkinSharedMessagingSpan bool) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.zipkinSharedMessagingSpan = zipkinSharedMessagingSpan
+	}
+}
+
+// BaggageTags creates a TracerOption that copies the values of the baggage items with the given keys
+// to the tags with the same keys, on every sampled span of the trace that carries the baggage items,
+// e.g. to filter the spans by tenant without each service tagging them.
+func (tracerOptions) BaggageTags(keys ...string) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.baggageTags = append(tracer.options.baggageTags, keys...)
+	}
+}
+
+// OperationNameNormalizer creates a TracerOption that rewrites the operation names of the spans
+// given to StartSpan and SetOperationName before they are used for sampling, metrics and reporting,
+// e.g. to replace "/users/123" with "/users/{id}" and keep the number of operations bounded.
+// The function is called concurrently and must be safe for concurrent use.
+func (tracerOptions) OperationNameNormalizer(normalizer func(operationName string) string) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.operationNameNormalizer = normalizer
+	}
+}
+
+// StartSpanInterceptors creates a TracerOption that adds interceptors to the chain called by StartSpan,
+// in the order they are given, see StartSpanInterceptor. The operation name is normalized after
+// the interceptors, just before the span is started.
+func (tracerOptions) StartSpanInterceptors(interceptors ...StartSpanInterceptor) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.startSpanInterceptors = append(tracer.options.startSpanInterceptors, interceptors...)
+	}
+}
+
+// DefaultBaggage creates a TracerOption that adds the baggage item to every new trace started by the tracer,
+// e.g. the deployment region or cluster, before the spans are returned to the application.
+// It is not added to the spans joining traces from other processes.
+func (tracerOptions) DefaultBaggage(key, value string) TracerOption {
+	return func(tracer *Tracer) {
+		if tracer.options.defaultBaggage == nil {
+			tracer.options.defaultBaggage = make(map[string]string)
+		}
+		tracer.options.defaultBaggage[key] = value
+	}
+}
+
+// BaggageObserver creates a TracerOption that adds an observer notified whenever baggage is set or overwritten
+// on a span, including the items rejected or truncated by the baggage restrictions.
+func (tracerOptions) BaggageObserver(observer BaggageObserver) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.baggageObservers = append(tracer.options.baggageObservers, observer)
+	}
+}
+
+// PropagatedBaggage creates a TracerOption that restricts the baggage items injected into the carriers
+// of outbound requests to the ones with the given keys, which can be glob patterns like in RedactTags.
+// Other baggage items can still be set and read in the process, and DebugLevelBaggageKey is always
+// propagated. See also LocalBaggage.
+func (tracerOptions) PropagatedBaggage(keys ...string) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.propagatedBaggage = append(tracer.options.propagatedBaggage, keys...)
+	}
+}
+
+// LocalBaggage creates a TracerOption that keeps the baggage items with the given keys, which can be glob
+// patterns like in RedactTags, in the process: they are never injected into the carriers of outbound requests,
+// e.g. for sensitive internal baggage. It takes precedence over PropagatedBaggage.
+func (tracerOptions) LocalBaggage(keys ...string) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.localBaggage = append(tracer.options.localBaggage, keys...)
+	}
+}
+
+// SpanDurationHistograms creates a TracerOption that records the durations of all spans, sampled
+// or not, in span_duration_seconds histograms tagged with the operation, span kind and error,
+// so that the latency of the operations is known even at low sampling rates. The histograms are
+// emitted by the MetricsFactory with the given bucket boundaries, or with the default buckets from
+// 1ms to 10s if none are given. Like other observers, it disables TracerOptions.MinimalUnsampledSpans.
+func (tracerOptions) SpanDurationHistograms(buckets ...time.Duration) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.spanDurationHistograms = true
+		tracer.options.spanDurationBuckets = buckets
+	}
+}
+
+// MaxBaggageSize creates a TracerOption that limits the total size of the baggage of a trace, counted as
+// the length of the keys and values of the baggage items, e.g. to keep the headers of the requests within
+// the limits of proxies. The baggage items that would exceed the budget are rejected, and the largest
+// baggage items extracted from upstream services are dropped until the baggage fits the budget.
+// The rejected and dropped items are counted by the baggage_budget_exceeded metric, if the tracer
+// has a MetricsFactory, see TracerOptions.BaggageMetricsKeys.
+func (tracerOptions) MaxBaggageSize(maxBaggageSize int) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.maxBaggageSize = maxBaggageSize
+	}
+}
+
+// BaggageMetricsKeys creates a TracerOption that tags the baggage_key_truncations and baggage_budget_exceeded
+// metrics with the given baggage keys. The baggage keys are set by the callers of the service, so the metrics
+// of the other keys are tagged with key=other to bound the number of metrics.
+func (tracerOptions) BaggageMetricsKeys(keys ...string) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.baggageMetricsKeys = append(tracer.options.baggageMetricsKeys, keys...)
+	}
+}
+
+// SignedBaggage creates a TracerOption that signs the baggage items with the given keys with HMAC-SHA256
+// and the given secret, so that the baggage items like authenticated tenant IDs cannot be spoofed by
+// intermediate hops. The signatures are injected as the baggage items with the keys suffixed with
+// BaggageSignatureSuffix, and are bound to the trace ID. On Extract, the signed baggage items with
+// missing or invalid signatures are dropped, so all the services of the trace must share the secret.
+// The keys are case-insensitive, and the signatures count toward TracerOptions.MaxBaggageSize.
+// The secret must not be empty, otherwise the signed baggage items are never propagated.
+func (tracerOptions) SignedBaggage(secret []byte, keys ...string) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.baggageSigningSecret = secret
+		tracer.options.signedBaggage = append(tracer.options.signedBaggage, keys...)
+	}
+}
+
+// BaggageTransformer creates a TracerOption that registers a BaggageTransformer validating or transforming
+// the baggage items with the given keys, or all baggage items if no keys are given. The keys can be glob
+// patterns like "tenant-*". The transformers are applied in the order they are registered, before the
+// baggage restrictions, and the baggage items they reject are not stored on the spans.
+func (tracerOptions) BaggageTransformer(transformer BaggageTransformer, keys ...string) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.baggageTransformers = append(tracer.options.baggageTransformers,
+			keyedBaggageTransformer{patterns: keys, transform: transformer})
+	}
+}
+
+// BaggageNamespace creates a TracerOption that registers a baggage namespace with the given restriction rules.
+// The baggage items of a registered namespace can only be set and read through the BaggageNamespace, see
+// NewBaggageNamespace, so that the baggage of a library cannot collide with or be observed by the application.
+func (tracerOptions) BaggageNamespace(name string, rules BaggageNamespaceRules) TracerOption {
+	return func(tracer *Tracer) {
+		tracer.options.baggageNamespaces = append(tracer.options.baggageNamespaces,
+			newBaggageNamespaceRestrictions(name, rules))
+	}
+}
+
 func (tracerOptions) Tag(key string, value interface{}) TracerOption {
 	return func(tracer *Tracer) {
 		tracer.tags = append(tracer.tags, Tag{key: key, value: value})
@@ -167,3 +599,15 @@ func (tracerOptions) DebugThrottler(throttler throttler.Throttler) TracerOption
 		tracer.debugThrottler = throttler
 	}
 }
+
+// DebugLevelThrottler creates a TracerOption that throttles the debug spans of the given debug level,
+// set with a sampling.priority greater than 1, instead of the throttler set with DebugThrottler,
+// e.g. to allow fewer traces of the levels that force the sampling of all downstream services.
+func (tracerOptions) DebugLevelThrottler(level uint16, levelThrottler throttler.Throttler) TracerOption {
+	return func(tracer *Tracer) {
+		if tracer.debugLevelThrottlers == nil {
+			tracer.debugLevelThrottlers = make(map[uint16]throttler.Throttler)
+		}
+		tracer.debugLevelThrottlers[level] = levelThrottler
+	}
+}
@@ -15,8 +15,11 @@
 package jaeger
 
 import (
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -361,6 +364,31 @@ func TestZipkinSharedRPCSpan(t *testing.T) {
 	tc.Close()
 }
 
+func TestZipkinSharedMessagingSpan(t *testing.T) {
+	tracer, tc := NewTracer("x", NewConstSampler(true), NewNullReporter(), TracerOptions.ZipkinSharedRPCSpan(true))
+
+	sp1 := tracer.StartSpan("producer", ext.SpanKindProducer)
+	sp2 := tracer.StartSpan("consumer", opentracing.ChildOf(sp1.Context()), ext.SpanKindConsumer)
+	assert.Equal(t, sp1.(*Span).context.spanID, sp2.(*Span).context.parentID, "configured separately from RPC spans")
+	assert.NotEqual(t, sp1.(*Span).context.spanID, sp2.(*Span).context.spanID)
+	sp2.Finish()
+	sp1.Finish()
+	tc.Close()
+
+	tracer, tc = NewTracer("x", NewConstSampler(true), NewNullReporter(), TracerOptions.ZipkinSharedMessagingSpan(true))
+
+	sp1 = tracer.StartSpan("producer", ext.SpanKindProducer)
+	sp2 = tracer.StartSpan("consumer", opentracing.ChildOf(sp1.Context()), ext.SpanKindConsumer)
+	assert.Equal(t, sp1.(*Span).context.spanID, sp2.(*Span).context.spanID)
+	assert.Equal(t, sp1.(*Span).context.parentID, sp2.(*Span).context.parentID)
+	sp3 := tracer.StartSpan("server", opentracing.ChildOf(sp1.Context()), ext.SpanKindRPCServer)
+	assert.Equal(t, sp1.(*Span).context.spanID, sp3.(*Span).context.parentID)
+	sp3.Finish()
+	sp2.Finish()
+	sp1.Finish()
+	tc.Close()
+}
+
 type testDebugThrottler struct {
 	process Process
 }
@@ -455,6 +483,31 @@ func TestSetGetTag(t *testing.T) {
 	assert.True(t, tracer.hostIPv4 == 0)
 }
 
+func TestDisableHostTags(t *testing.T) {
+	opentracer, tc := NewTracer("x", NewConstSampler(true), NewNullReporter(), TracerOptions.DisableHostTags(true))
+	tracer := opentracer.(*Tracer)
+	defer tc.Close()
+	_, ok := tracer.getTag(TracerIPTagKey)
+	assert.False(t, ok)
+	_, ok = tracer.getTag(TracerHostnameTagKey)
+	assert.False(t, ok)
+	assert.Zero(t, tracer.hostIPv4)
+
+	ipStr := "11.22.33.44"
+	opentracer, tc = NewTracer("x", NewConstSampler(true), NewNullReporter(),
+		TracerOptions.DisableHostTags(true),
+		TracerOptions.Tag(TracerIPTagKey, ipStr),
+		TracerOptions.Tag(TracerHostnameTagKey, "host"),
+	)
+	tracer = opentracer.(*Tracer)
+	defer tc.Close()
+	value, _ := tracer.getTag(TracerIPTagKey)
+	assert.Equal(t, ipStr, value)
+	value, _ = tracer.getTag(TracerHostnameTagKey)
+	assert.Equal(t, "host", value)
+	assert.NotZero(t, tracer.hostIPv4)
+}
+
 type dummyPropagator struct{}
 type dummyCarrier struct {
 	ok bool
@@ -511,3 +564,104 @@ func (jp *jaegerProbe) SameSpanContext(first opentracing.Span, second opentracin
 	secondCtx := second.(SpanContext)
 	return firstCtx.traceID == secondCtx.traceID && firstCtx.spanID == secondCtx.spanID
 }
+
+type healthCheckingSender struct {
+	fakeSender
+	healthErr error
+}
+
+func (s *healthCheckingSender) CheckHealth() error { return s.healthErr }
+
+func TestTracerReady(t *testing.T) {
+	sender := &healthCheckingSender{healthErr: errors.New("agent unreachable")}
+	reporter := NewRemoteReporter(sender)
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), NewCompositeReporter(NewNullReporter(), reporter))
+	defer closer.Close()
+	assert.EqualError(t, tracer.(*Tracer).Ready(), "agent unreachable")
+
+	sender.healthErr = nil
+	assert.NoError(t, tracer.(*Tracer).Ready())
+
+	// reporters without health checks are always ready
+	tracer, closer = NewTracer("DOOP", NewConstSampler(true), NewRemoteReporter(&fakeSender{}))
+	defer closer.Close()
+	assert.NoError(t, tracer.(*Tracer).Ready())
+}
+
+func TestTracerLastExportError(t *testing.T) {
+	flushErr := errors.New("flush error")
+	s := makeReporterSuiteWithSender(t, &fakeSender{bufferSize: 1, flushErr: flushErr})
+	defer s.close()
+	tracer := s.tracer.(*Tracer)
+	assert.NoError(t, tracer.LastExportError())
+
+	s.tracer.StartSpan("sp1").Finish()
+	for i := 0; i < 1000 && tracer.LastExportError() == nil; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, flushErr, tracer.LastExportError())
+}
+
+func TestTracerReportSpanStart(t *testing.T) {
+	reporter := NewInMemoryReporter()
+	tracer, closer := NewTracer("DOOP", NewConstSampler(true), reporter, TracerOptions.ReportSpanStart(true))
+	defer closer.Close()
+
+	span := tracer.StartSpan("op", opentracing.Tag{Key: "k", Value: "v"}).(*Span)
+	spans := reporter.GetSpans()
+	require.Len(t, spans, 1)
+	record := spans[0].(*Span)
+	assert.Equal(t, span.context, record.context)
+	assert.Equal(t, "op", record.OperationName())
+	assert.Equal(t, span.StartTime(), record.StartTime())
+	assert.Equal(t, time.Duration(0), record.Duration())
+	assert.Equal(t, "v", record.Tags()["k"])
+	assert.Equal(t, SpanPhaseStarted, record.Tags()[SpanPhaseTagKey])
+
+	span.Finish()
+	spans = reporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, span, spans[1])
+	assert.NotContains(t, span.Tags(), SpanPhaseTagKey)
+
+	// unsampled spans are not reported at start
+	tracer, closer = NewTracer("DOOP", NewConstSampler(false), reporter, TracerOptions.ReportSpanStart(true))
+	defer closer.Close()
+	reporter.Reset()
+	tracer.StartSpan("op")
+	assert.Equal(t, 0, reporter.SpansSubmitted())
+}
+
+func TestOperationNameNormalizer(t *testing.T) {
+	normalize := func(operationName string) string {
+		parts := strings.Split(operationName, "/")
+		for i, part := range parts {
+			if _, err := strconv.Atoi(part); err == nil {
+				parts[i] = "{id}"
+			}
+		}
+		return strings.Join(parts, "/")
+	}
+	sampler := &recordingSampler{}
+	tracer, closer := NewTracer("x", sampler, NewNullReporter(),
+		TracerOptions.OperationNameNormalizer(normalize))
+	defer closer.Close()
+
+	sp := tracer.StartSpan("/users/123").(*Span)
+	assert.Equal(t, "/users/{id}", sp.OperationName())
+	assert.Equal(t, []string{"/users/{id}"}, sampler.operations)
+
+	sp.SetOperationName("/users/456/orders/7")
+	assert.Equal(t, "/users/{id}/orders/{id}", sp.OperationName())
+}
+
+// recordingSampler samples all traces and records the operation names it was asked about.
+type recordingSampler struct {
+	ConstSampler
+	operations []string
+}
+
+func (s *recordingSampler) IsSampled(id TraceID, operation string) (bool, []Tag) {
+	s.operations = append(s.operations, operation)
+	return true, nil
+}
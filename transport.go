@@ -16,6 +16,9 @@ package jaeger
 
 import (
 	"io"
+	"sync/atomic"
+
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
 )
 
 // Transport abstracts the method of sending spans out of process.
@@ -36,3 +39,58 @@ type Transport interface {
 
 	io.Closer
 }
+
+// HealthChecker is implemented by Transports and Reporters that can check whether the tracing
+// backend is reachable. Unlike other Transport methods, CheckHealth may be called from any
+// go-routine, concurrently with span submission.
+type HealthChecker interface {
+	// CheckHealth returns an error if the backend is known to be unreachable.
+	CheckHealth() error
+}
+
+// BatchSizer is implemented by Transports that can tell the size of the last batch they emitted,
+// which the RemoteReporter reports in the reporter_batch_size_bytes gauge.
+type BatchSizer interface {
+	// LastBatchSize returns the number of spans and the serialized size in bytes of the last batch
+	// emitted by the Transport, successfully or not.
+	LastBatchSize() (spans int, bytes int)
+}
+
+// BatchInterceptor is invoked by a Transport just before a batch of spans is emitted.
+// It can enrich the batch with batch-level process tags, such as a batch ID, a sequence
+// number or a deployment revision, to correlate client batches with collector-side drops.
+//
+// The Process of the batch is a copy owned by the interceptor for the duration of the call,
+// so its tags can be appended to without affecting subsequent batches. The spans of the batch
+// must not be retained after the call returns.
+type BatchInterceptor func(batch *j.Batch)
+
+// InterceptBatch returns a copy of the batch with a private copy of the Process, enriched
+// by the interceptor. If the interceptor is nil, the batch is returned unchanged.
+func InterceptBatch(interceptor BatchInterceptor, batch *j.Batch) *j.Batch {
+	if interceptor == nil {
+		return batch
+	}
+	enriched := &j.Batch{Spans: batch.Spans}
+	if batch.Process != nil {
+		process := *batch.Process
+		process.Tags = append([]*j.Tag(nil), batch.Process.Tags...)
+		enriched.Process = &process
+	}
+	interceptor(enriched)
+	return enriched
+}
+
+// NewBatchSequenceInterceptor returns a BatchInterceptor that adds a process tag with the given key
+// to every batch, whose value is the sequence number of the batch, starting from 1. Gaps in the
+// sequence numbers observed by the collector indicate lost batches.
+func NewBatchSequenceInterceptor(tagKey string) BatchInterceptor {
+	var seqNo int64
+	return func(batch *j.Batch) {
+		if batch.Process == nil {
+			return
+		}
+		n := atomic.AddInt64(&seqNo, 1)
+		batch.Process.Tags = append(batch.Process.Tags, &j.Tag{Key: tagKey, VType: j.TagType_LONG, VLong: &n})
+	}
+}
@@ -16,15 +16,20 @@ package transport
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/opentracing/opentracing-go"
 	"github.com/uber/jaeger-client-go/thrift"
 
 	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/log"
 	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
 )
 
@@ -33,12 +38,20 @@ const defaultHTTPTimeout = time.Second * 5
 
 // HTTPTransport implements Transport by forwarding spans to a http server.
 type HTTPTransport struct {
-	url             string
-	client          *http.Client
-	batchSize       int
-	spans           []*j.Span
-	process         *j.Process
-	httpCredentials *HTTPBasicAuthCredentials
+	urls             []string
+	endpoints        *httpEndpoints
+	retryInterval    time.Duration
+	client           *http.Client
+	batchSize        int
+	batches          []*httpBatch // the spans buffered by process, in the order of their first span
+	httpCredentials  *HTTPBasicAuthCredentials
+	tlsConfig        *tls.Config
+	proxy            func(*http.Request) (*url.URL, error)
+	batchInterceptor jaeger.BatchInterceptor
+	protocolFactory  thrift.TProtocolFactory
+	lastBatchSpans   int
+	lastBatchBytes   int
+	logger           log.DebugLogger
 }
 
 // HTTPBasicAuthCredentials stores credentials for HTTP basic auth.
@@ -69,52 +82,171 @@ func HTTPBasicAuth(username string, password string) HTTPOption {
 }
 
 // HTTPRoundTripper configures the underlying Transport on the *http.Client
-// that is used
+// that is used. When a custom RoundTripper is provided, the HTTPTLSConfig and
+// HTTPProxy options are ignored, since they can be set on the RoundTripper directly.
 func HTTPRoundTripper(transport http.RoundTripper) HTTPOption {
 	return func(c *HTTPTransport) {
 		c.client.Transport = transport
 	}
 }
 
+// HTTPTLSConfig sets the TLS configuration used to connect to the collector,
+// e.g. to present a client certificate for mutual TLS or to trust a private CA.
+func HTTPTLSConfig(tlsConfig *tls.Config) HTTPOption {
+	return func(c *HTTPTransport) {
+		c.tlsConfig = tlsConfig
+	}
+}
+
+// HTTPProxy sets the function that returns the proxy to use for a given request,
+// e.g. http.ProxyURL(proxyURL). By default the proxy is taken from the environment
+// via http.ProxyFromEnvironment.
+func HTTPProxy(proxy func(*http.Request) (*url.URL, error)) HTTPOption {
+	return func(c *HTTPTransport) {
+		c.proxy = proxy
+	}
+}
+
+// HTTPEndpoints adds more collector URLs to send spans to. Requests are rotated
+// between all URLs in round-robin order. When a request to one of them fails,
+// it is retried on the next URL, and the failed one is skipped for the duration
+// of the endpoint retry interval.
+func HTTPEndpoints(urls ...string) HTTPOption {
+	return func(c *HTTPTransport) {
+		c.urls = append(c.urls, urls...)
+	}
+}
+
+// HTTPEndpointRetryInterval sets how long a collector URL is considered unhealthy
+// after a failed request. The default interval is 10 seconds.
+func HTTPEndpointRetryInterval(retryInterval time.Duration) HTTPOption {
+	return func(c *HTTPTransport) {
+		c.retryInterval = retryInterval
+	}
+}
+
+// HTTPBatchInterceptor sets the interceptor invoked before each batch is sent to the collector,
+// e.g. to add batch-level process tags.
+func HTTPBatchInterceptor(interceptor jaeger.BatchInterceptor) HTTPOption {
+	return func(c *HTTPTransport) {
+		c.batchInterceptor = interceptor
+	}
+}
+
+// HTTPProtocolFactory sets the Thrift protocol used to encode batches, for endpoints that do not
+// accept the binary protocol expected by jaeger-collector, e.g. thrift.NewTCompactProtocolFactory().
+func HTTPProtocolFactory(protocolFactory thrift.TProtocolFactory) HTTPOption {
+	return func(c *HTTPTransport) {
+		c.protocolFactory = protocolFactory
+	}
+}
+
+// HTTPLogger sets the logger used to report retries on the next collector endpoint
+// at debug level. The logger must implement log.DebugLogger for them to be logged.
+func HTTPLogger(logger log.Logger) HTTPOption {
+	return func(c *HTTPTransport) {
+		if logger != nil {
+			c.logger = log.DebugLogAdapter(logger)
+		}
+	}
+}
+
 // NewHTTPTransport returns a new HTTP-backend transport. url should be an http
 // url of the collector to handle POST request, typically something like:
 //     http://hostname:14268/api/traces?format=jaeger.thrift
 func NewHTTPTransport(url string, options ...HTTPOption) *HTTPTransport {
 	c := &HTTPTransport{
-		url:       url,
-		client:    &http.Client{Timeout: defaultHTTPTimeout},
-		batchSize: 100,
-		spans:     []*j.Span{},
+		urls:            []string{url},
+		client:          &http.Client{Timeout: defaultHTTPTimeout},
+		batchSize:       100,
+		protocolFactory: thrift.NewTBinaryProtocolFactoryDefault(),
+		logger:          log.DebugLogAdapter(log.NullLogger),
 	}
 
 	for _, option := range options {
 		option(c)
 	}
+	if c.client.Transport == nil && (c.tlsConfig != nil || c.proxy != nil) {
+		c.client.Transport = newHTTPRoundTripper(c.tlsConfig, c.proxy)
+	}
+	c.endpoints = newHTTPEndpoints(c.urls, c.retryInterval)
 	return c
 }
 
-// Append implements Transport.
+// newHTTPRoundTripper creates an http.Transport with the same defaults as
+// http.DefaultTransport, but using the given TLS config and proxy function.
+func newHTTPRoundTripper(tlsConfig *tls.Config, proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}
+}
+
+// httpBatch holds the buffered spans of a service of a tracer, which are sent with their own process.
+type httpBatch struct {
+	tracer  opentracing.Tracer
+	service string
+	process *j.Process
+	spans   []*j.Span
+}
+
+// Append implements Transport. The spans of different services, e.g. reported by several tracers
+// sharing the transport, are buffered in separate batches. When a batch is full, all the batches are
+// sent, so that the sent spans are always the oldest ones, as the reporter expects.
 func (c *HTTPTransport) Append(span *jaeger.Span) (int, error) {
-	if c.process == nil {
-		c.process = jaeger.BuildJaegerProcessThrift(span)
+	batch := c.batch(span)
+	batch.spans = append(batch.spans, jaeger.BuildJaegerThrift(span))
+	if len(batch.spans) < c.batchSize {
+		return 0, nil
 	}
-	jSpan := jaeger.BuildJaegerThrift(span)
-	c.spans = append(c.spans, jSpan)
-	if len(c.spans) >= c.batchSize {
-		return c.Flush()
+	return c.Flush()
+}
+
+// batch returns the batch of the span, creating it if needed.
+func (c *HTTPTransport) batch(span *jaeger.Span) *httpBatch {
+	tracer, service := span.Tracer(), span.ServiceName()
+	for _, batch := range c.batches {
+		if batch.tracer == tracer && batch.service == service {
+			return batch
+		}
 	}
-	return 0, nil
+	// the process is built for each batch to include the changes of the tracer's process tags
+	batch := &httpBatch{tracer: tracer, service: service, process: jaeger.BuildJaegerProcessThrift(span)}
+	c.batches = append(c.batches, batch)
+	return batch
 }
 
-// Flush implements Transport.
+// Flush implements Transport by sending all the batches. If some of them cannot be sent, it returns
+// the first error and the number of spans in the batches that failed, without the ones that were sent.
 func (c *HTTPTransport) Flush() (int, error) {
-	count := len(c.spans)
-	if count == 0 {
-		return 0, nil
+	var count, failed int
+	var err error
+	for i, batch := range c.batches {
+		count += len(batch.spans)
+		if sendErr := c.send(batch.process, batch.spans); sendErr != nil {
+			failed += len(batch.spans)
+			if err == nil {
+				err = sendErr
+			}
+		}
+		c.batches[i] = nil
 	}
-	err := c.send(c.spans)
-	c.spans = c.spans[:0]
-	return count, err
+	c.batches = c.batches[:0]
+	if err != nil {
+		return failed, err
+	}
+	return count, nil
 }
 
 // Close implements Transport.
@@ -122,18 +254,44 @@ func (c *HTTPTransport) Close() error {
 	return nil
 }
 
-func (c *HTTPTransport) send(spans []*j.Span) error {
-	batch := &j.Batch{
+// LastBatchSize implements jaeger.BatchSizer.
+func (c *HTTPTransport) LastBatchSize() (int, int) {
+	return c.lastBatchSpans, c.lastBatchBytes
+}
+
+func (c *HTTPTransport) send(process *j.Process, spans []*j.Span) error {
+	batch := jaeger.InterceptBatch(c.batchInterceptor, &j.Batch{
 		Spans:   spans,
-		Process: c.process,
-	}
-	body, err := serializeThrift(batch)
+		Process: process,
+	})
+	body, err := serializeThrift(batch, c.protocolFactory)
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequest("POST", c.url, body)
+	payload := body.Bytes()
+	c.lastBatchSpans, c.lastBatchBytes = len(spans), len(payload)
+	for _, endpoint := range c.endpoints.order() {
+		var retryable bool
+		if retryable, err = c.sendTo(endpoint.url, payload); err == nil {
+			c.endpoints.markHealthy(endpoint)
+			return nil
+		}
+		if !retryable {
+			return err
+		}
+		c.endpoints.markUnhealthy(endpoint)
+		c.logger.Debugf("Failed to send a batch of %d spans to %s, retrying on the next endpoint: %v",
+			len(spans), endpoint.url, err)
+	}
+	return err
+}
+
+// sendTo posts the payload to the given url. It returns whether the request
+// should be retried on another endpoint if it fails.
+func (c *HTTPTransport) sendTo(url string, payload []byte) (bool, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
 	if err != nil {
-		return err
+		return true, err
 	}
 	req.Header.Set("Content-Type", "application/x-thrift")
 
@@ -143,19 +301,66 @@ func (c *HTTPTransport) send(spans []*j.Span) error {
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return err
+		return true, err
 	}
 	io.Copy(ioutil.Discard, resp.Body)
 	resp.Body.Close()
 	if resp.StatusCode >= http.StatusBadRequest {
-		return fmt.Errorf("error from collector: %d", resp.StatusCode)
+		// client errors would be the same for all endpoints, so only server errors are retried
+		return resp.StatusCode >= http.StatusInternalServerError, &CollectorError{Status: resp.StatusCode}
+	}
+	return false, nil
+}
+
+// CollectorError is returned when the collector responds with an error status.
+type CollectorError struct {
+	Status int
+}
+
+func (e *CollectorError) Error() string {
+	return fmt.Sprintf("error from collector: %d", e.Status)
+}
+
+// StatusCode returns the HTTP status of the response of the collector.
+func (e *CollectorError) StatusCode() int {
+	return e.Status
+}
+
+// CheckHealth implements jaeger.HealthChecker by sending a HEAD request to the collector URLs.
+// The backend is considered reachable if any of the URLs responds with a status other than 5xx.
+func (c *HTTPTransport) CheckHealth() error {
+	var err error
+	for _, url := range c.urls {
+		if err = c.checkHealth(url); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (c *HTTPTransport) checkHealth(url string) error {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	if c.httpCredentials != nil {
+		req.SetBasicAuth(c.httpCredentials.username, c.httpCredentials.password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return &CollectorError{Status: resp.StatusCode}
 	}
 	return nil
 }
 
-func serializeThrift(obj thrift.TStruct) (*bytes.Buffer, error) {
+func serializeThrift(obj thrift.TStruct, protocolFactory thrift.TProtocolFactory) (*bytes.Buffer, error) {
 	t := thrift.NewTMemoryBuffer()
-	p := thrift.NewTBinaryProtocolTransport(t)
+	p := protocolFactory.GetProtocol(t)
 	if err := obj.Write(p); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,80 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"time"
+)
+
+// Default time an endpoint is skipped after a failed request
+const defaultEndpointRetryInterval = time.Second * 10
+
+type httpEndpoint struct {
+	url            string
+	unhealthyUntil time.Time
+}
+
+// httpEndpoints keeps track of the health of a list of collector endpoints and
+// rotates between them. It is not thread-safe, which is fine since the Transport
+// is only used from the reporter's go-routine.
+type httpEndpoints struct {
+	endpoints     []*httpEndpoint
+	next          int
+	retryInterval time.Duration
+	timeNow       func() time.Time
+}
+
+func newHTTPEndpoints(urls []string, retryInterval time.Duration) *httpEndpoints {
+	endpoints := make([]*httpEndpoint, len(urls))
+	for i, url := range urls {
+		endpoints[i] = &httpEndpoint{url: url}
+	}
+	if retryInterval <= 0 {
+		retryInterval = defaultEndpointRetryInterval
+	}
+	return &httpEndpoints{
+		endpoints:     endpoints,
+		retryInterval: retryInterval,
+		timeNow:       time.Now,
+	}
+}
+
+// order returns the endpoints in the order they should be tried for the next request.
+// Healthy endpoints come first, starting from the next one in round-robin order,
+// followed by the unhealthy ones as a last resort.
+func (e *httpEndpoints) order() []*httpEndpoint {
+	n := len(e.endpoints)
+	now := e.timeNow()
+	healthy := make([]*httpEndpoint, 0, n)
+	var unhealthy []*httpEndpoint
+	for i := 0; i < n; i++ {
+		endpoint := e.endpoints[(e.next+i)%n]
+		if now.Before(endpoint.unhealthyUntil) {
+			unhealthy = append(unhealthy, endpoint)
+		} else {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	e.next = (e.next + 1) % n
+	return append(healthy, unhealthy...)
+}
+
+func (e *httpEndpoints) markHealthy(endpoint *httpEndpoint) {
+	endpoint.unhealthyUntil = time.Time{}
+}
+
+func (e *httpEndpoints) markUnhealthy(endpoint *httpEndpoint) {
+	endpoint.unhealthyUntil = e.timeNow().Add(e.retryInterval)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func urlsOf(endpoints []*httpEndpoint) []string {
+	urls := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		urls[i] = endpoint.url
+	}
+	return urls
+}
+
+func TestHTTPEndpointsRotation(t *testing.T) {
+	e := newHTTPEndpoints([]string{"a", "b", "c"}, 0)
+	assert.Equal(t, defaultEndpointRetryInterval, e.retryInterval)
+	assert.Equal(t, []string{"a", "b", "c"}, urlsOf(e.order()))
+	assert.Equal(t, []string{"b", "c", "a"}, urlsOf(e.order()))
+	assert.Equal(t, []string{"c", "a", "b"}, urlsOf(e.order()))
+	assert.Equal(t, []string{"a", "b", "c"}, urlsOf(e.order()))
+}
+
+func TestHTTPEndpointsHealth(t *testing.T) {
+	now := time.Unix(1000, 0)
+	e := newHTTPEndpoints([]string{"a", "b", "c"}, time.Minute)
+	e.timeNow = func() time.Time { return now }
+
+	e.markUnhealthy(e.endpoints[0])
+	assert.Equal(t, []string{"b", "c", "a"}, urlsOf(e.order()))
+	assert.Equal(t, []string{"b", "c", "a"}, urlsOf(e.order()))
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, []string{"c", "a", "b"}, urlsOf(e.order()))
+
+	e.markUnhealthy(e.endpoints[1])
+	e.markHealthy(e.endpoints[1])
+	assert.Equal(t, []string{"a", "b", "c"}, urlsOf(e.order()))
+}
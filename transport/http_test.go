@@ -15,16 +15,23 @@
 package transport
 
 import (
+	"crypto/tls"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-client-go/thrift"
 
 	"github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/log"
 	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
 )
 
@@ -77,6 +84,10 @@ func TestHTTPTransport(t *testing.T) {
 		&HTTPBasicAuthCredentials{username: httpUsername, password: httpPassword},
 		server.authCredentials[0],
 	)
+	closer.Close()
+	spans, bytes := sender.LastBatchSize()
+	assert.Equal(t, 1, spans)
+	assert.True(t, bytes > 0)
 }
 
 func TestHTTPOptions(t *testing.T) {
@@ -94,6 +105,40 @@ func TestHTTPOptions(t *testing.T) {
 	assert.Equal(t, roundTripper, sender.client.Transport)
 }
 
+func TestHTTPTLSAndProxyOptions(t *testing.T) {
+	tlsConfig := &tls.Config{ServerName: "collector"}
+	proxyURL, err := url.Parse("http://proxy:3128")
+	require.NoError(t, err)
+
+	sender := NewHTTPTransport(
+		"some url",
+		HTTPTLSConfig(tlsConfig),
+		HTTPProxy(http.ProxyURL(proxyURL)),
+	)
+	transport, ok := sender.client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, tlsConfig, transport.TLSClientConfig)
+	req, err := http.NewRequest("POST", "http://localhost:14268/api/traces", nil)
+	require.NoError(t, err)
+	proxy, err := transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, proxyURL, proxy)
+
+	// explicit RoundTripper takes precedence
+	roundTripper := &http.Transport{}
+	sender = NewHTTPTransport(
+		"some url",
+		HTTPTLSConfig(tlsConfig),
+		HTTPRoundTripper(roundTripper),
+	)
+	assert.Equal(t, roundTripper, sender.client.Transport)
+	assert.Nil(t, roundTripper.TLSClientConfig)
+
+	// no options leaves the default transport of http.Client
+	sender = NewHTTPTransport("some url")
+	assert.Nil(t, sender.client.Transport)
+}
+
 type httpServer struct {
 	t               *testing.T
 	batches         []*j.Batch
@@ -159,3 +204,251 @@ func newHTTPServer(t *testing.T) *httpServer {
 
 	return server
 }
+
+func TestHTTPTransportFailover(t *testing.T) {
+	var primaryRequests, secondaryRequests int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryRequests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&secondaryRequests, 1)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer secondary.Close()
+
+	logger := &log.BytesBufferLogger{}
+	sender := NewHTTPTransport(
+		primary.URL,
+		HTTPEndpoints(secondary.URL),
+		HTTPEndpointRetryInterval(time.Hour),
+		HTTPLogger(logger),
+	)
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+	span := tracer.StartSpan("root").(*jaeger.Span)
+	for i := 0; i < 3; i++ {
+		_, err := sender.Append(span)
+		require.NoError(t, err)
+		n, err := sender.Flush()
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+	}
+	// the primary is skipped after the first failure
+	assert.EqualValues(t, 1, atomic.LoadInt32(&primaryRequests))
+	assert.EqualValues(t, 3, atomic.LoadInt32(&secondaryRequests))
+	assert.Contains(t, logger.String(),
+		"DEBUG: Failed to send a batch of 1 spans to "+primary.URL+", retrying on the next endpoint")
+}
+
+func TestHTTPTransportNoFailoverOnClientError(t *testing.T) {
+	var requests int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	primary := httptest.NewServer(handler)
+	defer primary.Close()
+	secondary := httptest.NewServer(handler)
+	defer secondary.Close()
+
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+
+	sender := NewHTTPTransport(primary.URL, HTTPEndpoints(secondary.URL))
+	_, err := sender.Append(tracer.StartSpan("root").(*jaeger.Span))
+	require.NoError(t, err)
+	_, err = sender.Flush()
+	assert.EqualError(t, err, "error from collector: 400")
+	require.IsType(t, &CollectorError{}, err)
+	assert.Equal(t, 400, err.(*CollectorError).StatusCode())
+	assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+}
+
+func TestHTTPTransportBatchInterceptor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+
+	var batches []*j.Batch
+	interceptor := jaeger.NewBatchSequenceInterceptor("batch.seq")
+	sender := NewHTTPTransport(server.URL, HTTPBatchInterceptor(func(batch *j.Batch) {
+		interceptor(batch)
+		batches = append(batches, batch)
+	}))
+	for i := 0; i < 2; i++ {
+		_, err := sender.Append(tracer.StartSpan("root").(*jaeger.Span))
+		require.NoError(t, err)
+		_, err = sender.Flush()
+		require.NoError(t, err)
+	}
+	require.Len(t, batches, 2)
+	for i, batch := range batches {
+		tags := batch.Process.Tags
+		require.NotEmpty(t, tags)
+		assert.Equal(t, "batch.seq", tags[len(tags)-1].Key)
+		assert.EqualValues(t, i+1, *tags[len(tags)-1].VLong)
+	}
+	assert.Len(t, batches[1].Process.Tags, len(batches[0].Process.Tags), "interceptor must not modify the process of the next batches")
+}
+
+func TestHTTPTransportProtocolFactory(t *testing.T) {
+	protocolFactory := thrift.NewTCompactProtocolFactory()
+	batches := make(chan *j.Batch, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		buffer := thrift.NewTMemoryBuffer()
+		buffer.Write(body)
+		batch := &j.Batch{}
+		require.NoError(t, batch.Read(protocolFactory.GetProtocol(buffer)))
+		batches <- batch
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+
+	sender := NewHTTPTransport(server.URL, HTTPProtocolFactory(protocolFactory))
+	_, err := sender.Append(tracer.StartSpan("root").(*jaeger.Span))
+	require.NoError(t, err)
+	_, err = sender.Flush()
+	require.NoError(t, err)
+
+	batch := <-batches
+	assert.Equal(t, "test", batch.Process.ServiceName)
+	require.Len(t, batch.Spans, 1)
+	assert.Equal(t, "root", batch.Spans[0].OperationName)
+}
+
+func TestHTTPTransportCheckHealth(t *testing.T) {
+	var status int32 = http.StatusMethodNotAllowed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	sender := NewHTTPTransport(server.URL)
+	assert.NoError(t, sender.CheckHealth())
+
+	atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+	assert.EqualError(t, sender.CheckHealth(), "error from collector: 503")
+
+	// any reachable endpoint makes the transport healthy
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer healthy.Close()
+	sender = NewHTTPTransport(server.URL, HTTPEndpoints(healthy.URL))
+	assert.NoError(t, sender.CheckHealth())
+
+	healthy.Close()
+	sender = NewHTTPTransport(healthy.URL)
+	assert.Error(t, sender.CheckHealth())
+}
+
+func TestHTTPTransportServiceNames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+
+	var batches []*j.Batch
+	sender := NewHTTPTransport(server.URL, HTTPBatchInterceptor(func(batch *j.Batch) {
+		batches = append(batches, batch)
+	}))
+	for _, opts := range [][]opentracing.StartSpanOption{nil, {jaeger.ServiceName("proxied")}, nil} {
+		n, err := sender.Append(tracer.StartSpan("root", opts...).(*jaeger.Span))
+		require.NoError(t, err)
+		assert.Equal(t, 0, n, "interleaved services do not force a flush")
+	}
+	n, err := sender.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	require.Len(t, batches, 2)
+	assert.Equal(t, "test", batches[0].Process.ServiceName)
+	assert.Len(t, batches[0].Spans, 2)
+	assert.Equal(t, "proxied", batches[1].Process.ServiceName)
+	assert.Len(t, batches[1].Spans, 1)
+
+	// all the batches are sent when one of them is full
+	batches = nil
+	HTTPBatchSize(2)(sender)
+	for _, opts := range [][]opentracing.StartSpanOption{nil, {jaeger.ServiceName("proxied")}, nil} {
+		n, err = sender.Append(tracer.StartSpan("root", opts...).(*jaeger.Span))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, n)
+	require.Len(t, batches, 2)
+	assert.Equal(t, "test", batches[0].Process.ServiceName)
+	assert.Len(t, batches[0].Spans, 2)
+	assert.Equal(t, "proxied", batches[1].Process.ServiceName)
+	n, err = sender.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestHTTPTransportFlushFailedBatch(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+
+	sender := NewHTTPTransport(server.URL)
+	for _, opts := range [][]opentracing.StartSpanOption{nil, nil, {jaeger.ServiceName("proxied")}} {
+		_, err := sender.Append(tracer.StartSpan("root", opts...).(*jaeger.Span))
+		require.NoError(t, err)
+	}
+	n, err := sender.Flush()
+	assert.EqualError(t, err, "error from collector: 400")
+	assert.Equal(t, 1, n, "only the spans of the failed batch are counted")
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+}
+
+func TestHTTPTransportProcessTagsChanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+	defer closer.Close()
+
+	var batches []*j.Batch
+	sender := NewHTTPTransport(server.URL, HTTPBatchInterceptor(func(batch *j.Batch) {
+		batches = append(batches, batch)
+	}))
+	for i := 0; i < 2; i++ {
+		tracer.(*jaeger.Tracer).SetProcessTag("batch", i)
+		_, err := sender.Append(tracer.StartSpan("root").(*jaeger.Span))
+		require.NoError(t, err)
+		_, err = sender.Flush()
+		require.NoError(t, err)
+	}
+	require.Len(t, batches, 2)
+	for i, batch := range batches {
+		var value int64 = -1
+		for _, tag := range batch.Process.Tags {
+			if tag.Key == "batch" {
+				value = *tag.VLong
+			}
+		}
+		assert.EqualValues(t, i, value)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jaeger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+)
+
+func TestInterceptBatch(t *testing.T) {
+	batch := &j.Batch{
+		Process: &j.Process{ServiceName: "svc", Tags: []*j.Tag{{Key: "k"}}},
+		Spans:   []*j.Span{{OperationName: "op"}},
+	}
+	assert.True(t, batch == InterceptBatch(nil, batch))
+
+	enriched := InterceptBatch(func(b *j.Batch) {
+		b.Process.Tags = append(b.Process.Tags, &j.Tag{Key: "batch.id"})
+	}, batch)
+	assert.Equal(t, batch.Spans, enriched.Spans)
+	assert.Equal(t, "svc", enriched.Process.ServiceName)
+	assert.Len(t, enriched.Process.Tags, 2)
+	assert.Len(t, batch.Process.Tags, 1, "original process must not be modified")
+}
+
+func TestBatchSequenceInterceptor(t *testing.T) {
+	interceptor := NewBatchSequenceInterceptor("batch.seq")
+	for i := int64(1); i <= 3; i++ {
+		batch := InterceptBatch(interceptor, &j.Batch{Process: &j.Process{ServiceName: "svc"}})
+		require.Len(t, batch.Process.Tags, 1)
+		assert.Equal(t, "batch.seq", batch.Process.Tags[0].Key)
+		assert.Equal(t, j.TagType_LONG, batch.Process.Tags[0].VType)
+		assert.Equal(t, i, *batch.Process.Tags[0].VLong)
+	}
+	// batches without process are left alone
+	interceptor(&j.Batch{})
+}
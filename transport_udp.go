@@ -20,6 +20,7 @@ import (
 
 	"github.com/uber/jaeger-client-go/thrift"
 
+	"github.com/uber/jaeger-client-go/thrift-gen/agent"
 	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
 	"github.com/uber/jaeger-client-go/utils"
 )
@@ -32,6 +33,10 @@ import (
 // in the batch, because the length of the list is encoded as varint32, as well as SeqId.
 const emitBatchOverhead = 30
 
+// batchInterceptorOverhead is the number of bytes reserved in each datagram for process tags
+// added by a BatchInterceptor.
+const batchInterceptorOverhead = 128
+
 var errSpanTooLarge = errors.New("Span is too large")
 
 type udpSender struct {
@@ -42,54 +47,202 @@ type udpSender struct {
 	spanBuffer      []*j.Span             // spans buffered before a flush
 	thriftBuffer    *thrift.TMemoryBuffer // buffer used to calculate byte size of a span
 	thriftProtocol  thrift.TProtocol
+	tracer          *Tracer // tracer of the buffered spans
 	process         *j.Process
+	processVersion  uint64 // version of the tracer's process tags used to build the process
 	processByteSize int
+	interceptor     BatchInterceptor
+	truncator       *spanTruncator
+	lastBatchSpans  int
+	lastBatchBytes  int
+	// parked holds the buffered spans of the other processes than the one of the spans
+	// appended last, in the order they were parked
+	parked []*udpBatch
 }
 
-// NewUDPTransport creates a reporter that submits spans to jaeger-agent
-func NewUDPTransport(hostPort string, maxPacketSize int) (Transport, error) {
-	if len(hostPort) == 0 {
-		hostPort = fmt.Sprintf("%s:%d", DefaultUDPSpanServerHost, DefaultUDPSpanServerPort)
+// udpBatch holds the buffered spans of a service of a tracer, while the spans of
+// another process are appended, see udpSender.switchBatch.
+type udpBatch struct {
+	tracer          *Tracer
+	process         *j.Process
+	processVersion  uint64
+	processByteSize int
+	byteBufferSize  int
+	spanBuffer      []*j.Span
+}
+
+// UDPTransportParams allows specifying options for initializing a UDPTransport. An instance of this struct should
+// be passed to NewUDPTransportWithParams.
+type UDPTransportParams struct {
+	utils.AgentClientUDPParams
+
+	// BatchInterceptor, if set, is invoked before each batch is emitted. The process tags it adds
+	// must fit into 128 bytes, which are reserved in each datagram.
+	BatchInterceptor BatchInterceptor
+
+	// TruncationPolicy defines how spans that do not fit into a datagram are truncated before
+	// being dropped, defaults to DefaultSpanTruncationPolicy().
+	TruncationPolicy *SpanTruncationPolicy
+
+	// Metrics is used to count truncated spans.
+	Metrics *Metrics
+}
+
+// NewUDPTransportWithParams creates a reporter that submits spans to jaeger-agent.
+// TODO: (breaking change) move to transport/ package.
+func NewUDPTransportWithParams(params UDPTransportParams) (Transport, error) {
+	if len(params.HostPort) == 0 {
+		params.HostPort = fmt.Sprintf("%s:%d", DefaultUDPSpanServerHost, DefaultUDPSpanServerPort)
 	}
-	if maxPacketSize == 0 {
-		maxPacketSize = utils.UDPPacketMaxLength
+	if params.MaxPacketSize == 0 {
+		if params.AutoDetectMaxPacketSize {
+			params.MaxPacketSize = utils.DetectUDPMaxPacketSize(params.HostPort)
+		} else {
+			params.MaxPacketSize = utils.UDPPacketMaxLength
+		}
 	}
 
-	protocolFactory := thrift.NewTCompactProtocolFactory()
+	overhead := emitBatchOverhead
+	if params.ProtocolFactory == nil {
+		params.ProtocolFactory = thrift.NewTCompactProtocolFactory()
+	} else {
+		overhead = calcEmitBatchOverhead(params.ProtocolFactory)
+	}
+	protocolFactory := params.ProtocolFactory
 
 	// Each span is first written to thriftBuffer to determine its size in bytes.
-	thriftBuffer := thrift.NewTMemoryBufferLen(maxPacketSize)
+	thriftBuffer := thrift.NewTMemoryBufferLen(params.MaxPacketSize)
 	thriftProtocol := protocolFactory.GetProtocol(thriftBuffer)
 
-	client, err := utils.NewAgentClientUDP(hostPort, maxPacketSize)
-	if err != nil {
-		return nil, err
+	maxSpanBytes := params.MaxPacketSize - overhead
+	if params.BatchInterceptor != nil {
+		maxSpanBytes -= batchInterceptorOverhead
 	}
 
 	sender := &udpSender{
-		client:         client,
-		maxSpanBytes:   maxPacketSize - emitBatchOverhead,
+		maxSpanBytes:   maxSpanBytes,
 		thriftBuffer:   thriftBuffer,
-		thriftProtocol: thriftProtocol}
+		thriftProtocol: thriftProtocol,
+		interceptor:    params.BatchInterceptor}
+
+	truncationPolicy := DefaultSpanTruncationPolicy()
+	if params.TruncationPolicy != nil {
+		truncationPolicy = *params.TruncationPolicy
+	}
+	truncator, err := newSpanTruncator(truncationPolicy, func(span *j.Span) int {
+		return sender.calcSizeOfSerializedThrift(span)
+	}, params.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	sender.truncator = truncator
+
+	client, err := utils.NewAgentClientUDPWithParams(params.AgentClientUDPParams)
+	if err != nil {
+		return nil, err
+	}
+	sender.client = client
 	return sender, nil
 }
 
+// NewUDPTransport creates a reporter that submits spans to jaeger-agent.
+// TODO: (breaking change) move to transport/ package.
+func NewUDPTransport(hostPort string, maxPacketSize int) (Transport, error) {
+	return NewUDPTransportWithParams(UDPTransportParams{
+		AgentClientUDPParams: utils.AgentClientUDPParams{
+			HostPort:      hostPort,
+			MaxPacketSize: maxPacketSize,
+		},
+	})
+}
+
+// calcEmitBatchOverhead measures how many bytes of the emitBatch message are used for envelope
+// when encoded with the given protocol, by emitting a batch with an empty process and no spans.
+func calcEmitBatchOverhead(protocolFactory thrift.TProtocolFactory) int {
+	buffer := thrift.NewTMemoryBuffer()
+	client := agent.NewAgentClientFactory(buffer, protocolFactory)
+	process := &j.Process{}
+	if err := client.EmitBatch(&j.Batch{Process: process, Spans: []*j.Span{}}); err != nil {
+		return emitBatchOverhead
+	}
+	envelopeSize := buffer.Len()
+	buffer.Reset()
+	process.Write(protocolFactory.GetProtocol(buffer))
+	// the length of the spans list may take up to 4 more bytes when encoded as varint
+	return envelopeSize - buffer.Len() + 4
+}
+
 func (s *udpSender) calcSizeOfSerializedThrift(thriftStruct thrift.TStruct) int {
 	s.thriftBuffer.Reset()
 	thriftStruct.Write(s.thriftProtocol)
 	return s.thriftBuffer.Len()
 }
 
+// Append implements Transport. The spans of different services or tracers, and the spans reported
+// before and after the process tags were changed, are buffered in separate batches, each with its own
+// process. When a batch is full, all the batches are emitted, so that the emitted spans are always
+// the oldest ones, as the reporter expects. The returned count only includes the span itself if it
+// is rejected with errSpanTooLarge.
 func (s *udpSender) Append(span *Span) (int, error) {
+	if s.process != nil && (s.tracer != span.tracer || s.process.ServiceName != span.ServiceName() ||
+		s.processChanged(span)) {
+		s.switchBatch(span)
+	}
+	return s.append(span)
+}
+
+// switchBatch parks the buffered spans of the current process, if any, and resumes
+// the batch of the tracer, service and process tags of the span if it was parked.
+// The parked batches are emitted by the next Flush, which reports their spans.
+func (s *udpSender) switchBatch(span *Span) {
+	_, version := span.tracer.processTags()
+	var resumed *udpBatch
+	for i, batch := range s.parked {
+		if batch.tracer == span.tracer && batch.process.ServiceName == span.ServiceName() &&
+			batch.processVersion == version {
+			resumed = batch
+			s.parked = append(s.parked[:i], s.parked[i+1:]...)
+			break
+		}
+	}
+	if len(s.spanBuffer) > 0 {
+		s.parked = append(s.parked, &udpBatch{
+			tracer:          s.tracer,
+			process:         s.process,
+			processVersion:  s.processVersion,
+			processByteSize: s.processByteSize,
+			byteBufferSize:  s.byteBufferSize,
+			spanBuffer:      s.spanBuffer,
+		})
+	}
+	if resumed == nil {
+		s.tracer = nil
+		s.process = nil
+		s.spanBuffer = nil
+		s.byteBufferSize = 0
+		return
+	}
+	s.tracer = resumed.tracer
+	s.process, s.processVersion, s.processByteSize = resumed.process, resumed.processVersion, resumed.processByteSize
+	s.byteBufferSize, s.spanBuffer = resumed.byteBufferSize, resumed.spanBuffer
+}
+
+func (s *udpSender) append(span *Span) (int, error) {
 	if s.process == nil {
+		s.tracer = span.tracer
+		_, s.processVersion = span.tracer.processTags()
 		s.process = BuildJaegerProcessThrift(span)
 		s.processByteSize = s.calcSizeOfSerializedThrift(s.process)
 		s.byteBufferSize += s.processByteSize
 	}
 	jSpan := BuildJaegerThrift(span)
 	spanSize := s.calcSizeOfSerializedThrift(jSpan)
-	if spanSize > s.maxSpanBytes {
-		return 1, errSpanTooLarge
+	// a span must fit into a datagram together with the process
+	if maxSpanSize := s.maxSpanBytes - s.processByteSize; spanSize > maxSpanSize {
+		jSpan, spanSize = s.truncator.truncate(jSpan, maxSpanSize)
+		if spanSize > maxSpanSize {
+			return 1, errSpanTooLarge
+		}
 	}
 
 	s.byteBufferSize += spanSize
@@ -101,23 +254,74 @@ func (s *udpSender) Append(span *Span) (int, error) {
 		return s.Flush()
 	}
 	// the latest span did not fit in the buffer
+	s.byteBufferSize -= spanSize
 	n, err := s.Flush()
 	s.spanBuffer = append(s.spanBuffer, jSpan)
 	s.byteBufferSize = spanSize + s.processByteSize
 	return n, err
 }
 
+// processChanged returns true if the process tags were changed since the buffered spans were appended.
+func (s *udpSender) processChanged(span *Span) bool {
+	_, version := span.tracer.processTags()
+	return s.processVersion != version
+}
+
+// Flush implements Transport by emitting the parked batches and the current one. If some of them
+// cannot be emitted, it returns the first error and the number of spans in the batches that failed.
 func (s *udpSender) Flush() (int, error) {
+	var flushed, failed int
+	var err error
+	for i, batch := range s.parked {
+		flushed += len(batch.spanBuffer)
+		if emitErr := s.emit(batch.process, batch.spanBuffer, batch.byteBufferSize); emitErr != nil {
+			failed += len(batch.spanBuffer)
+			if err == nil {
+				err = emitErr
+			}
+		}
+		s.parked[i] = nil
+	}
+	s.parked = s.parked[:0]
+	n, emitErr := s.flushCurrent()
+	if emitErr != nil {
+		failed += n
+		if err == nil {
+			err = emitErr
+		}
+	}
+	if err != nil {
+		return failed, err
+	}
+	return flushed + n, nil
+}
+
+// flushCurrent emits the batch of the spans appended last.
+func (s *udpSender) flushCurrent() (int, error) {
 	n := len(s.spanBuffer)
 	if n == 0 {
 		return 0, nil
 	}
-	err := s.client.EmitBatch(&j.Batch{Process: s.process, Spans: s.spanBuffer})
+	err := s.emit(s.process, s.spanBuffer, s.byteBufferSize)
 	s.resetBuffers()
-
 	return n, err
 }
 
+func (s *udpSender) emit(process *j.Process, spans []*j.Span, size int) error {
+	s.lastBatchSpans, s.lastBatchBytes = len(spans), size
+	return s.client.EmitBatch(InterceptBatch(s.interceptor, &j.Batch{Process: process, Spans: spans}))
+}
+
+// LastBatchSize implements BatchSizer. The size of the batch excludes the envelope of the datagram.
+func (s *udpSender) LastBatchSize() (int, int) {
+	return s.lastBatchSpans, s.lastBatchBytes
+}
+
+// CheckHealth implements HealthChecker by checking that the agent address can be resolved.
+func (s *udpSender) CheckHealth() error {
+	return s.client.CheckHealth()
+}
+
 func (s *udpSender) Close() error {
 	return s.client.Close()
 }
@@ -15,15 +15,22 @@
 package jaeger
 
 import (
+	"net"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/uber/jaeger-client-go/testutils"
 	"github.com/uber/jaeger-client-go/thrift"
+	"github.com/uber/jaeger-client-go/thrift-gen/agent"
 	j "github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+	"github.com/uber/jaeger-client-go/utils"
 )
 
 var (
@@ -82,6 +89,75 @@ func TestEmitBatchOverhead(t *testing.T) {
 	}
 }
 
+func TestCalcEmitBatchOverhead(t *testing.T) {
+	span := &Span{operationName: "test-span", tracer: jaegerTracer}
+	factories := map[string]thrift.TProtocolFactory{
+		"compact": thrift.NewTCompactProtocolFactory(),
+		"binary":  thrift.NewTBinaryProtocolFactoryDefault(),
+	}
+	for name, protocolFactory := range factories {
+		expectedOverhead := calcEmitBatchOverhead(protocolFactory)
+		sizeOf := func(s thrift.TStruct) int {
+			buffer := thrift.NewTMemoryBuffer()
+			require.NoError(t, s.Write(protocolFactory.GetProtocol(buffer)))
+			return buffer.Len()
+		}
+		jSpan := BuildJaegerThrift(span)
+		spanSize := sizeOf(jSpan)
+		for _, n := range []int{0, 1, 15, 500, 0xFFFF} {
+			transport := thrift.NewTMemoryBuffer()
+			client := j.NewAgentClientFactory(transport, protocolFactory)
+			batch := make([]*j.Span, n)
+			for x := 0; x < n; x++ {
+				batch[x] = jSpan
+			}
+			process := &j.Process{ServiceName: "svcName"}
+			require.NoError(t, client.EmitBatch(&j.Batch{Process: process, Spans: batch}))
+			overhead := transport.Len() - n*spanSize - sizeOf(process)
+			assert.True(t, overhead <= expectedOverhead,
+				"protocol %s, n=%d, expected overhead %d <= %d", name, n, overhead, expectedOverhead)
+		}
+	}
+}
+
+func TestUDPSenderBinaryProtocol(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	protocolFactory := thrift.NewTBinaryProtocolFactoryDefault()
+	sender, err := NewUDPTransportWithParams(UDPTransportParams{
+		AgentClientUDPParams: utils.AgentClientUDPParams{
+			HostPort:        conn.LocalAddr().String(),
+			ProtocolFactory: protocolFactory,
+		},
+	})
+	require.NoError(t, err)
+	defer sender.Close()
+
+	span := &Span{operationName: "test-span", tracer: jaegerTracer}
+	_, err = sender.Append(span)
+	require.NoError(t, err)
+	_, err = sender.Flush()
+	require.NoError(t, err)
+
+	buf := make([]byte, utils.UDPPacketMaxLength)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+
+	transport := thrift.NewTMemoryBuffer()
+	transport.Write(buf[:n])
+	protocol := protocolFactory.GetProtocol(transport)
+	name, _, _, err := protocol.ReadMessageBegin()
+	require.NoError(t, err)
+	assert.Equal(t, "emitBatch", name)
+	args := agent.NewAgentEmitBatchArgs()
+	require.NoError(t, args.Read(protocol))
+	require.Len(t, args.Batch.Spans, 1)
+	assert.Equal(t, "test-span", args.Batch.Spans[0].OperationName)
+}
+
 func TestUDPSenderFlush(t *testing.T) {
 	agent, err := testutils.StartMockAgent()
 	require.NoError(t, err)
@@ -114,6 +190,9 @@ func TestUDPSenderFlush(t *testing.T) {
 	assert.Equal(t, 0, len(udpSender.spanBuffer), "buffer should become empty")
 	assert.Equal(t, processSize, udpSender.byteBufferSize, "buffer size counter should be equal to the processSize")
 	assert.Nil(t, buffer[0], "buffer should not keep reference to the span")
+	spans, bytes := udpSender.LastBatchSize()
+	assert.Equal(t, 1, spans)
+	assert.Equal(t, spanSize+processSize, bytes)
 
 	for i := 0; i < 10000; i++ {
 		batches := agent.GetJaegerBatches()
@@ -218,3 +297,178 @@ func TestUDPSenderHugeSpan(t *testing.T) {
 	assert.Equal(t, errSpanTooLarge, err)
 	assert.Equal(t, 1, n)
 }
+
+func TestUDPSenderTruncatesLogsOfHugeSpan(t *testing.T) {
+	agent, err := testutils.StartMockAgent()
+	require.NoError(t, err)
+	defer agent.Close()
+
+	span := &Span{operationName: "test-span", tracer: jaegerTracer}
+	spanSize := getThriftSpanByteLength(t, span)
+	for i := 0; i < 100; i++ {
+		span.logs = append(span.logs, opentracing.LogRecord{
+			Timestamp: time.Now(),
+			Fields:    []log.Field{log.String("event", strings.Repeat("x", 100))},
+		})
+	}
+
+	sender, err := NewUDPTransport(agent.SpanServerAddr(), spanSize+2000+emitBatchOverhead)
+	require.NoError(t, err)
+	defer sender.Close()
+
+	appended, err := sender.Append(span)
+	require.NoError(t, err)
+	// the truncated span may fill the buffer exactly, in which case it is flushed by Append
+	flushed, err := sender.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 1, appended+flushed)
+
+	for i := 0; i < 10000 && len(agent.GetJaegerBatches()) == 0; i++ {
+		time.Sleep(1 * time.Millisecond)
+	}
+	batches := agent.GetJaegerBatches()
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0].Spans, 1)
+	jSpan := batches[0].Spans[0]
+	assert.True(t, len(jSpan.Logs) > 0 && len(jSpan.Logs) < 100, "expected some logs to be kept, got %d", len(jSpan.Logs))
+	require.NotEmpty(t, jSpan.Tags)
+	tag := jSpan.Tags[len(jSpan.Tags)-1]
+	assert.Equal(t, TruncatedLogsTagKey, tag.Key)
+	assert.EqualValues(t, 100-len(jSpan.Logs), *tag.VLong)
+	assert.Len(t, span.logs, 100, "the original span must not be modified")
+}
+
+func TestUDPSenderBatchInterceptor(t *testing.T) {
+	agent, err := testutils.StartMockAgent()
+	require.NoError(t, err)
+	defer agent.Close()
+
+	sender, err := NewUDPTransportWithParams(UDPTransportParams{
+		AgentClientUDPParams: utils.AgentClientUDPParams{HostPort: agent.SpanServerAddr()},
+		BatchInterceptor:     NewBatchSequenceInterceptor("batch.seq"),
+	})
+	require.NoError(t, err)
+	defer sender.Close()
+	assert.Equal(t, utils.UDPPacketMaxLength-emitBatchOverhead-batchInterceptorOverhead, sender.(*udpSender).maxSpanBytes)
+
+	span := &Span{operationName: "test-span", tracer: jaegerTracer}
+	for i := 0; i < 2; i++ {
+		_, err = sender.Append(span)
+		require.NoError(t, err)
+		_, err = sender.Flush()
+		require.NoError(t, err)
+	}
+	for i := 0; i < 10000 && len(agent.GetJaegerBatches()) < 2; i++ {
+		time.Sleep(1 * time.Millisecond)
+	}
+	batches := agent.GetJaegerBatches()
+	require.Len(t, batches, 2)
+	for i, batch := range batches {
+		tags := batch.Process.Tags
+		assert.Equal(t, "batch.seq", tags[len(tags)-1].Key)
+		assert.EqualValues(t, i+1, *tags[len(tags)-1].VLong)
+	}
+}
+
+func TestUDPSenderServiceNames(t *testing.T) {
+	agent, err := testutils.StartMockAgent()
+	require.NoError(t, err)
+	defer agent.Close()
+
+	sender, err := NewUDPTransport(agent.SpanServerAddr(), 0)
+	require.NoError(t, err)
+	defer sender.Close()
+
+	for _, service := range []string{"", "proxied", "proxied", ""} {
+		span := &Span{operationName: "test-span", tracer: jaegerTracer, service: service}
+		n, err := sender.Append(span)
+		require.NoError(t, err)
+		assert.Equal(t, 0, n, "interleaved services do not force a flush")
+	}
+	n, err := sender.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	for i := 0; i < 10000 && len(agent.GetJaegerBatches()) < 2; i++ {
+		time.Sleep(1 * time.Millisecond)
+	}
+	batches := agent.GetJaegerBatches()
+	require.Len(t, batches, 2)
+	assert.Equal(t, "proxied", batches[0].Process.ServiceName)
+	assert.Len(t, batches[0].Spans, 2)
+	assert.Equal(t, "svcName", batches[1].Process.ServiceName)
+	assert.Len(t, batches[1].Spans, 2)
+}
+
+func TestUDPSenderServiceNamesFlushAll(t *testing.T) {
+	agent, err := testutils.StartMockAgent()
+	require.NoError(t, err)
+	defer agent.Close()
+
+	span := &Span{operationName: "test-span", tracer: jaegerTracer}
+	spanSize := getThriftSpanByteLength(t, span)
+	processSize := getThriftProcessByteLengthFromTracer(t, jaegerTracer)
+	sender, err := NewUDPTransport(agent.SpanServerAddr(), 2*spanSize+processSize+emitBatchOverhead)
+	require.NoError(t, err)
+	defer sender.Close()
+
+	for i, service := range []string{"proxied", "", ""} {
+		n, err := sender.Append(&Span{operationName: "test-span", tracer: jaegerTracer, service: service})
+		require.NoError(t, err)
+		if i < 2 {
+			assert.Equal(t, 0, n)
+		} else {
+			assert.Equal(t, 3, n, "the parked batch is emitted with the full one")
+		}
+	}
+	n, err := sender.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	for i := 0; i < 10000 && len(agent.GetJaegerBatches()) < 2; i++ {
+		time.Sleep(1 * time.Millisecond)
+	}
+	batches := agent.GetJaegerBatches()
+	require.Len(t, batches, 2)
+	assert.Equal(t, "proxied", batches[0].Process.ServiceName)
+	assert.Len(t, batches[0].Spans, 1)
+	assert.Equal(t, "svcName", batches[1].Process.ServiceName)
+	assert.Len(t, batches[1].Spans, 2)
+}
+
+func TestUDPSenderTracersWithSameServiceName(t *testing.T) {
+	agent, err := testutils.StartMockAgent()
+	require.NoError(t, err)
+	defer agent.Close()
+
+	other, closer := NewTracer("svcName", NewConstSampler(false), NewNullReporter(), TracerOptions.Tag("zone", "b"))
+	defer closer.Close()
+
+	sender, err := NewUDPTransport(agent.SpanServerAddr(), 0)
+	require.NoError(t, err)
+	defer sender.Close()
+
+	for _, tracer := range []*Tracer{jaegerTracer, other.(*Tracer), jaegerTracer} {
+		n, err := sender.Append(&Span{operationName: "test-span", tracer: tracer})
+		require.NoError(t, err)
+		assert.Equal(t, 0, n)
+	}
+	n, err := sender.Flush()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	for i := 0; i < 10000 && len(agent.GetJaegerBatches()) < 2; i++ {
+		time.Sleep(1 * time.Millisecond)
+	}
+	batches := agent.GetJaegerBatches()
+	require.Len(t, batches, 2, "the spans of different tracers are emitted with their own process")
+	assert.Len(t, batches[0].Spans, 1)
+	var zone string
+	for _, tag := range batches[0].Process.Tags {
+		if tag.Key == "zone" {
+			zone = tag.GetVStr()
+		}
+	}
+	assert.Equal(t, "b", zone)
+	assert.Len(t, batches[1].Spans, 2)
+}
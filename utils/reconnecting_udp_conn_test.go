@@ -0,0 +1,152 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+)
+
+type fakeResolver struct {
+	sync.Mutex
+	addr *net.UDPAddr
+	err  error
+}
+
+func (r *fakeResolver) resolve(network string, hostPort string) (*net.UDPAddr, error) {
+	r.Lock()
+	defer r.Unlock()
+	return r.addr, r.err
+}
+
+func (r *fakeResolver) set(addr *net.UDPAddr, err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.addr, r.err = addr, err
+}
+
+func newUDPListener(t *testing.T) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	return conn
+}
+
+func assertReceived(t *testing.T, conn *net.UDPConn, expected string) {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 100)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(buf[:n]))
+}
+
+func TestReconnectingUDPConnFollowsResolvedAddress(t *testing.T) {
+	first := newUDPListener(t)
+	defer first.Close()
+	second := newUDPListener(t)
+	defer second.Close()
+
+	resolver := &fakeResolver{addr: first.LocalAddr().(*net.UDPAddr)}
+	conn, err := newReconnectingUDPConn("agent:6831", time.Hour, resolver.resolve, net.DialUDP, log.NullLogger)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetWriteBuffer(UDPPacketMaxLength))
+
+	_, err = conn.Write([]byte("one"))
+	require.NoError(t, err)
+	assertReceived(t, first, "one")
+
+	resolver.set(second.LocalAddr().(*net.UDPAddr), nil)
+	require.NoError(t, conn.attemptResolveAndDial())
+
+	_, err = conn.Write([]byte("two"))
+	require.NoError(t, err)
+	assertReceived(t, second, "two")
+}
+
+func TestReconnectingUDPConnResolvesOnWrite(t *testing.T) {
+	agent := newUDPListener(t)
+	defer agent.Close()
+
+	resolver := &fakeResolver{err: errors.New("no such host")}
+	conn, err := newReconnectingUDPConn("agent:6831", time.Hour, resolver.resolve, net.DialUDP, log.NullLogger)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("lost"))
+	assert.Equal(t, ErrUDPConnNotInitialized, err)
+
+	resolver.set(agent.LocalAddr().(*net.UDPAddr), nil)
+	_, err = conn.Write([]byte("found"))
+	require.NoError(t, err)
+	assertReceived(t, agent, "found")
+}
+
+func TestReconnectingUDPConnLoop(t *testing.T) {
+	agent := newUDPListener(t)
+	defer agent.Close()
+
+	resolver := &fakeResolver{err: errors.New("no such host")}
+	logger := &log.BytesBufferLogger{}
+	conn, err := newReconnectingUDPConn("agent:6831", time.Millisecond, resolver.resolve, net.DialUDP, logger)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Contains(t, logger.String(), "failed resolving destination address on connection startup endpoint=agent:6831 retry_in=1ms")
+
+	resolver.set(agent.LocalAddr().(*net.UDPAddr), nil)
+	for i := 0; i < 1000; i++ {
+		conn.connMtx.RLock()
+		connected := conn.conn != nil
+		conn.connMtx.RUnlock()
+		if connected {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	conn.connMtx.RLock()
+	assert.NotNil(t, conn.conn)
+	conn.connMtx.RUnlock()
+}
+
+func TestNewAgentClientUDPWithParams(t *testing.T) {
+	_, err := NewAgentClientUDPWithParams(AgentClientUDPParams{HostPort: "localhost"})
+	assert.Error(t, err, "missing port")
+
+	client, err := NewAgentClientUDPWithParams(AgentClientUDPParams{HostPort: "localhost:6831"})
+	require.NoError(t, err)
+	assert.IsType(t, &reconnectingUDPConn{}, client.connUDP)
+	assert.Equal(t, UDPPacketMaxLength, client.maxPacketSize)
+	client.Close()
+
+	client, err = NewAgentClientUDPWithParams(AgentClientUDPParams{
+		HostPort:                   "localhost:6831",
+		DisableAttemptReconnecting: true,
+	})
+	require.NoError(t, err)
+	assert.IsType(t, &net.UDPConn{}, client.connUDP)
+	client.Close()
+
+	client, err = NewAgentClientUDPWithParams(AgentClientUDPParams{HostPort: "127.0.0.1:6831"})
+	require.NoError(t, err)
+	assert.IsType(t, &net.UDPConn{}, client.connUDP)
+	client.Close()
+}
@@ -19,7 +19,11 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/uber/jaeger-client-go/log"
 	"github.com/uber/jaeger-client-go/thrift"
 
 	"github.com/uber/jaeger-client-go/thrift-gen/agent"
@@ -30,48 +34,293 @@ import (
 // UDPPacketMaxLength is the max size of UDP packet we want to send, synced with jaeger-agent
 const UDPPacketMaxLength = 65000
 
+// safeUDPPacketSize is the max size of UDP packet used for remote agents when the MTU
+// of the network path cannot be determined. It fits into a standard Ethernet frame
+// with some room left for tunneling overhead.
+const safeUDPPacketSize = 1400
+
+// defaultAttemptReconnectInterval is how often the agent hostname is re-resolved by default
+const defaultAttemptReconnectInterval = time.Second * 30
+
 // AgentClientUDP is a UDP client to Jaeger agent that implements agent.Agent interface.
 type AgentClientUDP struct {
 	agent.Agent
 	io.Closer
 
-	connUDP       *net.UDPConn
+	hostPort      string
+	connUDP       udpConn
 	client        *agent.AgentClient
 	maxPacketSize int                   // max size of datagram in bytes
 	thriftBuffer  *thrift.TMemoryBuffer // buffer used to calculate byte size of a span
 }
 
-// NewAgentClientUDP creates a client that sends spans to Jaeger Agent over UDP.
-func NewAgentClientUDP(hostPort string, maxPacketSize int) (*AgentClientUDP, error) {
-	if maxPacketSize == 0 {
-		maxPacketSize = UDPPacketMaxLength
+// Prefixes of agent addresses that refer to a unix domain socket path rather than a host:port.
+// The agent only understands datagrams, so both are served over a "unixgram" socket.
+var unixSocketPrefixes = []string{"unixgram://", "unix://"}
+
+// udpConn is the subset of the net.UDPConn API used by AgentClientUDP.
+type udpConn interface {
+	Write([]byte) (int, error)
+	SetWriteBuffer(int) error
+	Close() error
+}
+
+// AgentClientUDPParams allows specifying options for initializing an AgentClientUDP.
+// An instance of this struct should be passed to NewAgentClientUDPWithParams.
+type AgentClientUDPParams struct {
+	// HostPort is the address of the agent, e.g. "localhost:6831" or "[::1]:6831".
+	// A unix domain socket can be used by specifying its path as "unixgram:///path/to/socket".
+	HostPort string
+
+	// MaxPacketSize is the max size of datagram in bytes, defaults to UDPPacketMaxLength.
+	MaxPacketSize int
+
+	// AutoDetectMaxPacketSize, if MaxPacketSize is not set, detects the max size of datagram
+	// from the agent address using DetectUDPMaxPacketSize.
+	AutoDetectMaxPacketSize bool
+
+	// LocalAddr is the local address to send datagrams from, either an IP address or a host:port,
+	// to pin the source address on multi-homed hosts. It is ignored for unix domain sockets.
+	LocalAddr string
+
+	// LocalInterface is the name of the network interface to send datagrams from, e.g. "eth1".
+	// The first address of the interface in the same family as the agent address is used.
+	// It takes precedence over LocalAddr and is ignored for unix domain sockets.
+	LocalInterface string
+
+	// WriteBufferSize is the size of the socket send buffer (SO_SNDBUF) in bytes, defaults to MaxPacketSize.
+	// A larger buffer reduces the number of packets dropped under burst load.
+	WriteBufferSize int
+
+	// ProtocolFactory is the Thrift protocol used to encode batches, defaults to the compact protocol
+	// expected by jaeger-agent on port 6831. Use thrift.NewTBinaryProtocolFactoryDefault() for agents
+	// or other endpoints that expect the binary protocol, such as jaeger-agent on port 6832.
+	ProtocolFactory thrift.TProtocolFactory
+
+	// Logger is used to log errors of resolving the agent address, defaults to log.NullLogger.
+	Logger log.Logger
+
+	// DisableAttemptReconnecting disables periodic re-resolution of the agent hostname.
+	// It has no effect if the HostPort contains an IP address rather than a hostname.
+	DisableAttemptReconnecting bool
+
+	// AttemptReconnectInterval is how often the agent hostname is re-resolved,
+	// defaults to 30 seconds.
+	AttemptReconnectInterval time.Duration
+}
+
+// NewAgentClientUDPWithParams creates a client that sends spans to Jaeger Agent over UDP.
+// Unless disabled, the agent hostname is re-resolved periodically and after failed writes,
+// so that the client follows the agent when its address changes.
+func NewAgentClientUDPWithParams(params AgentClientUDPParams) (*AgentClientUDP, error) {
+	socketPath, isUnixSocket := parseUnixSocketPath(params.HostPort)
+	var host string
+	if !isUnixSocket {
+		// validate hostport
+		h, _, err := net.SplitHostPort(params.HostPort)
+		if err != nil {
+			return nil, err
+		}
+		host = h
 	}
 
-	thriftBuffer := thrift.NewTMemoryBufferLen(maxPacketSize)
-	protocolFactory := thrift.NewTCompactProtocolFactory()
-	client := agent.NewAgentClientFactory(thriftBuffer, protocolFactory)
+	if params.MaxPacketSize == 0 {
+		if params.AutoDetectMaxPacketSize {
+			params.MaxPacketSize = DetectUDPMaxPacketSize(params.HostPort)
+		} else {
+			params.MaxPacketSize = UDPPacketMaxLength
+		}
+	}
+	if params.WriteBufferSize == 0 {
+		params.WriteBufferSize = params.MaxPacketSize
+	}
+	if params.ProtocolFactory == nil {
+		params.ProtocolFactory = thrift.NewTCompactProtocolFactory()
+	}
+	if params.Logger == nil {
+		params.Logger = log.NullLogger
+	}
+	if params.AttemptReconnectInterval <= 0 {
+		params.AttemptReconnectInterval = defaultAttemptReconnectInterval
+	}
 
-	destAddr, err := net.ResolveUDPAddr("udp", hostPort)
-	if err != nil {
-		return nil, err
+	thriftBuffer := thrift.NewTMemoryBufferLen(params.MaxPacketSize)
+	client := agent.NewAgentClientFactory(thriftBuffer, params.ProtocolFactory)
+
+	// dialUDP binds the connection to the configured local address, if any
+	dialUDP := func(network string, _, raddr *net.UDPAddr) (*net.UDPConn, error) {
+		laddr, err := params.localUDPAddr(raddr)
+		if err != nil {
+			return nil, err
+		}
+		return net.DialUDP(network, laddr, raddr)
 	}
 
-	connUDP, err := net.DialUDP(destAddr.Network(), nil, destAddr)
-	if err != nil {
-		return nil, err
+	var connUDP udpConn
+	if isUnixSocket {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+		if err != nil {
+			return nil, err
+		}
+		connUDP = conn
+	} else if params.DisableAttemptReconnecting || net.ParseIP(host) != nil {
+		destAddr, err := net.ResolveUDPAddr("udp", params.HostPort)
+		if err != nil {
+			return nil, err
+		}
+
+		conn, err := dialUDP(destAddr.Network(), nil, destAddr)
+		if err != nil {
+			return nil, err
+		}
+		connUDP = conn
+	} else {
+		// host is a hostname, setup the resolver loop in case the host record changes during operation
+		conn, err := newReconnectingUDPConn(
+			params.HostPort,
+			params.AttemptReconnectInterval,
+			net.ResolveUDPAddr,
+			dialUDP,
+			params.Logger,
+		)
+		if err != nil {
+			return nil, err
+		}
+		connUDP = conn
 	}
-	if err := connUDP.SetWriteBuffer(maxPacketSize); err != nil {
+
+	if err := connUDP.SetWriteBuffer(params.WriteBufferSize); err != nil {
+		connUDP.Close()
 		return nil, err
 	}
 
 	clientUDP := &AgentClientUDP{
+		hostPort:      params.HostPort,
 		connUDP:       connUDP,
 		client:        client,
-		maxPacketSize: maxPacketSize,
+		maxPacketSize: params.MaxPacketSize,
 		thriftBuffer:  thriftBuffer}
 	return clientUDP, nil
 }
 
+// localUDPAddr returns the local address to send datagrams to raddr from, or nil to let the OS choose.
+func (p AgentClientUDPParams) localUDPAddr(raddr *net.UDPAddr) (*net.UDPAddr, error) {
+	if p.LocalInterface != "" {
+		iface, err := net.InterfaceByName(p.LocalInterface)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		wantIPv4 := raddr.IP.To4() != nil
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && (ipNet.IP.To4() != nil) == wantIPv4 {
+				localAddr := &net.UDPAddr{IP: ipNet.IP}
+				if ipNet.IP.IsLinkLocalUnicast() {
+					localAddr.Zone = iface.Name
+				}
+				return localAddr, nil
+			}
+		}
+		return nil, fmt.Errorf("network interface %s has no address to reach %s", p.LocalInterface, raddr.String())
+	}
+	if p.LocalAddr != "" {
+		if ip := net.ParseIP(p.LocalAddr); ip != nil {
+			return &net.UDPAddr{IP: ip}, nil
+		}
+		return net.ResolveUDPAddr("udp", p.LocalAddr)
+	}
+	return nil, nil
+}
+
+// CheckHealth checks that the agent address can be resolved, or that the unix domain socket exists.
+// Since UDP is connectionless, it cannot verify that the agent actually receives the datagrams.
+func (a *AgentClientUDP) CheckHealth() error {
+	if socketPath, ok := parseUnixSocketPath(a.hostPort); ok {
+		_, err := os.Stat(socketPath)
+		return err
+	}
+	_, err := net.ResolveUDPAddr("udp", a.hostPort)
+	return err
+}
+
+// parseUnixSocketPath returns the socket path if the address refers to a unix domain socket.
+func parseUnixSocketPath(hostPort string) (string, bool) {
+	for _, prefix := range unixSocketPrefixes {
+		if strings.HasPrefix(hostPort, prefix) {
+			return strings.TrimPrefix(hostPort, prefix), true
+		}
+	}
+	return "", false
+}
+
+// DetectUDPMaxPacketSize returns the max size of UDP packet that can be sent to the agent
+// at the given address without IP fragmentation. Agents on the loopback interface or
+// behind a unix domain socket can receive packets of UDPPacketMaxLength bytes. For other
+// agents the size is derived from the MTU of the network interface that routes to them,
+// falling back to a conservative 1400 bytes if the MTU cannot be determined.
+func DetectUDPMaxPacketSize(hostPort string) int {
+	if _, ok := parseUnixSocketPath(hostPort); ok {
+		return UDPPacketMaxLength
+	}
+	destAddr, err := net.ResolveUDPAddr("udp", hostPort)
+	if err != nil {
+		return safeUDPPacketSize
+	}
+	if destAddr.IP == nil || destAddr.IP.IsLoopback() || destAddr.IP.IsUnspecified() {
+		return UDPPacketMaxLength
+	}
+	// connecting a UDP socket does not send any packets, it only selects the route
+	conn, err := net.DialUDP(destAddr.Network(), nil, destAddr)
+	if err != nil {
+		return safeUDPPacketSize
+	}
+	defer conn.Close()
+	localIP := conn.LocalAddr().(*net.UDPAddr).IP
+	mtu := interfaceMTU(localIP)
+	if mtu <= 0 {
+		return safeUDPPacketSize
+	}
+	headersSize := 20 + 8 // IPv4 + UDP headers
+	if localIP.To4() == nil {
+		headersSize = 40 + 8 // IPv6 + UDP headers
+	}
+	if size := mtu - headersSize; size < UDPPacketMaxLength {
+		return size
+	}
+	return UDPPacketMaxLength
+}
+
+// interfaceMTU returns the MTU of the network interface with the given IP address, or 0 if not found.
+func interfaceMTU(ip net.IP) int {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return 0
+	}
+	for _, iface := range interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return iface.MTU
+			}
+		}
+	}
+	return 0
+}
+
+// NewAgentClientUDP creates a client that sends spans to Jaeger Agent over UDP.
+func NewAgentClientUDP(hostPort string, maxPacketSize int) (*AgentClientUDP, error) {
+	return NewAgentClientUDPWithParams(AgentClientUDPParams{
+		HostPort:      hostPort,
+		MaxPacketSize: maxPacketSize,
+	})
+}
+
 // EmitZipkinBatch implements EmitZipkinBatch() of Agent interface
 func (a *AgentClientUDP) EmitZipkinBatch(spans []*zipkincore.Span) error {
 	return errors.New("Not implemented")
@@ -85,13 +334,23 @@ func (a *AgentClientUDP) EmitBatch(batch *jaeger.Batch) error {
 		return err
 	}
 	if a.thriftBuffer.Len() > a.maxPacketSize {
-		return fmt.Errorf("Data does not fit within one UDP packet; size %d, max %d, spans %d",
-			a.thriftBuffer.Len(), a.maxPacketSize, len(batch.Spans))
+		return &PacketTooLargeError{Size: a.thriftBuffer.Len(), MaxSize: a.maxPacketSize, Spans: len(batch.Spans)}
 	}
 	_, err := a.connUDP.Write(a.thriftBuffer.Bytes())
 	return err
 }
 
+// PacketTooLargeError is returned when a batch does not fit within one UDP packet.
+type PacketTooLargeError struct {
+	Size    int
+	MaxSize int
+	Spans   int
+}
+
+func (e *PacketTooLargeError) Error() string {
+	return fmt.Sprintf("Data does not fit within one UDP packet; size %d, max %d, spans %d", e.Size, e.MaxSize, e.Spans)
+}
+
 // Close implements Close() of io.Closer and closes the underlying UDP connection.
 func (a *AgentClientUDP) Close() error {
 	return a.connUDP.Close()
@@ -0,0 +1,212 @@
+// Copyright (c) 2019 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/uber/jaeger-client-go/log"
+	"github.com/uber/jaeger-client-go/thrift-gen/jaeger"
+)
+
+func TestAgentClientUDPOverUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jaeger-agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "agent.sock")
+	agent, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	require.NoError(t, err)
+	defer agent.Close()
+
+	for _, prefix := range []string{"unixgram://", "unix://"} {
+		client, err := NewAgentClientUDPWithParams(AgentClientUDPParams{HostPort: prefix + socketPath})
+		require.NoError(t, err)
+		assert.IsType(t, &net.UnixConn{}, client.connUDP)
+
+		require.NoError(t, client.EmitBatch(&jaeger.Batch{Process: &jaeger.Process{ServiceName: "svc"}}))
+		require.NoError(t, agent.SetReadDeadline(time.Now().Add(time.Second)))
+		buf := make([]byte, UDPPacketMaxLength)
+		n, err := agent.Read(buf)
+		require.NoError(t, err)
+		assert.True(t, n > 0)
+		client.Close()
+	}
+}
+
+func TestAgentClientUDPOverIPv6(t *testing.T) {
+	agent, err := net.ListenUDP("udp6", &net.UDPAddr{IP: net.IPv6loopback})
+	if err != nil {
+		t.Skip("IPv6 is not available: ", err)
+	}
+	defer agent.Close()
+
+	client, err := NewAgentClientUDP(agent.LocalAddr().String(), 0)
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, client.EmitBatch(&jaeger.Batch{Process: &jaeger.Process{ServiceName: "svc"}}))
+	require.NoError(t, agent.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, UDPPacketMaxLength)
+	n, err := agent.Read(buf)
+	require.NoError(t, err)
+	assert.True(t, n > 0)
+}
+
+func TestDetectUDPMaxPacketSize(t *testing.T) {
+	assert.Equal(t, UDPPacketMaxLength, DetectUDPMaxPacketSize("localhost:6831"))
+	assert.Equal(t, UDPPacketMaxLength, DetectUDPMaxPacketSize("127.0.0.1:6831"))
+	assert.Equal(t, UDPPacketMaxLength, DetectUDPMaxPacketSize("unixgram:///var/run/jaeger.sock"))
+	assert.Equal(t, safeUDPPacketSize, DetectUDPMaxPacketSize("invalid-host-port"))
+
+	// the result for a remote address depends on the network configuration of the host
+	size := DetectUDPMaxPacketSize("192.0.2.1:6831")
+	assert.True(t, size > 0 && size <= UDPPacketMaxLength, "unexpected size %d", size)
+}
+
+func TestAgentClientUDPAutoDetectMaxPacketSize(t *testing.T) {
+	client, err := NewAgentClientUDPWithParams(AgentClientUDPParams{
+		HostPort:                "127.0.0.1:6831",
+		AutoDetectMaxPacketSize: true,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+	assert.Equal(t, UDPPacketMaxLength, client.maxPacketSize)
+
+	client, err = NewAgentClientUDPWithParams(AgentClientUDPParams{
+		HostPort:                "127.0.0.1:6831",
+		MaxPacketSize:           1000,
+		AutoDetectMaxPacketSize: true,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+	assert.Equal(t, 1000, client.maxPacketSize)
+}
+
+func TestAgentClientUDPDefaultLogger(t *testing.T) {
+	client, err := NewAgentClientUDPWithParams(AgentClientUDPParams{HostPort: "localhost:6831"})
+	require.NoError(t, err)
+	defer client.Close()
+	conn, ok := client.connUDP.(*reconnectingUDPConn)
+	require.True(t, ok, "expecting a reconnecting connection, got %T", client.connUDP)
+	assert.Equal(t, log.NullLogger, conn.logger)
+}
+
+func TestAgentClientUDPCheckHealth(t *testing.T) {
+	client, err := NewAgentClientUDP("127.0.0.1:6831", 0)
+	require.NoError(t, err)
+	defer client.Close()
+	assert.NoError(t, client.CheckHealth())
+
+	client, err = NewAgentClientUDPWithParams(AgentClientUDPParams{
+		HostPort: "jaeger-agent.invalid:6831",
+		Logger:   log.NullLogger,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+	assert.Error(t, client.CheckHealth())
+
+	dir, err := ioutil.TempDir("", "jaeger-agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "agent.sock")
+	agent, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	require.NoError(t, err)
+	client, err = NewAgentClientUDP("unixgram://"+socketPath, 0)
+	require.NoError(t, err)
+	defer client.Close()
+	assert.NoError(t, client.CheckHealth())
+	agent.Close()
+	require.NoError(t, os.Remove(socketPath))
+	assert.Error(t, client.CheckHealth())
+}
+
+func TestAgentClientUDPLocalAddr(t *testing.T) {
+	agent, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer agent.Close()
+
+	localConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	localAddr := localConn.LocalAddr().String()
+	localConn.Close() // free the port for the client
+
+	client, err := NewAgentClientUDPWithParams(AgentClientUDPParams{
+		HostPort:        agent.LocalAddr().String(),
+		LocalAddr:       localAddr,
+		WriteBufferSize: 1 << 20,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	require.NoError(t, client.EmitBatch(&jaeger.Batch{Process: &jaeger.Process{ServiceName: "svc"}}))
+	require.NoError(t, agent.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, UDPPacketMaxLength)
+	_, from, err := agent.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, localAddr, from.String())
+}
+
+func TestAgentClientUDPLocalInterface(t *testing.T) {
+	interfaces, err := net.Interfaces()
+	require.NoError(t, err)
+	var loopback string
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			loopback = iface.Name
+		}
+	}
+	if loopback == "" {
+		t.Skip("no loopback interface")
+	}
+
+	params := AgentClientUDPParams{LocalInterface: loopback}
+	laddr, err := params.localUDPAddr(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 6831})
+	require.NoError(t, err)
+	assert.True(t, laddr.IP.IsLoopback())
+
+	_, err = NewAgentClientUDPWithParams(AgentClientUDPParams{
+		HostPort:       "127.0.0.1:6831",
+		LocalInterface: "no-such-interface",
+	})
+	assert.Error(t, err)
+}
+
+func TestAgentClientUDPLocalAddrParsing(t *testing.T) {
+	raddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 6831}
+
+	laddr, err := AgentClientUDPParams{}.localUDPAddr(raddr)
+	require.NoError(t, err)
+	assert.Nil(t, laddr)
+
+	laddr, err = AgentClientUDPParams{LocalAddr: "127.0.0.1"}.localUDPAddr(raddr)
+	require.NoError(t, err)
+	assert.Equal(t, &net.UDPAddr{IP: net.ParseIP("127.0.0.1")}, laddr)
+
+	laddr, err = AgentClientUDPParams{LocalAddr: "127.0.0.1:1234"}.localUDPAddr(raddr)
+	require.NoError(t, err)
+	assert.Equal(t, 1234, laddr.Port)
+
+	_, err = AgentClientUDPParams{LocalAddr: "bad address"}.localUDPAddr(raddr)
+	assert.Error(t, err)
+}
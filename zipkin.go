@@ -39,10 +39,29 @@ type InjectableZipkinSpan interface {
 	SetFlags(flags byte)
 }
 
+// ExtractableZipkinSpan128 is an ExtractableZipkinSpan that also carries the high 64 bits
+// of 128-bit trace IDs. The high bits are preserved on the child spans, even when the tracer
+// does not generate 128-bit trace IDs itself.
+type ExtractableZipkinSpan128 interface {
+	ExtractableZipkinSpan
+	TraceIDHigh() uint64
+}
+
+// InjectableZipkinSpan128 is an InjectableZipkinSpan that also carries the high 64 bits
+// of 128-bit trace IDs.
+type InjectableZipkinSpan128 interface {
+	InjectableZipkinSpan
+	SetTraceIDHigh(traceIDHigh uint64)
+}
+
 type zipkinPropagator struct {
 	tracer *Tracer
 }
 
+func (p *zipkinPropagator) PropagationFormat() string {
+	return "zipkin"
+}
+
 func (p *zipkinPropagator) Inject(
 	ctx SpanContext,
 	abstractCarrier interface{},
@@ -52,7 +71,10 @@ func (p *zipkinPropagator) Inject(
 		return opentracing.ErrInvalidCarrier
 	}
 
-	carrier.SetTraceID(ctx.TraceID().Low) // TODO this cannot work with 128bit IDs
+	carrier.SetTraceID(ctx.TraceID().Low)
+	if carrier128, ok := carrier.(InjectableZipkinSpan128); ok {
+		carrier128.SetTraceIDHigh(ctx.TraceID().High)
+	}
 	carrier.SetSpanID(uint64(ctx.SpanID()))
 	carrier.SetParentID(uint64(ctx.ParentID()))
 	carrier.SetFlags(ctx.flags)
@@ -69,6 +91,9 @@ func (p *zipkinPropagator) Extract(abstractCarrier interface{}) (SpanContext, er
 	}
 	var ctx SpanContext
 	ctx.traceID.Low = carrier.TraceID()
+	if carrier128, ok := carrier.(ExtractableZipkinSpan128); ok {
+		ctx.traceID.High = carrier128.TraceIDHigh()
+	}
 	ctx.spanID = SpanID(carrier.SpanID())
 	ctx.parentID = SpanID(carrier.ParentID())
 	ctx.flags = carrier.Flags()
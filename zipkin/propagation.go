@@ -49,6 +49,11 @@ func NewZipkinB3HTTPHeaderPropagator(opts ...Option) Propagator {
 	return p
 }
 
+// PropagationFormat implements jaeger.NamedPropagationFormat.
+func (p Propagator) PropagationFormat() string {
+	return "b3"
+}
+
 // Inject conforms to the Injector interface for decoding Zipkin HTTP B3 headers
 func (p Propagator) Inject(
 	sc jaeger.SpanContext,
@@ -83,8 +88,8 @@ func (p Propagator) Extract(abstractCarrier interface{}) (jaeger.SpanContext, er
 		return jaeger.SpanContext{}, opentracing.ErrInvalidCarrier
 	}
 	var traceID jaeger.TraceID
-	var spanID uint64
-	var parentID uint64
+	var spanID jaeger.SpanID
+	var parentID jaeger.SpanID
 	sampled := false
 	var baggage map[string]string
 	err := textMapReader.ForeachKey(func(rawKey, value string) error {
@@ -93,9 +98,9 @@ func (p Propagator) Extract(abstractCarrier interface{}) (jaeger.SpanContext, er
 		if key == "x-b3-traceid" {
 			traceID, err = jaeger.TraceIDFromString(value)
 		} else if key == "x-b3-parentspanid" {
-			parentID, err = strconv.ParseUint(value, 16, 64)
+			parentID, err = jaeger.SpanIDFromString(value)
 		} else if key == "x-b3-spanid" {
-			spanID, err = strconv.ParseUint(value, 16, 64)
+			spanID, err = jaeger.SpanIDFromString(value)
 		} else if key == "x-b3-sampled" && (value == "1" || value == "true") {
 			sampled = true
 		} else if strings.HasPrefix(key, p.baggagePrefix) {
@@ -115,7 +120,7 @@ func (p Propagator) Extract(abstractCarrier interface{}) (jaeger.SpanContext, er
 	}
 	return jaeger.NewSpanContext(
 		traceID,
-		jaeger.SpanID(spanID),
-		jaeger.SpanID(parentID),
+		spanID,
+		parentID,
 		sampled, baggage), nil
 }
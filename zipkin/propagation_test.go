@@ -190,3 +190,14 @@ func TestInvalid128bitTraceID(t *testing.T) {
 	_, err := propagator.Extract(invalidTraceID)
 	assert.EqualError(t, err, opentracing.ErrSpanContextNotFound.Error())
 }
+
+func TestExtractorMalformedSpanID(t *testing.T) {
+	_, err := propagator.Extract(opentracing.TextMapCarrier{"x-b3-traceid": "1", "x-b3-spanid": "xyz"})
+	require.IsType(t, &jaeger.IDParseError{}, err)
+	assert.Equal(t, "SpanID", err.(*jaeger.IDParseError).ID)
+}
+
+func TestPropagationFormat(t *testing.T) {
+	var named jaeger.NamedPropagationFormat = propagator
+	assert.Equal(t, "b3", named.PropagationFormat())
+}
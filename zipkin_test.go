@@ -17,6 +17,7 @@ package jaeger
 import (
 	"testing"
 
+	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	"github.com/stretchr/testify/assert"
 )
@@ -66,3 +67,34 @@ func (s *TestZipkinSpan) SetTraceID(traceID uint64)   { s.traceID = traceID }
 func (s *TestZipkinSpan) SetSpanID(spanID uint64)     { s.spanID = spanID }
 func (s *TestZipkinSpan) SetParentID(parentID uint64) { s.parentID = parentID }
 func (s *TestZipkinSpan) SetFlags(flags byte)         { s.flags = flags }
+
+func TestZipkinPropagator128BitParent(t *testing.T) {
+	tracer, tCloser := NewTracer("x", NewConstSampler(true), NewNullReporter())
+	defer tCloser.Close()
+
+	parent := &TestZipkinSpan128{TestZipkinSpan: TestZipkinSpan{traceID: 1, spanID: 2, flags: 1}, traceIDHigh: 3}
+	parentCtx, err := tracer.Extract(ZipkinSpanFormat, parent)
+	assert.NoError(t, err)
+	sp := tracer.StartSpan("y", opentracing.ChildOf(parentCtx)).(*Span)
+	assert.Equal(t, TraceID{High: 3, Low: 1}, sp.context.traceID)
+
+	child := &TestZipkinSpan128{}
+	assert.NoError(t, tracer.Inject(sp.Context(), ZipkinSpanFormat, child))
+	assert.Equal(t, uint64(3), child.traceIDHigh)
+	assert.Equal(t, uint64(1), child.traceID)
+
+	textMap := opentracing.TextMapCarrier{}
+	assert.NoError(t, tracer.Inject(sp.Context(), opentracing.TextMap, textMap))
+	extracted, err := tracer.Extract(opentracing.TextMap, textMap)
+	assert.NoError(t, err)
+	assert.Equal(t, TraceID{High: 3, Low: 1}, extracted.(SpanContext).traceID)
+}
+
+// TestZipkinSpan128 is a mock-up of a Zipkin span carrying 128-bit trace IDs
+type TestZipkinSpan128 struct {
+	TestZipkinSpan
+	traceIDHigh uint64
+}
+
+func (s TestZipkinSpan128) TraceIDHigh() uint64                { return s.traceIDHigh }
+func (s *TestZipkinSpan128) SetTraceIDHigh(traceIDHigh uint64) { s.traceIDHigh = traceIDHigh }
@@ -16,10 +16,13 @@ package jaeger
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
 
 	"github.com/uber/jaeger-client-go/internal/spanlog"
 	z "github.com/uber/jaeger-client-go/thrift-gen/zipkincore"
@@ -56,7 +59,7 @@ func BuildZipkinThrift(s *Span) *z.Span {
 	timestamp := utils.TimeToMicrosecondsSinceEpochInt64(span.startTime)
 	duration := span.duration.Nanoseconds() / int64(time.Microsecond)
 	endpoint := &z.Endpoint{
-		ServiceName: span.tracer.serviceName,
+		ServiceName: span.serviceName(),
 		Ipv4:        int32(span.tracer.hostIPv4)}
 	thriftSpan := &z.Span{
 		TraceID:           int64(span.context.traceID.Low),
@@ -97,19 +100,26 @@ func buildAnnotations(span *zipkinSpan, endpoint *z.Endpoint) []*z.Annotation {
 		}
 	}
 	for _, log := range span.logs {
-		anno := &z.Annotation{
-			Timestamp: utils.TimeToMicrosecondsSinceEpochInt64(log.Timestamp),
-			Host:      endpoint}
-		if content, err := spanlog.MaterializeWithJSON(log.Fields); err == nil {
-			anno.Value = truncateString(string(content), span.tracer.options.maxTagValueLength)
-		} else {
-			anno.Value = err.Error()
-		}
-		annotations = append(annotations, anno)
+		annotations = append(annotations, buildLogAnnotation(span, endpoint, log.Timestamp, log.Fields))
+	}
+	for _, event := range span.events {
+		annotations = append(annotations, buildLogAnnotation(span, endpoint, event.Timestamp, event.fields()))
 	}
 	return annotations
 }
 
+func buildLogAnnotation(span *zipkinSpan, endpoint *z.Endpoint, timestamp time.Time, fields []log.Field) *z.Annotation {
+	anno := &z.Annotation{
+		Timestamp: utils.TimeToMicrosecondsSinceEpochInt64(timestamp),
+		Host:      endpoint}
+	if content, err := spanlog.MaterializeWithJSON(fields); err == nil {
+		anno.Value = truncateString(string(content), span.tracer.options.maxTagValueLength)
+	} else {
+		anno.Value = err.Error()
+	}
+	return anno
+}
+
 func buildBinaryAnnotations(span *zipkinSpan, endpoint *z.Endpoint) []*z.BinaryAnnotation {
 	// automatically adding local component or server/client address tag, and client version
 	annotations := make([]*z.BinaryAnnotation, 0, 2+len(span.tags))
@@ -151,7 +161,7 @@ func buildBinaryAnnotations(span *zipkinSpan, endpoint *z.Endpoint) []*z.BinaryA
 		if _, ok := specialTagHandlers[tag.key]; ok {
 			continue
 		}
-		if anno := buildBinaryAnnotation(tag.key, tag.value, span.tracer.options.maxTagValueLength, nil); anno != nil {
+		if anno := buildBinaryAnnotation(tag.key, tag.value, span.tracer.maxTagValueLength(tag.key), nil); anno != nil {
 			annotations = append(annotations, anno)
 		}
 	}
@@ -189,10 +199,20 @@ func buildBinaryAnnotation(key string, val interface{}, maxTagValueLength int, e
 	return bann
 }
 
+// stringify converts a tag value to a string. Slices, arrays and maps are encoded as JSON,
+// with the keys of maps sorted, so that the same value always produces the same string.
 func stringify(value interface{}) string {
 	if s, ok := value.(string); ok {
 		return s
 	}
+	if value != nil {
+		switch reflect.TypeOf(value).Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			if b, err := json.Marshal(value); err == nil {
+				return string(b)
+			}
+		}
+	}
 	return fmt.Sprintf("%+v", value)
 }
 
@@ -248,7 +268,8 @@ func (s *zipkinSpan) handleSpecialTags() {
 	defer s.Unlock()
 	if s.firstInProcess {
 		// append the process tags
-		s.tags = append(s.tags, s.tracer.tags...)
+		processTags, _ := s.tracer.processTags()
+		s.tags = append(s.tags, processTags...)
 	}
 	filteredTags := make([]Tag, 0, len(s.tags))
 	for _, tag := range s.tags {